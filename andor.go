@@ -0,0 +1,121 @@
+package csp
+
+// The best a component of the unassigned variables can do in a given context: values for its variables and the
+// soft constraint cost they incur, solution being nil when it has none
+type andOrResult struct {
+	solution map[string]int
+	cost     int
+}
+
+// AND/OR search, for Solver.AndOr. After every assignment the unassigned variables split into components sharing
+// no constraint, hard or soft, and each is solved on its own: the OR part picks a value, the AND part needs every
+// component under it to work out. A component's result is cached by its context, the values of the assigned
+// variables its constraints can see, so a residual subproblem that comes up again under another prefix is solved
+// once. Satisfaction stops at each component's first solution; optimization keeps the cheapest, the objective
+// breaking ties in the one component it's in. Either way the result is a single solution.
+func (solver *Solver) andOrSearch() {
+	constraints := append([]Constraint(nil), solver.Problem.Constraints...)
+	for _, soft := range solver.Problem.SoftConstraints {
+		constraints = append(constraints, soft.Constraint)
+	}
+	solver.andOrGraph = newConstraintGraph(solver.Problem.Variables, constraints)
+	solver.andOrCache = make(map[string]andOrResult)
+	solver.ContextHits = 0
+
+	assignment := make(map[string]int)
+	solution, cost := make(map[string]int), 0
+	for _, component := range solver.andOrGraph.components(solver.Problem.Variables, assignment) {
+		result := solver.andOrComponent(component, assignment)
+		if result.solution == nil {
+			return
+		}
+		for variable, value := range result.solution {
+			solution[variable] = value
+		}
+		cost += result.cost
+	}
+	solver.Solutions = append(solver.Solutions, solution)
+	if solver.Problem.Optimizing() {
+		solver.Best = solution
+		solver.BestObjective = solution[solver.Problem.Objective]
+		solver.BestCost = cost
+	}
+	solver.publish()
+}
+
+func (solver *Solver) andOrComponent(variables []string, assignment map[string]int) andOrResult {
+	key := solver.andOrGraph.contextKey(variables, assignment)
+	if result, cached := solver.andOrCache[key]; cached {
+		solver.ContextHits++
+		return result
+	}
+	variable, rest := variables[0], variables[1:]
+	var best andOrResult
+	for _, value := range solver.domains[variable] {
+		if solver.limitReached() {
+			// half explored, so not something to remember
+			delete(assignment, variable)
+			return andOrResult{}
+		}
+		solver.Nodes++
+		assignment[variable] = value
+		if solver.violated(variable, assignment) != nil {
+			solver.Failures++
+			continue
+		}
+		candidate := andOrResult{map[string]int{variable: value}, solver.completedSoftCost(variable, assignment)}
+		for _, component := range solver.andOrGraph.components(rest, assignment) {
+			result := solver.andOrComponent(component, assignment)
+			if result.solution == nil {
+				candidate.solution = nil
+				break
+			}
+			for other, otherValue := range result.solution {
+				candidate.solution[other] = otherValue
+			}
+			candidate.cost += result.cost
+		}
+		if candidate.solution == nil {
+			continue
+		}
+		if best.solution == nil || solver.andOrBetter(candidate, best) {
+			best = candidate
+		}
+		if !solver.Problem.Optimizing() {
+			break
+		}
+	}
+	delete(assignment, variable)
+	solver.andOrCache[key] = best
+	return best
+}
+
+// The weight of the soft constraints the variable's assignment completes and breaks
+func (solver *Solver) completedSoftCost(variable string, assignment map[string]int) int {
+	cost := 0
+	for _, i := range solver.softWatchers[variable] {
+		soft := solver.Problem.SoftConstraints[i]
+		complete := true
+		for _, other := range soft.Constraint.Scope() {
+			if _, assigned := assignment[other]; !assigned {
+				complete = false
+				break
+			}
+		}
+		if complete && !soft.Constraint.Satisfied(assignment) {
+			cost += soft.Weight
+		}
+	}
+	return cost
+}
+
+func (solver *Solver) andOrBetter(a, b andOrResult) bool {
+	if a.cost != b.cost {
+		return a.cost < b.cost
+	}
+	objective := solver.Problem.Objective
+	if _, inComponent := a.solution[objective]; objective != "" && inComponent {
+		return solver.Problem.Improves(a.solution[objective], b.solution[objective])
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package csp
+
+// A partial assignment: the value of every variable assigned so far, and the depth it was reached at, which is
+// how many variables that is. Constraint checks take one of these rather than a path of tree nodes, so they work
+// the same for the legacy tree, whose paths convert with PathAssignment, and for the solvers that build no tree.
+// Values is the map[string]int the rest of the package passes around and Constraint.Satisfied takes. The Solver
+// extends and backtracks a single one in place with Assign and Undo, so going a level deeper copies nothing.
+type Assignment struct {
+	Values map[string]int
+	Depth  int
+	trail  []string // the variables Assign gave values to, most recent last
+}
+
+// Nothing assigned yet, at depth 0
+func NewAssignment() Assignment {
+	return Assignment{Values: make(map[string]int)}
+}
+
+func (assignment Assignment) Value(variable string) (int, bool) {
+	value, assigned := assignment.Values[variable]
+	return value, assigned
+}
+
+// Goes one deeper, giving variable value
+func (assignment *Assignment) Assign(variable string, value int) {
+	assignment.Values[variable] = value
+	assignment.trail = append(assignment.trail, variable)
+	assignment.Depth++
+}
+
+// Takes back the latest Assign
+func (assignment *Assignment) Undo() {
+	last := len(assignment.trail) - 1
+	delete(assignment.Values, assignment.trail[last])
+	assignment.trail = assignment.trail[:last]
+	assignment.Depth--
+}
+
+// What a root to leaf path of the legacy tree assigns, each node's letter getting its value
+func PathAssignment(path []*Node) Assignment {
+	assignment := Assignment{Values: make(map[string]int, len(path)), Depth: len(path)}
+	for _, node := range path {
+		assignment.Values[node.Variable.Letter] = node.Variable.Value
+	}
+	return assignment
+}
@@ -0,0 +1,186 @@
+package csp
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// What AnalyzeModel measures about a model to pick a configuration for it. SearchSpace is the log10 of the
+// product of the domain sizes, Density how many of the pairs of variables share a constraint, from 0 to 1.
+type ModelFeatures struct {
+	Variables            int
+	Constraints          int
+	SoftConstraints      int
+	UnarySoftConstraints int
+	MaxDomain            int
+	MeanDomain           float64
+	SearchSpace          float64
+	MaxArity             int
+	MeanArity            float64
+	Density              float64
+	Components           int
+	Objective            bool
+}
+
+// Measures the model, in time about linear in its size except for Density, which looks at every pair of
+// variables in every constraint
+func AnalyzeModel(problem *Problem) ModelFeatures {
+	features := ModelFeatures{
+		Variables:       len(problem.Variables),
+		Constraints:     len(problem.Constraints),
+		SoftConstraints: len(problem.SoftConstraints),
+		Objective:       problem.Objective != "",
+	}
+	for _, variable := range problem.Variables {
+		size := len(problem.Domains[variable])
+		features.MeanDomain += float64(size)
+		if size > features.MaxDomain {
+			features.MaxDomain = size
+		}
+		if size > 0 {
+			features.SearchSpace += math.Log10(float64(size))
+		}
+	}
+	if features.Variables > 0 {
+		features.MeanDomain /= float64(features.Variables)
+	}
+
+	neighbors := make(map[string]map[string]bool)
+	edges := 0
+	link := func(scope []string) {
+		for i, first := range scope {
+			for _, second := range scope[i+1:] {
+				if neighbors[first] == nil {
+					neighbors[first] = make(map[string]bool)
+				}
+				if !neighbors[first][second] {
+					if neighbors[second] == nil {
+						neighbors[second] = make(map[string]bool)
+					}
+					neighbors[first][second], neighbors[second][first] = true, true
+					edges++
+				}
+			}
+		}
+	}
+	for _, constraint := range problem.Constraints {
+		scope := uniqueScope(constraint.Scope())
+		features.MeanArity += float64(len(scope))
+		if len(scope) > features.MaxArity {
+			features.MaxArity = len(scope)
+		}
+		link(scope)
+	}
+	if features.Constraints > 0 {
+		features.MeanArity /= float64(features.Constraints)
+	}
+	for _, soft := range problem.SoftConstraints {
+		scope := uniqueScope(soft.Constraint.Scope())
+		if len(scope) == 1 {
+			features.UnarySoftConstraints++
+		}
+		link(scope)
+	}
+	if pairs := features.Variables * (features.Variables - 1) / 2; pairs > 0 {
+		features.Density = float64(edges) / float64(pairs)
+	}
+	features.Components = len(problem.Components())
+	return features
+}
+
+// The settings the analyzer picks, which Apply copies onto a solver, and why, one reason per setting
+type Configuration struct {
+	VariableOrdering VariableOrdering
+	Propagation      bool
+	PreprocessLevel  PreprocessLevel
+	RestartCutoff    int
+	Decompose        bool
+	Optimization     OptimizationStrategy
+	Reasons          []string
+}
+
+// Search spaces below 10^tinySearchSpace are enumerated faster than anything can be worked out about them
+const tinySearchSpace = 4
+
+// Picks a configuration for a model with these features. These are rules of thumb, not tuning, meant to do
+// reasonably on anything rather than best on something:
+//
+//   - tiny search spaces get a plain lexicographic search with nothing on top
+//   - models falling apart into independent parts get solved part by part
+//   - models with at least as many constraints as variables, or with a third of the pairs of variables sharing
+//     one, get domwdeg with restarts, which learns where the trouble is, looser ones mrv
+//   - propagation is always on, and arc consistency runs up front when no constraint is on more than three
+//     variables, since finding supports in wider scopes can take longer than the search it saves
+//   - soft constraints that are mostly preferences on single variables get optimized core-guided, which finds
+//     few small cores on them, any others by branch and bound
+func (features ModelFeatures) Recommend() Configuration {
+	configuration := Configuration{Optimization: BranchAndBound}
+	reason := func(format string, a ...interface{}) {
+		configuration.Reasons = append(configuration.Reasons, fmt.Sprintf(format, a...))
+	}
+	if features.SearchSpace < tinySearchSpace {
+		reason("search space 10^%.1f: plain lexicographic search", features.SearchSpace)
+		return configuration
+	}
+
+	configuration.Propagation = true
+	if features.Components > 1 {
+		configuration.Decompose = true
+		reason("%d independent components: solved separately", features.Components)
+	}
+	if features.Constraints >= features.Variables || features.Density >= 1.0/3 {
+		configuration.VariableOrdering = DomainOverWeightedDegree
+		configuration.RestartCutoff = 100
+		reason("%d constraints on %d variables, density %.2f: domwdeg with restarts", features.Constraints,
+			features.Variables, features.Density)
+	} else {
+		configuration.VariableOrdering = MinimumRemainingValues
+		reason("%d constraints on %d variables, density %.2f: mrv", features.Constraints, features.Variables,
+			features.Density)
+	}
+	if features.MaxArity <= 3 {
+		configuration.PreprocessLevel = ArcConsistency
+		reason("constraints on at most %d variables: arc consistency up front", features.MaxArity)
+	} else {
+		reason("constraints on up to %d variables: propagation only", features.MaxArity)
+	}
+	if features.SoftConstraints > 0 {
+		if !features.Objective && 2*features.UnarySoftConstraints >= features.SoftConstraints {
+			configuration.Optimization = CoreGuided
+			reason("%d of %d soft constraints on single variables: core-guided", features.UnarySoftConstraints,
+				features.SoftConstraints)
+		} else {
+			reason("%d soft constraints: branch and bound", features.SoftConstraints)
+		}
+	}
+	return configuration
+}
+
+// Copies the configuration onto the solver
+func (configuration Configuration) Apply(solver *Solver) {
+	solver.VariableOrdering = configuration.VariableOrdering
+	solver.Propagation = configuration.Propagation
+	solver.PreprocessLevel = configuration.PreprocessLevel
+	solver.RestartCutoff = configuration.RestartCutoff
+	solver.Decompose = configuration.Decompose
+	solver.Optimization = configuration.Optimization
+}
+
+// The settings on one line, such as "domwdeg, restarts 100, preprocess arc, propagation, bnb"
+func (configuration Configuration) String() string {
+	parts := []string{configuration.VariableOrdering.String()}
+	if configuration.RestartCutoff > 0 {
+		parts = append(parts, fmt.Sprintf("restarts %d", configuration.RestartCutoff))
+	}
+	parts = append(parts, "preprocess "+configuration.PreprocessLevel.String())
+	if configuration.Propagation {
+		parts = append(parts, "propagation")
+	} else {
+		parts = append(parts, "no propagation")
+	}
+	if configuration.Decompose {
+		parts = append(parts, "decomposed")
+	}
+	return strings.Join(append(parts, configuration.Optimization.String()), ", ")
+}
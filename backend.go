@@ -0,0 +1,103 @@
+package csp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// A Backend solves a Problem with whatever engine it wraps, so models built with this package can be handed to
+// an external solver instead of the built-in search without being written twice.
+type Backend interface {
+	Solve(problem *Problem) (Result, error)
+}
+
+// What a solve came to, whichever backend or search did it, so callers can handle them all alike. Solutions holds
+// what was found, the best one last when optimizing, BestObjective and BestCost being that one's. Complete means
+// the search space was exhausted: no solutions then proves the problem unsatisfiable, and the last solution is
+// optimal. Status says the same in one word.
+type Result struct {
+	Status        SolveStatus
+	Solutions     []map[string]int
+	BestObjective int
+	BestCost      int
+	Complete      bool
+	Stats         Stats
+}
+
+// How much work a solve took. External backends only know the time.
+type Stats struct {
+	Nodes        int
+	Failures     int
+	Propagations int
+	Restarts     int
+	Elapsed      time.Duration
+}
+
+// Fills in Status and the best solution's objective and cost from Solutions and Complete
+func (result *Result) summarize(problem *Problem) {
+	switch {
+	case len(result.Solutions) == 0 && result.Complete:
+		result.Status = Unsatisfiable
+	case len(result.Solutions) == 0:
+		result.Status = Unknown
+	case result.Complete && problem.Optimizing():
+		result.Status = Optimal
+	default:
+		result.Status = Satisfiable
+	}
+	if len(result.Solutions) > 0 && problem.Optimizing() {
+		best := result.Solutions[len(result.Solutions)-1]
+		result.BestObjective = best[problem.Objective]
+		result.BestCost = problem.Cost(best)
+	}
+}
+
+// The built-in depth-first search as a Backend
+type SearchBackend struct {
+	MaxSolutions int
+	Propagation  bool
+}
+
+func (backend *SearchBackend) Solve(problem *Problem) (Result, error) {
+	solver := NewSolver(problem, WithMaxSolutions(backend.MaxSolutions))
+	solver.Propagation = backend.Propagation
+	solver.Solve()
+	return solver.Result(), nil
+}
+
+// Writes the model to a temporary file with export and runs command with args followed by that file's name,
+// returning what the command printed
+func runExternal(export func(io.Writer) error, suffix string, command string, args ...string) ([]byte, error) {
+	file, err := ioutil.TempFile("", "csp-*"+suffix)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	if err := export(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(command, append(args, file.Name())...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		// SAT solvers report their answer through the exit code, 10 for SAT and 20 for UNSAT
+		if code := exitErr.ExitCode(); code == 10 || code == 20 {
+			err = nil
+		} else {
+			err = fmt.Errorf("%s: %v: %s", command, err, bytes.TrimSpace(stderr.Bytes()))
+		}
+	}
+	return stdout.Bytes(), err
+}
@@ -0,0 +1,119 @@
+package csp
+
+import (
+	"bufio"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// What a solve came to, in the shape it's written out as JSON. Categorical values are written as their labels.
+// Optimization problems only list the best solution. Ranked solves have each solution's score, null for the
+// ones that aren't finite.
+type SolveResult struct {
+	Model     string                   `json:"model,omitempty"`
+	Status    string                   `json:"status"`
+	Solutions []map[string]interface{} `json:"solutions"`
+	Scores    []*float64               `json:"scores,omitempty"`
+	Objective *int                     `json:"objective,omitempty"`
+	Cost      *int                     `json:"cost,omitempty"`
+	Nodes     int                      `json:"nodes"`
+	Failures  int                      `json:"failures"`
+	Seconds   float64                  `json:"seconds"`
+	Estimate  *TreeEstimate            `json:"estimate,omitempty"`
+	Error     string                   `json:"error,omitempty"`
+}
+
+// The result of a finished solve
+func NewSolveResult(solver *Solver, elapsed time.Duration) SolveResult {
+	problem := solver.Problem
+	result := SolveResult{Status: solver.Status.String(), Solutions: []map[string]interface{}{},
+		Nodes: solver.Nodes, Failures: solver.Failures, Seconds: elapsed.Seconds()}
+	solutions := solver.Solutions
+	if problem.Optimizing() {
+		solutions = nil
+		if solver.Best != nil {
+			solutions = []map[string]int{solver.Best}
+			cost := solver.BestCost
+			result.Cost = &cost
+			if problem.Objective != "" {
+				objective := solver.BestObjective
+				result.Objective = &objective
+			}
+		}
+	}
+	for _, solution := range solutions {
+		result.Solutions = append(result.Solutions, SolutionValues(problem, solution))
+	}
+	for _, score := range solver.Scores {
+		score := score
+		if math.IsInf(score, 0) || math.IsNaN(score) {
+			result.Scores = append(result.Scores, nil)
+		} else {
+			result.Scores = append(result.Scores, &score)
+		}
+	}
+	if solver.EstimateProbes > 0 {
+		estimate := solver.Estimate
+		result.Estimate = &estimate
+	}
+	return result
+}
+
+// A solution with labels for the categorical variables' values, ready for encoding/json
+func SolutionValues(problem *Problem, solution map[string]int) map[string]interface{} {
+	values := make(map[string]interface{}, len(solution))
+	for variable, value := range solution {
+		if _, categorical := problem.Labels[variable]; categorical {
+			values[variable] = problem.FormatValue(variable, value)
+		} else {
+			values[variable] = value
+		}
+	}
+	return values
+}
+
+// The model files a manifest lists, one per line, relative to the manifest's directory. Blank lines and lines
+// starting with # are skipped.
+func ReadManifest(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var models []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(filepath.Dir(filename), line)
+		}
+		models = append(models, line)
+	}
+	return models, scanner.Err()
+}
+
+// Totals over a batch, written to summary.json next to the results
+type BatchSummary struct {
+	Instances int            `json:"instances"`
+	Statuses  map[string]int `json:"statuses"`
+	Errors    int            `json:"errors"`
+	Nodes     int            `json:"nodes"`
+	Seconds   float64        `json:"seconds"`
+	Results   []string       `json:"results"`
+}
+
+// Writes the value to the file as indented JSON
+func WriteJSONFile(filename string, value interface{}) error {
+	encoded, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(encoded, '\n'), 0644)
+}
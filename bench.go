@@ -0,0 +1,76 @@
+package csp
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// How one variable ordering did over the repeated runs of a benchmark. The search is deterministic, so nodes,
+// failures and solutions are the same on every run and only the times vary.
+type BenchResult struct {
+	Ordering  VariableOrdering
+	Nodes     int
+	Failures  int
+	Solutions int
+	Status    SolveStatus
+	Times     []time.Duration
+}
+
+// Solves the problem repeat times with each ordering, configure setting up everything else about the solvers
+func Benchmark(problem *Problem, orderings []VariableOrdering, repeat int, configure func(solver *Solver)) []BenchResult {
+	var results []BenchResult
+	for _, ordering := range orderings {
+		result := BenchResult{Ordering: ordering}
+		for run := 0; run < repeat; run++ {
+			solver := NewSolver(problem)
+			configure(solver)
+			solver.VariableOrdering = ordering
+			start := time.Now()
+			solutions := solver.Solve()
+			result.Times = append(result.Times, time.Since(start))
+			result.Nodes, result.Failures, result.Solutions, result.Status =
+				solver.Nodes, solver.Failures, len(solutions), solver.Status
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func (result BenchResult) Mean() time.Duration {
+	var total time.Duration
+	for _, elapsed := range result.Times {
+		total += elapsed
+	}
+	return total / time.Duration(len(result.Times))
+}
+
+func (result BenchResult) Median() time.Duration {
+	sorted := append([]time.Duration(nil), result.Times...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2]
+}
+
+// One row per ordering, with each one's median time relative to the fastest
+func PrintBenchTable(w io.Writer, results []BenchResult) {
+	fastest := time.Duration(0)
+	for _, result := range results {
+		if median := result.Median(); fastest == 0 || median < fastest {
+			fastest = median
+		}
+	}
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "strategy\tnodes\tfailures\tsolutions\tstatus\tmedian\tmean\trelative\t")
+	for _, result := range results {
+		relative := 1.0
+		if fastest > 0 {
+			relative = float64(result.Median()) / float64(fastest)
+		}
+		fmt.Fprintf(table, "%s\t%d\t%d\t%d\t%s\t%v\t%v\t%.2fx\t\n", result.Ordering, result.Nodes, result.Failures,
+			result.Solutions, result.Status, result.Median().Round(time.Microsecond),
+			result.Mean().Round(time.Microsecond), relative)
+	}
+	table.Flush()
+}
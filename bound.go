@@ -0,0 +1,132 @@
+package csp
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// How branch and bound stands. The incumbent is the best solution so far; the bounds are the best objective and
+// the lowest soft constraint cost any solution can still have, counting the incumbent, so for a minimized
+// objective ObjectiveBound and Objective are the lower and upper bounds on the optimum. Gap is how far apart they
+// are relative to the incumbent: of the cost while some subtree might still beat it, of the objective after that.
+// It's 0 once the incumbent is proven optimal and +Inf while there's no incumbent.
+type BoundEvent struct {
+	Nodes          int
+	Elapsed        time.Duration
+	HasObjective   bool // false when only the soft constraints count
+	Incumbent      bool
+	Objective      int
+	Cost           int
+	ObjectiveBound int
+	CostBound      int
+	Gap            float64
+}
+
+// "cost 0, bound 0, objective 12, bound 9, gap 25.0% after 1024 nodes"
+func (event BoundEvent) String() string {
+	if !event.Incumbent {
+		return fmt.Sprintf("no incumbent after %d nodes", event.Nodes)
+	}
+	text := fmt.Sprintf("cost %d, bound %d", event.Cost, event.CostBound)
+	if event.HasObjective {
+		text += fmt.Sprintf(", objective %d, bound %d", event.Objective, event.ObjectiveBound)
+	}
+	return text + fmt.Sprintf(", gap %.1f%% after %d nodes", 100*event.Gap, event.Nodes)
+}
+
+// One level of the search in progress: the values it tries, how many it has, and the domains and soft constraint
+// cost it started from. The untried values of every level, plus the subtree of the value the deepest one is on,
+// are all the search has left to look at.
+type frontierLevel struct {
+	variable string
+	values   []int
+	next     int
+	domains  map[string][]int
+	cost     int
+}
+
+// Where branch and bound stands right now, see BoundEvent
+func (solver *Solver) currentBound() BoundEvent {
+	objective := solver.Problem.Objective
+	event := BoundEvent{Nodes: solver.Nodes, Elapsed: time.Since(solver.started), HasObjective: objective != "",
+		Gap: math.Inf(1)}
+	haveObjective, haveCost := false, false
+	consider := func(value int, cost int) {
+		if objective != "" && (!haveObjective || solver.Problem.Improves(value, event.ObjectiveBound)) {
+			event.ObjectiveBound, haveObjective = value, true
+		}
+		if !haveCost || cost < event.CostBound {
+			event.CostBound, haveCost = cost, true
+		}
+	}
+	if solver.Best != nil {
+		event.Incumbent = true
+		event.Objective, event.Cost = solver.BestObjective, solver.BestCost
+		consider(event.Objective, event.Cost)
+	}
+
+	fixed, fixedValue := false, 0 // whether a level above has assigned the objective, and to what
+	for depth, level := range solver.frontier {
+		first := level.next // the first value whose subtree is still unexplored
+		if depth == len(solver.frontier)-1 && first > 0 {
+			first--
+		}
+		if level.variable == objective {
+			for _, value := range level.values[first:] {
+				consider(value, level.cost)
+			}
+			if level.next > 0 {
+				fixed, fixedValue = true, level.values[level.next-1]
+			}
+			continue
+		}
+		if first == len(level.values) {
+			continue
+		}
+		switch {
+		case objective == "":
+			consider(0, level.cost)
+		case fixed:
+			consider(fixedValue, level.cost)
+		default:
+			for _, value := range level.domains[objective] {
+				consider(value, level.cost)
+			}
+		}
+	}
+
+	if event.Incumbent {
+		switch {
+		case event.CostBound < event.Cost:
+			event.Gap = float64(event.Cost-event.CostBound) / math.Max(1, float64(event.Cost))
+		case objective != "":
+			event.Gap = math.Abs(float64(event.Objective-event.ObjectiveBound)) / math.Max(1, math.Abs(float64(event.Objective)))
+		default:
+			event.Gap = 0
+		}
+	}
+	return event
+}
+
+// Tells OnBound how things stand when they've changed since it was last told, and stops the search once the gap
+// is down to MaxGap, or to 0, which proves the incumbent optimal without looking any further
+func (solver *Solver) reportBound() {
+	if solver.OnBound == nil && solver.MaxGap <= 0 {
+		return
+	}
+	event := solver.currentBound()
+	last := solver.lastBound
+	if event.Incumbent == last.Incumbent && event.Objective == last.Objective && event.Cost == last.Cost &&
+		event.ObjectiveBound == last.ObjectiveBound && event.CostBound == last.CostBound {
+		return
+	}
+	solver.lastBound = event
+	if solver.OnBound != nil {
+		solver.OnBound(event)
+	}
+	if event.Incumbent && (event.Gap == 0 || event.Gap <= solver.MaxGap) {
+		solver.stopped = true
+		solver.gapClosed = true
+	}
+}
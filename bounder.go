@@ -0,0 +1,91 @@
+package csp
+
+// Estimates for branch and bound of how good a solution extending a partial assignment can get, so subtrees that
+// can't beat the incumbent get cut before their objective is assigned. ObjectiveBound returns an objective value
+// no such solution can improve on, given the domains of the variables still open, with ok false when it has no
+// estimate to offer. An estimate that's too optimistic only costs pruning; one that isn't optimistic enough cuts
+// off solutions, so relaxations are the way to get them.
+type Bounder interface {
+	ObjectiveBound(problem *Problem, assignment map[string]int, domains map[string][]int) (bound int, ok bool)
+}
+
+// Bounds the objective through the linear equations it's in, S == 3*A + 2*B say, relaxed to the box of the
+// domains: every open variable takes whichever end of its domain helps the objective most, whatever the other
+// constraints say. The tightest of those bounds and the objective's own domain wins. Propagation gets as far
+// when it can cut the objective's domain at the incumbent, but with soft constraints it can't.
+type LinearBounder struct{}
+
+func (LinearBounder) ObjectiveBound(problem *Problem, assignment map[string]int, domains map[string][]int) (int, bool) {
+	objective := problem.Objective
+	if value, assigned := assignment[objective]; assigned {
+		return value, true
+	}
+	values := domains[objective]
+	if len(values) == 0 {
+		return 0, false
+	}
+	// the objective's domain is a bound by itself
+	bound := values[0]
+	for _, value := range values {
+		if problem.Improves(value, bound) {
+			bound = value
+		}
+	}
+
+	for _, constraint := range problem.Constraints {
+		linear, ok := constraint.(*Linear)
+		if !ok || (linear.Operator != "=" && linear.Operator != "==") {
+			continue
+		}
+		coefficients := make(map[string]int)
+		for i, variable := range linear.Variables {
+			coefficients[variable] += linear.Coefficients[i]
+		}
+		own := coefficients[objective]
+		if own == 0 {
+			continue
+		}
+		// own * objective == rest, with rest as big as it gets when that's what improves the objective
+		biggest := problem.Maximizing == (own > 0)
+		rest, known := linear.Constant, true
+		for variable, coefficient := range coefficients {
+			if variable == objective {
+				continue
+			}
+			value, assigned := assignment[variable]
+			if !assigned {
+				domain := domains[variable]
+				if len(domain) == 0 {
+					known = false
+					break
+				}
+				low, high := domain[0], domain[0]
+				for _, candidate := range domain {
+					if candidate < low {
+						low = candidate
+					}
+					if candidate > high {
+						high = candidate
+					}
+				}
+				// -coefficient * value is added to rest, so it grows with the smallest coefficient * value
+				value = low
+				if (coefficient*high < coefficient*low) == biggest {
+					value = high
+				}
+			}
+			rest -= coefficient * value
+		}
+		if !known {
+			continue
+		}
+		relaxed := divCeil(rest, own)
+		if problem.Maximizing {
+			relaxed = divFloor(rest, own)
+		}
+		if problem.Improves(bound, relaxed) {
+			bound = relaxed
+		}
+	}
+	return bound, true
+}
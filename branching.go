@@ -0,0 +1,194 @@
+package csp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// A branch the search could take next: give Variable the value Value
+type Branch struct {
+	Variable string `json:"variable"`
+	Value    int    `json:"value"`
+}
+
+// Where the search is when it asks a BranchScorer: the assigned variables and the domains left, which must not be
+// modified
+type BranchState struct {
+	Depth      int              `json:"depth"`
+	Assignment map[string]int   `json:"assignment"`
+	Domains    map[string][]int `json:"domains"`
+}
+
+// Ranks the branches the search could take, a learned model say. ScoreBranches answers a score for every branch,
+// in order, higher being better: the search branches on the variable of the best one and tries that variable's
+// values best first. The context is cancelled once the solver stops waiting, see Solver.BranchTimeout.
+type BranchScorer interface {
+	ScoreBranches(ctx context.Context, state BranchState, branches []Branch) ([]float64, error)
+}
+
+// A function as a BranchScorer
+type BranchScorerFunc func(ctx context.Context, state BranchState, branches []Branch) ([]float64, error)
+
+func (scorer BranchScorerFunc) ScoreBranches(ctx context.Context, state BranchState,
+	branches []Branch) ([]float64, error) {
+	return scorer(ctx, state, branches)
+}
+
+// A BranchScorer served over HTTP: every call posts {"state": BranchState, "branches": [Branch]} to URL as JSON
+// and reads back {"scores": [number]}. Client is http.DefaultClient when nil.
+type HTTPBranchScorer struct {
+	URL    string
+	Client *http.Client
+}
+
+func (scorer HTTPBranchScorer) ScoreBranches(ctx context.Context, state BranchState,
+	branches []Branch) ([]float64, error) {
+	body, err := json.Marshal(struct {
+		State    BranchState `json:"state"`
+		Branches []Branch    `json:"branches"`
+	}{state, branches})
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, scorer.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	client := scorer.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("branch scorer answered %s", response.Status)
+	}
+	var scored struct {
+		Scores []float64 `json:"scores"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&scored); err != nil {
+		return nil, err
+	}
+	return scored.Scores, nil
+}
+
+// After this many fallbacks in a row the solver stops asking the scorer for the rest of the solve
+const branchScorerGiveUp = 10
+
+// The variable to branch on and the order to try its values in, from the BranchScorer when there is one and it
+// answers in time, from the variable and value orderings otherwise
+func (solver *Solver) branch(depth int, assignment map[string]int, domains map[string][]int) (string, []int) {
+	if solver.BranchScorer != nil && solver.branchFailures < branchScorerGiveUp {
+		if variable, values, ok := solver.scoreBranches(depth, assignment, domains); ok {
+			solver.branchFailures = 0
+			return variable, values
+		}
+		solver.BranchFallbacks++
+		solver.branchFailures++
+	}
+	variable := solver.nextVariable(depth, assignment, domains)
+	return variable, solver.valueOrder(variable, domains[variable])
+}
+
+// Asks the scorer about every value of the BranchCandidates variables the variable ordering would pick first,
+// false if it fails, runs out of time or answers the wrong number of scores
+func (solver *Solver) scoreBranches(depth int, assignment map[string]int,
+	domains map[string][]int) (string, []int, bool) {
+	var branches []Branch
+	for _, variable := range solver.candidateVariables(depth, assignment, domains) {
+		for _, value := range domains[variable] {
+			branches = append(branches, Branch{variable, value})
+		}
+	}
+	ctx := solver.TraceContext
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if solver.BranchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, solver.BranchTimeout)
+		defer cancel()
+	}
+	type answer struct {
+		scores []float64
+		err    error
+	}
+	// buffered, so a scorer that ignores the context and answers late doesn't block forever
+	answered := make(chan answer, 1)
+	state := BranchState{depth, copyAssignment(assignment), domains}
+	go func() {
+		scores, err := solver.BranchScorer.ScoreBranches(ctx, state, branches)
+		answered <- answer{scores, err}
+	}()
+	var scores []float64
+	select {
+	case result := <-answered:
+		if result.err != nil || len(result.scores) != len(branches) {
+			return "", nil, false
+		}
+		scores = result.scores
+	case <-ctx.Done():
+		return "", nil, false
+	}
+
+	best := 0
+	for i := range branches {
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+	variable := branches[best].Variable
+	var values []int
+	var valueScores []float64
+	for i, branch := range branches {
+		if branch.Variable == variable {
+			values = append(values, branch.Value)
+			valueScores = append(valueScores, scores[i])
+		}
+	}
+	sort.Stable(byScore{values, valueScores})
+	return variable, values, true
+}
+
+// The unassigned variables the variable ordering ranks first, at most BranchCandidates of them, 0 meaning all
+func (solver *Solver) candidateVariables(depth int, assignment map[string]int, domains map[string][]int) []string {
+	var open []string
+	for _, variable := range solver.Problem.Variables {
+		if _, assigned := assignment[variable]; !assigned {
+			open = append(open, variable)
+		}
+	}
+	if solver.BranchCandidates <= 0 || len(open) <= solver.BranchCandidates {
+		return open
+	}
+	// the ordering's pick first, then the smallest domains
+	first := solver.nextVariable(depth, assignment, domains)
+	sort.SliceStable(open, func(i, j int) bool {
+		if (open[i] == first) != (open[j] == first) {
+			return open[i] == first
+		}
+		return len(domains[open[i]]) < len(domains[open[j]])
+	})
+	return open[:solver.BranchCandidates]
+}
+
+// Values sorted by score, best first
+type byScore struct {
+	values []int
+	scores []float64
+}
+
+func (sorted byScore) Len() int           { return len(sorted.values) }
+func (sorted byScore) Less(i, j int) bool { return sorted.scores[i] > sorted.scores[j] }
+func (sorted byScore) Swap(i, j int) {
+	sorted.values[i], sorted.values[j] = sorted.values[j], sorted.values[i]
+	sorted.scores[i], sorted.scores[j] = sorted.scores[j], sorted.scores[i]
+}
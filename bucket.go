@@ -0,0 +1,287 @@
+package csp
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// A Factor maps every combination of values of its scope to a count. Table is laid out like a mixed radix number
+// over the scope's domain indices, with the last variable changing fastest.
+type Factor struct {
+	Scope []string
+	Table []*big.Int
+}
+
+// Exact solver that works by inference rather than search. Variables are eliminated one at a time: everything
+// mentioning the variable (its bucket) is multiplied together and the variable is summed out, leaving a smaller
+// factor for a later bucket. Once every variable is gone the remaining number is the solution count, and the
+// buckets can be walked backwards to enumerate solutions without ever backtracking.
+// The cost is exponential in the induced width of the elimination order rather than in the number of variables,
+// which makes it very fast on tree-like models and hopeless on dense ones; MaxTableSize guards against the latter.
+type BucketElimination struct {
+	Problem      *Problem
+	Order        []string // elimination order, first eliminated first
+	MaxTableSize int
+
+	buckets map[string][]*Factor
+	count   *big.Int
+}
+
+// BucketElimination constructor, using a greedy min-degree elimination order
+func NewBucketElimination(problem *Problem) *BucketElimination {
+	return &BucketElimination{problem, MinDegreeOrder(problem), 1 << 22, nil, nil}
+}
+
+// Greedily eliminates whichever variable currently has the fewest neighbours in the constraint graph, connecting
+// its neighbours to each other as it goes. Ties go to the variable that comes first in the problem.
+func MinDegreeOrder(problem *Problem) []string {
+	neighbours := make(map[string]map[string]bool)
+	for _, variable := range problem.Variables {
+		neighbours[variable] = make(map[string]bool)
+	}
+	for _, constraint := range problem.Constraints {
+		for _, a := range constraint.Scope() {
+			for _, b := range constraint.Scope() {
+				if a != b {
+					neighbours[a][b] = true
+				}
+			}
+		}
+	}
+
+	var order []string
+	eliminated := make(map[string]bool)
+	for len(order) < len(problem.Variables) {
+		best := ""
+		for _, variable := range problem.Variables {
+			if !eliminated[variable] && (best == "" || len(neighbours[variable]) < len(neighbours[best])) {
+				best = variable
+			}
+		}
+		for a := range neighbours[best] {
+			delete(neighbours[a], best)
+			for b := range neighbours[best] {
+				if a != b {
+					neighbours[a][b] = true
+				}
+			}
+		}
+		eliminated[best] = true
+		order = append(order, best)
+	}
+	return order
+}
+
+// Runs the elimination and returns the number of solutions
+func (be *BucketElimination) Count() (*big.Int, error) {
+	if err := be.eliminate(); err != nil {
+		return nil, err
+	}
+	return new(big.Int).Set(be.count), nil
+}
+
+// Enumerates up to max solutions (0 for all) by assigning variables in reverse elimination order. Each bucket
+// only mentions its own variable and ones assigned before it, and a value with a non-zero product is guaranteed to
+// extend to a full solution, so there are no dead ends.
+func (be *BucketElimination) Solutions(max int) ([]map[string]int, error) {
+	if err := be.eliminate(); err != nil {
+		return nil, err
+	}
+
+	var solutions []map[string]int
+	indices := make(map[string]int)
+	var assign func(position int) bool
+	assign = func(position int) bool {
+		if position < 0 {
+			solution := make(map[string]int, len(indices))
+			for variable, index := range indices {
+				solution[variable] = be.Problem.Domains[variable][index]
+			}
+			solutions = append(solutions, solution)
+			return max == 0 || len(solutions) < max
+		}
+		variable := be.Order[position]
+		for index := range be.Problem.Domains[variable] {
+			indices[variable] = index
+			possible := true
+			for _, factor := range be.buckets[variable] {
+				if factor.Table[be.offset(factor.Scope, indices)].Sign() == 0 {
+					possible = false
+					break
+				}
+			}
+			if possible && !assign(position-1) {
+				return false
+			}
+		}
+		delete(indices, variable)
+		return true
+	}
+	if be.count.Sign() > 0 {
+		assign(len(be.Order) - 1)
+	}
+	return solutions, nil
+}
+
+func (be *BucketElimination) eliminate() error {
+	if be.Problem.Optimizing() {
+		return fmt.Errorf("bucket elimination only handles satisfaction problems")
+	}
+	if len(be.Order) != len(be.Problem.Variables) {
+		return fmt.Errorf("elimination order has %d variables, problem has %d", len(be.Order), len(be.Problem.Variables))
+	}
+	position := make(map[string]int)
+	for i, variable := range be.Order {
+		position[variable] = i
+	}
+
+	be.buckets = make(map[string][]*Factor)
+	be.count = big.NewInt(1)
+	for _, variable := range be.Order {
+		if len(be.Problem.Domains[variable]) == 0 {
+			be.count.SetInt64(0)
+			return nil
+		}
+	}
+	place := func(factor *Factor) {
+		if len(factor.Scope) == 0 {
+			be.count.Mul(be.count, factor.Table[0])
+			return
+		}
+		first := factor.Scope[0]
+		for _, variable := range factor.Scope {
+			if position[variable] < position[first] {
+				first = variable
+			}
+		}
+		be.buckets[first] = append(be.buckets[first], factor)
+	}
+
+	for _, constraint := range be.Problem.Constraints {
+		factor, err := be.constraintFactor(constraint)
+		if err != nil {
+			return err
+		}
+		place(factor)
+	}
+	for _, variable := range be.Order {
+		message, err := be.sumOut(variable)
+		if err != nil {
+			return err
+		}
+		place(message)
+	}
+	return nil
+}
+
+// Tabulates a constraint over every combination of its scope's values
+func (be *BucketElimination) constraintFactor(constraint Constraint) (*Factor, error) {
+	scope := uniqueScope(constraint.Scope())
+	size, err := be.tableSize(scope)
+	if err != nil {
+		return nil, err
+	}
+	factor := &Factor{scope, make([]*big.Int, size)}
+	assignment := make(map[string]int)
+	be.forEachTuple(scope, func(offset int, indices map[string]int) {
+		for _, variable := range scope {
+			assignment[variable] = be.Problem.Domains[variable][indices[variable]]
+		}
+		factor.Table[offset] = new(big.Int)
+		if constraint.Satisfied(assignment) {
+			factor.Table[offset].SetInt64(1)
+		}
+	})
+	return factor, nil
+}
+
+// Multiplies the variable's bucket together and sums the variable out of the product
+func (be *BucketElimination) sumOut(variable string) (*Factor, error) {
+	var scope []string
+	seen := map[string]bool{variable: true}
+	for _, factor := range be.buckets[variable] {
+		for _, other := range factor.Scope {
+			if !seen[other] {
+				seen[other] = true
+				scope = append(scope, other)
+			}
+		}
+	}
+	size, err := be.tableSize(append([]string{variable}, scope...))
+	if err != nil {
+		return nil, err
+	}
+
+	message := &Factor{scope, make([]*big.Int, size/len(be.Problem.Domains[variable]))}
+	product := new(big.Int)
+	be.forEachTuple(scope, func(offset int, indices map[string]int) {
+		total := new(big.Int)
+		for index := range be.Problem.Domains[variable] {
+			indices[variable] = index
+			product.SetInt64(1)
+			for _, factor := range be.buckets[variable] {
+				product.Mul(product, factor.Table[be.offset(factor.Scope, indices)])
+				if product.Sign() == 0 {
+					break
+				}
+			}
+			total.Add(total, product)
+		}
+		delete(indices, variable)
+		message.Table[offset] = total
+	})
+	return message, nil
+}
+
+func (be *BucketElimination) tableSize(scope []string) (int, error) {
+	size := 1
+	for _, variable := range scope {
+		size *= len(be.Problem.Domains[variable])
+		if size > be.MaxTableSize {
+			return 0, fmt.Errorf("factor over %v exceeds %d entries, the model is too densely connected", scope, be.MaxTableSize)
+		}
+	}
+	return size, nil
+}
+
+// Calls visit for every combination of domain indices of scope, in table order
+func (be *BucketElimination) forEachTuple(scope []string, visit func(offset int, indices map[string]int)) {
+	indices := make(map[string]int)
+	var walk func(position int, offset int)
+	walk = func(position int, offset int) {
+		if position == len(scope) {
+			visit(offset, indices)
+			return
+		}
+		variable := scope[position]
+		for index := range be.Problem.Domains[variable] {
+			indices[variable] = index
+			walk(position+1, offset*len(be.Problem.Domains[variable])+index)
+		}
+		delete(indices, variable)
+	}
+	walk(0, 0)
+}
+
+func (be *BucketElimination) offset(scope []string, indices map[string]int) int {
+	offset := 0
+	for _, variable := range scope {
+		offset = offset*len(be.Problem.Domains[variable]) + indices[variable]
+	}
+	return offset
+}
+
+// Constraints are allowed to mention a variable twice, factors aren't
+func uniqueScope(scope []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, variable := range scope {
+		if !seen[variable] {
+			seen[variable] = true
+			unique = append(unique, variable)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
@@ -0,0 +1,29 @@
+package csp
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+// A path of 70 three-valued variables has 3 * 2^69 colourings, far more than an int64 holds
+func TestBucketEliminationCountsPastInt64(t *testing.T) {
+	problem := NewProblem()
+	for i := 0; i < 70; i++ {
+		problem.AddVariableRange(fmt.Sprint("x", i), 0, 2)
+		if i > 0 {
+			problem.AddConstraint(NewNotEqual(fmt.Sprint("x", i-1), fmt.Sprint("x", i)))
+		}
+	}
+	want := new(big.Int).Lsh(big.NewInt(3), 69)
+	count, err := NewBucketElimination(problem).Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count.Cmp(want) != 0 {
+		t.Errorf("bucket elimination counted %s, want %s", count, want)
+	}
+	if counted := NewCounter(problem).Count(); counted.Cmp(want) != 0 {
+		t.Errorf("counter counted %s, want %s", counted, want)
+	}
+}
@@ -0,0 +1,46 @@
+package csp
+
+import "encoding/binary"
+
+// Past this many entries per constraint the cache stops growing and only answers what it already knows
+const checkCacheLimit = 1 << 16
+
+// Whether constraint i holds, from the cache when its scope has had these values before. A constraint only looks
+// at its own scope, so the values there (and which of them are unassigned) are all the key needs.
+func (solver *Solver) satisfied(i int, assignment map[string]int) bool {
+	constraint := solver.Problem.Constraints[i]
+	if solver.checkCache == nil {
+		return constraint.Satisfied(assignment)
+	}
+	key := solver.checkKey[:0]
+	for _, variable := range constraint.Scope() {
+		if value, assigned := assignment[variable]; assigned {
+			key = append(key, 1)
+			key = binary.AppendVarint(key, int64(value))
+		} else {
+			key = append(key, 0)
+		}
+	}
+	solver.checkKey = key
+	if satisfied, known := solver.checkCache[i][string(key)]; known {
+		solver.CacheHits++
+		return satisfied
+	}
+	solver.CacheMisses++
+	satisfied := constraint.Satisfied(assignment)
+	if solver.checkCache[i] == nil {
+		solver.checkCache[i] = make(map[string]bool)
+	}
+	if len(solver.checkCache[i]) < checkCacheLimit {
+		solver.checkCache[i][string(key)] = satisfied
+	}
+	return satisfied
+}
+
+// The share of constraint checks the cache answered, 0 when nothing was checked
+func (solver *Solver) CacheHitRate() float64 {
+	if solver.CacheHits+solver.CacheMisses == 0 {
+		return 0
+	}
+	return float64(solver.CacheHits) / float64(solver.CacheHits+solver.CacheMisses)
+}
@@ -0,0 +1,118 @@
+package csp
+
+// A copy of the problem that can be changed without touching the original: variables, domains, labels and the
+// lists of constraints are all its own. The constraints themselves are shared, since they're never modified once
+// added, which also keeps them valid keys for Consistency and RemoveConstraint on either problem.
+func (problem *Problem) Clone() *Problem {
+	clone := &Problem{
+		Variables:          append([]string(nil), problem.Variables...),
+		Domains:            make(map[string][]int, len(problem.Domains)),
+		Constraints:        append([]Constraint(nil), problem.Constraints...),
+		Labels:             make(map[string][]string, len(problem.Labels)),
+		Objective:          problem.Objective,
+		Maximizing:         problem.Maximizing,
+		SoftConstraints:    append([]SoftConstraint(nil), problem.SoftConstraints...),
+		DefaultConsistency: problem.DefaultConsistency,
+	}
+	for variable, domain := range problem.Domains {
+		clone.Domains[variable] = append([]int(nil), domain...)
+	}
+	for variable, labels := range problem.Labels {
+		clone.Labels[variable] = append([]string(nil), labels...)
+	}
+	for constraint, level := range problem.Consistency {
+		clone.SetConsistency(constraint, level)
+	}
+	return clone
+}
+
+// A copy of the solver for what-if analysis: its own clone of the problem, the same settings and limits, the
+// results and counts so far, and what it has learned across solves (saved phases, constraint weights). Adding a
+// hypothetical constraint to the clone's Problem and solving it leaves this solver as it was, so the outcomes can
+// be compared. Hooks and tracers are shared. A solver can't be cloned while it's solving.
+func (solver *Solver) Clone() *Solver {
+	solver.mutex.RLock()
+	running := solver.running
+	solver.mutex.RUnlock()
+	if running {
+		panic("csp: Clone called on a Solver that is solving")
+	}
+
+	clone := solver.derived(solver.Problem.Clone())
+	clone.MaxSolutions = solver.MaxSolutions
+	clone.Decompose = solver.Decompose
+	clone.Parallel = solver.Parallel
+	clone.Compile = solver.Compile
+	clone.Anytime = solver.Anytime
+	clone.PhaseSaving = solver.PhaseSaving
+	clone.SymmetryBreaking = solver.SymmetryBreaking
+	clone.Score = solver.Score
+	clone.TopK = solver.TopK
+	clone.OnNode = solver.OnNode
+	clone.OnBound = solver.OnBound
+	clone.Hint = copyAssignment(solver.Hint)
+	clone.InitialWeights = make(map[string]float64, len(solver.InitialWeights))
+	for key, weight := range solver.InitialWeights {
+		clone.InitialWeights[key] = weight
+	}
+
+	// solutions are never modified once found, so the clone can share them
+	clone.Solutions = append([]map[string]int(nil), solver.Solutions...)
+	clone.Scores = append([]float64(nil), solver.Scores...)
+	clone.Best = solver.Best
+	clone.BestObjective = solver.BestObjective
+	clone.BestCost = solver.BestCost
+	clone.Closest = solver.Closest
+	clone.ClosestViolations = solver.ClosestViolations
+	clone.Diagram = solver.Diagram
+	clone.Symmetries = solver.Symmetries
+	clone.Status = solver.Status
+	clone.Nodes = solver.Nodes
+	clone.Failures = solver.Failures
+	clone.Propagations = solver.Propagations
+	clone.Restarts = solver.Restarts
+	clone.CacheHits = solver.CacheHits
+	clone.CacheMisses = solver.CacheMisses
+	clone.ContextHits = solver.ContextHits
+	clone.BoundPrunes = solver.BoundPrunes
+	clone.Cores, clone.CoreBound = solver.Cores, solver.CoreBound
+	clone.phases = copyAssignment(solver.phases)
+	clone.weights = append([]float64(nil), solver.weights...)
+	clone.snapshot = solver.Snapshot()
+	return clone
+}
+
+// Nil stays nil
+func copyAssignment(assignment map[string]int) map[string]int {
+	if assignment == nil {
+		return nil
+	}
+	copied := make(map[string]int, len(assignment))
+	for variable, value := range assignment {
+		copied[variable] = value
+	}
+	return copied
+}
+
+// A copy of the tree whose nodes and variables are all its own, so pruning or deepening it leaves this one alone
+func (root *Root) Clone() *Root {
+	clone := &Root{Depth: root.Depth}
+	for _, child := range root.Children {
+		clone.Children = append(clone.Children, child.clone())
+	}
+	return clone
+}
+
+func (node *Node) clone() *Node {
+	if node == nil {
+		return nil
+	}
+	clone := &Node{Tombstone: node.Tombstone}
+	if node.Variable != nil {
+		clone.Variable = NewVariable(node.Variable.Letter, node.Variable.Value)
+	}
+	for _, child := range node.Children {
+		clone.Children = append(clone.Children, child.clone())
+	}
+	return clone
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// csp batch manifest.txt [--strategy=lex] [--timeout=30s] [--solutions=1] [--no-propagation] [--out=results]
+func RunBatch(args []string) {
+	usage := "usage: csp batch manifest.txt [--strategy=lex] [--timeout=30s] [--solutions=1] [--no-propagation] [--out=results]"
+	var ordering csp.VariableOrdering
+	var timeout time.Duration
+	maxSolutions, propagation := 1, true
+	var manifest string
+	out := "results"
+	for _, arg := range args {
+		name, value, _ := strings.Cut(arg, "=")
+		var err error
+		switch name {
+		case "--strategy":
+			ordering, err = csp.ParseVariableOrdering(value)
+		case "--timeout":
+			if timeout, err = time.ParseDuration(value); err == nil && timeout < 0 {
+				err = errors.New("--timeout can't be negative")
+			}
+		case "--solutions":
+			if maxSolutions, err = strconv.Atoi(value); err == nil && maxSolutions < 0 {
+				err = errors.New("--solutions can't be negative")
+			}
+		case "--no-propagation":
+			propagation = false
+		case "--out":
+			out = value
+		default:
+			if strings.HasPrefix(arg, "--") || manifest != "" {
+				err = errors.New(usage)
+			}
+			manifest = arg
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+	}
+	if manifest == "" {
+		fail(usage)
+		return
+	}
+	models, err := csp.ReadManifest(manifest)
+	if err == nil {
+		err = os.MkdirAll(out, 0755)
+	}
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	summary := csp.BatchSummary{Statuses: make(map[string]int)}
+	written := map[string]bool{"summary.json": true}
+	for _, model := range models {
+		var result csp.SolveResult
+		problem, err := csp.LoadModelFile(model)
+		if err != nil {
+			result = csp.SolveResult{Status: "error", Solutions: []map[string]interface{}{}, Error: err.Error()}
+			summary.Errors++
+		} else {
+			solver := csp.NewSolver(problem)
+			solver.VariableOrdering = ordering
+			solver.Timeout = timeout
+			solver.MaxSolutions = maxSolutions
+			solver.Propagation = propagation
+			solver.Sorted = true
+			start := time.Now()
+			solver.Solve()
+			result = csp.NewSolveResult(solver, time.Since(start))
+		}
+		result.Model = model
+		summary.Instances++
+		summary.Statuses[result.Status]++
+		summary.Nodes += result.Nodes
+		summary.Seconds += result.Seconds
+
+		// results are named after their models, numbered when two models share a name
+		base := strings.TrimSuffix(filepath.Base(model), filepath.Ext(model))
+		name := base + ".json"
+		for n := 2; written[name]; n++ {
+			name = fmt.Sprintf("%s-%d.json", base, n)
+		}
+		written[name] = true
+		if err := csp.WriteJSONFile(filepath.Join(out, name), result); err != nil {
+			fail(err)
+			return
+		}
+		summary.Results = append(summary.Results, name)
+		fmt.Printf("%-40s %-14s %10d nodes %10.3fs\n", model, result.Status, result.Nodes, result.Seconds)
+	}
+
+	if err := csp.WriteJSONFile(filepath.Join(out, "summary.json"), summary); err != nil {
+		fail(err)
+		return
+	}
+	var statuses []string
+	for _, status := range []string{"optimal", "satisfiable", "unsatisfiable", "unknown", "error"} {
+		if summary.Statuses[status] > 0 {
+			statuses = append(statuses, fmt.Sprintf("%d %s", summary.Statuses[status], status))
+		}
+	}
+	fmt.Printf("%d instances: %s, %d nodes, %.3fs. Results in %s\n", summary.Instances,
+		strings.Join(statuses, ", "), summary.Nodes, summary.Seconds, out)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// csp bench model.json [--strategies=lex,mrv,domwdeg] [--repeat=10] [--solutions=N] [--no-propagation]
+func RunBench(args []string) {
+	usage := "usage: csp bench model.json [--strategies=lex,mrv,domwdeg] [--repeat=10] [--solutions=N] [--no-propagation]"
+	orderings := []csp.VariableOrdering{csp.LexicographicOrder, csp.MinimumRemainingValues, csp.DomainOverWeightedDegree}
+	repeat, maxSolutions, propagation := 10, 0, true
+	var model string
+	for _, arg := range args {
+		name, value, _ := strings.Cut(arg, "=")
+		var err error
+		switch name {
+		case "--strategies":
+			orderings = nil
+			for _, strategy := range strings.Split(value, ",") {
+				var ordering csp.VariableOrdering
+				if ordering, err = csp.ParseVariableOrdering(strategy); err != nil {
+					break
+				}
+				orderings = append(orderings, ordering)
+			}
+		case "--repeat":
+			if repeat, err = strconv.Atoi(value); err == nil && repeat < 1 {
+				err = fmt.Errorf("--repeat must be at least 1")
+			}
+		case "--solutions":
+			if maxSolutions, err = strconv.Atoi(value); err == nil && maxSolutions < 0 {
+				err = fmt.Errorf("--solutions can't be negative")
+			}
+		case "--no-propagation":
+			propagation = false
+		default:
+			if strings.HasPrefix(arg, "--") || model != "" {
+				err = errors.New(usage)
+			}
+			model = arg
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+	}
+	if model == "" {
+		fail(usage)
+		return
+	}
+	problem, err := csp.LoadModelFile(model)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	results := csp.Benchmark(problem, orderings, repeat, func(solver *csp.Solver) {
+		solver.Propagation = propagation
+		solver.MaxSolutions = maxSolutions
+	})
+	fmt.Printf("%s, %d runs each\n", model, repeat)
+	csp.PrintBenchTable(os.Stdout, results)
+}
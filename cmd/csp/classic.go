@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// The original experiment: solve the A-H puzzle with the plain ordering and with the selection heuristic
+func RunClassicDemo() {
+	root := csp.Root{}
+	root.Depth = 1
+	root.PopulateRoot("A")
+
+	for i := 0; i < 8; i++ {
+		root.GenerateTree()
+	}
+
+	root.PrintValidPaths(os.Stdout, colorize)
+	root.ReportInvalidPaths(os.Stdout)
+
+	heuristicRoot := csp.Root{}
+	heuristicRoot.Depth = 1
+	heuristicRoot.PopulateRoot("H")
+
+	for i := 0; i < 8; i++ {
+		heuristicRoot.GenerateTreeWithHeuristic()
+	}
+
+	heuristicRoot.PrintValidPathsWithHeuristic(os.Stdout, colorize)
+	heuristicRoot.ReportInvalidPaths(os.Stdout)
+}
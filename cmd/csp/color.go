@@ -0,0 +1,11 @@
+package main
+
+import "os"
+
+// Whether to color terminal output: on when stdout is a terminal, unless NO_COLOR is set or --no-color given
+var colorize = isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == ""
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	csp "github.com/GSGerritsen/go-csp"
+	"github.com/GSGerritsen/go-csp/models"
+)
+
+// csp color graph.col|graph.mtx [k]
+// Without k, tries k = 1, 2, ... until the graph is colorable, which gives the chromatic number.
+func RunColoring(args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		fail("usage: csp color graph.col|graph.mtx [k]")
+		return
+	}
+	vertexCount, edges, err := models.LoadGraph(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	low, high := 1, vertexCount
+	if len(args) == 2 {
+		k, err := strconv.Atoi(args[1])
+		if err != nil || k < 1 {
+			fail(fmt.Sprintf("invalid color count %q", args[1]))
+			return
+		}
+		low, high = k, k
+	}
+
+	for k := low; k <= high; k++ {
+		solver := csp.NewSolver(models.GraphColoring(edges, k))
+		solver.MaxSolutions = 1
+		solutions := solver.Solve()
+		ExitStatus = StatusExitCode(solver.Status)
+		if len(solutions) == 0 {
+			fmt.Printf("Not %d-colorable (nodes: %d)\n", k, solver.Nodes)
+			continue
+		}
+		fmt.Printf("%d-colorable (nodes: %d)\n", k, solver.Nodes)
+		for _, variable := range solver.Problem.Variables {
+			fmt.Printf("%s:%d\n", variable, solutions[0][variable])
+		}
+		return
+	}
+}
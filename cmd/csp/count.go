@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// What csp solve --count --format=json prints. The count is a string since it easily outgrows a JSON number.
+type countResult struct {
+	Status  string  `json:"status"`
+	Method  string  `json:"method"`
+	Count   string  `json:"count"`
+	Nodes   int     `json:"nodes,omitempty"`
+	Size    int     `json:"size,omitempty"` // of the decision diagram
+	Seconds float64 `json:"seconds"`
+}
+
+// Counts the solutions of the problem's hard constraints without listing them, by search (see Counter), by
+// bucket elimination (see BucketElimination) or by compiling them into a decision diagram (see CompileMDD)
+func runCount(problem *csp.Problem, method string, format string, quiet bool) {
+	start := time.Now()
+	var count *big.Int
+	nodes, size := 0, 0
+	switch method {
+	case "mdd":
+		mdd := csp.CompileMDD(problem)
+		count = mdd.Count()
+		size = mdd.Size()
+	case "bucket":
+		var err error
+		if count, err = csp.NewBucketElimination(problem).Count(); err != nil {
+			if format == "json" {
+				ExitStatus = ExitError
+				printJSON(csp.SolveResult{Status: "error", Solutions: []map[string]interface{}{}, Error: err.Error()})
+				return
+			}
+			fail(err)
+			return
+		}
+	default:
+		counter := csp.NewCounter(problem)
+		count = counter.Count()
+		nodes = counter.Nodes
+	}
+
+	status := csp.Satisfiable
+	ExitStatus = ExitSatisfiable
+	if count.Sign() == 0 {
+		status = csp.Unsatisfiable
+		ExitStatus = ExitUnsatisfiable
+	}
+	if format == "json" {
+		printJSON(countResult{status.String(), method, count.String(), nodes, size, time.Since(start).Seconds()})
+		return
+	}
+	if quiet {
+		fmt.Println(count)
+		return
+	}
+	fmt.Printf("Solutions: %s (counted by %s)\n", count, method)
+	if nodes > 0 {
+		fmt.Println("Nodes:", nodes)
+	}
+	if size > 0 {
+		fmt.Println("Diagram nodes:", size)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	csp "github.com/GSGerritsen/go-csp"
+	"github.com/GSGerritsen/go-csp/models"
+)
+
+// csp cryptarithm SEND+MORE=MONEY
+func RunCryptarithm(args []string) {
+	if len(args) != 1 {
+		fail("usage: csp cryptarithm SEND+MORE=MONEY")
+		return
+	}
+	problem, err := models.Cryptarithm(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	solver := csp.NewSolver(problem)
+	solutions := solver.Solve()
+	ExitStatus = StatusExitCode(solver.Status)
+	if len(solutions) == 0 {
+		fmt.Println("No solution")
+	}
+	for _, solution := range solutions {
+		equation := args[0]
+		for variable, value := range solution {
+			if len(variable) == 1 {
+				equation = strings.Replace(strings.ToUpper(equation), variable, strconv.Itoa(value), -1)
+			}
+		}
+		fmt.Println(equation)
+	}
+	fmt.Printf("Nodes: %d, failures: %d\n", solver.Nodes, solver.Failures)
+}
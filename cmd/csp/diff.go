@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// csp diff model.json CONFIG CONFIG, a configuration being a variable ordering, optionally with /prop to propagate
+func RunDiff(args []string) {
+	if len(args) != 3 {
+		fail("usage: csp diff model.json CONFIG CONFIG, CONFIG being lex, mrv or domwdeg, optionally with /prop")
+		return
+	}
+	problem, err := csp.LoadModelFile(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+	var solvers [2]*csp.Solver
+	for side, config := range args[1:] {
+		name, propagation := strings.CutSuffix(config, "/prop")
+		ordering, err := csp.ParseVariableOrdering(name)
+		if err != nil {
+			fail(err)
+			return
+		}
+		solvers[side] = csp.NewSolver(problem)
+		solvers[side].VariableOrdering = ordering
+		solvers[side].Propagation = propagation
+	}
+	csp.DiffSearches([2]string{args[1], args[2]}, solvers).Print(os.Stdout)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// Solves with one of the engines besides the Solver that csp solve can pick, printing what it found the same way
+// whichever engine it was
+func runEngine(problem *csp.Problem, solve func() (csp.Result, error), format string, quiet bool) {
+	start := time.Now()
+	result, err := solve()
+	if err != nil && format == "json" {
+		ExitStatus = ExitError
+		printJSON(csp.SolveResult{Status: "error", Solutions: []map[string]interface{}{}, Error: err.Error()})
+		return
+	}
+	if err != nil {
+		fail(err)
+		return
+	}
+	ExitStatus = StatusExitCode(result.Status)
+	best := map[string]int(nil)
+	if problem.Optimizing() && len(result.Solutions) > 0 {
+		best = result.Solutions[len(result.Solutions)-1]
+	}
+
+	if format == "json" {
+		output := csp.SolveResult{Status: result.Status.String(), Solutions: []map[string]interface{}{},
+			Nodes: result.Stats.Nodes, Failures: result.Stats.Failures, Seconds: time.Since(start).Seconds()}
+		solutions := result.Solutions
+		if problem.Optimizing() {
+			solutions = nil
+			if best != nil {
+				solutions = []map[string]int{best}
+				cost := result.BestCost
+				output.Cost = &cost
+				if problem.Objective != "" {
+					objective := result.BestObjective
+					output.Objective = &objective
+				}
+			}
+		}
+		for _, solution := range solutions {
+			output.Solutions = append(output.Solutions, csp.SolutionValues(problem, solution))
+		}
+		printJSON(output)
+		return
+	}
+	if problem.Optimizing() {
+		switch {
+		case best != nil && quiet:
+			fmt.Println(csp.FormatSolution(problem, best))
+		case quiet:
+		case best == nil:
+			fmt.Printf("No solution (%s)\n", result.Status)
+		default:
+			fmt.Println(csp.FormatSolution(problem, best))
+			if problem.Objective != "" {
+				fmt.Printf("Objective: %d\n", result.BestObjective)
+			}
+			fmt.Printf("Cost: %d (%s after %d improvements)\n", result.BestCost, result.Status, len(result.Solutions))
+		}
+	} else {
+		for _, solution := range result.Solutions {
+			fmt.Println(csp.FormatSolution(problem, solution))
+		}
+		if !quiet {
+			fmt.Printf("Solutions: %d (%s)\n", len(result.Solutions), result.Status)
+		}
+	}
+	if !quiet && result.Stats.Nodes > 0 {
+		fmt.Printf("Nodes: %d, failures: %d\n", result.Stats.Nodes, result.Stats.Failures)
+	}
+}
+
+// The Result of an engine that only handles satisfaction problems and returns up to max solutions (0 for all),
+// so stopping short of max means it ran out of them
+func satisfactionResult(solutions []map[string]int, max int, stats csp.Stats) csp.Result {
+	result := csp.Result{Solutions: solutions, Complete: max == 0 || len(solutions) < max, Stats: stats}
+	result.Status = csp.Satisfiable
+	if len(solutions) == 0 {
+		result.Status = csp.Unsatisfiable
+	}
+	return result
+}
+
+// Cycle-cutset conditioning, see CycleCutset
+func solveCutset(problem *csp.Problem, maxSolutions int) func() (csp.Result, error) {
+	return func() (csp.Result, error) {
+		cc := csp.NewCycleCutset(problem)
+		solutions, err := cc.Solve(maxSolutions)
+		return satisfactionResult(solutions, maxSolutions, csp.Stats{Nodes: cc.Nodes}), err
+	}
+}
+
+// Lazy clause generation, see LCGSolver
+func solveLCG(problem *csp.Problem, maxSolutions int) func() (csp.Result, error) {
+	return func() (csp.Result, error) {
+		solver := csp.NewLCGSolver(problem)
+		solver.MaxSolutions = maxSolutions
+		solutions, err := solver.Solve()
+		return satisfactionResult(solutions, maxSolutions, csp.Stats{Nodes: solver.Nodes, Failures: solver.Failures}),
+			err
+	}
+}
+
+// Solves a binary reformulation with the Solver and decodes the solutions, see HiddenVariableEncoding and
+// DualEncoding. Soft constraints don't survive either encoding and the objective doesn't survive the dual one, so
+// those models are refused rather than solved as something else.
+func solveEncoded(problem *csp.Problem, encoding string, maxSolutions int) func() (csp.Result, error) {
+	return func() (csp.Result, error) {
+		if len(problem.SoftConstraints) > 0 || encoding == "dual" && problem.Objective != "" {
+			return csp.Result{}, fmt.Errorf("the %s encoding would lose the model's objective or soft constraints",
+				encoding)
+		}
+		encode := csp.HiddenVariableEncoding
+		if encoding == "dual" {
+			encode = csp.DualEncoding
+		}
+		encoded, err := encode(problem)
+		if err != nil {
+			return csp.Result{}, err
+		}
+		solver := csp.NewModelSolver(encoded.Problem, maxSolutions, csp.WithTracer(spanTracer))
+		solver.Solve()
+		result := solver.Result()
+		result.Solutions = nil
+		for _, solution := range solver.Solutions {
+			result.Solutions = append(result.Solutions, encoded.Decode(solution))
+		}
+		return result, nil
+	}
+}
+
+// The engine a csp solve flag picked. External backends are named minizinc, minizinc:SOLVER or sat:COMMAND.
+func pickEngine(name string, problem *csp.Problem, maxSolutions int) func() (csp.Result, error) {
+	kind, command, _ := strings.Cut(name, ":")
+	switch kind {
+	case "minizinc":
+		backend := &csp.MiniZincBackend{Solver: command, MaxSolutions: maxSolutions}
+		return func() (csp.Result, error) { return backend.Solve(problem) }
+	case "sat":
+		backend := &csp.SATBackend{Command: command, MaxSolutions: maxSolutions}
+		return func() (csp.Result, error) { return backend.Solve(problem) }
+	case "cutset":
+		return solveCutset(problem, maxSolutions)
+	case "lcg":
+		return solveLCG(problem, maxSolutions)
+	case "hidden", "dual":
+		return solveEncoded(problem, name, maxSolutions)
+	}
+	panic("unknown engine " + name)
+}
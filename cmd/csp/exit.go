@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// Exit codes, so scripts can tell how a solve went, or that the command couldn't run at all
+const (
+	ExitSatisfiable   = 0
+	ExitUnsatisfiable = 1
+	ExitUnknown       = 2 // a limit or timeout stopped the search before it could tell
+	ExitError         = 3
+)
+
+// What main exits with once the command returns
+var ExitStatus = ExitSatisfiable
+
+// The exit code for a solve that ended this way
+func StatusExitCode(status csp.SolveStatus) int {
+	switch status {
+	case csp.Satisfiable, csp.Optimal:
+		return ExitSatisfiable
+	case csp.Unsatisfiable:
+		return ExitUnsatisfiable
+	}
+	return ExitUnknown
+}
+
+// Prints an error or usage line on standard error and makes the command exit with ExitError
+func fail(a ...interface{}) {
+	fmt.Fprintln(os.Stderr, a...)
+	ExitStatus = ExitError
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"os"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// csp explore model.json
+func RunExplore(args []string) {
+	if len(args) != 1 {
+		fail("usage: csp explore model.json")
+		return
+	}
+	problem, err := csp.LoadModelFile(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+	// no propagation, so every failed branch comes down to the one constraint it broke
+	csp.ExploreTree(problem, csp.RecordSearch(csp.NewSolver(problem)), os.Stdin, os.Stdout)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// csp export smtlib|cpsat|minizinc|cnf model.json [out]
+//
+// Writes the model in another solver's input format, to standard output unless a file is given: SMT-LIB2 for Z3
+// or cvc5 (see ExportSMTLIB), a CP-SAT model in protobuf text format for OR-Tools (see ExportCPSAT), MiniZinc (see
+// ExportMiniZinc) or the direct encoding as DIMACS CNF for a SAT solver (see EncodeCNF).
+func RunExport(args []string) {
+	exporters := map[string]func(*csp.Problem, io.Writer) error{
+		"smtlib":   csp.ExportSMTLIB,
+		"cpsat":    csp.ExportCPSAT,
+		"minizinc": csp.ExportMiniZinc,
+		"cnf": func(problem *csp.Problem, w io.Writer) error {
+			cnf, err := csp.EncodeCNF(problem)
+			if err != nil {
+				return err
+			}
+			return cnf.Write(w)
+		},
+	}
+	if len(args) < 2 || len(args) > 3 || exporters[args[0]] == nil {
+		fail("usage: csp export smtlib|cpsat|minizinc|cnf model.json [out]")
+		return
+	}
+	problem, err := csp.LoadModelFile(args[1])
+	if err != nil {
+		fail(err)
+		return
+	}
+	var out io.Writer = os.Stdout
+	if len(args) == 3 {
+		file, err := os.Create(args[2])
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := exporters[args[0]](problem, out); err != nil {
+		fail(err)
+	}
+}
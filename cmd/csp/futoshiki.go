@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+
+	csp "github.com/GSGerritsen/go-csp"
+	"github.com/GSGerritsen/go-csp/models"
+)
+
+// csp futoshiki puzzle.txt / csp kakuro puzzle.txt
+func RunGridPuzzle(kind string, args []string) {
+	if len(args) != 1 {
+		fail(fmt.Sprintf("usage: csp %s puzzle.txt", kind))
+		return
+	}
+
+	var problem *csp.Problem
+	var print func(solution map[string]int)
+	if kind == "futoshiki" {
+		grid, inequalities, err := models.LoadFutoshiki(args[0])
+		if err != nil {
+			fail(err)
+			return
+		}
+		problem = models.Futoshiki(grid, inequalities)
+		print = func(solution map[string]int) { models.PrintGrid(len(grid), solution) }
+	} else {
+		grid, err := models.LoadKakuro(args[0])
+		if err != nil {
+			fail(err)
+			return
+		}
+		problem = models.Kakuro(grid)
+		print = func(solution map[string]int) { models.PrintKakuro(grid, solution) }
+	}
+
+	solver := csp.NewSolver(problem)
+	solver.MaxSolutions = 2
+	solutions := solver.Solve()
+	ExitStatus = StatusExitCode(solver.Status)
+	if len(solutions) == 0 {
+		fmt.Println("No solution")
+		return
+	}
+	print(solutions[0])
+	if len(solutions) > 1 {
+		fmt.Println("Puzzle has more than one solution")
+	}
+	fmt.Printf("Nodes: %d, failures: %d\n", solver.Nodes, solver.Failures)
+}
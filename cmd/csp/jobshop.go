@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	csp "github.com/GSGerritsen/go-csp"
+	"github.com/GSGerritsen/go-csp/models"
+)
+
+// csp jobshop instance.txt
+func RunJobShop(args []string) {
+	if len(args) != 1 {
+		fail("usage: csp jobshop instance.txt")
+		return
+	}
+	jobs, err := models.LoadJobShop(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	solver := csp.NewSolver(models.JobShop(jobs))
+	solver.Solve()
+	ExitStatus = StatusExitCode(solver.Status)
+	if solver.Best == nil {
+		fmt.Println("No schedule")
+		return
+	}
+	for _, incumbent := range solver.Solutions {
+		fmt.Printf("Found schedule with makespan %d\n", incumbent["makespan"])
+	}
+	fmt.Printf("Optimal makespan: %d\n", solver.BestObjective)
+	for j, job := range jobs {
+		fmt.Printf("Job %d:", j+1)
+		for o, operation := range job {
+			start := solver.Best[models.OperationVariable(j, o)]
+			fmt.Printf(" M%d[%d-%d]", operation.Machine, start, start+operation.Duration)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("Nodes: %d, failures: %d\n", solver.Nodes, solver.Failures)
+}
@@ -0,0 +1,115 @@
+//go:build !(js && wasm)
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+func main() {
+	var args []string
+	var profiling csp.Profiling
+	spans, otlp := "", ""
+	for i := 0; i < len(os.Args); i++ {
+		switch arg := os.Args[i]; {
+		case arg == "--no-color":
+			colorize = false
+		case arg == "--cpuprofile" && i+1 < len(os.Args):
+			i++
+			profiling.CPUProfile = os.Args[i]
+		case arg == "--memprofile" && i+1 < len(os.Args):
+			i++
+			profiling.MemoryProfile = os.Args[i]
+		case arg == "--trace" && i+1 < len(os.Args):
+			i++
+			profiling.Trace = os.Args[i]
+		case arg == "--spans" && i+1 < len(os.Args):
+			i++
+			spans = os.Args[i]
+		case arg == "--otlp" && i+1 < len(os.Args):
+			i++
+			otlp = os.Args[i]
+		default:
+			args = append(args, arg)
+		}
+	}
+	os.Args = args
+	stopTracing, err := startTracing(spans, otlp)
+	defer stopTracing()
+	if err != nil {
+		fail(err)
+		os.Exit(ExitStatus)
+	}
+	stopProfiling, err := profiling.Start()
+	defer stopProfiling()
+	if err != nil {
+		fail(err)
+		stopProfiling()
+		stopTracing()
+		os.Exit(ExitStatus)
+	}
+	if len(os.Args) < 2 {
+		RunClassicDemo()
+		return
+	}
+
+	switch os.Args[1] {
+	case "sudoku":
+		RunSudoku(os.Args[2:])
+	case "queens":
+		RunQueens(os.Args[2:])
+	case "color":
+		RunColoring(os.Args[2:])
+	case "cryptarithm":
+		RunCryptarithm(os.Args[2:])
+	case "nonogram":
+		RunNonogram(os.Args[2:])
+	case "futoshiki", "kakuro":
+		RunGridPuzzle(os.Args[1], os.Args[2:])
+	case "jobshop":
+		RunJobShop(os.Args[2:])
+	case "random":
+		RunRandom(os.Args[2:])
+	case "sweep":
+		RunSweep(os.Args[2:])
+	case "solve":
+		RunSolve(os.Args[2:])
+	case "repl":
+		RunREPL(os.Args[2:])
+	case "mermaid":
+		RunMermaid(os.Args[2:])
+	case "export":
+		RunExport(os.Args[2:])
+	case "report":
+		RunReport(os.Args[2:])
+	case "tightness":
+		RunTightness(os.Args[2:])
+	case "tune":
+		RunTune(os.Args[2:])
+	case "explore":
+		RunExplore(os.Args[2:])
+	case "local":
+		RunLocal(os.Args[2:])
+	case "serve":
+		RunServe(os.Args[2:])
+	case "bench":
+		RunBench(os.Args[2:])
+	case "diff":
+		RunDiff(os.Args[2:])
+	case "batch":
+		RunBatch(os.Args[2:])
+	case "demo":
+		RunDemo(os.Args[2:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", os.Args[1])
+		fail(usage)
+	}
+	if ExitStatus != ExitSatisfiable {
+		stopProfiling()
+		stopTracing()
+		os.Exit(ExitStatus)
+	}
+}
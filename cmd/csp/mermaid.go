@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// csp mermaid model.json [graph | tree]
+func RunMermaid(args []string) {
+	if len(args) < 1 || len(args) > 2 || len(args) == 2 && args[1] != "graph" && args[1] != "tree" {
+		fail("usage: csp mermaid model.json [graph | tree]")
+		return
+	}
+	problem, err := csp.LoadModelFile(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+	if len(args) == 2 && args[1] == "tree" {
+		err = csp.ExportMermaidTree(problem, csp.RecordSearch(csp.NewSolver(problem)), os.Stdout)
+	} else {
+		err = csp.ExportMermaidConstraintGraph(problem, os.Stdout)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
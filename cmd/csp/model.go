@@ -0,0 +1,392 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	csp "github.com/GSGerritsen/go-csp"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Where csp solve and serve send their spans, set by --spans and --otlp; nil traces nothing
+var spanTracer trace.Tracer
+
+// csp solve [--watch] [--quiet] [--timeout=30s] [--format=text|json] [--score=EXPR [--top=K]]
+// [--strategy=lex|mrv|domwdeg] [--restarts=CUTOFF] [--decay=0.5] [--weights=FILE] [--probe=DIVES] [--cache]
+// [--and-or] [--gap=FRACTION] [--bounds] [--objective-order] [--linear-bound] [--optimize=bnb|core|rds]
+// [--auto] [--profile=FILE] [--scorer=URL [--scorer-timeout=50ms] [--scorer-candidates=N]] [--estimate=PROBES]
+// [--progress] [--export=FILE.tsv|FILE.xlsx] [--count[=search|bucket|mdd]] [--cutset] [--lcg]
+// [--encoding=hidden|dual] [--backend=minizinc[:SOLVER]|sat:COMMAND]
+// model.json [max solutions]
+//
+// Exits with ExitSatisfiable, ExitUnsatisfiable or ExitUnknown, depending on how the solve went. With --quiet only
+// the solutions get printed, the best one when optimizing. --format=json prints a SolveResult instead. A model
+// named - is read from standard input. --score lists the solutions best first by an expression over the
+// variables (see ParseScore), --top only the K best of them. --restarts and --decay set RestartCutoff and
+// WeightDecay; --weights has domwdeg start from the weights in FILE, if it exists, and saves them there after.
+// --probe dives into the search space before searching, see Solver.ProbeDives, and --cache memoizes constraint
+// checks, see Solver.CheckCache. --and-or solves by AND/OR search, see Solver.AndOr. Solutions come out sorted
+// by value in the model's variable order whatever the strategy, see Solver.Sorted. When optimizing, --gap stops
+// once the incumbent is within FRACTION of optimal and --bounds prints the bounds as they move, see BoundEvent.
+// --objective-order tries the values best for the objective first, see Solver.ObjectiveOrdering,
+// --linear-bound prunes with the linear relaxation of the objective's equations, see LinearBounder, and
+// --optimize picks how the soft constraint cost gets optimized, see OptimizationStrategy. --auto picks all of
+// the search settings from the model instead, see Solver.AutoConfigure, and prints what it picked; --profile
+// takes them from a profile csp tune wrote, see Race. --scorer has the service at URL rank the branches, see
+// HTTPBranchScorer, falling back on the orderings when it takes longer than --scorer-timeout. --estimate doesn't
+// solve, but estimates how big the search tree is from that many random probes, see TreeEstimate. --progress
+// shows a progress bar on standard error, see ProgressEvent. --export writes the solutions to a spreadsheet too,
+// see ExportSolutions, the improving ones in order when optimizing. --count counts the solutions of the hard
+// constraints instead of listing them, by searching with component caching (see Counter), with --count=bucket by
+// bucket elimination (see BucketElimination) and with --count=mdd by compiling a decision diagram (see CompileMDD).
+// --cutset solves by cycle-cutset conditioning instead of the Solver, see CycleCutset, and --lcg by lazy clause
+// generation, see LCGSolver; the search settings don't apply to either. --encoding has the Solver solve a binary
+// reformulation of the model, see HiddenVariableEncoding and DualEncoding. --backend hands the model to an external
+// solver instead: the minizinc tool, optionally with one of its solvers, or a SAT solver binary such as kissat, see
+// MiniZincBackend and SATBackend.
+func RunSolve(args []string) {
+	var positional []string
+	watch, quiet, format := false, false, "text"
+	var timeout time.Duration
+	var score csp.ScoreFunc
+	top := 0
+	strategy, restarts, decay, weightsFile := csp.LexicographicOrder, 0, 0.0, ""
+	dives, cache, andOr := 0, false, false
+	gap, bounds, objectiveOrder, linearBound := 0.0, false, false, false
+	optimization, auto, profile := csp.BranchAndBound, false, ""
+	scorer, scorerTimeout, scorerCandidates := "", 50*time.Millisecond, 0
+	estimate, progress, export := 0, false, ""
+	count := ""
+	var engines []string
+	for _, arg := range args {
+		name, value, _ := strings.Cut(arg, "=")
+		switch name {
+		case "--watch":
+			watch = true
+		case "--quiet":
+			quiet = true
+		case "--format":
+			if value != "text" && value != "json" {
+				fail("invalid format", value+", expected text or json")
+				return
+			}
+			format = value
+		case "--timeout":
+			var err error
+			if timeout, err = time.ParseDuration(value); err != nil || timeout < 0 {
+				fail("invalid timeout", value)
+				return
+			}
+		case "--score":
+			var err error
+			if score, err = csp.ParseScore(value); err != nil {
+				fail("invalid score:", err)
+				return
+			}
+		case "--top":
+			var err error
+			if top, err = strconv.Atoi(value); err != nil || top < 1 {
+				fail("invalid top", value)
+				return
+			}
+		case "--strategy":
+			var err error
+			if strategy, err = csp.ParseVariableOrdering(value); err != nil {
+				fail(err)
+				return
+			}
+		case "--restarts":
+			var err error
+			if restarts, err = strconv.Atoi(value); err != nil || restarts < 1 {
+				fail("invalid restart cutoff", value)
+				return
+			}
+		case "--decay":
+			var err error
+			if decay, err = strconv.ParseFloat(value, 64); err != nil || decay < 0 || decay > 1 {
+				fail("invalid decay", value+", expected a fraction from 0 to 1")
+				return
+			}
+		case "--weights":
+			weightsFile = value
+		case "--cache":
+			cache = true
+		case "--and-or":
+			andOr = true
+		case "--gap":
+			var err error
+			if gap, err = strconv.ParseFloat(value, 64); err != nil || gap < 0 {
+				fail("invalid gap", value+", expected a fraction such as 0.05")
+				return
+			}
+		case "--bounds":
+			bounds = true
+		case "--objective-order":
+			objectiveOrder = true
+		case "--linear-bound":
+			linearBound = true
+		case "--auto":
+			auto = true
+		case "--profile":
+			profile = value
+		case "--scorer":
+			scorer = value
+		case "--scorer-timeout":
+			var err error
+			if scorerTimeout, err = time.ParseDuration(value); err != nil || scorerTimeout < 0 {
+				fail("invalid scorer timeout", value)
+				return
+			}
+		case "--progress":
+			progress = true
+		case "--export":
+			export = value
+		case "--count":
+			if value == "" {
+				value = "search"
+			}
+			if value != "search" && value != "bucket" && value != "mdd" {
+				fail("invalid counting method", value+", expected search, bucket or mdd")
+				return
+			}
+			count = value
+		case "--cutset":
+			engines = append(engines, "cutset")
+		case "--lcg":
+			engines = append(engines, "lcg")
+		case "--encoding":
+			if value != "hidden" && value != "dual" {
+				fail("invalid encoding", value+", expected hidden or dual")
+				return
+			}
+			engines = append(engines, value)
+		case "--backend":
+			if kind, command, _ := strings.Cut(value, ":"); kind != "minizinc" && (kind != "sat" || command == "") {
+				fail("invalid backend", value+", expected minizinc, minizinc:SOLVER or sat:COMMAND")
+				return
+			}
+			engines = append(engines, value)
+		case "--estimate":
+			var err error
+			if estimate, err = strconv.Atoi(value); err != nil || estimate < 1 {
+				fail("invalid number of probes", value)
+				return
+			}
+		case "--scorer-candidates":
+			var err error
+			if scorerCandidates, err = strconv.Atoi(value); err != nil || scorerCandidates < 0 {
+				fail("invalid number of scorer candidates", value)
+				return
+			}
+		case "--optimize":
+			var err error
+			if optimization, err = csp.ParseOptimizationStrategy(value); err != nil {
+				fail(err)
+				return
+			}
+		case "--probe":
+			var err error
+			if dives, err = strconv.Atoi(value); err != nil || dives < 1 {
+				fail("invalid number of dives", value)
+				return
+			}
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	maxSolutions := 0
+	if len(positional) == 2 {
+		var err error
+		if maxSolutions, err = strconv.Atoi(positional[1]); err != nil || maxSolutions < 0 {
+			fail(fmt.Sprintf("invalid solution count %q", positional[1]))
+			return
+		}
+	}
+	if len(positional) < 1 || len(positional) > 2 {
+		fail("usage: " + solveUsage)
+		return
+	}
+	if len(engines) > 1 || len(engines) > 0 && count != "" {
+		fail("--count, --cutset, --lcg, --encoding and --backend each pick how to solve, give one of them")
+		return
+	}
+	if watch && positional[0] == "-" {
+		fail("can't watch standard input")
+		return
+	}
+	if watch {
+		csp.WatchModel(positional[0], maxSolutions, os.Stdout, colorize, nil)
+		return
+	}
+	problem, err := csp.LoadModelFile(positional[0])
+	if err != nil && format == "json" {
+		ExitStatus = ExitError
+		printJSON(csp.SolveResult{Status: "error", Solutions: []map[string]interface{}{}, Error: err.Error()})
+		return
+	}
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	if count != "" {
+		runCount(problem, count, format, quiet)
+		return
+	}
+	if len(engines) == 1 {
+		runEngine(problem, pickEngine(engines[0], problem, maxSolutions), format, quiet)
+		return
+	}
+	if top > 0 && score == nil {
+		fail("--top needs a --score")
+		return
+	}
+	if auto && profile != "" {
+		fail("--auto and --profile both pick the settings, give one of them")
+		return
+	}
+	if weightsFile != "" && strategy != csp.DomainOverWeightedDegree {
+		fail("--weights needs --strategy=domwdeg")
+		return
+	}
+	solver := csp.NewModelSolver(problem, maxSolutions, csp.WithTracer(spanTracer))
+	solver.Timeout = timeout
+	solver.Score = score
+	solver.TopK = top
+	solver.VariableOrdering = strategy
+	solver.RestartCutoff = restarts
+	solver.WeightDecay = decay
+	solver.ProbeDives = dives
+	solver.CheckCache = cache
+	solver.AndOr = andOr
+	solver.MaxGap = gap
+	solver.ObjectiveOrdering = objectiveOrder
+	if linearBound {
+		solver.Bounder = csp.LinearBounder{}
+	}
+	solver.Optimization = optimization
+	solver.AutoConfigure = auto
+	if profile != "" {
+		configuration, err := csp.LoadProfile(profile)
+		if err != nil {
+			fail(err)
+			return
+		}
+		configuration.Apply(solver)
+	}
+	if scorer != "" {
+		solver.BranchScorer = csp.HTTPBranchScorer{URL: scorer}
+		solver.BranchTimeout = scorerTimeout
+		solver.BranchCandidates = scorerCandidates
+	}
+	solver.EstimateProbes = estimate
+	shown := false
+	if progress {
+		solver.ProgressInterval = time.Second
+		solver.OnProgress = func(event csp.ProgressEvent) {
+			fmt.Fprint(os.Stderr, "\r", event, "   ")
+			shown = true
+		}
+	}
+	if bounds && format == "text" && !quiet {
+		solver.OnBound = func(event csp.BoundEvent) { fmt.Println("Bound:", event) }
+	}
+	if weightsFile != "" {
+		if solver.InitialWeights, err = csp.LoadWeights(weightsFile); err != nil && !os.IsNotExist(err) {
+			fail(err)
+			return
+		}
+	}
+	start := time.Now()
+	solutions := solver.Solve()
+	if shown {
+		fmt.Fprintln(os.Stderr)
+	}
+	ExitStatus = StatusExitCode(solver.Status)
+	if weightsFile != "" {
+		if err := csp.SaveWeights(weightsFile, solver.Weights()); err != nil {
+			fail(err)
+			return
+		}
+	}
+	if export != "" {
+		if err := csp.ExportSolutions(export, problem, solutions); err != nil {
+			fail(err)
+			return
+		}
+	}
+	if format == "json" {
+		printJSON(csp.NewSolveResult(solver, time.Since(start)))
+		return
+	}
+	if estimate > 0 {
+		if solver.Status == csp.Unsatisfiable {
+			fmt.Println("No solution (unsatisfiable)")
+		} else {
+			fmt.Println("Estimate:", solver.Estimate)
+		}
+		return
+	}
+	if problem.Optimizing() {
+		switch {
+		case solver.Best != nil && quiet:
+			fmt.Println(csp.FormatSolution(problem, solver.Best))
+		case quiet:
+		case solver.Best == nil:
+			fmt.Printf("No solution (%s)\n", solver.Status)
+		default:
+			fmt.Println(csp.FormatSolution(problem, solver.Best))
+			if problem.Objective != "" {
+				fmt.Printf("Objective: %d\n", solver.BestObjective)
+			}
+			fmt.Printf("Cost: %d (%s after %d improvements)\n", solver.BestCost, solver.Status, len(solutions))
+		}
+	} else {
+		for i, solution := range solutions {
+			if solver.Scores != nil && !quiet {
+				fmt.Printf("%s (score %g)\n", csp.FormatSolution(problem, solution), solver.Scores[i])
+			} else {
+				fmt.Println(csp.FormatSolution(problem, solution))
+			}
+		}
+		if !quiet {
+			fmt.Printf("Solutions: %d (%s)\n", len(solutions), solver.Status)
+		}
+	}
+	if !quiet {
+		fmt.Printf("Nodes: %d, failures: %d", solver.Nodes, solver.Failures)
+		if solver.Restarts > 0 {
+			fmt.Printf(", restarts: %d", solver.Restarts)
+		}
+		if solver.AndOr {
+			fmt.Printf(", context hits: %d", solver.ContextHits)
+		}
+		if solver.Bounder != nil {
+			fmt.Printf(", bound prunes: %d", solver.BoundPrunes)
+		}
+		if solver.Optimization == csp.CoreGuided {
+			fmt.Printf(", cores: %d", solver.Cores)
+		}
+		if solver.BranchScorer != nil {
+			fmt.Printf(", scorer fallbacks: %d", solver.BranchFallbacks)
+		}
+		if solver.CheckCache {
+			fmt.Printf(", cache hits: %d of %d checks (%.0f%%)", solver.CacheHits, solver.CacheHits+solver.CacheMisses,
+				100*solver.CacheHitRate())
+		}
+		fmt.Println()
+		if auto {
+			fmt.Println("Configuration:", solver.Configuration)
+		}
+	}
+}
+
+func printJSON(value interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(value)
+}
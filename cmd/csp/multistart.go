@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// csp local model.json [--starts=N] [--workers=N] [--steps=N] [--start-steps=N] [--timeout=30s] [--seed=N] [--swap]
+//
+// Looks for a solution by local search from --starts starts (NumCPU by default) running --workers at a time, with
+// --steps and --timeout limiting all of them together, see MultiStart, and --start-steps, 100000 by default, each
+// one. --swap moves by swapping values as well as
+// changing them, see SwapValues. Local search can't prove there is no solution, so not finding one exits with
+// ExitUnknown.
+func RunLocal(args []string) {
+	usage := "usage: csp local model.json [--starts=N] [--workers=N] [--steps=N] [--start-steps=N] [--timeout=30s] [--seed=N] [--swap]"
+	starts, workers, steps, startSteps, seed, swap := runtime.NumCPU(), 0, 0, 100000, int64(0), false
+	var timeout time.Duration
+	var model string
+	for _, arg := range args {
+		name, value, _ := strings.Cut(arg, "=")
+		var err error
+		switch name {
+		case "--starts":
+			if starts, err = strconv.Atoi(value); err == nil && starts < 1 {
+				err = fmt.Errorf("--starts must be at least 1")
+			}
+		case "--workers":
+			if workers, err = strconv.Atoi(value); err == nil && workers < 0 {
+				err = fmt.Errorf("--workers can't be negative")
+			}
+		case "--steps":
+			if steps, err = strconv.Atoi(value); err == nil && steps < 0 {
+				err = fmt.Errorf("--steps can't be negative")
+			}
+		case "--start-steps":
+			if startSteps, err = strconv.Atoi(value); err == nil && startSteps < 0 {
+				err = fmt.Errorf("--start-steps can't be negative")
+			}
+		case "--timeout":
+			timeout, err = time.ParseDuration(value)
+		case "--seed":
+			seed, err = strconv.ParseInt(value, 10, 64)
+		case "--swap":
+			swap = true
+		default:
+			if strings.HasPrefix(arg, "--") || model != "" {
+				err = errors.New(usage)
+			}
+			model = arg
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+	}
+	if model == "" {
+		fail(usage)
+		return
+	}
+	problem, err := csp.LoadModelFile(model)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	search := csp.NewLocalSearch(problem)
+	search.Seed, search.MaxSteps = seed, startSteps
+	if swap {
+		search.Neighborhoods = []csp.Neighborhood{csp.ChangeValue{}, csp.SwapValues{}}
+	}
+	multi := csp.NewMultiStart(search, starts)
+	multi.Workers, multi.MaxSteps, multi.Timeout = workers, steps, timeout
+	start := time.Now()
+	best, solved := multi.Solve()
+	if solved {
+		fmt.Println(csp.FormatSolution(problem, best))
+	} else {
+		ExitStatus = ExitUnknown
+		if best != nil {
+			fmt.Println(csp.FormatSolution(problem, best))
+		}
+		fmt.Printf("No solution found, best breaks %d constraints\n", multi.BestViolations)
+	}
+	fmt.Printf("Starts: %d, steps: %d, time: %v\n", multi.Started, multi.Steps, time.Since(start).Round(time.Millisecond))
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+
+	csp "github.com/GSGerritsen/go-csp"
+	"github.com/GSGerritsen/go-csp/models"
+)
+
+// csp nonogram puzzle.txt
+func RunNonogram(args []string) {
+	if len(args) != 1 {
+		fail("usage: csp nonogram puzzle.txt")
+		return
+	}
+	rows, cols, err := models.LoadNonogram(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	solver := csp.NewSolver(models.Nonogram(rows, cols))
+	solver.MaxSolutions = 2
+	solutions := solver.Solve()
+	ExitStatus = StatusExitCode(solver.Status)
+	if len(solutions) == 0 {
+		fmt.Println("No solution")
+		return
+	}
+	models.PrintNonogram(len(rows), len(cols), solutions[0])
+	if len(solutions) > 1 {
+		fmt.Println("Puzzle has more than one solution")
+	}
+	fmt.Printf("Nodes: %d, failures: %d\n", solver.Nodes, solver.Failures)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	csp "github.com/GSGerritsen/go-csp"
+	"github.com/GSGerritsen/go-csp/gen"
+)
+
+// csp sweep n d [--density=0.5] [--tightness=0.05:0.95:0.05] [--instances=20] [--seed=1] [--timeout=10s]
+// [--strategy=mrv] [--csv=FILE]
+//
+// Solves random instances across the densities and tightnesses, see Sweep, prints a row per point as it's done
+// and the hardness peak at the end, and writes the points to the --csv file when given.
+func RunSweep(args []string) {
+	usage := "usage: csp sweep n d [--density=0.5] [--tightness=0.05:0.95:0.05] [--instances=20] [--seed=1] " +
+		"[--timeout=10s] [--strategy=mrv] [--csv=FILE]"
+	sweep := gen.Sweep{Instances: 20, Seed: 1, Timeout: 10 * time.Second}
+	densities, tightnesses := "0.5", "0.05:0.95:0.05"
+	ordering := csp.MinimumRemainingValues
+	var csvFile string
+	var sizes []int
+	for _, arg := range args {
+		name, value, _ := strings.Cut(arg, "=")
+		var err error
+		switch name {
+		case "--density":
+			densities = value
+		case "--tightness":
+			tightnesses = value
+		case "--instances":
+			if sweep.Instances, err = strconv.Atoi(value); err == nil && sweep.Instances < 1 {
+				err = errors.New("--instances must be at least 1")
+			}
+		case "--seed":
+			sweep.Seed, err = strconv.ParseInt(value, 10, 64)
+		case "--timeout":
+			sweep.Timeout, err = time.ParseDuration(value)
+		case "--strategy":
+			ordering, err = csp.ParseVariableOrdering(value)
+		case "--csv":
+			csvFile = value
+		default:
+			if strings.HasPrefix(arg, "--") || len(sizes) == 2 {
+				err = errors.New(usage)
+				break
+			}
+			var size int
+			if size, err = strconv.Atoi(arg); err == nil && size < 1 {
+				err = errors.New("n and d must be positive")
+			}
+			sizes = append(sizes, size)
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+	}
+	if len(sizes) != 2 {
+		fail(usage)
+		return
+	}
+	sweep.N, sweep.D = sizes[0], sizes[1]
+	var err error
+	if sweep.Densities, err = gen.ParseSweepRange(densities); err != nil {
+		fail("--density:", err)
+		return
+	}
+	if sweep.Tightnesses, err = gen.ParseSweepRange(tightnesses); err != nil {
+		fail("--tightness:", err)
+		return
+	}
+	sweep.Configure = func(solver *csp.Solver) {
+		solver.Propagation = true
+		solver.VariableOrdering = ordering
+	}
+
+	fmt.Printf("n=%d d=%d, %d instances per point\n", sweep.N, sweep.D, sweep.Instances)
+	points := sweep.Run(func(point gen.SweepPoint) {
+		fmt.Printf("density %.3f tightness %.3f: %d/%d satisfiable, median %d nodes\n", point.Density,
+			point.Tightness, point.Satisfiable, point.Instances, point.MedianNodes)
+	})
+	fmt.Println()
+	gen.PrintSweepTable(os.Stdout, points)
+	if peak, ok := gen.HardnessPeak(points); ok {
+		fmt.Printf("Hardness peak: density %.3f, tightness %.3f, kappa %.3f, %.0f%% satisfiable, median %d nodes\n",
+			peak.Density, peak.Tightness, peak.Kappa, 100*peak.SatisfiableFraction(), peak.MedianNodes)
+	}
+	if csvFile == "" {
+		return
+	}
+	file, err := os.Create(csvFile)
+	if err != nil {
+		fail(err)
+		return
+	}
+	defer file.Close()
+	if err := gen.WriteSweepCSV(file, points); err != nil {
+		fail(err)
+		return
+	}
+	fmt.Println("Wrote", csvFile)
+}
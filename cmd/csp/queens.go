@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	csp "github.com/GSGerritsen/go-csp"
+	"github.com/GSGerritsen/go-csp/models"
+)
+
+// csp queens n
+func RunQueens(args []string) {
+	if len(args) != 1 {
+		fail("usage: csp queens n")
+		return
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		fail(fmt.Sprintf("invalid board size %q", args[0]))
+		return
+	}
+
+	solver := csp.NewSolver(models.NQueens(n))
+	solver.MaxSolutions = 1
+	solutions := solver.Solve()
+	ExitStatus = StatusExitCode(solver.Status)
+	if len(solutions) == 0 {
+		fmt.Println("No solution")
+	} else {
+		models.PrintBoard(n, solutions[0])
+	}
+	fmt.Printf("Nodes: %d, failures: %d\n", solver.Nodes, solver.Failures)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	csp "github.com/GSGerritsen/go-csp"
+	"github.com/GSGerritsen/go-csp/gen"
+)
+
+// csp random n d density tightness [seed]
+func RunRandom(args []string) {
+	if len(args) < 4 || len(args) > 5 {
+		fail("usage: csp random n d density tightness [seed]")
+		return
+	}
+	n, errN := strconv.Atoi(args[0])
+	d, errD := strconv.Atoi(args[1])
+	density, errDensity := strconv.ParseFloat(args[2], 64)
+	tightness, errTightness := strconv.ParseFloat(args[3], 64)
+	seed := int64(1)
+	var errSeed error
+	if len(args) == 5 {
+		seed, errSeed = strconv.ParseInt(args[4], 10, 64)
+	}
+	for _, err := range []error{errN, errD, errDensity, errTightness, errSeed} {
+		if err != nil {
+			fail(err)
+			return
+		}
+	}
+	if n < 1 || d < 1 || density < 0 || density > 1 || tightness < 0 || tightness > 1 {
+		fail("n and d must be positive, density and tightness between 0 and 1")
+		return
+	}
+
+	solver := csp.NewSolver(gen.RandomBinaryCSP(n, d, density, tightness, seed))
+	solver.MaxSolutions = 1
+	solutions := solver.Solve()
+	ExitStatus = StatusExitCode(solver.Status)
+	if len(solutions) == 0 {
+		fmt.Println("Unsatisfiable")
+	} else {
+		fmt.Println("Satisfiable")
+	}
+	fmt.Printf("Nodes: %d, failures: %d\n", solver.Nodes, solver.Failures)
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"os"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// csp repl
+func RunREPL(args []string) {
+	if len(args) != 0 {
+		fail("usage: csp repl")
+		return
+	}
+	csp.REPL(os.Stdin, os.Stdout)
+}
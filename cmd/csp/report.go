@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// csp report model.json [report.html]
+func RunReport(args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		fail("usage: csp report model.json [report.html]")
+		return
+	}
+	problem, err := csp.LoadModelFile(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+	output := "report.html"
+	if len(args) == 2 {
+		output = args[1]
+	}
+
+	report := csp.BuildReport(args[0], csp.NewSolver(problem))
+	file, err := os.Create(output)
+	if err != nil {
+		fail(err)
+		return
+	}
+	defer file.Close()
+	if err := report.WriteHTML(file); err != nil {
+		fail(err)
+		return
+	}
+	fmt.Printf("Wrote %s (%d solutions, %d nodes)\n", output, len(report.Solutions), report.Nodes)
+}
@@ -0,0 +1,92 @@
+//go:build !(js && wasm)
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+const serveUsage = "usage: csp serve [address] [--store=dir | --db=FILE] [--max-running=N] [--max-queued=N] " +
+	"[--max-timeout=duration] [--max-nodes=N] [--max-memory=MB] [--max-model=KB]"
+
+// csp serve [address] [--store=dir | --db=FILE] [--max-running=N] [--max-queued=N] [--max-timeout=duration]
+// [--max-nodes=N] [--max-memory=MB] [--max-model=KB]
+//
+// --store keeps the jobs as files in dir, see FileJobStore, and --db in a bbolt database, see BoltJobStore.
+func RunServe(args []string) {
+	var positional []string
+	dir, db := "", ""
+	server := csp.NewServer()
+	for _, arg := range args {
+		name, value, _ := strings.Cut(arg, "=")
+		var err error
+		switch name {
+		case "--store":
+			dir = value
+		case "--db":
+			db = value
+		case "--max-running":
+			server.MaxRunning, err = strconv.Atoi(value)
+		case "--max-queued":
+			server.MaxQueued, err = strconv.Atoi(value)
+		case "--max-timeout":
+			server.MaxTimeout, err = time.ParseDuration(value)
+		case "--max-nodes":
+			server.MaxNodes, err = strconv.Atoi(value)
+		case "--max-memory":
+			var megabytes uint64
+			megabytes, err = strconv.ParseUint(value, 10, 64)
+			server.MaxMemory = megabytes << 20
+		case "--max-model":
+			var kilobytes uint64
+			kilobytes, err = strconv.ParseUint(value, 10, 53)
+			server.MaxModelBytes = int64(kilobytes) << 10
+		default:
+			positional = append(positional, arg)
+		}
+		if err != nil || server.MaxRunning < 0 || server.MaxQueued < 0 || server.MaxTimeout < 0 || server.MaxNodes < 0 {
+			fail("bad value:", arg)
+			return
+		}
+	}
+	address := "localhost:8080"
+	if len(positional) == 1 && !strings.HasPrefix(positional[0], "--") {
+		address = positional[0]
+	} else if len(positional) > 0 {
+		fail(serveUsage)
+		return
+	}
+	if dir != "" && db != "" {
+		fail("--store and --db both pick where the jobs go, give one of them")
+		return
+	}
+	server.Tracer = spanTracer
+	var err error
+	switch {
+	case dir != "":
+		server.Store, err = csp.NewFileJobStore(dir)
+	case db != "":
+		var store *csp.BoltJobStore
+		if store, err = csp.NewBoltJobStore(db); err == nil {
+			defer store.Close()
+			server.Store = store
+		}
+	}
+	if err == nil && server.Store != nil {
+		err = server.Restore()
+	}
+	if err != nil {
+		fail(err)
+		return
+	}
+	fmt.Printf("Dashboard on http://%s/\n", address)
+	if err := http.ListenAndServe(address, server.Handler()); err != nil {
+		fail(err)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+
+	csp "github.com/GSGerritsen/go-csp"
+	"github.com/GSGerritsen/go-csp/models"
+)
+
+// csp sudoku puzzle.txt [cages.txt]
+func RunSudoku(args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		fail("usage: csp sudoku puzzle.txt [cages.txt]")
+		return
+	}
+	grid, err := models.LoadSudoku(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+	var cages []models.Cage
+	if len(args) == 2 {
+		cages, err = models.LoadCages(args[1])
+		if err != nil {
+			fail(err)
+			return
+		}
+	}
+
+	solver := csp.NewSolver(models.KillerSudoku(grid, cages))
+	solver.MaxSolutions = 2
+	solutions := solver.Solve()
+	ExitStatus = StatusExitCode(solver.Status)
+	if len(solutions) == 0 {
+		fmt.Println("No solution")
+		return
+	}
+	models.PrintSudoku(solutions[0])
+	if len(solutions) > 1 {
+		fmt.Println("Puzzle has more than one solution")
+	}
+	fmt.Printf("Nodes: %d, failures: %d\n", solver.Nodes, solver.Failures)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// csp tightness model.json [--samples=10000]
+//
+// Prints the TightnessReport of the model's hard constraints, sampling scopes too big to count with --samples
+// random tuples.
+func RunTightness(args []string) {
+	usage := "usage: csp tightness model.json [--samples=10000]"
+	samples := 10000
+	var model string
+	for _, arg := range args {
+		name, value, _ := strings.Cut(arg, "=")
+		var err error
+		switch name {
+		case "--samples":
+			if samples, err = strconv.Atoi(value); err == nil && samples <= 0 {
+				err = errors.New("--samples must be positive")
+			}
+		default:
+			if strings.HasPrefix(arg, "--") || model != "" {
+				err = errors.New(usage)
+			}
+			model = arg
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+	}
+	if model == "" {
+		fail(usage)
+		return
+	}
+	problem, err := csp.LoadModelFile(model)
+	if err != nil {
+		fail(err)
+		return
+	}
+	csp.AnalyzeTightness(problem, samples).Print(os.Stdout)
+}
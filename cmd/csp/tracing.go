@@ -0,0 +1,55 @@
+//go:build !(js && wasm)
+
+package main
+
+import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Points spanTracer at an OpenTelemetry tracer exporting to the file spans, a JSON object per span, and to
+// the OTLP/HTTP collector at the URL otlp, whichever are set. stop flushes what's left once the command is done.
+func startTracing(spans string, otlp string) (stop func(), err error) {
+	if spans == "" && otlp == "" {
+		return func() {}, nil
+	}
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "csp"))),
+	}
+	var file *os.File
+	if spans != "" {
+		if file, err = os.Create(spans); err != nil {
+			return func() {}, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return func() {}, err
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+	if otlp != "" {
+		exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(otlp))
+		if err != nil {
+			if file != nil {
+				file.Close()
+			}
+			return func() {}, err
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+	provider := sdktrace.NewTracerProvider(options...)
+	spanTracer = provider.Tracer("github.com/GSGerritsen/go-csp")
+	return func() {
+		provider.Shutdown(context.Background())
+		if file != nil {
+			file.Close()
+		}
+	}, nil
+}
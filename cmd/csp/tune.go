@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// csp tune model.json... [--budget=1m] [--solutions=1] [--out=profile.json]
+//
+// Races the TuningCandidates on the models within the budget, see Race, prints the standings and writes the
+// winner as a profile to --out, profile.json by default, for csp solve --profile to use.
+func RunTune(args []string) {
+	usage := "usage: csp tune model.json... [--budget=1m] [--solutions=1] [--out=profile.json]"
+	budget, maxSolutions, out := time.Minute, 1, "profile.json"
+	var models []string
+	for _, arg := range args {
+		name, value, _ := strings.Cut(arg, "=")
+		var err error
+		switch name {
+		case "--budget":
+			if budget, err = time.ParseDuration(value); err == nil && budget <= 0 {
+				err = errors.New("--budget must be positive")
+			}
+		case "--solutions":
+			if maxSolutions, err = strconv.Atoi(value); err == nil && maxSolutions < 0 {
+				err = errors.New("--solutions can't be negative")
+			}
+		case "--out":
+			out = value
+		default:
+			if strings.HasPrefix(arg, "--") {
+				err = errors.New(usage)
+			}
+			models = append(models, arg)
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+	}
+	if len(models) == 0 {
+		fail(usage)
+		return
+	}
+	var instances []*csp.Problem
+	for _, model := range models {
+		problem, err := csp.LoadModelFile(model)
+		if err != nil {
+			fail(model+":", err)
+			return
+		}
+		instances = append(instances, problem)
+	}
+
+	race := csp.NewRace(instances, csp.TuningCandidates(instances))
+	race.Budget, race.MaxSolutions = budget, maxSolutions
+	winner := race.Run()
+	fmt.Printf("%d configurations, %d rounds, %d runs\n", len(race.Candidates), race.Rounds, race.Runs)
+	csp.PrintRaceTable(os.Stdout, race)
+	if err := csp.SaveProfile(out, winner); err != nil {
+		fail(err)
+		return
+	}
+	fmt.Println("Wrote", out+":", winner)
+}
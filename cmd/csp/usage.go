@@ -0,0 +1,42 @@
+package main
+
+// Usage of csp solve, on its own and as part of usage
+const solveUsage = `csp solve [--watch] [--quiet] [--timeout=30s] [--format=text|json] [--score=EXPR [--top=K]]
+      [--strategy=lex|mrv|domwdeg] [--restarts=CUTOFF] [--decay=0.5] [--weights=FILE] [--probe=DIVES] [--cache]
+      [--and-or] [--gap=FRACTION] [--bounds] [--objective-order] [--linear-bound] [--optimize=bnb|core|rds]
+      [--auto] [--profile=FILE] [--scorer=URL [--scorer-timeout=50ms] [--scorer-candidates=N]]
+      [--estimate=PROBES] [--progress] [--export=FILE.tsv|FILE.xlsx] [--count[=search|bucket|mdd]] [--cutset]
+      [--lcg] [--encoding=hidden|dual] [--backend=minizinc[:SOLVER]|sat:COMMAND]
+      model.json|- [max solutions]`
+
+// Usage of csp as a whole, printed for an unknown command
+const usage = `usage: csp [--no-color] [--spans spans.jsonl] [--otlp http://collector:4318] [--cpuprofile cpu.out]
+    [--memprofile mem.out] [--trace trace.out] [command]
+
+Without a command csp runs the original A-H puzzle. Commands:
+  csp sudoku puzzle.txt [cages.txt]
+  csp queens n
+  csp color graph.col|graph.mtx [k]
+  csp cryptarithm SEND+MORE=MONEY
+  csp nonogram puzzle.txt
+  csp futoshiki puzzle.txt
+  csp kakuro puzzle.txt
+  csp jobshop instance.txt
+  csp random n d density tightness [seed]
+  csp sweep n d [--density=0.5] [--tightness=0.05:0.95:0.05] [--instances=20] [--seed=1] [--timeout=10s]
+      [--strategy=mrv] [--csv=FILE]
+  ` + solveUsage + `
+  csp repl
+  csp local model.json [--starts=N] [--workers=N] [--steps=N] [--start-steps=N] [--timeout=30s] [--seed=N] [--swap]
+  csp tightness model.json [--samples=10000]
+  csp tune model.json... [--budget=1m] [--solutions=1] [--out=profile.json]
+  csp explore model.json
+  csp report model.json [report.html]
+  csp mermaid model.json [graph | tree]
+  csp export smtlib|cpsat|minizinc|cnf model.json [out]
+  csp bench model.json [--strategies=lex,mrv,domwdeg] [--repeat=10]
+  csp diff model.json CONFIG CONFIG
+  csp batch manifest.txt
+  csp serve [address] [--store=dir | --db=FILE] [--max-running=N] [--max-queued=N] [--max-timeout=30s] [--max-nodes=N]
+      [--max-memory=MB] [--max-model=KB]
+  csp demo name`
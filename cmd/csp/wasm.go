@@ -0,0 +1,35 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"encoding/json"
+	"syscall/js"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// The WebAssembly entry point, for running the solver in a browser. Build it with
+//
+//	GOOS=js GOARCH=wasm go build -o csp.wasm ./cmd/csp
+//
+// and load it with Go's wasm_exec.js. It defines a global cspSolve(modelJSON, optionsJSON) answering a result
+// JSON string, see csp.SolveJSON and csp.SolveOptions; the options may be left out. The solve runs on the calling
+// thread, so pages wanting to stay responsive call it from a Web Worker.
+func main() {
+	js.Global().Set("cspSolve", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) == 0 || args[0].Type() != js.TypeString {
+			return string(csp.SolveJSON(nil, csp.SolveOptions{}))
+		}
+		var options csp.SolveOptions
+		if len(args) > 1 && args[1].Type() == js.TypeString {
+			if err := json.Unmarshal([]byte(args[1].String()), &options); err != nil {
+				encoded, _ := json.Marshal(csp.SolveResult{Status: "error", Solutions: []map[string]interface{}{},
+					Error: "options: " + err.Error()})
+				return string(encoded)
+			}
+		}
+		return string(csp.SolveJSON([]byte(args[0].String()), options))
+	}))
+	select {}
+}
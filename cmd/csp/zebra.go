@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	csp "github.com/GSGerritsen/go-csp"
+	"github.com/GSGerritsen/go-csp/models"
+)
+
+// csp demo <name> [size]
+func RunDemo(args []string) {
+	if len(args) < 1 {
+		fail("usage: csp demo [classic [all] | classic8 | zebra | presolve | timetable | latin n | magic n]")
+		return
+	}
+
+	switch args[0] {
+	case "classic":
+		if len(args) == 2 && args[1] == "all" {
+			// every path the tree search explored, tombstones in red
+			root := csp.Root{}
+			root.Depth = 1
+			root.PopulateRoot("A")
+			for i := 0; i < csp.MaximumDepth; i++ {
+				root.GenerateTree()
+			}
+			root.PrintAllPaths(os.Stdout, colorize)
+			return
+		}
+		RunClassicDemo()
+	case "classic8":
+		if !models.CheckClassic8() {
+			ExitStatus = ExitError
+		}
+	case "zebra":
+		problem := models.Zebra()
+		solver := csp.NewSolver(problem)
+		solutions := solver.Solve()
+		for _, solution := range solutions {
+			models.PrintZebra(problem, solution)
+			nationality := func(item string) string {
+				variable := models.ZebraHouseVariable(solution[item]-1, 0)
+				return problem.FormatValue(variable, solution[variable])
+			}
+			fmt.Printf("The %s drinks water and the %s owns the zebra\n", nationality("Water"), nationality("Zebra"))
+		}
+		fmt.Printf("Solutions: %d, nodes: %d, failures: %d\n", len(solutions), solver.Nodes, solver.Failures)
+	case "presolve":
+		problem := models.Zebra()
+		presolved := csp.Presolve(problem)
+		fmt.Println(presolved.Report)
+		if presolved.Problem == nil {
+			return
+		}
+		solver := csp.NewSolver(presolved.Problem)
+		solver.Propagation = true
+		for _, solution := range solver.Solve() {
+			models.PrintZebra(problem, presolved.Restore(solution))
+		}
+		fmt.Printf("Nodes: %d, failures: %d\n", solver.Nodes, solver.Failures)
+	case "timetable":
+		exams, rooms, slots, conflicts := models.DemoTimetable()
+		problem := models.ExamTimetable(exams, rooms, slots, conflicts)
+		solver := csp.NewSolver(problem)
+		solver.Solve()
+		if solver.Best == nil {
+			fmt.Println("No timetable")
+			return
+		}
+		models.PrintTimetable(problem, exams, slots, solver.Best)
+		fmt.Printf("Students with back to back exams: %d (after %d improvements)\n", solver.BestCost, len(solver.Solutions))
+		fmt.Printf("Nodes: %d, failures: %d\n", solver.Nodes, solver.Failures)
+	case "latin", "magic":
+		if len(args) != 2 {
+			fail(fmt.Sprintf("usage: csp demo %s n", args[0]))
+			return
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			fail(fmt.Sprintf("invalid square size %q", args[1]))
+			return
+		}
+		problem := models.LatinSquare(n)
+		if args[0] == "magic" {
+			problem = models.MagicSquare(n)
+		}
+		solver := csp.NewSolver(problem)
+		solver.MaxSolutions = 1
+		solutions := solver.Solve()
+		if len(solutions) == 0 {
+			fmt.Println("No solution")
+		} else {
+			models.PrintGrid(n, solutions[0])
+		}
+		fmt.Printf("Nodes: %d, failures: %d\n", solver.Nodes, solver.Failures)
+	default:
+		fail(fmt.Sprintf("unknown demo %q", args[0]))
+	}
+}
@@ -0,0 +1,156 @@
+package csp
+
+// Incremental evaluation of a complete assignment for local search: which hard constraints it breaks, and for
+// every variable how many of the broken ones it's in, its conflict count. Set changes one variable and only
+// looks again at the constraints on it, updating the counts of the variables in those whose status flipped, so a
+// move costs the degree of the variable rather than a check of the whole model. Delta asks what a move would do
+// without making it. Custom move operators can build on the same machinery; soft constraints don't count.
+//
+// An AllDifferent is checked pair by pair, every two variables sharing a value counting as a conflict of their
+// own, since telling a search only that some values repeat somewhere gives it nothing to go on.
+type Conflicts struct {
+	Problem     *Problem
+	assignment  map[string]int
+	checks      []Constraint     // the problem's constraints, AllDifferent ones as pairs
+	owners      []int            // the constraint each check comes from
+	constraints map[string][]int // the checks on each variable
+	scopes      [][]string
+	violated    []bool // by check
+	broken      []int  // how many checks each of the problem's constraints has broken
+	violations  int
+	counts      map[string]int
+	conflicted  []string       // the variables whose count isn't 0, in no particular order
+	position    map[string]int // where each of them is in conflicted
+}
+
+// Conflicts constructor, starting from a complete assignment, which it copies
+func NewConflicts(problem *Problem, assignment map[string]int) *Conflicts {
+	conflicts := &Conflicts{
+		Problem:     problem,
+		assignment:  copyAssignment(assignment),
+		constraints: make(map[string][]int),
+		broken:      make([]int, len(problem.Constraints)),
+		counts:      make(map[string]int),
+		position:    make(map[string]int),
+	}
+	for owner, constraint := range problem.Constraints {
+		allDifferent, pairwise := constraint.(*AllDifferent)
+		if !pairwise {
+			conflicts.addCheck(constraint, owner)
+			continue
+		}
+		for i, first := range allDifferent.Variables {
+			for _, second := range allDifferent.Variables[i+1:] {
+				conflicts.addCheck(NewNotEqual(first, second), owner)
+			}
+		}
+	}
+	return conflicts
+}
+
+func (conflicts *Conflicts) Value(variable string) int {
+	return conflicts.assignment[variable]
+}
+
+// The current assignment. It must not be modified; Set is how it changes.
+func (conflicts *Conflicts) Assignment() map[string]int {
+	return conflicts.assignment
+}
+
+// How many constraints the assignment breaks, counting every pair sharing a value in an AllDifferent, 0 for a
+// solution
+func (conflicts *Conflicts) Violations() int {
+	return conflicts.violations
+}
+
+// How many of the broken constraints, or AllDifferent pairs, the variable is in
+func (conflicts *Conflicts) Count(variable string) int {
+	return conflicts.counts[variable]
+}
+
+// The variables in some broken constraint, in no particular order. The slice changes with every Set and must not
+// be modified.
+func (conflicts *Conflicts) Conflicted() []string {
+	return conflicts.conflicted
+}
+
+// Whether the problem's ith constraint is broken
+func (conflicts *Conflicts) Violated(i int) bool {
+	return conflicts.broken[i] > 0
+}
+
+// How the number of broken constraints would change if variable took value, negative being better
+func (conflicts *Conflicts) Delta(variable string, value int) int {
+	current := conflicts.assignment[variable]
+	if value == current {
+		return 0
+	}
+	conflicts.assignment[variable] = value
+	delta := 0
+	for _, i := range conflicts.constraints[variable] {
+		if broken := !conflicts.checks[i].Satisfied(conflicts.assignment); broken != conflicts.violated[i] {
+			if broken {
+				delta++
+			} else {
+				delta--
+			}
+		}
+	}
+	conflicts.assignment[variable] = current
+	return delta
+}
+
+// Gives variable value, updating the counts
+func (conflicts *Conflicts) Set(variable string, value int) {
+	if conflicts.assignment[variable] == value {
+		return
+	}
+	conflicts.assignment[variable] = value
+	for _, i := range conflicts.constraints[variable] {
+		if broken := !conflicts.checks[i].Satisfied(conflicts.assignment); broken != conflicts.violated[i] {
+			conflicts.flip(i)
+		}
+	}
+}
+
+func (conflicts *Conflicts) addCheck(check Constraint, owner int) {
+	i := len(conflicts.checks)
+	conflicts.checks = append(conflicts.checks, check)
+	conflicts.owners = append(conflicts.owners, owner)
+	conflicts.scopes = append(conflicts.scopes, uniqueScope(check.Scope()))
+	conflicts.violated = append(conflicts.violated, false)
+	for _, variable := range conflicts.scopes[i] {
+		conflicts.constraints[variable] = append(conflicts.constraints[variable], i)
+	}
+	if !check.Satisfied(conflicts.assignment) {
+		conflicts.flip(i)
+	}
+}
+
+// Turns the ith check from satisfied to broken or back, and its variables' counts with it
+func (conflicts *Conflicts) flip(i int) {
+	conflicts.violated[i] = !conflicts.violated[i]
+	change := 1
+	if !conflicts.violated[i] {
+		change = -1
+	}
+	conflicts.violations += change
+	conflicts.broken[conflicts.owners[i]] += change
+	for _, variable := range conflicts.scopes[i] {
+		conflicts.counts[variable] += change
+		switch conflicts.counts[variable] {
+		case 1:
+			if change == 1 {
+				conflicts.position[variable] = len(conflicts.conflicted)
+				conflicts.conflicted = append(conflicts.conflicted, variable)
+			}
+		case 0:
+			// the last one takes its place
+			at, last := conflicts.position[variable], conflicts.conflicted[len(conflicts.conflicted)-1]
+			conflicts.conflicted[at] = last
+			conflicts.position[last] = at
+			conflicts.conflicted = conflicts.conflicted[:len(conflicts.conflicted)-1]
+			delete(conflicts.position, variable)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package csp
+
+import "fmt"
+
+// How hard the propagation engine works on a constraint, trading the cost of propagating against how much gets
+// pruned. Every level still has the search check the constraint once its variables have values.
+type ConsistencyLevel int
+
+const (
+	AutoConsistency   ConsistencyLevel = iota // a registered propagator, the constraint's own, or the generic one
+	CheckOnly                                 // no propagation at all
+	ForwardChecking                           // values go once all but one of the variables are fixed
+	BoundsConsistency                         // the smallest and largest values need a support
+	DomainConsistency                         // every value needs a support, however big the scope's domains
+)
+
+var consistencyNames = []string{"auto", "check", "forward", "bounds", "domain"}
+
+func (level ConsistencyLevel) String() string {
+	if level >= 0 && int(level) < len(consistencyNames) {
+		return consistencyNames[level]
+	}
+	return fmt.Sprintf("ConsistencyLevel(%d)", int(level))
+}
+
+// "auto", "check", "forward", "bounds" or "domain"
+func ParseConsistencyLevel(name string) (ConsistencyLevel, error) {
+	for level, known := range consistencyNames {
+		if name == known {
+			return ConsistencyLevel(level), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown consistency level %q, expected auto, check, forward, bounds or domain", name)
+}
+
+// Propagates the constraint at the given level rather than the problem's default. The constraint is the same
+// pointer that was added.
+func (problem *Problem) SetConsistency(constraint Constraint, level ConsistencyLevel) {
+	if problem.Consistency == nil {
+		problem.Consistency = make(map[Constraint]ConsistencyLevel)
+	}
+	problem.Consistency[constraint] = level
+}
+
+// The level the engine propagates the constraint at
+func (problem *Problem) ConsistencyOf(constraint Constraint) ConsistencyLevel {
+	if level, set := problem.Consistency[constraint]; set {
+		return level
+	}
+	return problem.DefaultConsistency
+}
+
+// For constraints at CheckOnly
+type checkOnlyPropagator struct{}
+
+func (checkOnlyPropagator) Propagate(store *DomainStore) bool { return true }
+func (checkOnlyPropagator) Subscriptions() DomainEvent        { return 0 }
+func (checkOnlyPropagator) Priority() int                     { return 0 }
@@ -0,0 +1,428 @@
+package csp
+
+// A Constraint gets checked against partial assignments: variables that haven't been assigned yet are simply missing
+// from the map. Satisfied should only return false when the values that ARE assigned already violate the constraint,
+// so that dead ends get tombstoned as early as possible.
+type Constraint interface {
+	Scope() []string
+	Satisfied(assignment map[string]int) bool
+}
+
+// No two variables in the scope may share a value
+type AllDifferent struct {
+	Variables []string
+}
+
+// AllDifferent constructor
+func NewAllDifferent(variables ...string) *AllDifferent {
+	return &AllDifferent{variables}
+}
+
+func (constraint *AllDifferent) Scope() []string {
+	return constraint.Variables
+}
+
+func (constraint *AllDifferent) Satisfied(assignment map[string]int) bool {
+	seen := make(map[int]bool)
+	for _, variable := range constraint.Variables {
+		value, assigned := assignment[variable]
+		if !assigned {
+			continue
+		}
+		if seen[value] {
+			return false
+		}
+		seen[value] = true
+	}
+	return true
+}
+
+// Catch-all for constraints that are easiest to write as plain Go. The function receives the scope's values in
+// scope order and only gets called once all of them are assigned.
+type Predicate struct {
+	Variables []string
+	Check     func(values []int) bool
+}
+
+// Predicate constructor
+func NewPredicate(check func(values []int) bool, variables ...string) *Predicate {
+	return &Predicate{variables, check}
+}
+
+func (constraint *Predicate) Scope() []string {
+	return constraint.Variables
+}
+
+func (constraint *Predicate) Satisfied(assignment map[string]int) bool {
+	values := make([]int, len(constraint.Variables))
+	for i, variable := range constraint.Variables {
+		value, assigned := assignment[variable]
+		if !assigned {
+			return true
+		}
+		values[i] = value
+	}
+	return constraint.Check(values)
+}
+
+// Binary disequality, the bread and butter of coloring and scheduling models
+type NotEqual struct {
+	First  string
+	Second string
+}
+
+// NotEqual constructor
+func NewNotEqual(first string, second string) *NotEqual {
+	return &NotEqual{first, second}
+}
+
+func (constraint *NotEqual) Scope() []string {
+	return []string{constraint.First, constraint.Second}
+}
+
+func (constraint *NotEqual) Satisfied(assignment map[string]int) bool {
+	first, firstAssigned := assignment[constraint.First]
+	second, secondAssigned := assignment[constraint.Second]
+	return !firstAssigned || !secondAssigned || first != second
+}
+
+// Sum of Coefficients[i] * Variables[i], compared against Constant with one of "=", "!=", "<", "<=", ">", ">="
+type Linear struct {
+	Variables    []string
+	Coefficients []int
+	Operator     string
+	Constant     int
+}
+
+// Linear constructor
+func NewLinear(variables []string, coefficients []int, operator string, constant int) *Linear {
+	return &Linear{variables, coefficients, operator, constant}
+}
+
+// Plain sum of the variables, every coefficient 1
+func NewSum(variables []string, operator string, constant int) *Linear {
+	coefficients := make([]int, len(variables))
+	for i := range coefficients {
+		coefficients[i] = 1
+	}
+	return NewLinear(variables, coefficients, operator, constant)
+}
+
+func (constraint *Linear) Scope() []string {
+	return constraint.Variables
+}
+
+func (constraint *Linear) Satisfied(assignment map[string]int) bool {
+	total := 0
+	for i, variable := range constraint.Variables {
+		value, assigned := assignment[variable]
+		if !assigned {
+			return true
+		}
+		total += constraint.Coefficients[i] * value
+	}
+	return Compare(total, constraint.Operator, constraint.Constant)
+}
+
+// Extensional constraint: the scope's values must match one of the allowed tuples. Big tables are compiled into
+// an MDD once up front, and checked and filtered through that rather than by scanning the tuple list every time.
+type Table struct {
+	Variables []string
+	Tuples    [][]int
+
+	diagram *MDD
+}
+
+// Tables with more tuples than this get an MDD
+const tableMDDThreshold = 64
+
+// Table constructor
+func NewTable(variables []string, tuples [][]int) *Table {
+	table := &Table{variables, tuples, nil}
+	if len(tuples) > tableMDDThreshold && len(uniqueScope(variables)) == len(variables) {
+		table.diagram = NewTupleMDD(variables, tuples)
+	}
+	return table
+}
+
+func (constraint *Table) Scope() []string {
+	return constraint.Variables
+}
+
+// A partial assignment is fine as long as some tuple still agrees with every assigned value
+func (constraint *Table) Satisfied(assignment map[string]int) bool {
+	if constraint.diagram != nil {
+		return constraint.diagram.Consistent(assignment)
+	}
+	for _, tuple := range constraint.Tuples {
+		matches := true
+		for i, variable := range constraint.Variables {
+			if value, assigned := assignment[variable]; assigned && value != tuple[i] {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// Only available for tables big enough to have an MDD, see DomainFilter
+func (constraint *Table) Filter(domains map[string][]int) (map[string][]int, bool) {
+	if constraint.diagram == nil {
+		return nil, false
+	}
+	return constraint.diagram.Filter(domains), true
+}
+
+// Channels two views of the same permutation onto each other: Forward[i] = j exactly when Backward[j-1] = i+1.
+// Values are 1-indexed positions, like every other domain in this package.
+type Inverse struct {
+	Forward  []string
+	Backward []string
+}
+
+// Inverse constructor
+func NewInverse(forward []string, backward []string) *Inverse {
+	return &Inverse{forward, backward}
+}
+
+func (constraint *Inverse) Scope() []string {
+	return append(append([]string(nil), constraint.Forward...), constraint.Backward...)
+}
+
+func (constraint *Inverse) Satisfied(assignment map[string]int) bool {
+	return channelled(constraint.Forward, constraint.Backward, assignment) &&
+		channelled(constraint.Backward, constraint.Forward, assignment)
+}
+
+func channelled(from []string, to []string, assignment map[string]int) bool {
+	for i, variable := range from {
+		value, assigned := assignment[variable]
+		if !assigned {
+			continue
+		}
+		if value < 1 || value > len(to) {
+			return false
+		}
+		if other, assigned := assignment[to[value-1]]; assigned && other != i+1 {
+			return false
+		}
+	}
+	return true
+}
+
+// Tasks sharing a single resource, such as jobs on one machine: no two of them may overlap in time. Each variable
+// is a task's start time, and the task occupies [start, start+duration).
+type Disjunctive struct {
+	Starts    []string
+	Durations []int
+}
+
+// Disjunctive constructor
+func NewDisjunctive(starts []string, durations []int) *Disjunctive {
+	return &Disjunctive{starts, durations}
+}
+
+func (constraint *Disjunctive) Scope() []string {
+	return constraint.Starts
+}
+
+func (constraint *Disjunctive) Satisfied(assignment map[string]int) bool {
+	for i := range constraint.Starts {
+		first, assigned := assignment[constraint.Starts[i]]
+		if !assigned {
+			continue
+		}
+		for j := i + 1; j < len(constraint.Starts); j++ {
+			second, assigned := assignment[constraint.Starts[j]]
+			if !assigned {
+				continue
+			}
+			if first < second+constraint.Durations[j] && second < first+constraint.Durations[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// The scope, read left to right, must spell a word accepted by a deterministic finite automaton.
+// Transitions[state][value] is the next state; a missing entry means the automaton rejects.
+type Regular struct {
+	Variables   []string
+	Transitions []map[int]int
+	Start       int
+	Accepting   map[int]bool
+}
+
+// Regular constructor
+func NewRegular(variables []string, transitions []map[int]int, start int, accepting map[int]bool) *Regular {
+	return &Regular{variables, transitions, start, accepting}
+}
+
+func (constraint *Regular) Scope() []string {
+	return constraint.Variables
+}
+
+// Runs the automaton over the sequence tracking every state it could be in, where an unassigned variable could be
+// any symbol. The assignment is still fine if an accepting state is reachable at the end.
+func (constraint *Regular) Satisfied(assignment map[string]int) bool {
+	states := map[int]bool{constraint.Start: true}
+	for _, variable := range constraint.Variables {
+		value, assigned := assignment[variable]
+		next := make(map[int]bool)
+		for state := range states {
+			if assigned {
+				if target, ok := constraint.Transitions[state][value]; ok {
+					next[target] = true
+				}
+				continue
+			}
+			for _, target := range constraint.Transitions[state] {
+				next[target] = true
+			}
+		}
+		if len(next) == 0 {
+			return false
+		}
+		states = next
+	}
+	for state := range states {
+		if constraint.Accepting[state] {
+			return true
+		}
+	}
+	return false
+}
+
+// Variable = Value, or Variable != Value when Negated
+type Literal struct {
+	Variable string
+	Value    int
+	Negated  bool
+}
+
+const (
+	LiteralFalse = iota
+	LiteralOpen
+	LiteralTrue
+)
+
+func (literal Literal) Holds(value int) bool {
+	return (value == literal.Value) != literal.Negated
+}
+
+// Whether the literal is already decided by a domain: true if every value satisfies it, false if none does
+func (literal Literal) Status(domain []int) int {
+	holds, fails := false, false
+	for _, value := range domain {
+		if literal.Holds(value) {
+			holds = true
+		} else {
+			fails = true
+		}
+	}
+	switch {
+	case holds && !fails:
+		return LiteralTrue
+	case fails && !holds:
+		return LiteralFalse
+	}
+	return LiteralOpen
+}
+
+// Disjunction: at least one of the literals has to hold
+type Clause struct {
+	Literals []Literal
+}
+
+// Clause constructor
+func NewClause(literals ...Literal) *Clause {
+	return &Clause{literals}
+}
+
+func (constraint *Clause) Scope() []string {
+	var scope []string
+	for _, literal := range constraint.Literals {
+		scope = append(scope, literal.Variable)
+	}
+	return uniqueScope(scope)
+}
+
+func (constraint *Clause) Satisfied(assignment map[string]int) bool {
+	for _, literal := range constraint.Literals {
+		value, assigned := assignment[literal.Variable]
+		if !assigned || literal.Holds(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// The scope's values must differ from Reference in at least Minimum places. Unassigned variables still count as
+// able to differ, so partial assignments fail as soon as too few variables are left to make up the distance.
+type HammingDistance struct {
+	Variables []string
+	Reference map[string]int
+	Minimum   int
+}
+
+// HammingDistance constructor
+func NewHammingDistance(variables []string, reference map[string]int, minimum int) *HammingDistance {
+	return &HammingDistance{variables, reference, minimum}
+}
+
+func (constraint *HammingDistance) Scope() []string {
+	return constraint.Variables
+}
+
+func (constraint *HammingDistance) Satisfied(assignment map[string]int) bool {
+	possible := 0
+	for _, variable := range constraint.Variables {
+		value, assigned := assignment[variable]
+		reference, referenced := constraint.Reference[variable]
+		if !assigned || !referenced || value != reference {
+			possible++
+		}
+	}
+	return possible >= constraint.Minimum
+}
+
+// Breaks the symmetry between interchangeable values: reading the variables in order, Values[i+1] may only show
+// up after Values[i] already has. A variable assigned Values[i+1] only violates it once every variable before it
+// is assigned and none of them took Values[i].
+type ValuePrecedence struct {
+	Variables []string
+	Values    []int
+}
+
+// ValuePrecedence constructor
+func NewValuePrecedence(variables []string, values []int) *ValuePrecedence {
+	return &ValuePrecedence{variables, values}
+}
+
+func (constraint *ValuePrecedence) Scope() []string {
+	return constraint.Variables
+}
+
+func (constraint *ValuePrecedence) Satisfied(assignment map[string]int) bool {
+	rank := make(map[int]int)
+	for i, value := range constraint.Values {
+		rank[value] = i
+	}
+	seen := make(map[int]bool)
+	for _, variable := range constraint.Variables {
+		value, assigned := assignment[variable]
+		if !assigned {
+			return true
+		}
+		if i, ranked := rank[value]; ranked && i > 0 && !seen[constraint.Values[i-1]] {
+			return false
+		}
+		seen[value] = true
+	}
+	return true
+}
@@ -0,0 +1,255 @@
+package csp
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Core-guided optimization of the soft constraint cost, WPM1 style, for models where so many soft constraints
+// break that branch and bound drowns in incumbents. Every soft constraint starts out hard. While that's
+// unsatisfiable, the search finds a core, soft constraints that can't all hold together, shrunk until dropping any
+// one of them makes the rest satisfiable. The cheapest weight in the core is a price any solution pays, so it goes
+// on the lower bound, and every constraint in the core gets a fresh 0/1 relaxation variable that lets it break,
+// with exactly one of them allowed to. Constraints weighing more than the cheapest are split first, the rest of
+// their weight staying as it was. The first satisfiable relaxation is an optimal assignment. With an objective,
+// a last branch and bound finds the best objective among the assignments of that cost.
+//
+// Every step is a solve of its own with the solver's settings, and shrinking a core takes one per constraint in
+// it, so this pays off when cores are small and the cost high, and loses to branch and bound otherwise.
+func (solver *Solver) coreGuidedSearch() {
+	problem := solver.Problem
+	softs := make([]*relaxedConstraint, len(problem.SoftConstraints))
+	weights := make([]int, len(problem.SoftConstraints))
+	for i, soft := range problem.SoftConstraints {
+		softs[i] = &relaxedConstraint{Constraint: soft.Constraint}
+		weights[i] = soft.Weight
+	}
+	var relaxations []string
+	var cardinalities []Constraint
+
+	for {
+		relaxed := solver.relaxedProblem(relaxations, cardinalities, softs)
+		solution, stopped := solver.coreSolve(relaxed)
+		if stopped {
+			return
+		}
+		if solution != nil {
+			for _, variable := range relaxations {
+				delete(solution, variable)
+			}
+			solver.Best = solution
+			solver.BestCost = problem.Cost(solution)
+			solver.BestObjective = solution[problem.Objective]
+			break
+		}
+		core, stopped := solver.shrinkCore(relaxations, cardinalities, softs)
+		if stopped {
+			return
+		}
+		if len(core) == 0 {
+			return // the hard constraints alone are unsatisfiable
+		}
+		solver.Cores++
+		cheapest := weights[core[0]]
+		for _, i := range core {
+			if weights[i] < cheapest {
+				cheapest = weights[i]
+			}
+		}
+		solver.CoreBound += cheapest
+		var added []string
+		for _, i := range core {
+			if weights[i] > cheapest {
+				unrelaxed := *softs[i]
+				unrelaxed.Relaxations = append([]string(nil), softs[i].Relaxations...)
+				softs = append(softs, &unrelaxed)
+				weights = append(weights, weights[i]-cheapest)
+				weights[i] = cheapest
+			}
+			relaxation := solver.relaxationVariable(len(relaxations))
+			relaxations = append(relaxations, relaxation)
+			added = append(added, relaxation)
+			softs[i].Relaxations = append(softs[i].Relaxations, relaxation)
+		}
+		cardinalities = append(cardinalities, NewSum(added, "==", 1))
+	}
+
+	if problem.Objective != "" {
+		// the cost is optimal, the objective only as good as whatever came first
+		solver.optimizeObjectiveAtCost()
+	}
+	solver.Solutions = []map[string]int{solver.Best}
+}
+
+// The soft constraints that can't all hold, as indices into softs, shrunk by trying to leave them out: half of
+// them at a time at first, then ever fewer, down to one at a time, after which every one left is needed. Empty
+// when even the hard constraints can't hold.
+func (solver *Solver) shrinkCore(relaxations []string, cardinalities []Constraint, softs []*relaxedConstraint) ([]int, bool) {
+	var core []int
+	for i := range softs {
+		core = append(core, i)
+	}
+	for chunk := (len(core) + 1) / 2; chunk > 0; chunk /= 2 {
+		for position := 0; position < len(core); {
+			end := position + chunk
+			if end > len(core) {
+				end = len(core)
+			}
+			without := append(append([]int(nil), core[:position]...), core[end:]...)
+			kept := make([]*relaxedConstraint, len(without))
+			for j, i := range without {
+				kept[j] = softs[i]
+			}
+			solution, stopped := solver.coreSolve(solver.relaxedProblem(relaxations, cardinalities, kept))
+			if stopped {
+				return nil, true
+			}
+			if solution == nil {
+				core = without
+			} else {
+				position = end
+			}
+		}
+	}
+	return core, false
+}
+
+// The problem with the relaxation variables after its own, which works out best for the propagation, and with
+// the cardinality constraints on the relaxations and the soft constraints given turned hard on top of its own
+// constraints
+func (solver *Solver) relaxedProblem(relaxations []string, cardinalities []Constraint, softs []*relaxedConstraint) *Problem {
+	problem := solver.Problem
+	relaxed := &Problem{
+		Variables:          append(append([]string(nil), problem.Variables...), relaxations...),
+		Domains:            make(map[string][]int, len(problem.Domains)+len(relaxations)),
+		Constraints:        append(append([]Constraint(nil), problem.Constraints...), cardinalities...),
+		Labels:             problem.Labels,
+		DefaultConsistency: problem.DefaultConsistency,
+		Consistency:        problem.Consistency,
+	}
+	for variable, domain := range problem.Domains {
+		relaxed.Domains[variable] = domain
+	}
+	for _, relaxation := range relaxations {
+		relaxed.Domains[relaxation] = []int{0, 1}
+	}
+	for _, soft := range softs {
+		relaxed.AddConstraint(soft)
+	}
+	return relaxed
+}
+
+// A name for the nth relaxation variable that the problem doesn't use already
+func (solver *Solver) relaxationVariable(n int) string {
+	name := fmt.Sprintf("_relax%d", n)
+	for {
+		if _, taken := solver.Problem.Domains[name]; !taken {
+			return name
+		}
+		name = "_" + name
+	}
+}
+
+// Looks for one solution, returning nil if there is none, or stopped when a limit of this solver's ran out first
+func (solver *Solver) coreSolve(problem *Problem) (map[string]int, bool) {
+	search := solver.coreSolver(problem)
+	if search == nil {
+		solver.stopped = true
+		return nil, true
+	}
+	search.MaxSolutions = 1
+	search.Solve()
+	solver.absorb(search)
+	if search.Status == Unknown {
+		solver.stopped = true
+		return nil, true
+	}
+	if len(search.Solutions) == 0 {
+		return nil, false
+	}
+	return search.Solutions[0], false
+}
+
+// A solver for one step, with what's left of this solver's limits and listening for its Interrupt. Nil when
+// there's nothing left.
+func (solver *Solver) coreSolver(problem *Problem) *Solver {
+	search := solver.derived(problem)
+	if solver.Timeout > 0 {
+		if search.Timeout = time.Until(solver.deadline); search.Timeout <= 0 {
+			return nil
+		}
+	}
+	if solver.MaxNodes > 0 {
+		if search.MaxNodes = solver.MaxNodes - solver.Nodes; search.MaxNodes <= 0 {
+			return nil
+		}
+	}
+	if solver.MaxFailures > 0 {
+		if search.MaxFailures = solver.MaxFailures - solver.Failures; search.MaxFailures <= 0 {
+			return nil
+		}
+	}
+	if atomic.LoadInt32(&solver.interrupted) != 0 {
+		return nil
+	}
+	search.OnNode = func(SearchEvent) {
+		if atomic.LoadInt32(&solver.interrupted) != 0 {
+			search.Interrupt()
+		}
+	}
+	return search
+}
+
+// Adds a step's statistics to this solver's
+func (solver *Solver) absorb(search *Solver) {
+	solver.Nodes += search.Nodes
+	solver.Failures += search.Failures
+	solver.Propagations += search.Propagations
+	solver.Restarts += search.Restarts
+}
+
+// A soft constraint the core-guided search made hard: it holds when the constraint does, or when one of its
+// relaxation variables is 1, and can't be told broken while one of them is unassigned
+type relaxedConstraint struct {
+	Constraint  Constraint
+	Relaxations []string
+}
+
+func (constraint *relaxedConstraint) Scope() []string {
+	return append(append([]string(nil), constraint.Constraint.Scope()...), constraint.Relaxations...)
+}
+
+func (constraint *relaxedConstraint) Satisfied(assignment map[string]int) bool {
+	for _, relaxation := range constraint.Relaxations {
+		if value, assigned := assignment[relaxation]; !assigned || value == 1 {
+			return true
+		}
+	}
+	return constraint.Constraint.Satisfied(assignment)
+}
+
+// The soft constraints broken so far weigh at most Max. Soft constraints hold until they're known to break, so
+// partial assignments only fail once they're past the limit.
+type costLimit struct {
+	Soft []SoftConstraint
+	Max  int
+}
+
+func (constraint *costLimit) Scope() []string {
+	var scope []string
+	for _, soft := range constraint.Soft {
+		scope = append(scope, soft.Constraint.Scope()...)
+	}
+	return uniqueScope(scope)
+}
+
+func (constraint *costLimit) Satisfied(assignment map[string]int) bool {
+	cost := 0
+	for _, soft := range constraint.Soft {
+		if !soft.Constraint.Satisfied(assignment) {
+			cost += soft.Weight
+		}
+	}
+	return cost <= constraint.Max
+}
@@ -0,0 +1,188 @@
+package csp
+
+import (
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Counts solutions without enumerating them (#CSP). After every assignment the unassigned variables are split into
+// connected components, each component is counted on its own and the counts are multiplied. Component counts are
+// cached by the component's variables plus the values of the assigned variables its constraints can see, so a
+// residual subproblem that shows up again under a different prefix is only ever counted once.
+// Soft constraints and the objective are ignored: this counts the assignments satisfying every hard constraint.
+type Counter struct {
+	Problem   *Problem
+	Nodes     int
+	CacheHits int
+
+	cache map[string]*big.Int
+	graph *constraintGraph
+}
+
+// Which constraints each variable is in, for splitting unassigned variables into independent components and
+// telling which assigned variables a component can see
+type constraintGraph struct {
+	watchers map[string][]Constraint
+	position map[string]int
+}
+
+func newConstraintGraph(variables []string, constraints []Constraint) *constraintGraph {
+	graph := &constraintGraph{watchers: make(map[string][]Constraint), position: make(map[string]int)}
+	for i, variable := range variables {
+		graph.position[variable] = i
+	}
+	for _, constraint := range constraints {
+		for _, variable := range uniqueScope(constraint.Scope()) {
+			graph.watchers[variable] = append(graph.watchers[variable], constraint)
+		}
+	}
+	return graph
+}
+
+// Counter constructor
+func NewCounter(problem *Problem) *Counter {
+	return &Counter{Problem: problem}
+}
+
+func (counter *Counter) Count() *big.Int {
+	counter.Nodes = 0
+	counter.CacheHits = 0
+	counter.cache = make(map[string]*big.Int)
+	counter.graph = newConstraintGraph(counter.Problem.Variables, counter.Problem.Constraints)
+
+	assignment := make(map[string]int)
+	total := big.NewInt(1)
+	for _, component := range counter.graph.components(counter.Problem.Variables, assignment) {
+		total.Mul(total, counter.countComponent(component, assignment))
+	}
+	return total
+}
+
+// How many solutions give the variable each value of its domain, the marginals of the uniform distribution over
+// solutions times the count. Every value gets counted with the variable fixed, sharing one cache, so it's about as
+// cheap as a few Counts.
+func (counter *Counter) ValueCounts(variable string) map[int]*big.Int {
+	counter.Nodes = 0
+	counter.CacheHits = 0
+	counter.cache = make(map[string]*big.Int)
+	counter.graph = newConstraintGraph(counter.Problem.Variables, counter.Problem.Constraints)
+
+	var rest []string
+	for _, other := range counter.Problem.Variables {
+		if other != variable {
+			rest = append(rest, other)
+		}
+	}
+	counts := make(map[int]*big.Int)
+	assignment := make(map[string]int)
+	for _, value := range counter.Problem.Domains[variable] {
+		counter.Nodes++
+		assignment[variable] = value
+		count := new(big.Int)
+		if counter.consistent(variable, assignment) {
+			count.SetInt64(1)
+			for _, component := range counter.graph.components(rest, assignment) {
+				count.Mul(count, counter.countComponent(component, assignment))
+				if count.Sign() == 0 {
+					break
+				}
+			}
+		}
+		counts[value] = count
+	}
+	return counts
+}
+
+func (counter *Counter) countComponent(variables []string, assignment map[string]int) *big.Int {
+	if len(variables) == 0 {
+		return big.NewInt(1)
+	}
+	key := counter.graph.contextKey(variables, assignment)
+	if count, cached := counter.cache[key]; cached {
+		counter.CacheHits++
+		return count
+	}
+
+	variable := variables[0]
+	rest := variables[1:]
+	total := new(big.Int)
+	for _, value := range counter.Problem.Domains[variable] {
+		counter.Nodes++
+		assignment[variable] = value
+		if counter.consistent(variable, assignment) {
+			product := big.NewInt(1)
+			for _, component := range counter.graph.components(rest, assignment) {
+				product.Mul(product, counter.countComponent(component, assignment))
+				if product.Sign() == 0 {
+					break
+				}
+			}
+			total.Add(total, product)
+		}
+	}
+	delete(assignment, variable)
+
+	counter.cache[key] = total
+	return total
+}
+
+func (counter *Counter) consistent(variable string, assignment map[string]int) bool {
+	for _, constraint := range counter.graph.watchers[variable] {
+		if !constraint.Satisfied(assignment) {
+			return false
+		}
+	}
+	return true
+}
+
+// Splits unassigned variables into groups that share no constraint, keeping problem order inside each group
+func (graph *constraintGraph) components(variables []string, assignment map[string]int) [][]string {
+	inSet := make(map[string]bool)
+	for _, variable := range variables {
+		inSet[variable] = true
+	}
+	visited := make(map[string]bool)
+	var components [][]string
+	for _, start := range variables {
+		if visited[start] {
+			continue
+		}
+		visited[start] = true
+		component := []string{start}
+		for i := 0; i < len(component); i++ {
+			for _, constraint := range graph.watchers[component[i]] {
+				for _, other := range constraint.Scope() {
+					if inSet[other] && !visited[other] {
+						visited[other] = true
+						component = append(component, other)
+					}
+				}
+			}
+		}
+		sort.Slice(component, func(a int, b int) bool {
+			return graph.position[component[a]] < graph.position[component[b]]
+		})
+		components = append(components, component)
+	}
+	return components
+}
+
+// The component's variables, then every assigned variable sharing a constraint with the component and its value
+func (graph *constraintGraph) contextKey(variables []string, assignment map[string]int) string {
+	var context []string
+	seen := make(map[string]bool)
+	for _, variable := range variables {
+		for _, constraint := range graph.watchers[variable] {
+			for _, other := range constraint.Scope() {
+				if value, assigned := assignment[other]; assigned && !seen[other] {
+					seen[other] = true
+					context = append(context, other+"="+strconv.Itoa(value))
+				}
+			}
+		}
+	}
+	sort.Strings(context)
+	return strings.Join(variables, ",") + "|" + strings.Join(context, ",")
+}
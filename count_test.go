@@ -0,0 +1,17 @@
+package csp_test
+
+import (
+	"testing"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+func TestCounterMatchesEnumeration(t *testing.T) {
+	for name, problem := range randomInstances() {
+		want := len(enumerate(problem))
+		counter := csp.NewCounter(problem)
+		if count := counter.Count(); !count.IsInt64() || count.Int64() != int64(want) {
+			t.Errorf("%s: counted %s solutions, the solver found %d", name, count, want)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package csp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Writes the problem as an OR-Tools CP-SAT model in protobuf text format (a CpModelProto), which can be loaded
+// with text_format.Parse and solved with CP-SAT directly. Variables keep their names and come first, in problem
+// order, so a CP-SAT solution lines up with Problem.Variables. Anything CP-SAT has no constraint for is exported
+// as the table of tuples it allows.
+// Soft constraints turn into a violation Boolean each, and the objective minimizes their weighted sum first and
+// the problem's objective second, the same order the Solver uses.
+func ExportCPSAT(problem *Problem, w io.Writer) error {
+	model := &cpsatModel{problem: problem, index: make(map[string]int)}
+	for _, variable := range problem.Variables {
+		model.index[variable] = model.addVariable(variable, problem.Domains[variable])
+	}
+	for _, constraint := range problem.Constraints {
+		if err := model.addConstraint(constraint, unconditional); err != nil {
+			return err
+		}
+	}
+
+	var objectiveVars, objectiveCoeffs []int
+	for i, soft := range problem.SoftConstraints {
+		violated := model.addVariable(fmt.Sprintf("soft%d_violated", i), []int{0, 1})
+		// enforced unless violated, in CP-SAT's encoding of negated literals
+		if err := model.addConstraint(soft.Constraint, -violated-1); err != nil {
+			return err
+		}
+		objectiveVars = append(objectiveVars, violated)
+		objectiveCoeffs = append(objectiveCoeffs, soft.Weight)
+	}
+	if problem.Objective != "" {
+		coefficient := 1
+		if problem.Maximizing {
+			coefficient = -1
+		}
+		if len(objectiveVars) > 0 {
+			// the objective only breaks ties between equal costs, so a unit of cost has to outweigh its whole range
+			low, high := domainBounds(problem.Domains[problem.Objective])
+			for i := range objectiveCoeffs {
+				objectiveCoeffs[i] *= high - low + 1
+			}
+		}
+		objectiveVars = append(objectiveVars, model.index[problem.Objective])
+		objectiveCoeffs = append(objectiveCoeffs, coefficient)
+	}
+
+	out := bufio.NewWriter(w)
+	for _, variable := range model.variables {
+		fmt.Fprintf(out, "variables {\n  name: %q\n  domain: %s\n}\n", variable.name, cpsatList(variable.domain))
+	}
+	for _, constraint := range model.constraints {
+		fmt.Fprintf(out, "constraints {\n%s}\n", constraint)
+	}
+	if len(objectiveVars) > 0 {
+		fmt.Fprintf(out, "objective {\n  vars: %s\n  coeffs: %s\n}\n", cpsatList(objectiveVars), cpsatList(objectiveCoeffs))
+	}
+	return out.Flush()
+}
+
+type cpsatModel struct {
+	problem     *Problem
+	index       map[string]int
+	variables   []cpsatVariable
+	constraints []string
+}
+
+type cpsatVariable struct {
+	name   string
+	domain []int
+}
+
+func (model *cpsatModel) addVariable(name string, domain []int) int {
+	model.variables = append(model.variables, cpsatVariable{name, cpsatDomain(domain)})
+	return len(model.variables) - 1
+}
+
+// No literal can be this negative, so it marks constraints that always hold
+const unconditional = math.MinInt32
+
+// enforcement is a literal the constraint is conditional on, or unconditional
+func (model *cpsatModel) add(enforcement int, body string) {
+	if enforcement != unconditional {
+		body = fmt.Sprintf("  enforcement_literal: %d\n", enforcement) + body
+	}
+	model.constraints = append(model.constraints, body)
+}
+
+func (model *cpsatModel) vars(variables []string) []int {
+	indices := make([]int, len(variables))
+	for i, variable := range variables {
+		indices[i] = model.index[variable]
+	}
+	return indices
+}
+
+// CP-SAT only accepts enforcement literals on some constraint types, so conditional constraints are always
+// written as linear constraints or tables
+func (model *cpsatModel) addConstraint(constraint Constraint, enforcement int) error {
+	switch constraint := constraint.(type) {
+	case *AllDifferent:
+		if enforcement != unconditional {
+			break
+		}
+		var exprs []string
+		for _, index := range model.vars(constraint.Variables) {
+			exprs = append(exprs, fmt.Sprintf("    exprs { vars: %d coeffs: 1 }\n", index))
+		}
+		model.add(enforcement, "  all_diff {\n"+strings.Join(exprs, "")+"  }\n")
+		return nil
+	case *NotEqual:
+		model.addLinear(enforcement, []int{model.index[constraint.First], model.index[constraint.Second]},
+			[]int{1, -1}, "!=", 0)
+		return nil
+	case *Linear:
+		model.addLinear(enforcement, model.vars(constraint.Variables), constraint.Coefficients,
+			constraint.Operator, constraint.Constant)
+		return nil
+	case *Table:
+		model.addTable(enforcement, constraint.Variables, constraint.Tuples)
+		return nil
+	case *Clause:
+		if enforcement != unconditional {
+			break
+		}
+		var literals []int
+		for _, literal := range constraint.Literals {
+			holds := model.addVariable(fmt.Sprintf("literal%d", len(model.variables)), []int{0, 1})
+			index := []int{model.index[literal.Variable]}
+			model.addLinear(holds, index, []int{1}, "=", literal.Value)
+			model.addLinear(-holds-1, index, []int{1}, "!=", literal.Value)
+			if literal.Negated {
+				holds = -holds - 1
+			}
+			literals = append(literals, holds)
+		}
+		model.add(enforcement, fmt.Sprintf("  bool_or {\n    literals: %s\n  }\n", cpsatList(literals)))
+		return nil
+	case *Inverse:
+		// CP-SAT's inverse is 0-indexed, these are 1-indexed positions, so it's channelled pair by pair instead
+		for i, forward := range constraint.Forward {
+			for j, backward := range constraint.Backward {
+				pair := []string{forward, backward}
+				i, j := i, j
+				check := NewPredicate(func(values []int) bool {
+					return (values[0] == j+1) == (values[1] == i+1)
+				}, pair...)
+				if err := model.addTableOf(check, enforcement); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case *Disjunctive:
+		if enforcement != unconditional {
+			break
+		}
+		var intervals []int
+		for i, start := range constraint.Starts {
+			index := model.index[start]
+			intervals = append(intervals, len(model.constraints))
+			model.add(unconditional, fmt.Sprintf("  interval {\n    start { vars: %d coeffs: 1 }\n"+
+				"    end { vars: %d coeffs: 1 offset: %d }\n    size { offset: %d }\n  }\n",
+				index, index, constraint.Durations[i], constraint.Durations[i]))
+		}
+		model.add(unconditional, fmt.Sprintf("  no_overlap {\n    intervals: %s\n  }\n", cpsatList(intervals)))
+		return nil
+	case *Regular:
+		if enforcement != unconditional {
+			break
+		}
+		var tails, heads, labels, finals []int
+		for state, transitions := range constraint.Transitions {
+			for _, value := range sortedKeys(transitions) {
+				tails = append(tails, state)
+				heads = append(heads, transitions[value])
+				labels = append(labels, value)
+			}
+		}
+		for _, state := range sortedKeys(constraint.Accepting) {
+			if constraint.Accepting[state] {
+				finals = append(finals, state)
+			}
+		}
+		model.add(unconditional, fmt.Sprintf("  automaton {\n    starting_state: %d\n    final_states: %s\n"+
+			"    transition_tail: %s\n    transition_head: %s\n    transition_label: %s\n    vars: %s\n  }\n",
+			constraint.Start, cpsatList(finals), cpsatList(tails), cpsatList(heads), cpsatList(labels),
+			cpsatList(model.vars(constraint.Variables))))
+		return nil
+	}
+	return model.addTableOf(constraint, enforcement)
+}
+
+func (model *cpsatModel) addTableOf(constraint Constraint, enforcement int) error {
+	scope, tuples, ok := AllowedTuples(constraint, model.problem.Domains)
+	if !ok {
+		return fmt.Errorf("constraint over %v is too large to export as a table", constraint.Scope())
+	}
+	model.addTable(enforcement, scope, tuples)
+	return nil
+}
+
+func (model *cpsatModel) addTable(enforcement int, variables []string, tuples [][]int) {
+	var values []int
+	for _, tuple := range tuples {
+		values = append(values, tuple...)
+	}
+	model.add(enforcement, fmt.Sprintf("  table {\n    vars: %s\n    values: %s\n  }\n",
+		cpsatList(model.vars(variables)), cpsatList(values)))
+}
+
+// The right hand side becomes the domain the linear expression has to fall in
+func (model *cpsatModel) addLinear(enforcement int, vars []int, coeffs []int, operator string, constant int) {
+	var domain []int
+	switch operator {
+	case "=":
+		domain = []int{constant, constant}
+	case "!=":
+		domain = []int{math.MinInt64, constant - 1, constant + 1, math.MaxInt64}
+	case "<":
+		domain = []int{math.MinInt64, constant - 1}
+	case "<=":
+		domain = []int{math.MinInt64, constant}
+	case ">":
+		domain = []int{constant + 1, math.MaxInt64}
+	case ">=":
+		domain = []int{constant, math.MaxInt64}
+	default:
+		// an operator Compare doesn't know is never satisfied
+		domain = []int{1, 0}
+		vars, coeffs = nil, nil
+	}
+	model.add(enforcement, fmt.Sprintf("  linear {\n    vars: %s\n    coeffs: %s\n    domain: %s\n  }\n",
+		cpsatList(vars), cpsatList(coeffs), cpsatList(domain)))
+}
+
+// CP-SAT domains are sorted disjoint intervals, flattened to [low1, high1, low2, high2, ...]
+func cpsatDomain(domain []int) []int {
+	values := append([]int(nil), domain...)
+	sort.Ints(values)
+	var intervals []int
+	for i, value := range values {
+		switch {
+		case i == 0:
+			intervals = append(intervals, value, value)
+		case value == intervals[len(intervals)-1]:
+		case value == intervals[len(intervals)-1]+1:
+			intervals[len(intervals)-1] = value
+		default:
+			intervals = append(intervals, value, value)
+		}
+	}
+	return intervals
+}
+
+func domainBounds(domain []int) (int, int) {
+	if len(domain) == 0 {
+		return 0, 0
+	}
+	low, high := domain[0], domain[0]
+	for _, value := range domain {
+		if value < low {
+			low = value
+		}
+		if value > high {
+			high = value
+		}
+	}
+	return low, high
+}
+
+func cpsatList(values []int) string {
+	items := make([]string, len(values))
+	for i, value := range values {
+		items[i] = fmt.Sprint(value)
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
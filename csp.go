@@ -1,11 +1,17 @@
-package main
+package csp
 
 import (
 	"fmt"
+	"io"
+	"runtime"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 )
 
-const maximumDepth = 8
+// How many variables the classic puzzle has, and so how deep its tree goes
+const MaximumDepth = 8
 
 // Used to represent what variables exist at a given depth of the tree
 var LetterDepth = map[int]string{
@@ -31,7 +37,7 @@ var LetterDepthWithHeuristic = map[int]string{
 }
 
 type Root struct {
-	Children [4]*Node
+	Children []*Node
 	Depth    int
 }
 
@@ -60,10 +66,11 @@ func NewVariable(variableLetter string, value int) *Variable {
 
 // Variable choice may depend on some heuristic, leaving it open to caller
 func (root *Root) PopulateRoot(variableLetter string) {
-	for i := 0; i < 4; i++ {
+	root.Children = nil
+	for _, value := range ClassicDomain.Values() {
 		variable := NewNode(variableLetter)
-		root.Children[i] = variable
-		root.Children[i].Variable.Value = i + 1
+		variable.Variable.Value = value
+		root.Children = append(root.Children, variable)
 	}
 }
 
@@ -74,12 +81,12 @@ func (node *Node) MarkTombstone() {
 // Where the real work goes on. On each call to GenerateTree(), the search space gets pruned, and then the next layer of variables gets added
 // to any paths that haven't failed yet
 func (root *Root) GenerateTree() {
-	root.Prune()
+	root.Prune(runtime.NumCPU())
 	root.IncreaseSearchDepth()
 }
 
 func (root *Root) GenerateTreeWithHeuristic() {
-	root.PruneWithHeuristic()
+	root.PruneWithHeuristic(runtime.NumCPU())
 	root.IncreaseSearchDepthWithHeuristic()
 }
 
@@ -116,33 +123,52 @@ func (root *Root) GeneratePaths() [][]*Node {
 }
 
 // Generate all root-leaf paths in the search space, and for each one check if a constraint has been violated. If it has, mark
-// the tombstone of the last node in that path to indicate a dead end. This path will no longer be expanded
+// the tombstone of the last node in that path to indicate a dead end. This path will no longer be expanded.
+// The paths are split between workers goroutines; 1 checks them serially.
+func (root *Root) Prune(workers int) {
+	prunePaths(root.GeneratePaths(), CheckConstraints, workers)
+}
 
-func (root *Root) Prune() {
-	paths := root.GeneratePaths()
+func (root *Root) PruneWithHeuristic(workers int) {
+	prunePaths(root.GeneratePaths(), CheckConstraintsUsingSelectionHeuristic, workers)
+}
 
-	for i := 0; i < len(paths); i++ {
-		if CheckConstraints(paths[i]) != true {
-			paths[i][len(paths[i])-1].MarkTombstone()
+// The paths are independent of each other, so they're split into one contiguous chunk per worker. Every path ends
+// in a different leaf, which means each tombstone only ever gets written by the one worker that checked its path.
+func prunePaths(paths [][]*Node, check func(assignment Assignment) bool, workers int) {
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+	if workers <= 1 {
+		for i := 0; i < len(paths); i++ {
+			if check(PathAssignment(paths[i])) != true {
+				paths[i][len(paths[i])-1].MarkTombstone()
+			}
 		}
+		return
 	}
-}
 
-func (root *Root) PruneWithHeuristic() {
-	paths := root.GeneratePaths()
-
-	for i := 0; i < len(paths); i++ {
-		if CheckConstraintsUsingSelectionHeuristic(paths[i]) != true {
-			paths[i][len(paths[i])-1].MarkTombstone()
-		}
+	var wait sync.WaitGroup
+	chunk := (len(paths) + workers - 1) / workers
+	for start := 0; start < len(paths); start += chunk {
+		end := start + chunk
+		if end > len(paths) {
+			end = len(paths)
+		}
+		wait.Add(1)
+		go func(paths [][]*Node) {
+			defer wait.Done()
+			prunePaths(paths, check, 1)
+		}(paths[start:end])
 	}
+	wait.Wait()
 }
 
 // Assumes a node with no children yet assigned, and that variable only has its letter asigned, not value yet (which this function handles)
 func (node *Node) AddVariableLayer(variableLetter string) {
-	for i := 0; i < 4; i++ {
+	for _, value := range ClassicDomain.Values() {
 		newNode := NewNode(variableLetter)
-		newNode.Variable.Value = i + 1
+		newNode.Variable.Value = value
 		node.Children = append(node.Children, newNode)
 	}
 }
@@ -160,7 +186,7 @@ func RecursivelyAddVariableLayer(node *Node, variableLetter string) {
 
 }
 
-// Increases depth of the search space by one, adding 4 new child nodes to each node whose tombstone is not marked, meaning we
+// Increases depth of the search space by one, adding a child node per ClassicDomain value to each node whose tombstone is not marked, meaning we
 // want to continue exploring this path for a model state.
 func (root *Root) IncreaseSearchDepth() {
 	if root == nil || root.Depth == 8 {
@@ -193,79 +219,70 @@ func (root *Root) IncreaseSearchDepthWithHeuristic() {
 }
 
 // Here is where the constraints get checked:
-// We switch on the length of the 'variables' input, which equals the depth of the tree at a given time.
-// i.e, if len(variables) == 4, we know that we have (A, B, C, D), and can thus check constraints involving those variables
-// As a reference:
-// variables[0].Variable == A
-// variables[1].Variable == B
-// variables[2].Variable == C
-// variables[3].Variable == D
-// variables[4].Variable == E
-// variables[5].Variable == F
-// variables[6].Variable == G
-// variables[7].Variable == H
-
-func CheckConstraints(variables []*Node) bool {
-
-	switch len(variables) {
+// We switch on the depth of the assignment, which equals the depth of the tree at a given time.
+// i.e, if the depth is 4, we know that we have (A, B, C, D), and can thus check constraints involving those variables
+
+func CheckConstraints(assignment Assignment) bool {
+	values := assignment.Values
+	switch assignment.Depth {
 	case 2:
-		if variables[0].Variable.Value == variables[1].Variable.Value {
+		if values["A"] == values["B"] {
 			return false
 		}
 	case 4:
-		if variables[2].Variable.Value == variables[3].Variable.Value {
+		if values["C"] == values["D"] {
 			return false
 		}
 	case 5:
-		if variables[2].Variable.Value == variables[4].Variable.Value {
+		if values["C"] == values["E"] {
 			return false
 		}
-		if variables[4].Variable.Value >= variables[3].Variable.Value-1 {
+		if values["E"] >= values["D"]-1 {
 			return false
 		}
 	case 6:
-		if AbsoluteValue(variables[5].Variable.Value-variables[1].Variable.Value) != 1 {
+		if AbsoluteValue(values["F"]-values["B"]) != 1 {
 			return false
 		}
-		if variables[2].Variable.Value == variables[5].Variable.Value {
+		if values["C"] == values["F"] {
 			return false
 		}
-		if variables[3].Variable.Value == variables[5].Variable.Value {
+		if values["D"] == values["F"] {
 			return false
 		}
-		if AbsoluteValue(variables[4].Variable.Value-variables[5].Variable.Value)%2 == 0 {
+		if AbsoluteValue(values["E"]-values["F"])%2 == 0 {
 			return false
 		}
 	case 7:
-		if variables[0].Variable.Value <= variables[6].Variable.Value {
+		if values["A"] <= values["G"] {
 			return false
 		}
-		if AbsoluteValue(variables[6].Variable.Value-variables[2].Variable.Value) != 1 {
+		if AbsoluteValue(values["G"]-values["C"]) != 1 {
 			return false
 		}
-		if variables[3].Variable.Value <= variables[6].Variable.Value {
+		if values["D"] <= values["G"] {
 			return false
 		}
-		if variables[6].Variable.Value == variables[5].Variable.Value {
+		if values["G"] == values["F"] {
 			return false
 		}
 	case 8:
-		if variables[0].Variable.Value > variables[7].Variable.Value {
+		if values["A"] > values["H"] {
 			return false
 		}
-		if variables[6].Variable.Value >= variables[7].Variable.Value {
+		if values["G"] >= values["H"] {
 			return false
 		}
-		if AbsoluteValue(variables[7].Variable.Value-variables[2].Variable.Value)%2 != 0 {
+		if AbsoluteValue(values["H"]-values["C"])%2 != 0 {
 			return false
 		}
-		if variables[7].Variable.Value == variables[3].Variable.Value {
+		if values["H"] == values["D"] {
 			return false
 		}
-		if variables[4].Variable.Value == variables[7].Variable.Value-2 {
+		if values["E"] == values["H"]-2 {
 			return false
 		}
-		if variables[7].Variable.Value == variables[5].Variable.Value {
+		if values["H"] == values["F"] {
 			return false
 		}
 
@@ -278,75 +295,67 @@ func CheckConstraints(variables []*Node) bool {
 // This way we can fail sooner than using the original A to H ordering.
 // As an aside, I should figure out a more generic way to check constraints where the ordering can just be passed as an argument.
 // Ordering: H, F, G, D, E, C, A, B.
-// variables[0].Variable == H
-// variables[1].Variable == F
-// variables[2].Variable == G
-// variables[3].Variable == D
-// variables[4].Variable == E
-// variables[5].Variable == C
-// variables[6].Variable == A
-// variables[7].Variable == B
-
-func CheckConstraintsUsingSelectionHeuristic(variables []*Node) bool {
-
-	switch len(variables) {
+
+func CheckConstraintsUsingSelectionHeuristic(assignment Assignment) bool {
+	values := assignment.Values
+	switch assignment.Depth {
 	case 2:
-		if variables[0].Variable.Value == variables[1].Variable.Value {
+		if values["H"] == values["F"] {
 			return false
 		}
 	case 3:
-		if variables[2].Variable.Value >= variables[0].Variable.Value {
+		if values["G"] >= values["H"] {
 			return false
 		}
-		if variables[2].Variable.Value == variables[1].Variable.Value {
+		if values["G"] == values["F"] {
 			return false
 		}
 	case 4:
-		if variables[0].Variable.Value == variables[3].Variable.Value {
+		if values["H"] == values["D"] {
 			return false
 		}
-		if variables[3].Variable.Value <= variables[2].Variable.Value {
+		if values["D"] <= values["G"] {
 			return false
 		}
-		if variables[3].Variable.Value == variables[1].Variable.Value {
+		if values["D"] == values["F"] {
 			return false
 		}
 	case 5:
-		if variables[4].Variable.Value >= variables[3].Variable.Value-1 {
+		if values["E"] >= values["D"]-1 {
 			return false
 		}
-		if AbsoluteValue(variables[4].Variable.Value-variables[1].Variable.Value)%2 == 0 {
+		if AbsoluteValue(values["E"]-values["F"])%2 == 0 {
 			return false
 		}
-		if variables[4].Variable.Value == variables[0].Variable.Value-2 {
+		if values["E"] == values["H"]-2 {
 			return false
 		}
 	case 6:
-		if AbsoluteValue(variables[2].Variable.Value-variables[5].Variable.Value) != 1 {
+		if AbsoluteValue(values["G"]-values["C"]) != 1 {
 			return false
 		}
-		if AbsoluteValue(variables[0].Variable.Value-variables[5].Variable.Value)%2 != 0 {
+		if AbsoluteValue(values["H"]-values["C"])%2 != 0 {
 			return false
 		}
-		if variables[3].Variable.Value == variables[5].Variable.Value {
+		if values["D"] == values["C"] {
 			return false
 		}
-		if variables[4].Variable.Value == variables[5].Variable.Value {
+		if values["E"] == values["C"] {
 			return false
 		}
-		if variables[5].Variable.Value == variables[1].Variable.Value {
+		if values["C"] == values["F"] {
 			return false
 		}
 
 	case 7:
-		if variables[6].Variable.Value <= variables[2].Variable.Value {
+		if values["A"] <= values["G"] {
 			return false
 		}
-		if variables[6].Variable.Value > variables[0].Variable.Value {
+		if values["A"] > values["H"] {
 			return false
 		}
 	case 8:
-		if AbsoluteValue(variables[1].Variable.Value-variables[7].Variable.Value) != 1 {
+		if AbsoluteValue(values["F"]-values["B"]) != 1 {
 			return false
 		}
 	}
@@ -361,18 +370,38 @@ func AbsoluteValue(a int) int {
 	return a
 }
 
+// Evaluates "a operator b" for the comparison operators constraints are allowed to use. Unknown operators never hold.
+func Compare(a int, operator string, b int) bool {
+	switch operator {
+	case "=", "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
 //-------------- PRINTING RESULTS -------------------//
 
 func (node *Node) String() string {
 	return node.Variable.Letter + ":" + strconv.Itoa(node.Variable.Value)
 }
 
+// Keeps the first of the nodes assigning the same variable the same value, whether or not they're the same node
 func RemoveDuplicates(nodes *[]*Node) {
-	encountered := make(map[*Node]bool)
+	encountered := make(map[Variable]bool)
 	j := 0
 	for i, x := range *nodes {
-		if !encountered[x] {
-			encountered[x] = true
+		if !encountered[*x.Variable] {
+			encountered[*x.Variable] = true
 			(*nodes)[j] = (*nodes)[i]
 			j++
 		}
@@ -380,7 +409,21 @@ func RemoveDuplicates(nodes *[]*Node) {
 	*nodes = (*nodes)[:j]
 }
 
-func (root *Root) PrintValidPaths() {
+// Keeps the first of the solutions that are the same assignment, as symmetric branches of a search can find
+func RemoveDuplicateSolutions(solutions *[]map[string]int) {
+	encountered := make(map[string]bool)
+	j := 0
+	for i, x := range *solutions {
+		if key := assignmentKey(x); !encountered[key] {
+			encountered[key] = true
+			(*solutions)[j] = (*solutions)[i]
+			j++
+		}
+	}
+	*solutions = (*solutions)[:j]
+}
+
+func (root *Root) PrintValidPaths(w io.Writer, colorize bool) {
 	paths := root.GeneratePaths()
 	var validPaths [][]*Node
 	for _, path := range paths {
@@ -388,13 +431,11 @@ func (root *Root) PrintValidPaths() {
 			validPaths = append(validPaths, path)
 		}
 	}
-	fmt.Println("Valid paths:")
-	for _, p := range validPaths {
-		fmt.Printf("%v\n", p)
-	}
+	fmt.Fprintln(w, "Valid paths:")
+	PrintPathTable(w, validPaths, MaximumDepth, colorize)
 }
 
-func (root *Root) PrintValidPathsWithHeuristic() {
+func (root *Root) PrintValidPathsWithHeuristic(w io.Writer, colorize bool) {
 	paths := root.GeneratePaths()
 	var validPaths [][]*Node
 	for _, path := range paths {
@@ -402,44 +443,78 @@ func (root *Root) PrintValidPathsWithHeuristic() {
 			validPaths = append(validPaths, path)
 		}
 	}
-	fmt.Println("Valid paths:")
-	for _, p := range validPaths {
-		fmt.Printf("%v\n", p)
-	}
+	fmt.Fprintln(w, "Valid paths:")
+	PrintPathTable(w, validPaths, MaximumDepth, colorize)
 }
 
-func (root *Root) ReportInvalidPaths() {
-	paths := root.GeneratePaths()
-	count := 0
-	for _, path := range paths {
-		if path[len(path)-1].Tombstone == true {
-			count++
-		}
-	}
-	fmt.Printf("Total invalid paths: %d\n", count)
+// Every root to leaf path, tombstones included
+func (root *Root) PrintAllPaths(w io.Writer, colorize bool) {
+	PrintPathTable(w, root.GeneratePaths(), MaximumDepth, colorize)
 }
 
-func main() {
-	root := Root{}
-	root.Depth = 1
-	root.PopulateRoot("A")
+const (
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorReset = "\x1b[0m"
+)
 
-	for i := 0; i < 8; i++ {
-		root.GenerateTree()
+// Wraps text in the color when colorize is on
+func colored(text string, color string, colorize bool) string {
+	if !colorize {
+		return text
 	}
+	return color + text + colorReset
+}
 
-	root.PrintValidPaths()
-	root.ReportInvalidPaths()
-
-	heuristicRoot := Root{}
-	heuristicRoot.Depth = 1
-	heuristicRoot.PopulateRoot("H")
-
-	for i := 0; i < 8; i++ {
-		heuristicRoot.GenerateTreeWithHeuristic()
+// One row per path and one column per variable, in letter order whatever order the paths assigned them in, so
+// tables from different orderings line up. Complete paths (depth values) that survived are green, tombstoned
+// ones red when colorize is on, and variables a path never reached are left blank.
+func PrintPathTable(w io.Writer, paths [][]*Node, depth int, colorize bool) {
+	seen := make(map[string]bool)
+	var letters []string
+	for _, path := range paths {
+		for _, node := range path {
+			if !seen[node.Variable.Letter] {
+				seen[node.Variable.Letter] = true
+				letters = append(letters, node.Variable.Letter)
+			}
+		}
 	}
+	sort.Strings(letters)
 
-	heuristicRoot.PrintValidPathsWithHeuristic()
-	heuristicRoot.ReportInvalidPaths()
+	header := ""
+	for _, letter := range letters {
+		header += fmt.Sprintf("%3s", letter)
+	}
+	fmt.Fprintln(w, header)
+	for _, path := range paths {
+		values := PathAssignment(path).Values
+		row := ""
+		for _, letter := range letters {
+			if value, assigned := values[letter]; assigned {
+				row += fmt.Sprintf("%3d", value)
+			} else {
+				row += "   "
+			}
+		}
+		row = strings.TrimRight(row, " ")
+		switch last := path[len(path)-1]; {
+		case last.Tombstone:
+			row = colored(row, colorRed, colorize)
+		case len(path) == depth:
+			row = colored(row, colorGreen, colorize)
+		}
+		fmt.Fprintln(w, row)
+	}
+}
 
+func (root *Root) ReportInvalidPaths(w io.Writer) {
+	paths := root.GeneratePaths()
+	count := 0
+	for _, path := range paths {
+		if path[len(path)-1].Tombstone == true {
+			count++
+		}
+	}
+	fmt.Fprintf(w, "Total invalid paths: %d\n", count)
 }
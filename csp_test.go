@@ -0,0 +1,37 @@
+package csp
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Builds the full A-H tree, pruning with the workers, and counts the paths PrintValidPaths would print
+func buildClassicTree(workers int) int {
+	root := Root{}
+	root.Depth = 1
+	root.PopulateRoot("A")
+	for i := 0; i < MaximumDepth; i++ {
+		root.Prune(workers)
+		root.IncreaseSearchDepth()
+	}
+	valid := 0
+	for _, path := range root.GeneratePaths() {
+		if path[len(path)-1].Variable.Letter == "H" && !path[len(path)-1].Tombstone {
+			valid++
+		}
+	}
+	return valid
+}
+
+func BenchmarkPrunePaths(b *testing.B) {
+	want := buildClassicTree(1)
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprint("workers=", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if valid := buildClassicTree(workers); valid != want {
+					b.Fatalf("%d valid paths, %d with one worker", valid, want)
+				}
+			}
+		})
+	}
+}
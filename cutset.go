@@ -0,0 +1,222 @@
+package csp
+
+import "fmt"
+
+// Cycle-cutset conditioning. Once the variables in the cutset have values, what's left of the constraint graph is a
+// forest, and a tree-structured CSP can be solved without backtracking: make it directionally arc consistent from
+// the leaves up, then assign values from the roots down. So the only real search is over the cutset, which is
+// exponential in the cutset size rather than in the whole problem.
+type CycleCutset struct {
+	Problem *Problem
+	Cutset  []string
+	Nodes   int
+
+	neighbours map[string]map[string]bool
+	watchers   map[string][]Constraint
+}
+
+// CycleCutset constructor, which also picks the cutset
+func NewCycleCutset(problem *Problem) *CycleCutset {
+	cc := &CycleCutset{Problem: problem}
+	cc.neighbours = make(map[string]map[string]bool)
+	cc.watchers = make(map[string][]Constraint)
+	for _, variable := range problem.Variables {
+		cc.neighbours[variable] = make(map[string]bool)
+	}
+	for _, constraint := range problem.Constraints {
+		for _, a := range constraint.Scope() {
+			cc.watchers[a] = append(cc.watchers[a], constraint)
+			for _, b := range constraint.Scope() {
+				if a != b {
+					cc.neighbours[a][b] = true
+				}
+			}
+		}
+	}
+	cc.Cutset = cc.findCutset()
+	return cc
+}
+
+// Greedy cutset: strip away everything of degree one or less (it can't be on a cycle), then move the highest
+// degree variable left into the cutset, and repeat until nothing is left. Since a constraint over three or more
+// free variables would form a triangle, every constraint ends up with at most two variables outside the cutset.
+func (cc *CycleCutset) findCutset() []string {
+	remaining := make(map[string]bool)
+	for _, variable := range cc.Problem.Variables {
+		remaining[variable] = true
+	}
+	degree := func(variable string) int {
+		count := 0
+		for neighbour := range cc.neighbours[variable] {
+			if remaining[neighbour] {
+				count++
+			}
+		}
+		return count
+	}
+
+	var cutset []string
+	for {
+		for stripped := true; stripped; {
+			stripped = false
+			for _, variable := range cc.Problem.Variables {
+				if remaining[variable] && degree(variable) <= 1 {
+					delete(remaining, variable)
+					stripped = true
+				}
+			}
+		}
+		if len(remaining) == 0 {
+			break
+		}
+		best := ""
+		for _, variable := range cc.Problem.Variables {
+			if remaining[variable] && (best == "" || degree(variable) > degree(best)) {
+				best = variable
+			}
+		}
+		delete(remaining, best)
+		cutset = append(cutset, best)
+	}
+	return cutset
+}
+
+// Returns up to max solutions (0 for all)
+func (cc *CycleCutset) Solve(max int) ([]map[string]int, error) {
+	if cc.Problem.Optimizing() {
+		return nil, fmt.Errorf("cycle-cutset conditioning only handles satisfaction problems")
+	}
+	cc.Nodes = 0
+	var solutions []map[string]int
+	cc.searchCutset(0, make(map[string]int), func(solution map[string]int) bool {
+		solutions = append(solutions, solution)
+		return max == 0 || len(solutions) < max
+	})
+	return solutions, nil
+}
+
+func (cc *CycleCutset) searchCutset(depth int, assignment map[string]int, found func(map[string]int) bool) bool {
+	if depth == len(cc.Cutset) {
+		return cc.solveForest(assignment, found)
+	}
+	variable := cc.Cutset[depth]
+	for _, value := range cc.Problem.Domains[variable] {
+		cc.Nodes++
+		assignment[variable] = value
+		if cc.consistent(variable, assignment) && !cc.searchCutset(depth+1, assignment, found) {
+			delete(assignment, variable)
+			return false
+		}
+		delete(assignment, variable)
+	}
+	return true
+}
+
+func (cc *CycleCutset) consistent(variable string, assignment map[string]int) bool {
+	for _, constraint := range cc.watchers[variable] {
+		if !constraint.Satisfied(assignment) {
+			return false
+		}
+	}
+	return true
+}
+
+// Solves what's left once the cutset is fixed. Every remaining constraint involves one or two free variables, so
+// checking a value (or a pair of values) against the cutset assignment settles it completely.
+func (cc *CycleCutset) solveForest(assignment map[string]int, found func(map[string]int) bool) bool {
+	inCutset := make(map[string]bool)
+	for _, variable := range cc.Cutset {
+		inCutset[variable] = true
+	}
+
+	domains := make(map[string][]int)
+	for _, variable := range cc.Problem.Variables {
+		if inCutset[variable] {
+			continue
+		}
+		for _, value := range cc.Problem.Domains[variable] {
+			assignment[variable] = value
+			if cc.consistent(variable, assignment) {
+				domains[variable] = append(domains[variable], value)
+			}
+		}
+		delete(assignment, variable)
+		if len(domains[variable]) == 0 {
+			return true
+		}
+	}
+
+	// breadth first from a root per tree, so parents always come before their children
+	var order []string
+	parent := make(map[string]string)
+	visited := make(map[string]bool)
+	for _, root := range cc.Problem.Variables {
+		if inCutset[root] || visited[root] {
+			continue
+		}
+		visited[root] = true
+		queue := []string{root}
+		for len(queue) > 0 {
+			variable := queue[0]
+			queue = queue[1:]
+			order = append(order, variable)
+			for _, neighbour := range cc.Problem.Variables {
+				if cc.neighbours[variable][neighbour] && !inCutset[neighbour] && !visited[neighbour] {
+					visited[neighbour] = true
+					parent[neighbour] = variable
+					queue = append(queue, neighbour)
+				}
+			}
+		}
+	}
+
+	// directional arc consistency, children first: every parent value left has a support in each child
+	for i := len(order) - 1; i >= 0; i-- {
+		child := order[i]
+		up, hasParent := parent[child]
+		if !hasParent {
+			continue
+		}
+		var supported []int
+		for _, parentValue := range domains[up] {
+			assignment[up] = parentValue
+			for _, childValue := range domains[child] {
+				cc.Nodes++
+				assignment[child] = childValue
+				if cc.consistent(child, assignment) {
+					supported = append(supported, parentValue)
+					break
+				}
+			}
+			delete(assignment, child)
+		}
+		delete(assignment, up)
+		if len(supported) == 0 {
+			return true
+		}
+		domains[up] = supported
+	}
+
+	// roots down, which can't hit a dead end any more
+	var assign func(position int) bool
+	assign = func(position int) bool {
+		if position == len(order) {
+			solution := make(map[string]int, len(assignment))
+			for variable, value := range assignment {
+				solution[variable] = value
+			}
+			return found(solution)
+		}
+		variable := order[position]
+		for _, value := range domains[variable] {
+			assignment[variable] = value
+			if cc.consistent(variable, assignment) && !assign(position+1) {
+				delete(assignment, variable)
+				return false
+			}
+		}
+		delete(assignment, variable)
+		return true
+	}
+	return assign(0)
+}
@@ -0,0 +1,181 @@
+package csp
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Splits the problem along the connected components of its constraint graph (soft constraints count as edges too).
+// Variables that share no constraint, even indirectly, end up in different subproblems which can be solved on
+// their own. Variable order within each component follows the original order.
+func (problem *Problem) Components() []*Problem {
+	parent := make(map[string]string)
+	var find func(variable string) string
+	find = func(variable string) string {
+		if parent[variable] != variable {
+			parent[variable] = find(parent[variable])
+		}
+		return parent[variable]
+	}
+	for _, variable := range problem.Variables {
+		parent[variable] = variable
+	}
+	join := func(scope []string) {
+		for _, variable := range scope[1:] {
+			parent[find(variable)] = find(scope[0])
+		}
+	}
+	for _, constraint := range problem.Constraints {
+		if len(constraint.Scope()) > 0 {
+			join(constraint.Scope())
+		}
+	}
+	for _, soft := range problem.SoftConstraints {
+		if len(soft.Constraint.Scope()) > 0 {
+			join(soft.Constraint.Scope())
+		}
+	}
+
+	index := make(map[string]int)
+	var components []*Problem
+	for _, variable := range problem.Variables {
+		root := find(variable)
+		if _, exists := index[root]; !exists {
+			index[root] = len(components)
+			components = append(components, NewProblem())
+			components[len(components)-1].DefaultConsistency = problem.DefaultConsistency
+			components[len(components)-1].Consistency = problem.Consistency
+		}
+		component := components[index[root]]
+		component.AddVariable(variable, problem.Domains[variable])
+		if labels, categorical := problem.Labels[variable]; categorical {
+			component.Labels[variable] = labels
+		}
+		if variable == problem.Objective {
+			component.Objective = problem.Objective
+			component.Maximizing = problem.Maximizing
+		}
+	}
+	for _, constraint := range problem.Constraints {
+		if len(constraint.Scope()) > 0 {
+			components[index[find(constraint.Scope()[0])]].AddConstraint(constraint)
+		}
+	}
+	for _, soft := range problem.SoftConstraints {
+		if len(soft.Constraint.Scope()) > 0 {
+			components[index[find(soft.Constraint.Scope()[0])]].AddSoftConstraint(soft.Constraint, soft.Weight)
+		}
+	}
+	return components
+}
+
+// Solves every component separately, in parallel if asked to, and stitches the results back together. Every
+// combination of component solutions is a solution of the whole problem, so they're combined as a cross product,
+// stopping at MaxSolutions. In optimization mode the objective is a single variable and every soft constraint
+// joins the variables it's on, so each lies within one component and the total cost is the sum of the
+// components' costs: the optimum is the union of each component's optimum, with any solution at all of the
+// components that have nothing to optimize. That union is the one incumbent the decomposed solve reports. Like the
+// core-guided steps, the component solvers listen for this solver's Interrupt.
+func (solver *Solver) solveComponents(components []*Problem) []map[string]int {
+	optimizing := solver.Problem.Optimizing()
+	solvers := make([]*Solver, len(components))
+	for i, component := range components {
+		componentSolver := solver.derived(component)
+		componentSolver.MaxSolutions = solver.MaxSolutions
+		if optimizing {
+			// a gap on each component doesn't add up to the gap of the whole, so each gets solved to optimality
+			componentSolver.MaxGap = 0
+			if !component.Optimizing() {
+				componentSolver.MaxSolutions = 1
+			}
+		}
+		componentSolver.TraceContext = solver.traceContext
+		onNode := solver.OnNode
+		componentSolver.OnNode = func(event SearchEvent) {
+			if atomic.LoadInt32(&solver.interrupted) != 0 {
+				componentSolver.Interrupt()
+			}
+			if onNode != nil {
+				onNode(event)
+			}
+		}
+		solvers[i] = componentSolver
+	}
+
+	if solver.Parallel {
+		var wait sync.WaitGroup
+		for _, componentSolver := range solvers {
+			wait.Add(1)
+			go func(componentSolver *Solver) {
+				defer wait.Done()
+				componentSolver.Solve()
+			}(componentSolver)
+		}
+		wait.Wait()
+	} else {
+		for _, componentSolver := range solvers {
+			componentSolver.Solve()
+		}
+	}
+
+	var parts [][]map[string]int
+	for _, componentSolver := range solvers {
+		solver.Nodes += componentSolver.Nodes
+		solver.Failures += componentSolver.Failures
+		solver.Propagations += componentSolver.Propagations
+		solver.stopped = solver.stopped || componentSolver.Status == Unknown
+		if componentSolver.Problem.Optimizing() {
+			if componentSolver.Best == nil {
+				return nil
+			}
+			parts = append(parts, []map[string]int{componentSolver.Best})
+		} else {
+			if len(componentSolver.Solutions) == 0 {
+				return nil
+			}
+			parts = append(parts, componentSolver.Solutions)
+		}
+	}
+	if optimizing {
+		best := make(map[string]int, len(solver.Problem.Variables))
+		for i, componentSolver := range solvers {
+			for variable, value := range parts[i][0] {
+				best[variable] = value
+			}
+			solver.BestCost += componentSolver.BestCost
+		}
+		solver.Best = best
+		solver.BestObjective = best[solver.Problem.Objective]
+		solver.Solutions = []map[string]int{best}
+		solver.publish()
+		if !solver.stopped {
+			// every component was solved to optimality, which closes the gap
+			solver.reportBound()
+		}
+		return solver.Solutions
+	}
+
+	var solutions []map[string]int
+	var combine func(part int, partial map[string]int) bool
+	combine = func(part int, partial map[string]int) bool {
+		if part == len(parts) {
+			solution := make(map[string]int, len(partial))
+			for variable, value := range partial {
+				solution[variable] = value
+			}
+			solutions = append(solutions, solution)
+			return solver.MaxSolutions == 0 || len(solutions) < solver.MaxSolutions
+		}
+		for _, componentSolution := range parts[part] {
+			for variable, value := range componentSolution {
+				partial[variable] = value
+			}
+			if !combine(part+1, partial) {
+				return false
+			}
+		}
+		return true
+	}
+	combine(0, make(map[string]int))
+	return solutions
+}
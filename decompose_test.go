@@ -0,0 +1,60 @@
+package csp
+
+import "testing"
+
+// Two components: x and y with a soft preference and an objective, p and q with three solutions and nothing to
+// optimize
+func splitOptimization() *Problem {
+	problem := NewProblem()
+	problem.AddVariableRange("x", 0, 3)
+	problem.AddVariableRange("y", 0, 3)
+	problem.AddVariableRange("p", 0, 2)
+	problem.AddVariableRange("q", 0, 2)
+	problem.AddConstraint(MustConstraint("x != y"))
+	problem.AddSoftConstraint(MustConstraint("x + y == 5"), 2)
+	problem.AddSoftConstraint(MustConstraint("x == 0"), 1)
+	problem.AddConstraint(MustConstraint("p < q"))
+	problem.Maximize("x")
+	return problem
+}
+
+func TestDecomposedOptimization(t *testing.T) {
+	whole := NewSolver(splitOptimization())
+	whole.Solve()
+	for _, parallel := range []bool{false, true} {
+		split := NewSolver(splitOptimization(), WithParallelism(parallel))
+		solutions := split.Solve()
+		if len(solutions) != 1 {
+			t.Errorf("parallel %v: %d solutions, want the one incumbent", parallel, len(solutions))
+		}
+		if split.Status != whole.Status || split.BestCost != whole.BestCost ||
+			split.BestObjective != whole.BestObjective {
+			t.Errorf("parallel %v: status %v, cost %d, objective %d; without decomposing %v, %d, %d", parallel,
+				split.Status, split.BestCost, split.BestObjective, whole.Status, whole.BestCost, whole.BestObjective)
+		}
+		if cost := split.Problem.Cost(split.Best); cost != split.BestCost || !satisfiesAll(split.Problem, split.Best) {
+			t.Errorf("parallel %v: incumbent %v breaks a constraint or costs %d, not %d", parallel, split.Best, cost,
+				split.BestCost)
+		}
+	}
+}
+
+func TestDecomposedInterrupt(t *testing.T) {
+	problem := wideProblem()
+	problem.AddConstraint(MustConstraint("a != b"))
+	solver := NewSolver(problem, WithParallelism(false))
+	solver.Interrupt()
+	solver.Solve()
+	if solver.Status != Unknown {
+		t.Fatalf("interrupted decomposed solve ended %v", solver.Status)
+	}
+}
+
+func satisfiesAll(problem *Problem, assignment map[string]int) bool {
+	for _, constraint := range problem.Constraints {
+		if !constraint.Satisfied(assignment) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package csp
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// How two searches of the same problem compare, A and B being two solver configurations, usually two orderings
+type SearchDiff struct {
+	Labels           [2]string
+	Trees            [2]*SearchTree
+	NodesPerDepth    [2][]int
+	FailuresPerDepth [2][]int
+	FirstFailure     [2]int // depth of the first failed node, -1 when nothing failed
+	Explored         [2]int // distinct partial assignments explored, order of assignment aside
+	Shared           int    // partial assignments both explored
+	Common           int    // nodes both searches visited in the same order before going their own ways
+	DivergedAt       [2]string
+}
+
+// Records both searches and compares them. Without propagation every failure gets a depth and a culprit, so it's
+// the clearer comparison unless propagation is what's being compared.
+func DiffSearches(labels [2]string, solvers [2]*Solver) *SearchDiff {
+	diff := &SearchDiff{Labels: labels}
+	var sequences [2][]string
+	var prefixes [2]map[string]bool
+	for side, solver := range solvers {
+		tree := RecordSearch(solver)
+		diff.Trees[side] = tree
+		diff.FirstFailure[side] = -1
+		prefixes[side] = make(map[string]bool)
+		var walk func(node *SearchTreeNode, depth int, path []string)
+		walk = func(node *SearchTreeNode, depth int, path []string) {
+			for _, child := range node.Children {
+				step := child.Variable + "=" + solver.Problem.FormatValue(child.Variable, child.Value)
+				childPath := append(path[:len(path):len(path)], step)
+				sequences[side] = append(sequences[side], strings.Join(childPath, " "))
+				prefixes[side][prefixKey(childPath)] = true
+				for len(diff.NodesPerDepth[side]) <= depth {
+					diff.NodesPerDepth[side] = append(diff.NodesPerDepth[side], 0)
+					diff.FailuresPerDepth[side] = append(diff.FailuresPerDepth[side], 0)
+				}
+				diff.NodesPerDepth[side][depth]++
+				if child.Outcome != NodeExtended {
+					diff.FailuresPerDepth[side][depth]++
+					if diff.FirstFailure[side] < 0 {
+						diff.FirstFailure[side] = depth
+					}
+				}
+				walk(child, depth+1, childPath)
+			}
+		}
+		walk(tree.Root, 0, nil)
+		diff.Explored[side] = len(prefixes[side])
+	}
+
+	for prefix := range prefixes[0] {
+		if prefixes[1][prefix] {
+			diff.Shared++
+		}
+	}
+	for diff.Common < len(sequences[0]) && diff.Common < len(sequences[1]) &&
+		sequences[0][diff.Common] == sequences[1][diff.Common] {
+		diff.Common++
+	}
+	for side := range sequences {
+		if diff.Common < len(sequences[side]) {
+			diff.DivergedAt[side] = sequences[side][diff.Common]
+		}
+	}
+	return diff
+}
+
+// The same partial assignment reached in a different order is the same prefix
+func prefixKey(path []string) string {
+	sorted := append([]string(nil), path...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, " ")
+}
+
+// A side by side table of nodes and failures per depth, then where the searches part ways and how much of what
+// they explored they have in common
+func (diff *SearchDiff) Print(w io.Writer) {
+	fmt.Fprintf(w, "%-7s %22s %22s\n", "", diff.Labels[0], diff.Labels[1])
+	fmt.Fprintf(w, "%-7s %11s %10s %11s %10s\n", "depth", "nodes", "failures", "nodes", "failures")
+	depths := len(diff.NodesPerDepth[0])
+	if len(diff.NodesPerDepth[1]) > depths {
+		depths = len(diff.NodesPerDepth[1])
+	}
+	var totals [2][2]int
+	for depth := 0; depth < depths; depth++ {
+		fmt.Fprintf(w, "%-7d", depth)
+		for side := range diff.NodesPerDepth {
+			nodes, failures := 0, 0
+			if depth < len(diff.NodesPerDepth[side]) {
+				nodes, failures = diff.NodesPerDepth[side][depth], diff.FailuresPerDepth[side][depth]
+			}
+			totals[side][0] += nodes
+			totals[side][1] += failures
+			fmt.Fprintf(w, " %11d %10d", nodes, failures)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "%-7s %11d %10d %11d %10d\n", "total", totals[0][0], totals[0][1], totals[1][0], totals[1][1])
+	fmt.Fprintln(w)
+
+	for side, label := range diff.Labels {
+		fmt.Fprintf(w, "%s: %d solutions, first failure ", label, diff.Trees[side].Root.Solutions)
+		if diff.FirstFailure[side] < 0 {
+			fmt.Fprintln(w, "never")
+		} else {
+			fmt.Fprintf(w, "at depth %d\n", diff.FirstFailure[side])
+		}
+		if diff.Trees[side].Truncated {
+			fmt.Fprintf(w, "%s: stopped recording after %d nodes, the rest is left out\n", label, maxRecordedNodes)
+		}
+	}
+	switch {
+	case diff.DivergedAt[0] == "" && diff.DivergedAt[1] == "":
+		fmt.Fprintln(w, "The searches are identical")
+	default:
+		fmt.Fprintf(w, "The searches agree on the first %d nodes, then\n", diff.Common)
+		for side, label := range diff.Labels {
+			next := diff.DivergedAt[side]
+			if next == "" {
+				next = "(done)"
+			}
+			fmt.Fprintf(w, "  %s goes to %s\n", label, next)
+		}
+	}
+	union := diff.Explored[0] + diff.Explored[1] - diff.Shared
+	if union > 0 {
+		fmt.Fprintf(w, "Partial assignments explored: %d by %s, %d by %s, %d by both (%.0f%% overlap)\n",
+			diff.Explored[0], diff.Labels[0], diff.Explored[1], diff.Labels[1], diff.Shared,
+			100*float64(diff.Shared)/float64(union))
+	}
+}
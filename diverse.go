@@ -0,0 +1,31 @@
+package csp
+
+// Up to k solutions, every two of them differing in at least minDistance variables, for presenting a human with
+// real alternatives rather than near copies. Found greedily: each new solution must be far enough from all the
+// previous ones, so fewer than k come back when the search runs out. Objectives and soft constraints are ignored,
+// every solution only has to satisfy the hard constraints. The solver's own settings (propagation, preprocessing,
+// limits) carry over, and its Solutions and statistics are left alone.
+func (solver *Solver) DiverseSolutions(k int, minDistance int) []map[string]int {
+	var solutions []map[string]int
+	for len(solutions) < k {
+		problem := &Problem{
+			Variables:          solver.Problem.Variables,
+			Domains:            solver.Problem.Domains,
+			Constraints:        append([]Constraint(nil), solver.Problem.Constraints...),
+			Labels:             solver.Problem.Labels,
+			DefaultConsistency: solver.Problem.DefaultConsistency,
+			Consistency:        solver.Problem.Consistency,
+		}
+		for _, previous := range solutions {
+			problem.AddConstraint(NewHammingDistance(problem.Variables, previous, minDistance))
+		}
+
+		search := solver.derived(problem)
+		search.MaxSolutions = 1
+		if len(search.Solve()) == 0 {
+			break
+		}
+		solutions = append(solutions, search.Solutions[0])
+	}
+	return solutions
+}
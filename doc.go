@@ -0,0 +1,7 @@
+// Package csp models and solves finite domain constraint satisfaction and optimization problems. Build a Problem
+// from variables and constraints, then solve it with the one-call helpers SolveOne, AllSolutions and
+// CountSolutions, many at once with SolveAll, or with a Solver configured through SolverOptions. Third-party
+// constraints plug in through RegisterConstraint and RegisterPropagator. Ready made models of classic puzzles are
+// in the models package and random benchmark instances come from the gen package; the csp command in cmd/csp is
+// the command line frontend to all of this.
+package csp
@@ -0,0 +1,88 @@
+package csp
+
+import "sort"
+
+// A variable's values, kept sorted and without repeats. Functions added with OnChange are called with the values
+// that went every time the domain shrinks, so whatever was built from it, such as a layer of the legacy tree, can
+// follow along.
+type Domain struct {
+	values    []int
+	listeners []func(removed []int)
+}
+
+// Domain constructor, values in any order
+func NewDomain(values ...int) *Domain {
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	domain := &Domain{}
+	for i, value := range sorted {
+		if i == 0 || value != sorted[i-1] {
+			domain.values = append(domain.values, value)
+		}
+	}
+	return domain
+}
+
+// low..high inclusive, empty when high < low
+func NewDomainRange(low int, high int) *Domain {
+	domain := &Domain{}
+	for value := low; value <= high; value++ {
+		domain.values = append(domain.values, value)
+	}
+	return domain
+}
+
+// The values smallest first. They must not be modified.
+func (domain *Domain) Values() []int {
+	return domain.values
+}
+
+func (domain *Domain) Size() int {
+	return len(domain.values)
+}
+
+func (domain *Domain) Contains(value int) bool {
+	i := sort.SearchInts(domain.values, value)
+	return i < len(domain.values) && domain.values[i] == value
+}
+
+// The smallest and largest values, ok being false for an empty domain
+func (domain *Domain) Bounds() (low int, high int, ok bool) {
+	if len(domain.values) == 0 {
+		return 0, 0, false
+	}
+	return domain.values[0], domain.values[len(domain.values)-1], true
+}
+
+// Reports whether the value was there to remove
+func (domain *Domain) RemoveValue(value int) bool {
+	return domain.Restrict(func(other int) bool { return other != value }) > 0
+}
+
+// Keeps only the values keep returns true for, returning how many went
+func (domain *Domain) Restrict(keep func(value int) bool) int {
+	var kept, removed []int
+	for _, value := range domain.values {
+		if keep(value) {
+			kept = append(kept, value)
+		} else {
+			removed = append(removed, value)
+		}
+	}
+	if len(removed) == 0 {
+		return 0
+	}
+	domain.values = kept
+	for _, listener := range domain.listeners {
+		listener(removed)
+	}
+	return len(removed)
+}
+
+// Calls listener with the values that went whenever the domain shrinks
+func (domain *Domain) OnChange(listener func(removed []int)) {
+	domain.listeners = append(domain.listeners, listener)
+}
+
+// The values every variable of the classic A-H puzzle, and so every layer of the legacy tree, can take
+var ClassicDomain = NewDomainRange(1, 4)
@@ -0,0 +1,137 @@
+package csp
+
+import "fmt"
+
+// A binary reformulation of a problem, for algorithms that only deal with constraints between two variables.
+// Problem is the reformulated problem; Decode turns its solutions back into solutions of the original.
+type BinaryEncoding struct {
+	Problem *Problem
+
+	original *Problem
+	tuples   map[string]encodedConstraint
+}
+
+// The constraint a new variable stands for: its value i means the scope takes Tuples[i-1]
+type encodedConstraint struct {
+	scope  []string
+	tuples [][]int
+}
+
+// Hidden variable encoding: every constraint over more than two variables gets a new variable whose values are
+// the tuples it allows, tied to each variable of its scope by a binary constraint saying that variable takes its
+// part of the chosen tuple. The original variables, the other constraints and the objective stay as they are;
+// soft constraints are left out. Fails if a constraint's scope is too big to list its tuples.
+func HiddenVariableEncoding(problem *Problem) (*BinaryEncoding, error) {
+	encoding := newBinaryEncoding(problem)
+	encoding.Problem.Objective = problem.Objective
+	encoding.Problem.Maximizing = problem.Maximizing
+	for _, variable := range problem.Variables {
+		encoding.Problem.AddVariable(variable, problem.Domains[variable])
+	}
+	for i, constraint := range problem.Constraints {
+		if len(uniqueScope(constraint.Scope())) <= 2 {
+			encoding.Problem.AddConstraint(constraint)
+			continue
+		}
+		hidden, encoded, err := encoding.encode(fmt.Sprintf("hidden%d", i+1), constraint)
+		if err != nil {
+			return nil, err
+		}
+		for position, variable := range encoded.scope {
+			var pairs [][]int
+			for t, tuple := range encoded.tuples {
+				pairs = append(pairs, []int{t + 1, tuple[position]})
+			}
+			encoding.Problem.AddConstraint(NewTable([]string{hidden, variable}, pairs))
+		}
+	}
+	return encoding, nil
+}
+
+// Dual encoding: every constraint becomes a variable whose values are the tuples it allows, and every two
+// constraints sharing variables get a binary constraint only allowing tuples that agree on them. Variables no
+// constraint mentions are kept as they are. Only the hard constraints are encoded, with no objective.
+// Fails if a constraint's scope is too big to list its tuples.
+func DualEncoding(problem *Problem) (*BinaryEncoding, error) {
+	encoding := newBinaryEncoding(problem)
+	covered := make(map[string]bool)
+	var duals []string
+	for i, constraint := range problem.Constraints {
+		dual, encoded, err := encoding.encode(fmt.Sprintf("dual%d", i+1), constraint)
+		if err != nil {
+			return nil, err
+		}
+		duals = append(duals, dual)
+		for _, variable := range encoded.scope {
+			covered[variable] = true
+		}
+	}
+	for _, variable := range problem.Variables {
+		if !covered[variable] {
+			encoding.Problem.AddVariable(variable, problem.Domains[variable])
+		}
+	}
+
+	for i, first := range duals {
+		for _, second := range duals[i+1:] {
+			a, b := encoding.tuples[first], encoding.tuples[second]
+			var shared [][2]int
+			for p, variable := range a.scope {
+				for q, other := range b.scope {
+					if variable == other {
+						shared = append(shared, [2]int{p, q})
+					}
+				}
+			}
+			if len(shared) == 0 {
+				continue
+			}
+			var pairs [][]int
+			for s, left := range a.tuples {
+				for t, right := range b.tuples {
+					agree := true
+					for _, positions := range shared {
+						agree = agree && left[positions[0]] == right[positions[1]]
+					}
+					if agree {
+						pairs = append(pairs, []int{s + 1, t + 1})
+					}
+				}
+			}
+			encoding.Problem.AddConstraint(NewTable([]string{first, second}, pairs))
+		}
+	}
+	return encoding, nil
+}
+
+func newBinaryEncoding(problem *Problem) *BinaryEncoding {
+	return &BinaryEncoding{NewProblem(), problem, make(map[string]encodedConstraint)}
+}
+
+// Adds the variable standing for the constraint's allowed tuples
+func (encoding *BinaryEncoding) encode(name string, constraint Constraint) (string, encodedConstraint, error) {
+	scope, tuples, ok := AllowedTuples(constraint, encoding.original.Domains)
+	if !ok {
+		return "", encodedConstraint{}, fmt.Errorf("constraint over %v is too large to encode", constraint.Scope())
+	}
+	encoded := encodedConstraint{scope, tuples}
+	encoding.tuples[name] = encoded
+	encoding.Problem.AddVariableRange(name, 1, len(tuples))
+	return name, encoded, nil
+}
+
+// The original problem's solution behind a solution of the encoding
+func (encoding *BinaryEncoding) Decode(solution map[string]int) map[string]int {
+	decoded := make(map[string]int)
+	for variable, value := range solution {
+		encoded, isNew := encoding.tuples[variable]
+		if !isNew {
+			decoded[variable] = value
+			continue
+		}
+		for position, original := range encoded.scope {
+			decoded[original] = encoded.tuples[value-1][position]
+		}
+	}
+	return decoded
+}
@@ -0,0 +1,75 @@
+package csp_test
+
+import (
+	"context"
+	"fmt"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// x < y over 1..3
+func ordered() *csp.Problem {
+	problem := csp.NewProblem()
+	problem.AddVariableRange("x", 1, 3)
+	problem.AddVariableRange("y", 1, 3)
+	problem.AddConstraint(csp.MustConstraint("x < y"))
+	return problem
+}
+
+func ExampleSolveOne() {
+	solution, found := csp.SolveOne(ordered())
+	fmt.Println(solution, found)
+	// Output: map[x:1 y:2] true
+}
+
+func ExampleAllSolutions() {
+	for _, solution := range csp.AllSolutions(ordered()) {
+		fmt.Println(solution)
+	}
+	// Output:
+	// map[x:1 y:2]
+	// map[x:1 y:3]
+	// map[x:2 y:3]
+}
+
+func ExampleCountSolutions() {
+	fmt.Println(csp.CountSolutions(ordered()))
+	// Output: 3
+}
+
+func ExampleSolveAll() {
+	unsatisfiable := ordered()
+	unsatisfiable.AddConstraint(csp.MustConstraint("y < x"))
+	results, _ := csp.SolveAll(context.Background(), []*csp.Problem{ordered(), unsatisfiable}, 2)
+	for _, result := range results {
+		fmt.Println(result.Status)
+	}
+	// Output:
+	// satisfiable
+	// unsatisfiable
+}
+
+// Prunes the even values of a variable, standing in for a domain specific propagator from another package
+type oddPropagator struct{ variable string }
+
+func (odd oddPropagator) Propagate(store *csp.DomainStore) bool {
+	return store.Restrict(odd.variable, func(value int) bool { return value%2 == 1 })
+}
+
+func (oddPropagator) Subscriptions() csp.DomainEvent { return csp.ValueRemoved }
+
+func (oddPropagator) Priority() int { return 0 }
+
+func ExampleRegisterPropagator() {
+	odd := csp.NewPredicate(func(values []int) bool { return values[0]%2 == 1 }, "y")
+	csp.RegisterPropagator("example-odd", func(constraint csp.Constraint) csp.Propagator {
+		if constraint == odd {
+			return oddPropagator{"y"}
+		}
+		return nil
+	})
+	problem := ordered()
+	problem.AddConstraint(odd)
+	fmt.Println(csp.AllSolutions(problem))
+	// Output: [map[x:1 y:3] map[x:2 y:3]]
+}
@@ -0,0 +1,229 @@
+package csp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Nodes RecordSearch keeps before it stops growing the tree
+const maxRecordedNodes = 100000
+
+// The search tree of one solve, as recorded by RecordSearch. The root stands for the empty assignment.
+type SearchTree struct {
+	Root      *SearchTreeNode
+	Nodes     int
+	Truncated bool // the search went on past maxRecordedNodes
+}
+
+type SearchTreeNode struct {
+	Variable  string
+	Value     int
+	Outcome   NodeOutcome
+	Violated  Constraint
+	Solution  bool // every variable is assigned here and nothing broke
+	Parent    *SearchTreeNode
+	Children  []*SearchTreeNode
+	Size      int // nodes in the subtree, this one included
+	Solutions int // solutions in the subtree
+}
+
+// Solves with the solver's settings while recording every node it visits. The solver's OnNode is taken over for
+// the duration, and its MaxNodes lowered to just past maxRecordedNodes, there being no point going on unrecorded.
+func RecordSearch(solver *Solver) *SearchTree {
+	tree := &SearchTree{Root: &SearchTreeNode{Outcome: NodeExtended}}
+	stack := []*SearchTreeNode{tree.Root}
+	previous, maxNodes := solver.OnNode, solver.MaxNodes
+	defer func() { solver.OnNode, solver.MaxNodes = previous, maxNodes }()
+	if maxNodes == 0 || maxNodes > maxRecordedNodes {
+		solver.MaxNodes = maxRecordedNodes + 1
+	}
+	solver.OnNode = func(event SearchEvent) {
+		if event.Outcome == NodeSolution {
+			// past the cap the stack no longer follows the search
+			if !tree.Truncated {
+				stack[event.Depth].Solution = true
+			}
+			return
+		}
+		if tree.Nodes == maxRecordedNodes {
+			tree.Truncated = true
+			return
+		}
+		tree.Nodes++
+		parent := stack[event.Depth]
+		node := &SearchTreeNode{event.Variable, event.Value, event.Outcome, event.Violated, false, parent, nil, 0, 0}
+		parent.Children = append(parent.Children, node)
+		if event.Outcome == NodeExtended {
+			stack = append(stack[:event.Depth+1], node)
+		}
+	}
+	solver.Solve()
+	tree.Root.count()
+	return tree
+}
+
+func (node *SearchTreeNode) count() {
+	node.Size = 1
+	node.Solutions = 0
+	if node.Solution {
+		node.Solutions = 1
+	}
+	for _, child := range node.Children {
+		child.count()
+		node.Size += child.Size
+		node.Solutions += child.Solutions
+	}
+}
+
+// The assignment from the root down to the node
+func (node *SearchTreeNode) Assignment() map[string]int {
+	assignment := make(map[string]int)
+	for current := node; current.Parent != nil; current = current.Parent {
+		assignment[current.Variable] = current.Value
+	}
+	return assignment
+}
+
+const exploreHelp = `Commands:
+  ls                 children of the current node
+  cd N, cd .., cd /  move to child N, the parent, or the root
+  cd sN              move to solution N of the last solutions listing
+  tree [DEPTH]       the subtree below the current node, DEPTH levels deep (default 2)
+  why                what happened at the current node
+  path               the assignment leading to the current node
+  solutions          every solution node, to jump to with cd
+  help, quit`
+
+// Walks the recorded tree like a file system, a command per line from in
+func ExploreTree(problem *Problem, tree *SearchTree, in io.Reader, out io.Writer) {
+	fmt.Fprintf(out, "%d nodes, %d solutions", tree.Nodes, tree.Root.Solutions)
+	if tree.Truncated {
+		fmt.Fprintf(out, " (stopped recording after %d nodes)", maxRecordedNodes)
+	}
+	fmt.Fprintln(out, `. Type "help" for the commands.`)
+
+	current := tree.Root
+	var found []*SearchTreeNode
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(out, "%s> ", exploreLocation(problem, current))
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "quit", "exit":
+			return
+		case "help":
+			fmt.Fprintln(out, exploreHelp)
+		case "ls":
+			for i, child := range current.Children {
+				fmt.Fprintf(out, "[%d] %s\n", i+1, describeNode(problem, child))
+			}
+		case "cd":
+			if len(fields) != 2 {
+				fmt.Fprintln(out, "usage: cd N, cd sN, cd .. or cd /")
+				break
+			}
+			switch fields[1] {
+			case "..":
+				if current.Parent != nil {
+					current = current.Parent
+				}
+			case "/":
+				current = tree.Root
+			default:
+				// sN picks a node from the last solutions listing
+				targets := current.Children
+				if strings.HasPrefix(fields[1], "s") {
+					targets = found
+				}
+				n, err := strconv.Atoi(strings.TrimPrefix(fields[1], "s"))
+				if err != nil || n < 1 || n > len(targets) {
+					fmt.Fprintf(out, "no node %s here\n", fields[1])
+					break
+				}
+				current = targets[n-1]
+			}
+		case "tree":
+			depth := 2
+			if len(fields) == 2 {
+				if n, err := strconv.Atoi(fields[1]); err == nil && n > 0 {
+					depth = n
+				}
+			}
+			printSubtree(problem, current, "", depth, out)
+		case "why":
+			if current.Parent == nil {
+				fmt.Fprintln(out, "the root, before any assignment")
+				break
+			}
+			fmt.Fprintln(out, describeNode(problem, current))
+		case "path":
+			fmt.Fprintln(out, FormatSolution(problem, current.Assignment()))
+		case "solutions":
+			found = nil
+			var collect func(node *SearchTreeNode)
+			collect = func(node *SearchTreeNode) {
+				if node.Solution {
+					found = append(found, node)
+				}
+				for _, child := range node.Children {
+					collect(child)
+				}
+			}
+			collect(tree.Root)
+			for i, node := range found {
+				fmt.Fprintf(out, "[s%d] %s\n", i+1, FormatSolution(problem, node.Assignment()))
+			}
+		default:
+			fmt.Fprintf(out, "unknown command %q, try help\n", fields[0])
+		}
+	}
+}
+
+// "A=1 B=2", or "/" for the root
+func exploreLocation(problem *Problem, node *SearchTreeNode) string {
+	if node.Parent == nil {
+		return "/"
+	}
+	var steps []string
+	for current := node; current.Parent != nil; current = current.Parent {
+		steps = append([]string{current.Variable + "=" + problem.FormatValue(current.Variable, current.Value)}, steps...)
+	}
+	return strings.Join(steps, " ")
+}
+
+// "B=2  12 nodes, 1 solution" for a node that was extended, "B=3  ✗ constraint violated: A != B" for a failure
+func describeNode(problem *Problem, node *SearchTreeNode) string {
+	name := node.Variable + "=" + problem.FormatValue(node.Variable, node.Value)
+	switch {
+	case node.Solution:
+		return name + "  ✓ solution"
+	case node.Outcome == NodeExtended:
+		return fmt.Sprintf("%s  %d nodes, %d solutions", name, node.Size, node.Solutions)
+	case node.Violated != nil:
+		return fmt.Sprintf("%s  ✗ %s: %s", name, node.Outcome, describeConstraint(node.Violated))
+	}
+	return fmt.Sprintf("%s  ✗ %s", name, node.Outcome)
+}
+
+func printSubtree(problem *Problem, node *SearchTreeNode, indent string, depth int, out io.Writer) {
+	for _, child := range node.Children {
+		marker := " "
+		if len(child.Children) > 0 && depth == 1 {
+			marker = "+"
+		}
+		fmt.Fprintf(out, "%s%s %s\n", indent, marker, describeNode(problem, child))
+		if depth > 1 {
+			printSubtree(problem, child, indent+"  ", depth-1, out)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package csp
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Solutions in wide format for spreadsheets: a header row naming the variables in problem order, then a row per
+// solution. Categorical values are written as their labels, the rest as numbers; a variable a solution leaves
+// unassigned gets an empty cell.
+
+// Writes the solutions as tab separated values. Tabs and newlines in labels become spaces.
+func WriteSolutionsTSV(w io.Writer, problem *Problem, solutions []map[string]int) error {
+	buffered := bufio.NewWriter(w)
+	clean := strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+	for i, variable := range problem.Variables {
+		if i > 0 {
+			buffered.WriteByte('\t')
+		}
+		buffered.WriteString(clean.Replace(variable))
+	}
+	buffered.WriteByte('\n')
+	for _, solution := range solutions {
+		for i, variable := range problem.Variables {
+			if i > 0 {
+				buffered.WriteByte('\t')
+			}
+			if value, assigned := solution[variable]; assigned {
+				buffered.WriteString(clean.Replace(problem.FormatValue(variable, value)))
+			}
+		}
+		buffered.WriteByte('\n')
+	}
+	return buffered.Flush()
+}
+
+// Writes the solutions as an Excel workbook with one sheet, Solutions, the header row frozen. Numbers are number
+// cells, so they sort and sum as numbers, and labels are text cells.
+func WriteSolutionsXLSX(w io.Writer, problem *Problem, solutions []map[string]int) error {
+	archive := zip.NewWriter(w)
+	modified := time.Now()
+	create := func(name string) (io.Writer, error) {
+		return archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	}
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRelationships},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRelationships},
+	}
+	for _, part := range parts {
+		file, err := create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(file, xml.Header+part.content); err != nil {
+			return err
+		}
+	}
+	sheet, err := create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	if err := writeXLSXSheet(sheet, problem, solutions); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func writeXLSXSheet(w io.Writer, problem *Problem, solutions []map[string]int) error {
+	buffered := bufio.NewWriter(w)
+	buffered.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" ` +
+		`state="frozen"/></sheetView></sheetViews><sheetData>`)
+	text := func(reference string, value string) {
+		fmt.Fprintf(buffered, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, reference)
+		xml.EscapeText(buffered, []byte(value))
+		buffered.WriteString("</t></is></c>")
+	}
+	buffered.WriteString(`<row r="1">`)
+	for i, variable := range problem.Variables {
+		text(xlsxColumn(i)+"1", variable)
+	}
+	buffered.WriteString("</row>")
+	for row, solution := range solutions {
+		number := strconv.Itoa(row + 2)
+		fmt.Fprintf(buffered, `<row r="%s">`, number)
+		for i, variable := range problem.Variables {
+			value, assigned := solution[variable]
+			switch {
+			case !assigned:
+			case problem.Labels[variable] != nil:
+				text(xlsxColumn(i)+number, problem.FormatValue(variable, value))
+			default:
+				fmt.Fprintf(buffered, `<c r="%s%s"><v>%d</v></c>`, xlsxColumn(i), number, value)
+			}
+		}
+		buffered.WriteString("</row>")
+	}
+	buffered.WriteString("</sheetData></worksheet>")
+	return buffered.Flush()
+}
+
+// Spreadsheet column name for a 0 based index: A .. Z, AA, AB ...
+func xlsxColumn(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+const (
+	xlsxContentTypes = `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ` +
+		`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ` +
+		`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+	xlsxRelationships = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" ` +
+		`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" ` +
+		`Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbook = `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Solutions" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRelationships = `<Relationships ` +
+		`xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" ` +
+		`Target="worksheets/sheet1.xml"/></Relationships>`
+)
+
+// Writes the solutions to a file in the format its extension says, .tsv or .xlsx
+func ExportSolutions(filename string, problem *Problem, solutions []map[string]int) error {
+	var write func(io.Writer, *Problem, []map[string]int) error
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".tsv":
+		write = WriteSolutionsTSV
+	case ".xlsx":
+		write = WriteSolutionsXLSX
+	default:
+		return fmt.Errorf("can't export to %s, expected a .tsv or .xlsx file", filename)
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(file, problem, solutions); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,476 @@
+package csp
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+)
+
+// A constraint written as an infix expression over integer variables, like "|F - B| == 1" or
+// "A + 2*B <= C && A != 3". Arithmetic has +, -, *, / (truncating), %, unary minus, |x|, abs(x), min(x, y, ...)
+// and max(x, y, ...); comparisons (=, ==, !=, <, <=, >, >=) give 1 or 0, and so can be summed; !, && and || combine
+// them. The constraint holds when the whole expression is nonzero. Division by zero makes it fail. Names may
+// contain letters, digits, _ and dots, and must not start with a digit. Since | both opens and closes an absolute
+// value, nest absolute values with abs(...) instead.
+type Expression struct {
+	Source    string
+	Variables []string
+	root      *expressionNode
+}
+
+// Parses an expression constraint. Comparisons between two linear sides come back as a Linear (or a NotEqual for
+// plain "A != B") so the propagation engine and the exporters can deal with them; anything else is an Expression.
+func ParseConstraint(source string) (Constraint, error) {
+	tokens, err := tokenizeExpression(source)
+	if err != nil {
+		return nil, err
+	}
+	parser := &expressionParser{source: source, tokens: tokens}
+	root, err := parser.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	if constraint := linearConstraint(root); constraint != nil {
+		return constraint, nil
+	}
+	expression := &Expression{Source: source, root: root}
+	seen := make(map[string]bool)
+	root.walk(func(node *expressionNode) {
+		if node.operator == "variable" && !seen[node.variable] {
+			seen[node.variable] = true
+			expression.Variables = append(expression.Variables, node.variable)
+		}
+	})
+	return expression, nil
+}
+
+// ParseConstraint for expressions known to be right, like literals in code; panics if it doesn't parse
+func MustConstraint(source string) Constraint {
+	constraint, err := ParseConstraint(source)
+	if err != nil {
+		panic(err)
+	}
+	return constraint
+}
+
+func (constraint *Expression) Scope() []string {
+	return constraint.Variables
+}
+
+func (constraint *Expression) Satisfied(assignment map[string]int) bool {
+	for _, variable := range constraint.Variables {
+		if _, assigned := assignment[variable]; !assigned {
+			return true
+		}
+	}
+	value, ok := constraint.root.evaluate(assignment)
+	return ok && value != 0
+}
+
+func (constraint *Expression) String() string {
+	return constraint.Source
+}
+
+// operator is "number", "variable", a function name, or the operator symbol ("neg" for unary minus)
+type expressionNode struct {
+	operator  string
+	value     int
+	variable  string
+	arguments []*expressionNode
+}
+
+// The node's value, with ok false on division by zero
+func (node *expressionNode) evaluate(assignment map[string]int) (int, bool) {
+	switch node.operator {
+	case "number":
+		return node.value, true
+	case "variable":
+		return assignment[node.variable], true
+	}
+	values := make([]int, len(node.arguments))
+	for i, argument := range node.arguments {
+		var ok bool
+		// && and || only look at the right side when they have to
+		if i == 1 && (node.operator == "&&" && values[0] == 0 || node.operator == "||" && values[0] != 0) {
+			return truth(node.operator == "||"), true
+		}
+		if values[i], ok = argument.evaluate(assignment); !ok {
+			return 0, false
+		}
+	}
+
+	switch node.operator {
+	case "neg":
+		return -values[0], true
+	case "!":
+		return truth(values[0] == 0), true
+	case "abs":
+		return AbsoluteValue(values[0]), true
+	case "min", "max":
+		result := values[0]
+		for _, value := range values[1:] {
+			if node.operator == "min" && value < result || node.operator == "max" && value > result {
+				result = value
+			}
+		}
+		return result, true
+	case "+":
+		return values[0] + values[1], true
+	case "-":
+		return values[0] - values[1], true
+	case "*":
+		return values[0] * values[1], true
+	case "/", "%":
+		if values[1] == 0 {
+			return 0, false
+		}
+		if node.operator == "/" {
+			return values[0] / values[1], true
+		}
+		return values[0] % values[1], true
+	case "&&", "||":
+		return truth(values[1] != 0), true
+	}
+	return truth(Compare(values[0], node.operator, values[1])), true
+}
+
+func truth(condition bool) int {
+	if condition {
+		return 1
+	}
+	return 0
+}
+
+func (node *expressionNode) walk(visit func(*expressionNode)) {
+	visit(node)
+	for _, argument := range node.arguments {
+		argument.walk(visit)
+	}
+}
+
+// The node as sum of coefficient*variable plus a constant, if it is one. Variables are in order of appearance.
+func (node *expressionNode) linear() ([]string, map[string]int, int, bool) {
+	switch node.operator {
+	case "number":
+		return nil, nil, node.value, true
+	case "variable":
+		return []string{node.variable}, map[string]int{node.variable: 1}, 0, true
+	case "neg":
+		return node.scaled(node.arguments[0], -1)
+	case "*":
+		// one side has to be a plain number
+		for side := 0; side < 2; side++ {
+			if _, coefficients, factor, ok := node.arguments[side].linear(); ok && len(coefficients) == 0 {
+				return node.scaled(node.arguments[1-side], factor)
+			}
+		}
+		return nil, nil, 0, false
+	case "+", "-":
+		leftVariables, left, leftConstant, leftOk := node.arguments[0].linear()
+		sign := 1
+		if node.operator == "-" {
+			sign = -1
+		}
+		rightVariables, right, rightConstant, rightOk := node.scaled(node.arguments[1], sign)
+		if !leftOk || !rightOk {
+			return nil, nil, 0, false
+		}
+		variables := leftVariables
+		coefficients := make(map[string]int)
+		for variable, coefficient := range left {
+			coefficients[variable] = coefficient
+		}
+		for _, variable := range rightVariables {
+			if _, exists := coefficients[variable]; !exists {
+				variables = append(variables, variable)
+			}
+			coefficients[variable] += right[variable]
+		}
+		return variables, coefficients, leftConstant + rightConstant, true
+	}
+	return nil, nil, 0, false
+}
+
+func (node *expressionNode) scaled(argument *expressionNode, factor int) ([]string, map[string]int, int, bool) {
+	variables, coefficients, constant, ok := argument.linear()
+	if !ok {
+		return nil, nil, 0, false
+	}
+	scaled := make(map[string]int)
+	for variable, coefficient := range coefficients {
+		scaled[variable] = coefficient * factor
+	}
+	return variables, scaled, constant * factor, true
+}
+
+// A comparison of two linear sides as a Linear, moving everything but the constant to the left, or a NotEqual for
+// "A != B". nil when the expression isn't like that, or no variable is left.
+func linearConstraint(root *expressionNode) Constraint {
+	if !validOperator(root.operator) {
+		return nil
+	}
+	leftVariables, left, leftConstant, leftOk := root.arguments[0].linear()
+	rightVariables, right, rightConstant, rightOk := root.arguments[1].linear()
+	if !leftOk || !rightOk {
+		return nil
+	}
+	linear := NewLinear(nil, nil, root.operator, rightConstant-leftConstant)
+	coefficients := make(map[string]int)
+	for variable, coefficient := range left {
+		coefficients[variable] = coefficient
+	}
+	for variable, coefficient := range right {
+		coefficients[variable] -= coefficient
+	}
+	seen := make(map[string]bool)
+	for _, variable := range append(leftVariables, rightVariables...) {
+		if !seen[variable] && coefficients[variable] != 0 {
+			linear.Variables = append(linear.Variables, variable)
+			linear.Coefficients = append(linear.Coefficients, coefficients[variable])
+		}
+		seen[variable] = true
+	}
+	if len(linear.Variables) == 0 {
+		// everything cancelled out, like "A < A"; as an Expression it keeps its variables and still gets checked
+		return nil
+	}
+	if linear.Operator == "!=" && linear.Constant == 0 && len(linear.Variables) == 2 &&
+		linear.Coefficients[0] == 1 && linear.Coefficients[1] == -1 {
+		return NewNotEqual(linear.Variables[0], linear.Variables[1])
+	}
+	return linear
+}
+
+type expressionToken struct {
+	text     string
+	position int
+}
+
+func tokenizeExpression(source string) ([]expressionToken, error) {
+	var tokens []expressionToken
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && unicode.IsDigit(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, expressionToken{string(runes[start:i]), start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' ||
+				runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, expressionToken{string(runes[start:i]), start})
+		default:
+			if i+1 < len(runes) {
+				switch pair := string(runes[i : i+2]); pair {
+				case "==", "!=", "<=", ">=", "&&", "||":
+					tokens = append(tokens, expressionToken{pair, i})
+					i += 2
+					continue
+				}
+			}
+			switch r {
+			case '=', '<', '>', '!', '+', '-', '*', '/', '%', '(', ')', '|', ',':
+				tokens = append(tokens, expressionToken{string(r), i})
+				i++
+			default:
+				return nil, fmt.Errorf("%q: unexpected %q at position %d", source, r, i+1)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// Recursive descent, loosest binding first: ||, &&, !, comparison, + -, * / %, unary minus, atoms
+type expressionParser struct {
+	source   string
+	tokens   []expressionToken
+	position int
+}
+
+func (parser *expressionParser) parse() (*expressionNode, error) {
+	root, err := parser.or()
+	if err != nil {
+		return nil, err
+	}
+	if parser.position < len(parser.tokens) {
+		return nil, parser.errorf("unexpected %q", parser.tokens[parser.position].text)
+	}
+	return root, nil
+}
+
+func (parser *expressionParser) errorf(format string, args ...interface{}) error {
+	position := len([]rune(parser.source)) + 1
+	if parser.position < len(parser.tokens) {
+		position = parser.tokens[parser.position].position + 1
+	}
+	return fmt.Errorf("%q: %s at position %d", parser.source, fmt.Sprintf(format, args...), position)
+}
+
+func (parser *expressionParser) peek() string {
+	if parser.position < len(parser.tokens) {
+		return parser.tokens[parser.position].text
+	}
+	return ""
+}
+
+func (parser *expressionParser) accept(texts ...string) (string, bool) {
+	next := parser.peek()
+	for _, text := range texts {
+		if next == text {
+			parser.position++
+			return text, true
+		}
+	}
+	return "", false
+}
+
+func (parser *expressionParser) expect(text string) error {
+	if _, ok := parser.accept(text); !ok {
+		if parser.peek() == "" {
+			return parser.errorf("expected %q", text)
+		}
+		return parser.errorf("expected %q instead of %q", text, parser.peek())
+	}
+	return nil
+}
+
+// One level of left associative binary operators
+func (parser *expressionParser) binary(next func() (*expressionNode, error), operators ...string) (*expressionNode, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := parser.accept(operators...)
+		if !ok {
+			return left, nil
+		}
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		left = &expressionNode{operator: operator, arguments: []*expressionNode{left, right}}
+	}
+}
+
+func (parser *expressionParser) or() (*expressionNode, error) {
+	return parser.binary(parser.and, "||")
+}
+
+func (parser *expressionParser) and() (*expressionNode, error) {
+	return parser.binary(parser.not, "&&")
+}
+
+func (parser *expressionParser) not() (*expressionNode, error) {
+	if _, ok := parser.accept("!"); ok {
+		argument, err := parser.not()
+		if err != nil {
+			return nil, err
+		}
+		return &expressionNode{operator: "!", arguments: []*expressionNode{argument}}, nil
+	}
+	return parser.comparison()
+}
+
+// Comparisons don't chain: "A < B < C" is an error rather than comparing a truth value with C
+func (parser *expressionParser) comparison() (*expressionNode, error) {
+	left, err := parser.sum()
+	if err != nil {
+		return nil, err
+	}
+	operator, ok := parser.accept("=", "==", "!=", "<", "<=", ">", ">=")
+	if !ok {
+		return left, nil
+	}
+	right, err := parser.sum()
+	if err != nil {
+		return nil, err
+	}
+	if _, chained := parser.accept("=", "==", "!=", "<", "<=", ">", ">="); chained {
+		parser.position--
+		return nil, parser.errorf("comparisons can't be chained")
+	}
+	return &expressionNode{operator: operator, arguments: []*expressionNode{left, right}}, nil
+}
+
+func (parser *expressionParser) sum() (*expressionNode, error) {
+	return parser.binary(parser.product, "+", "-")
+}
+
+func (parser *expressionParser) product() (*expressionNode, error) {
+	return parser.binary(parser.unary, "*", "/", "%")
+}
+
+func (parser *expressionParser) unary() (*expressionNode, error) {
+	if _, ok := parser.accept("-"); ok {
+		argument, err := parser.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &expressionNode{operator: "neg", arguments: []*expressionNode{argument}}, nil
+	}
+	return parser.atom()
+}
+
+func (parser *expressionParser) atom() (*expressionNode, error) {
+	next := parser.peek()
+	switch {
+	case next == "":
+		return nil, parser.errorf("unexpected end")
+	case next == "(":
+		parser.position++
+		inner, err := parser.or()
+		if err != nil {
+			return nil, err
+		}
+		return inner, parser.expect(")")
+	case next == "|":
+		parser.position++
+		inner, err := parser.sum()
+		if err != nil {
+			return nil, err
+		}
+		return &expressionNode{operator: "abs", arguments: []*expressionNode{inner}}, parser.expect("|")
+	case unicode.IsDigit([]rune(next)[0]):
+		value, err := strconv.Atoi(next)
+		if err != nil {
+			return nil, parser.errorf("number %s out of range", next)
+		}
+		parser.position++
+		return &expressionNode{operator: "number", value: value}, nil
+	case unicode.IsLetter([]rune(next)[0]) || next[0] == '_':
+		parser.position++
+		if _, call := parser.accept("("); !call {
+			return &expressionNode{operator: "variable", variable: next}, nil
+		}
+		if next != "abs" && next != "min" && next != "max" {
+			parser.position -= 2
+			return nil, parser.errorf("unknown function %q", next)
+		}
+		node := &expressionNode{operator: next}
+		for {
+			argument, err := parser.or()
+			if err != nil {
+				return nil, err
+			}
+			node.arguments = append(node.arguments, argument)
+			if _, more := parser.accept(","); !more {
+				break
+			}
+		}
+		if next == "abs" && len(node.arguments) != 1 {
+			return nil, parser.errorf("abs takes one argument")
+		}
+		return node, parser.expect(")")
+	}
+	return nil, parser.errorf("unexpected %q", next)
+}
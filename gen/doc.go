@@ -0,0 +1,3 @@
+// Package gen generates random csp Problems for benchmarking: binary CSPs of model RB with a given density and
+// tightness, and sweeps over those parameters that locate the hardness peak around the phase transition.
+package gen
@@ -0,0 +1,209 @@
+package gen
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// A sweep over the density and tightness of RandomBinaryCSP instances with N variables of domain size D, for
+// locating the phase transition: solving Instances instances at every pair of Densities and Tightnesses, seeds
+// Seed, Seed+1 and so on, the same at every point. Random instances go from almost all satisfiable to almost all
+// not as the constraints get tighter, and the hard ones bunch up in between, around Kappa 1.
+//
+// Every solve stops at the first solution, or at Timeout when it's positive, counting as unknown then. Configure,
+// when not nil, sets the solver up before each solve.
+type Sweep struct {
+	N           int
+	D           int
+	Densities   []float64
+	Tightnesses []float64
+	Instances   int
+	Seed        int64
+	Timeout     time.Duration
+	Configure   func(solver *csp.Solver)
+}
+
+// The statistics of the instances at one point of a sweep. Kappa is the expected constrainedness of model B
+// instances there, (n-1)/2 * density * log_d(1/(1-tightness)).
+type SweepPoint struct {
+	Density     float64
+	Tightness   float64
+	Kappa       float64
+	Instances   int
+	Satisfiable int
+	Unknown     int
+	MeanNodes   float64
+	MedianNodes int
+	MaxNodes    int
+	MeanTime    time.Duration
+}
+
+// The fraction of the point's instances found satisfiable
+func (point SweepPoint) SatisfiableFraction() float64 {
+	if point.Instances == 0 {
+		return 0
+	}
+	return float64(point.Satisfiable) / float64(point.Instances)
+}
+
+// Solves the instances at every point, densities outermost, calling done after each point when not nil
+func (sweep *Sweep) Run(done func(point SweepPoint)) []SweepPoint {
+	var points []SweepPoint
+	for _, density := range sweep.Densities {
+		for _, tightness := range sweep.Tightnesses {
+			point := sweep.point(density, tightness)
+			if done != nil {
+				done(point)
+			}
+			points = append(points, point)
+		}
+	}
+	return points
+}
+
+func (sweep *Sweep) point(density float64, tightness float64) SweepPoint {
+	point := SweepPoint{Density: density, Tightness: tightness, Instances: sweep.Instances,
+		Kappa: ModelBKappa(sweep.N, sweep.D, density, tightness)}
+	var nodes []int
+	var total time.Duration
+	for i := 0; i < sweep.Instances; i++ {
+		solver := csp.NewSolver(RandomBinaryCSP(sweep.N, sweep.D, density, tightness, sweep.Seed+int64(i)))
+		if sweep.Configure != nil {
+			sweep.Configure(solver)
+		}
+		solver.MaxSolutions = 1
+		solver.Timeout = sweep.Timeout
+		start := time.Now()
+		solver.Solve()
+		total += time.Since(start)
+		switch solver.Status {
+		case csp.Satisfiable:
+			point.Satisfiable++
+		case csp.Unknown:
+			point.Unknown++
+		}
+		nodes = append(nodes, solver.Nodes)
+	}
+	if len(nodes) == 0 {
+		return point
+	}
+	sort.Ints(nodes)
+	sum := 0
+	for _, n := range nodes {
+		sum += n
+	}
+	point.MeanNodes = float64(sum) / float64(len(nodes))
+	point.MedianNodes = nodes[len(nodes)/2]
+	point.MaxNodes = nodes[len(nodes)-1]
+	point.MeanTime = total / time.Duration(len(nodes))
+	return point
+}
+
+// Expected constrainedness of RandomBinaryCSP(n, d, density, tightness), infinite when tightness is 1
+func ModelBKappa(n int, d int, density float64, tightness float64) float64 {
+	if d < 2 {
+		return 0
+	}
+	return float64(n-1) / 2 * density * -math.Log(1-tightness) / math.Log(float64(d))
+}
+
+// The point of the sweep with the highest median nodes, the hardness peak, false when there are no points
+func HardnessPeak(points []SweepPoint) (SweepPoint, bool) {
+	if len(points) == 0 {
+		return SweepPoint{}, false
+	}
+	peak := points[0]
+	for _, point := range points[1:] {
+		if point.MedianNodes > peak.MedianNodes ||
+			point.MedianNodes == peak.MedianNodes && point.MeanNodes > peak.MeanNodes {
+			peak = point
+		}
+	}
+	return peak, true
+}
+
+// One row per point
+func PrintSweepTable(w io.Writer, points []SweepPoint) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "density\ttightness\tkappa\tsatisfiable\tunknown\tmedian nodes\tmean nodes\tmax nodes\t"+
+		"mean time\t")
+	for _, point := range points {
+		fmt.Fprintf(table, "%.3f\t%.3f\t%.3f\t%d/%d\t%d\t%d\t%.1f\t%d\t%v\t\n", point.Density, point.Tightness,
+			point.Kappa, point.Satisfiable, point.Instances, point.Unknown, point.MedianNodes, point.MeanNodes,
+			point.MaxNodes, point.MeanTime.Round(time.Microsecond))
+	}
+	table.Flush()
+}
+
+// The points as CSV with a header row, times in seconds, for plotting
+func WriteSweepCSV(w io.Writer, points []SweepPoint) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"density", "tightness", "kappa", "instances", "satisfiable", "unknown",
+		"median_nodes", "mean_nodes", "max_nodes", "mean_seconds"})
+	for _, point := range points {
+		writer.Write([]string{
+			strconv.FormatFloat(point.Density, 'g', -1, 64),
+			strconv.FormatFloat(point.Tightness, 'g', -1, 64),
+			strconv.FormatFloat(point.Kappa, 'g', 6, 64),
+			strconv.Itoa(point.Instances),
+			strconv.Itoa(point.Satisfiable),
+			strconv.Itoa(point.Unknown),
+			strconv.Itoa(point.MedianNodes),
+			strconv.FormatFloat(point.MeanNodes, 'g', 6, 64),
+			strconv.Itoa(point.MaxNodes),
+			strconv.FormatFloat(point.MeanTime.Seconds(), 'g', 6, 64),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Values between 0 and 1 given as one value, a comma separated list, or FROM:TO:STEP, both ends included
+func ParseSweepRange(text string) ([]float64, error) {
+	var values []float64
+	if from, rest, ranged := strings.Cut(text, ":"); ranged {
+		to, stepText, ok := strings.Cut(rest, ":")
+		if !ok {
+			return nil, fmt.Errorf("range %q isn't FROM:TO:STEP", text)
+		}
+		bounds := make([]float64, 3)
+		for i, part := range []string{from, to, stepText} {
+			value, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return nil, err
+			}
+			bounds[i] = value
+		}
+		if bounds[2] <= 0 {
+			return nil, fmt.Errorf("step of %q must be positive", text)
+		}
+		// stepping by index, so rounding doesn't drop the last value
+		for i := 0; bounds[0]+float64(i)*bounds[2] <= bounds[1]+bounds[2]/2; i++ {
+			value := bounds[0] + float64(i)*bounds[2]
+			values = append(values, math.Round(value*1e9)/1e9)
+		}
+	} else {
+		for _, part := range strings.Split(text, ",") {
+			value, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+	}
+	for _, value := range values {
+		if value < 0 || value > 1 {
+			return nil, fmt.Errorf("%v isn't between 0 and 1", value)
+		}
+	}
+	return values, nil
+}
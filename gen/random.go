@@ -0,0 +1,51 @@
+package gen
+
+import (
+	"math/rand"
+	"strconv"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// Variable name for a generated instance: X1 .. Xn
+func RandomVariable(i int) string {
+	return "X" + strconv.Itoa(i+1)
+}
+
+// Generates a random binary CSP following model B: n variables with domain 1..d, exactly
+// round(density * n(n-1)/2) constrained pairs picked uniformly, and each of those forbidding exactly
+// round(tightness * d*d) value pairs. The same arguments always produce the same instance.
+func RandomBinaryCSP(n int, d int, density float64, tightness float64, seed int64) *csp.Problem {
+	random := rand.New(rand.NewSource(seed))
+	problem := csp.NewProblem()
+	for i := 0; i < n; i++ {
+		problem.AddVariableRange(RandomVariable(i), 1, d)
+	}
+
+	var pairs [][2]int
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			pairs = append(pairs, [2]int{i, j})
+		}
+	}
+	random.Shuffle(len(pairs), func(a int, b int) { pairs[a], pairs[b] = pairs[b], pairs[a] })
+	constrained := int(density*float64(len(pairs)) + 0.5)
+
+	var tuples [][]int
+	for a := 1; a <= d; a++ {
+		for b := 1; b <= d; b++ {
+			tuples = append(tuples, []int{a, b})
+		}
+	}
+	forbidden := int(tightness*float64(len(tuples)) + 0.5)
+
+	for _, pair := range pairs[:constrained] {
+		order := random.Perm(len(tuples))
+		var allowed [][]int
+		for _, index := range order[forbidden:] {
+			allowed = append(allowed, tuples[index])
+		}
+		problem.AddConstraint(csp.NewTable([]string{RandomVariable(pair[0]), RandomVariable(pair[1])}, allowed))
+	}
+	return problem
+}
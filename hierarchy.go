@@ -0,0 +1,106 @@
+package csp
+
+import (
+	"fmt"
+	"math"
+)
+
+// How much a constraint in a hierarchy matters. Required constraints are hard; the others are satisfied as far as
+// the stronger ones let them be.
+type Strength int
+
+const (
+	Required Strength = iota
+	Strong
+	Medium
+	Weak
+)
+
+var strengthNames = []string{"required", "strong", "medium", "weak"}
+
+func (strength Strength) String() string {
+	if strength >= 0 && int(strength) < len(strengthNames) {
+		return strengthNames[strength]
+	}
+	return fmt.Sprintf("Strength(%d)", int(strength))
+}
+
+// The strength by name: required, strong, medium or weak
+func ParseStrength(name string) (Strength, error) {
+	for strength, known := range strengthNames {
+		if name == known {
+			return Strength(strength), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown strength %q, expected required, strong, medium or weak", name)
+}
+
+// Constraints by strength, each with a weight among the constraints of its strength. Apply turns them into hard
+// and soft constraints, weighting the soft ones so that minimizing the cost is comparing assignments level by
+// level: whichever violates less weight of strong constraints is better, whatever happens below, ties go to the
+// one violating less medium weight, then less weak weight. The solver's branch and bound then finds the best
+// assignment under the hierarchy.
+type ConstraintHierarchy struct {
+	Levels [Weak + 1][]SoftConstraint
+}
+
+func (hierarchy *ConstraintHierarchy) Add(constraint Constraint, strength Strength, weight int) {
+	hierarchy.Levels[strength] = append(hierarchy.Levels[strength], SoftConstraint{constraint, weight})
+}
+
+// Adds the constraints to the problem, the required ones as hard constraints. Soft constraints the problem has
+// already are counted as weak. Fails if the weights the levels need don't fit in an int.
+func (hierarchy *ConstraintHierarchy) Apply(problem *Problem) error {
+	multipliers, err := hierarchy.multipliers(problem)
+	if err != nil {
+		return err
+	}
+	for _, hard := range hierarchy.Levels[Required] {
+		problem.AddConstraint(hard.Constraint)
+	}
+	for strength := Strong; strength <= Weak; strength++ {
+		for _, soft := range hierarchy.Levels[strength] {
+			problem.AddSoftConstraint(soft.Constraint, soft.Weight*multipliers[strength])
+		}
+	}
+	return nil
+}
+
+// What a violated weight unit of each level costs: one more than everything below it violated at once
+func (hierarchy *ConstraintHierarchy) multipliers(problem *Problem) ([Weak + 1]int, error) {
+	var multipliers [Weak + 1]int
+	below := 0
+	for _, soft := range problem.SoftConstraints {
+		below += soft.Weight
+	}
+	for strength := Weak; strength > Required; strength-- {
+		if below == math.MaxInt {
+			return multipliers, fmt.Errorf("constraint hierarchy is too heavy: %s weights overflow", strength)
+		}
+		multipliers[strength] = below + 1
+		for _, soft := range hierarchy.Levels[strength] {
+			if soft.Weight < 1 {
+				return multipliers, fmt.Errorf("%s constraint %s needs a positive weight", strength,
+					describeConstraint(soft.Constraint))
+			}
+			if soft.Weight > (math.MaxInt-below)/multipliers[strength] {
+				return multipliers, fmt.Errorf("constraint hierarchy is too heavy: %s weights overflow", strength)
+			}
+			below += soft.Weight * multipliers[strength]
+		}
+	}
+	return multipliers, nil
+}
+
+// The weight of each level's constraints a complete assignment violates, Required included
+func (hierarchy *ConstraintHierarchy) Violations(assignment map[string]int) [Weak + 1]int {
+	var violations [Weak + 1]int
+	for strength, level := range hierarchy.Levels {
+		for _, constraint := range level {
+			if !constraint.Constraint.Satisfied(assignment) {
+				violations[strength] += constraint.Weight
+			}
+		}
+	}
+	return violations
+}
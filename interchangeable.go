@@ -0,0 +1,154 @@
+package csp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A set of solutions written compactly: every variable maps to a set of values, and every combination of them is
+// a solution
+type SolutionBundle map[string][]int
+
+// Neighborhood interchangeability: two values of a variable are interchangeable when every constraint on the
+// variable allows exactly the same combinations of the other variables' values with either of them. Swapping
+// one for the other then never breaks a solution. Returns each variable's values grouped into such classes, in
+// domain order. Constraints whose scope is too big to enumerate make every value of their variables its own class.
+func NeighborhoodInterchangeable(problem *Problem) map[string][][]int {
+	involved := make(map[string][]Constraint)
+	for _, constraint := range problem.Constraints {
+		for _, variable := range uniqueScope(constraint.Scope()) {
+			involved[variable] = append(involved[variable], constraint)
+		}
+	}
+	for _, soft := range problem.SoftConstraints {
+		for _, variable := range uniqueScope(soft.Constraint.Scope()) {
+			involved[variable] = append(involved[variable], &weightedConstraint{soft})
+		}
+	}
+
+	classes := make(map[string][][]int)
+	for _, variable := range problem.Variables {
+		signatures := make(map[int][]string)
+		for _, value := range problem.Domains[variable] {
+			signatures[value] = nil
+		}
+		for _, constraint := range involved[variable] {
+			for _, value := range problem.Domains[variable] {
+				signatures[value] = append(signatures[value], supportSignature(problem, constraint, variable, value))
+			}
+		}
+
+		index := make(map[string]int)
+		for _, value := range problem.Domains[variable] {
+			signature := strings.Join(signatures[value], "|")
+			if variable == problem.Objective {
+				// the objective's values are never interchangeable, whatever the constraints say
+				signature = fmt.Sprint(value)
+			}
+			if class, exists := index[signature]; exists {
+				classes[variable][class] = append(classes[variable][class], value)
+				continue
+			}
+			index[signature] = len(classes[variable])
+			classes[variable] = append(classes[variable], []int{value})
+		}
+	}
+	return classes
+}
+
+// The combinations of the other variables in the constraint's scope that go with variable = value, as a string,
+// or the value itself when there are too many combinations to list
+func supportSignature(problem *Problem, constraint Constraint, variable string, value int) string {
+	domains := make(map[string][]int)
+	for _, other := range constraint.Scope() {
+		domains[other] = problem.Domains[other]
+	}
+	domains[variable] = []int{value}
+	_, tuples, ok := AllowedTuples(constraint, domains)
+	if !ok {
+		return fmt.Sprintf("value %d", value)
+	}
+	// the variable's own column always holds value, so blank it out
+	scope := uniqueScope(constraint.Scope())
+	var rows []string
+	for _, tuple := range tuples {
+		var row []string
+		for i, other := range scope {
+			if other != variable {
+				row = append(row, fmt.Sprint(tuple[i]))
+			}
+		}
+		rows = append(rows, strings.Join(row, " "))
+	}
+	sort.Strings(rows)
+	return strings.Join(rows, ",")
+}
+
+// Enumerates solutions as bundles: only one value of each interchangeable class is searched, and each solution
+// found stands for every way of swapping in the other values of its classes. MaxSolutions counts bundles.
+// The solver's settings carry over, and its own Solutions are left alone.
+func (solver *Solver) SolveBundled() []SolutionBundle {
+	classes := NeighborhoodInterchangeable(solver.Problem)
+	reduced := &Problem{
+		Variables:          solver.Problem.Variables,
+		Domains:            make(map[string][]int),
+		Constraints:        solver.Problem.Constraints,
+		Labels:             solver.Problem.Labels,
+		Objective:          solver.Problem.Objective,
+		Maximizing:         solver.Problem.Maximizing,
+		SoftConstraints:    solver.Problem.SoftConstraints,
+		DefaultConsistency: solver.Problem.DefaultConsistency,
+		Consistency:        solver.Problem.Consistency,
+	}
+	members := make(map[string]map[int][]int)
+	for _, variable := range solver.Problem.Variables {
+		members[variable] = make(map[int][]int)
+		for _, class := range classes[variable] {
+			reduced.Domains[variable] = append(reduced.Domains[variable], class[0])
+			members[variable][class[0]] = class
+		}
+	}
+
+	search := solver.derived(reduced)
+	search.MaxSolutions = solver.MaxSolutions
+	var bundles []SolutionBundle
+	for _, solution := range search.Solve() {
+		bundle := make(SolutionBundle)
+		for variable, value := range solution {
+			bundle[variable] = members[variable][value]
+		}
+		bundles = append(bundles, bundle)
+	}
+	return bundles
+}
+
+// How many plain solutions the bundle stands for
+func (bundle SolutionBundle) Size() int {
+	size := 1
+	for _, values := range bundle {
+		size *= len(values)
+	}
+	return size
+}
+
+// One variable per line in problem order, "E = 3" for a single value and "E ∈ {2, 4}" for several
+func FormatBundle(problem *Problem, bundle SolutionBundle) string {
+	var lines []string
+	for _, variable := range problem.Variables {
+		values, exists := bundle[variable]
+		if !exists {
+			continue
+		}
+		var formatted []string
+		for _, value := range values {
+			formatted = append(formatted, problem.FormatValue(variable, value))
+		}
+		if len(formatted) == 1 {
+			lines = append(lines, fmt.Sprintf("%s = %s", variable, formatted[0]))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s ∈ {%s}", variable, strings.Join(formatted, ", ")))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,495 @@
+package csp
+
+import "fmt"
+
+// Lazy clause generation: propagation in the CP style, but every value a propagator removes is recorded together
+// with the reason it was removed (the earlier removals that forced it). When propagation fails, those reasons form
+// an implication graph that conflict analysis walks back to a first unique implication point, exactly like a CDCL
+// SAT solver, producing a learned clause that gets added to the model and a level to backjump to. Learned clauses
+// stop the search from running into the same failure again in a different part of the tree.
+//
+// Literals are of the form x = v and x != v. Constraints with dedicated explanations (Clause, NotEqual,
+// AllDifferent) give precise reasons; anything else is propagated generically and blames every removal on the
+// other variables of its scope, which is always correct but learns weaker clauses.
+// Only satisfaction problems are handled.
+//
+// Solving is incremental: each learned clause remembers which of the model's constraints it was derived from, so
+// after constraints get added to or removed from the Problem, the next Solve keeps every learned clause whose
+// constraints are all still there (Reused counts them). Constraints are told apart by identity, which is why
+// RemoveConstraint needs the same pointer that was added.
+type LCGSolver struct {
+	Problem      *Problem
+	MaxSolutions int // 0 means find all solutions
+	Solutions    []map[string]int
+	Nodes        int
+	Failures     int
+	Learned      []*Clause
+	Reused       int
+
+	learnedDependencies [][]Constraint
+	learnedDomains      map[string][]int
+
+	alive       map[string][]bool
+	removedBy   map[string][]int
+	position    map[string]map[int]int
+	events      []lcgEvent
+	levelStarts []int
+
+	constraints    []Constraint
+	dependencies   [][]Constraint
+	watchers       map[string][]int
+	queue          []int
+	queued         []bool
+	propagating    int
+	conflictSource int
+}
+
+// One entry of the trail: either a search decision x = v, or the removal of v from x's domain
+type lcgEvent struct {
+	variable string
+	value    int
+	decision bool
+	level    int
+	reason   []int
+	source   int // the constraint that removed the value, -1 for decisions and the removals they imply
+}
+
+// LCGSolver constructor
+func NewLCGSolver(problem *Problem) *LCGSolver {
+	return &LCGSolver{Problem: problem}
+}
+
+func (solver *LCGSolver) Solve() ([]map[string]int, error) {
+	if solver.Problem.Optimizing() {
+		return nil, fmt.Errorf("lazy clause generation only handles satisfaction problems")
+	}
+	solver.Solutions = nil
+	solver.Nodes, solver.Failures = 0, 0
+	solver.alive = make(map[string][]bool)
+	solver.removedBy = make(map[string][]int)
+	solver.position = make(map[string]map[int]int)
+	solver.events = nil
+	solver.levelStarts = nil
+	solver.constraints = nil
+	solver.dependencies = nil
+	solver.watchers = make(map[string][]int)
+	solver.queue = nil
+	solver.queued = nil
+
+	for _, variable := range solver.Problem.Variables {
+		domain := solver.Problem.Domains[variable]
+		solver.alive[variable] = make([]bool, len(domain))
+		solver.removedBy[variable] = make([]int, len(domain))
+		solver.position[variable] = make(map[int]int)
+		for i, value := range domain {
+			solver.alive[variable][i] = true
+			solver.removedBy[variable][i] = -1
+			solver.position[variable][value] = i
+		}
+		if len(domain) == 0 {
+			return nil, nil
+		}
+	}
+	present := make(map[Constraint]bool)
+	for _, constraint := range solver.Problem.Constraints {
+		solver.addConstraint(constraint, []Constraint{constraint})
+		present[constraint] = true
+	}
+	learned, dependencies := solver.Learned, solver.learnedDependencies
+	solver.Learned, solver.learnedDependencies, solver.Reused = nil, nil, 0
+	// clauses also rely on each variable taking one of the values in its domain, which stays true as long as
+	// no domain gained a value
+	for variable, domain := range solver.learnedDomains {
+		if current, exists := solver.Problem.Domains[variable]; exists {
+			for _, value := range current {
+				if !containsValue(domain, value) {
+					learned = nil
+				}
+			}
+		}
+	}
+	solver.learnedDomains = make(map[string][]int)
+	for _, variable := range solver.Problem.Variables {
+		solver.learnedDomains[variable] = append([]int(nil), solver.Problem.Domains[variable]...)
+	}
+	for i, clause := range learned {
+		valid := true
+		for _, dependency := range dependencies[i] {
+			valid = valid && present[dependency]
+		}
+		if valid {
+			solver.learn(clause, dependencies[i])
+			solver.Reused++
+		}
+	}
+
+	conflict := solver.propagate()
+	for {
+		if conflict != nil {
+			solver.Failures++
+			if !solver.resolve(conflict) {
+				return solver.Solutions, nil
+			}
+			conflict = solver.propagate()
+			continue
+		}
+
+		variable := solver.unfixed()
+		if variable == "" {
+			if conflict = solver.checkComplete(); conflict != nil {
+				continue
+			}
+			solution := solver.current()
+			solver.Solutions = append(solver.Solutions, solution)
+			if solver.MaxSolutions > 0 && len(solver.Solutions) >= solver.MaxSolutions {
+				return solver.Solutions, nil
+			}
+			// block this solution and carry on: the blocking clause is false straight away, and its conflict is
+			// every removal that fixed the variables to their current values. It depends on itself, which is never
+			// part of the model, so nothing learned from it outlives this Solve.
+			var literals []Literal
+			for _, other := range solver.Problem.Variables {
+				literals = append(literals, Literal{other, solution[other], true})
+				conflict = append(conflict, solver.fixedReason(other)...)
+			}
+			blocking := NewClause(literals...)
+			solver.conflictSource = len(solver.constraints)
+			solver.addConstraint(blocking, []Constraint{blocking})
+			continue
+		}
+
+		solver.Nodes++
+		solver.levelStarts = append(solver.levelStarts, len(solver.events))
+		value := solver.values(variable)[0]
+		decision := solver.record(lcgEvent{variable, value, true, solver.level(), nil, -1})
+		solver.propagating = -1
+		for _, other := range solver.values(variable)[1:] {
+			solver.remove(variable, other, []int{decision})
+		}
+		conflict = solver.propagate()
+	}
+}
+
+// dependencies are the model constraints the new one follows from
+func (solver *LCGSolver) addConstraint(constraint Constraint, dependencies []Constraint) {
+	index := len(solver.constraints)
+	solver.constraints = append(solver.constraints, constraint)
+	solver.dependencies = append(solver.dependencies, dependencies)
+	solver.queued = append(solver.queued, false)
+	for _, variable := range uniqueScope(constraint.Scope()) {
+		solver.watchers[variable] = append(solver.watchers[variable], index)
+	}
+	solver.schedule(index)
+}
+
+func (solver *LCGSolver) level() int {
+	return len(solver.levelStarts)
+}
+
+func (solver *LCGSolver) record(event lcgEvent) int {
+	solver.events = append(solver.events, event)
+	return len(solver.events) - 1
+}
+
+func (solver *LCGSolver) values(variable string) []int {
+	var values []int
+	for i, alive := range solver.alive[variable] {
+		if alive {
+			values = append(values, solver.Problem.Domains[variable][i])
+		}
+	}
+	return values
+}
+
+func (solver *LCGSolver) unfixed() string {
+	for _, variable := range solver.Problem.Variables {
+		if len(solver.values(variable)) > 1 {
+			return variable
+		}
+	}
+	return ""
+}
+
+func (solver *LCGSolver) current() map[string]int {
+	solution := make(map[string]int)
+	for _, variable := range solver.Problem.Variables {
+		solution[variable] = solver.values(variable)[0]
+	}
+	return solution
+}
+
+// Removes value from variable because of the reason events. Returns a conflict if that empties the domain.
+func (solver *LCGSolver) remove(variable string, value int, reason []int) []int {
+	i, exists := solver.position[variable][value]
+	if !exists || !solver.alive[variable][i] {
+		return nil
+	}
+	if len(solver.values(variable)) == 1 {
+		return append(append([]int(nil), reason...), solver.fixedReason(variable)...)
+	}
+	solver.alive[variable][i] = false
+	solver.removedBy[variable][i] = solver.record(lcgEvent{variable, value, false, solver.level(), reason,
+		solver.propagating})
+	for _, index := range solver.watchers[variable] {
+		solver.schedule(index)
+	}
+	return nil
+}
+
+// The removals that account for a variable's domain being what it is now
+func (solver *LCGSolver) fixedReason(variable string) []int {
+	var reason []int
+	for i, event := range solver.removedBy[variable] {
+		if !solver.alive[variable][i] {
+			reason = append(reason, event)
+		}
+	}
+	return reason
+}
+
+func (solver *LCGSolver) schedule(index int) {
+	if !solver.queued[index] {
+		solver.queued[index] = true
+		solver.queue = append(solver.queue, index)
+	}
+}
+
+// Runs constraints until fixpoint, returning the conflict's events if one fails
+func (solver *LCGSolver) propagate() []int {
+	for len(solver.queue) > 0 {
+		index := solver.queue[0]
+		solver.queue = solver.queue[1:]
+		solver.queued[index] = false
+		solver.propagating = index
+		if conflict := solver.propagateConstraint(solver.constraints[index]); conflict != nil {
+			solver.conflictSource = index
+			for _, queued := range solver.queue {
+				solver.queued[queued] = false
+			}
+			solver.queue = nil
+			return conflict
+		}
+	}
+	return nil
+}
+
+func (solver *LCGSolver) propagateConstraint(constraint Constraint) []int {
+	switch constraint := constraint.(type) {
+	case *Clause:
+		return solver.propagateClause(constraint)
+	case *NotEqual:
+		for _, pair := range [][2]string{{constraint.First, constraint.Second}, {constraint.Second, constraint.First}} {
+			if values := solver.values(pair[0]); len(values) == 1 {
+				if conflict := solver.remove(pair[1], values[0], solver.fixedReason(pair[0])); conflict != nil {
+					return conflict
+				}
+			}
+		}
+		return nil
+	case *AllDifferent:
+		for _, fixed := range constraint.Variables {
+			values := solver.values(fixed)
+			if len(values) != 1 {
+				continue
+			}
+			for _, other := range constraint.Variables {
+				if other == fixed {
+					continue
+				}
+				if conflict := solver.remove(other, values[0], solver.fixedReason(fixed)); conflict != nil {
+					return conflict
+				}
+			}
+		}
+		return nil
+	}
+	return solver.propagateGeneric(constraint)
+}
+
+// Unit propagation. A literal is false once its removal happened (x = v) or once x is fixed to v (x != v).
+func (solver *LCGSolver) propagateClause(clause *Clause) []int {
+	var open []Literal
+	var falsity []int
+	for _, literal := range clause.Literals {
+		switch literal.Status(solver.values(literal.Variable)) {
+		case LiteralTrue:
+			return nil
+		case LiteralOpen:
+			open = append(open, literal)
+		default:
+			if literal.Negated {
+				falsity = append(falsity, solver.fixedReason(literal.Variable)...)
+			} else if i, exists := solver.position[literal.Variable][literal.Value]; exists {
+				falsity = append(falsity, solver.removedBy[literal.Variable][i])
+			}
+		}
+	}
+	if len(open) == 0 {
+		return falsity
+	}
+	for _, literal := range open[1:] {
+		if literal.Variable != open[0].Variable {
+			return nil
+		}
+	}
+	for _, value := range solver.values(open[0].Variable) {
+		holds := false
+		for _, literal := range open {
+			holds = holds || literal.Holds(value)
+		}
+		if !holds {
+			if conflict := solver.remove(open[0].Variable, value, falsity); conflict != nil {
+				return conflict
+			}
+		}
+	}
+	return nil
+}
+
+// Arc consistency through HasSupport when the scope is small enough, forward checking otherwise, with every
+// removal blamed on the current domains of the rest of the scope
+func (solver *LCGSolver) propagateGeneric(constraint Constraint) []int {
+	scope := uniqueScope(constraint.Scope())
+	domains := make(map[string][]int)
+	open, size := 0, 1
+	for _, variable := range scope {
+		domains[variable] = solver.values(variable)
+		if len(domains[variable]) > 1 {
+			open++
+		}
+		if size <= genericPropagationLimit {
+			size *= len(domains[variable])
+		}
+	}
+	if open > 1 && size > genericPropagationLimit {
+		return nil
+	}
+
+	for _, variable := range scope {
+		var reason []int
+		for _, other := range scope {
+			if other != variable {
+				reason = append(reason, solver.fixedReason(other)...)
+			}
+		}
+		for _, value := range domains[variable] {
+			if !HasSupport(constraint, variable, value, domains) {
+				if conflict := solver.remove(variable, value, reason); conflict != nil {
+					return conflict
+				}
+			}
+		}
+		domains[variable] = solver.values(variable)
+	}
+	return nil
+}
+
+// Generic propagation may skip big scopes, so complete assignments get a final check
+func (solver *LCGSolver) checkComplete() []int {
+	assignment := solver.current()
+	for index, constraint := range solver.constraints {
+		if !constraint.Satisfied(assignment) {
+			solver.conflictSource = index
+			var conflict []int
+			for _, variable := range uniqueScope(constraint.Scope()) {
+				conflict = append(conflict, solver.fixedReason(variable)...)
+			}
+			return conflict
+		}
+	}
+	return nil
+}
+
+// First UIP conflict analysis: keep replacing the latest current-level event in the conflict by its reason until
+// only one current-level event is left. The negation of what remains is the learned clause, which becomes unit
+// after backjumping to the second highest level in it. Returns false when the conflict doesn't depend on any
+// decision, meaning the search space is exhausted.
+func (solver *LCGSolver) resolve(conflict []int) bool {
+	set := make(map[int]bool)
+	for _, event := range conflict {
+		set[event] = true
+	}
+	dependencies := make(map[Constraint]bool)
+	depend := func(source int) {
+		if source != -1 {
+			for _, dependency := range solver.dependencies[source] {
+				dependencies[dependency] = true
+			}
+		}
+	}
+	depend(solver.conflictSource)
+	level := solver.level()
+	for {
+		latest, count := -1, 0
+		for event := range set {
+			if solver.events[event].level == level {
+				count++
+				if event > latest {
+					latest = event
+				}
+			}
+		}
+		if count == 0 {
+			// everything involved is implied by earlier levels, look there instead
+			if level == 0 {
+				return false
+			}
+			level--
+			continue
+		}
+		if count == 1 {
+			break
+		}
+		delete(set, latest)
+		depend(solver.events[latest].source)
+		for _, event := range solver.events[latest].reason {
+			set[event] = true
+		}
+	}
+	if level == 0 {
+		return false
+	}
+
+	var literals []Literal
+	backjump := 0
+	for event := range set {
+		e := solver.events[event]
+		literals = append(literals, Literal{e.variable, e.value, e.decision})
+		if e.level != level && e.level > backjump {
+			backjump = e.level
+		}
+	}
+	solver.backtrack(backjump)
+	var derivedFrom []Constraint
+	for _, constraint := range solver.constraints {
+		if dependencies[constraint] {
+			derivedFrom = append(derivedFrom, constraint)
+		}
+	}
+	solver.learn(NewClause(literals...), derivedFrom)
+	return true
+}
+
+func (solver *LCGSolver) learn(clause *Clause, dependencies []Constraint) {
+	solver.Learned = append(solver.Learned, clause)
+	solver.learnedDependencies = append(solver.learnedDependencies, dependencies)
+	solver.addConstraint(clause, dependencies)
+}
+
+func (solver *LCGSolver) backtrack(level int) {
+	start := solver.levelStarts[level]
+	for i := len(solver.events) - 1; i >= start; i-- {
+		event := solver.events[i]
+		if !event.decision {
+			position := solver.position[event.variable][event.value]
+			solver.alive[event.variable][position] = true
+			solver.removedBy[event.variable][position] = -1
+		}
+	}
+	solver.events = solver.events[:start]
+	solver.levelStarts = solver.levelStarts[:level]
+	for _, index := range solver.queue {
+		solver.queued[index] = false
+	}
+	solver.queue = nil
+}
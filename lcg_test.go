@@ -0,0 +1,21 @@
+package csp_test
+
+import (
+	"fmt"
+	"testing"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+func TestLCGSolverMatchesEnumeration(t *testing.T) {
+	for name, problem := range randomInstances() {
+		want := enumerate(problem)
+		solutions, err := csp.NewLCGSolver(problem).Solve()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got := solutionLines(problem, solutions); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s: lazy clause generation found %d solutions, the solver %d", name, len(got), len(want))
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package csp
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Min-conflicts local search: start from a complete assignment, then over and over pick a variable in a broken
+// constraint and give it the value that breaks the fewest, until nothing is broken. It never proves anything, but
+// on big, loosely constrained problems, large n-queens say, it gets to a solution long before a complete search
+// would. With probability Noise a step takes a random value instead, and a variable can't go back to a value it
+// left in the last Tabu steps unless that beats the best so far, both to get out of local minima. Only the hard
+// constraints count.
+//
+// Every step takes the best move of a neighborhood picked at random from Neighborhoods, ChangeValue when there are
+// none, so models with structure of their own can move in bigger steps, see Neighborhood. Noise then takes a
+// random move of it instead, and Tabu keeps any variable the move changes from going back within that many steps.
+//
+// MaxSteps and Timeout are the limits, 0 meaning unlimited, so set one of them on problems that might have no
+// solution. Initial gives starting values, random for the variables it leaves out. Steps counts the steps taken;
+// Best is the assignment breaking the fewest constraints seen, BestViolations how many.
+type LocalSearch struct {
+	Problem  *Problem
+	MaxSteps int
+	Timeout  time.Duration
+	Seed     int64
+	Noise    float64
+	Tabu     int
+	Initial  map[string]int
+
+	Neighborhoods []Neighborhood
+
+	Steps          int
+	Best           map[string]int
+	BestViolations int
+
+	stop func() bool // asked before every step, for searches running as part of something bigger
+}
+
+// LocalSearch constructor, with Noise 0.1 and Tabu 10
+func NewLocalSearch(problem *Problem) *LocalSearch {
+	return &LocalSearch{Problem: problem, Noise: 0.1, Tabu: 10}
+}
+
+type tabuMove struct {
+	variable string
+	value    int
+}
+
+// Searches until every constraint holds or a limit runs out, returning Best and whether it's a solution
+func (search *LocalSearch) Solve() (map[string]int, bool) {
+	for _, variable := range search.Problem.Variables {
+		if len(search.Problem.Domains[variable]) == 0 {
+			search.Best, search.BestViolations = nil, 0
+			return nil, false
+		}
+	}
+	random := rand.New(rand.NewSource(search.Seed))
+	deadline := time.Now().Add(search.Timeout)
+	conflicts := NewConflicts(search.Problem, search.start(random))
+	search.Steps = 0
+	search.Best, search.BestViolations = copyAssignment(conflicts.Assignment()), conflicts.Violations()
+	tabu := make(map[tabuMove]int) // the step each move stays forbidden until
+
+	for conflicts.Violations() > 0 {
+		if search.MaxSteps > 0 && search.Steps >= search.MaxSteps ||
+			search.Timeout > 0 && search.Steps%64 == 0 && time.Now().After(deadline) ||
+			search.stop != nil && search.stop() {
+			break
+		}
+		search.Steps++
+		neighborhood := Neighborhood(ChangeValue{})
+		if len(search.Neighborhoods) > 0 {
+			neighborhood = search.Neighborhoods[random.Intn(len(search.Neighborhoods))]
+		}
+		moves := neighborhood.Moves(conflicts, random)
+		if len(moves) == 0 {
+			continue
+		}
+		move, found := moves[random.Intn(len(moves))], true
+		if random.Float64() >= search.Noise {
+			best, ties := 0, 0
+			found = false
+			for _, candidate := range moves {
+				if !changes(conflicts, candidate) {
+					continue
+				}
+				delta := conflicts.MoveDelta(candidate)
+				aspiring := conflicts.Violations()+delta < search.BestViolations
+				if search.tabu(tabu, candidate) && !aspiring {
+					continue
+				}
+				switch {
+				case !found || delta < best:
+					move, best, ties, found = candidate, delta, 1, true
+				case delta == best:
+					// every tie equally likely
+					if ties++; random.Intn(ties) == 0 {
+						move = candidate
+					}
+				}
+			}
+		}
+		if !found || !changes(conflicts, move) {
+			continue
+		}
+		for _, variable := range move.Variables {
+			tabu[tabuMove{variable, conflicts.Value(variable)}] = search.Steps + search.Tabu
+		}
+		conflicts.Apply(move)
+		if conflicts.Violations() < search.BestViolations {
+			search.Best, search.BestViolations = copyAssignment(conflicts.Assignment()), conflicts.Violations()
+		}
+	}
+	return search.Best, search.BestViolations == 0
+}
+
+// Whether the move would put some variable back to a value it left in the last Tabu steps
+func (search *LocalSearch) tabu(tabu map[tabuMove]int, move Move) bool {
+	for i, variable := range move.Variables {
+		if tabu[tabuMove{variable, move.Values[i]}] > search.Steps {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether the move changes anything
+func changes(conflicts *Conflicts, move Move) bool {
+	for i, variable := range move.Variables {
+		if conflicts.Value(variable) != move.Values[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// Initial, completed with random values
+func (search *LocalSearch) start(random *rand.Rand) map[string]int {
+	assignment := make(map[string]int, len(search.Problem.Variables))
+	for _, variable := range search.Problem.Variables {
+		domain := search.Problem.Domains[variable]
+		if value, given := search.Initial[variable]; given {
+			assignment[variable] = value
+		} else if len(domain) > 0 {
+			assignment[variable] = domain[random.Intn(len(domain))]
+		}
+	}
+	return assignment
+}
@@ -0,0 +1,329 @@
+package csp
+
+import (
+	"math/big"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A multi-valued decision diagram holding a set of complete assignments. Layer i branches on Variables[i], every
+// path from the root to the true terminal is one solution, and identical subdiagrams are shared, so solution sets
+// with a lot of structure take up far less room than an explicit list of paths.
+type MDD struct {
+	Variables []string
+	Root      int
+
+	nodes  []mddNode
+	unique map[string]int
+}
+
+// Node 0 is the false terminal, node 1 the true terminal. Every node knows how many solutions lie below it.
+type mddNode struct {
+	values   []int // ascending
+	children []int
+	count    *big.Int
+}
+
+// Compiles every solution of the problem's hard constraints into an MDD, branching on variables in problem order.
+// The subdiagram below a prefix only depends on the prefix values that share a constraint with some later
+// variable, so compiled subdiagrams are cached by exactly those values.
+func CompileMDD(problem *Problem) *MDD {
+	mdd := &MDD{problem.Variables, 0, nil, make(map[string]int)}
+	mdd.nodes = []mddNode{{nil, nil, big.NewInt(0)}, {nil, nil, big.NewInt(1)}}
+
+	watchers := make(map[string][]Constraint)
+	for _, constraint := range problem.Constraints {
+		for _, variable := range uniqueScope(constraint.Scope()) {
+			watchers[variable] = append(watchers[variable], constraint)
+		}
+	}
+	depthOf := make(map[string]int)
+	for depth, variable := range problem.Variables {
+		depthOf[variable] = depth
+	}
+	// frontier[d]: prefix variables (depth < d) sharing a constraint with a variable at depth >= d
+	frontier := make([][]string, len(problem.Variables)+1)
+	for depth := range frontier {
+		seen := make(map[string]bool)
+		for _, constraint := range problem.Constraints {
+			scope := uniqueScope(constraint.Scope())
+			reachesSuffix := false
+			for _, variable := range scope {
+				if depthOf[variable] >= depth {
+					reachesSuffix = true
+				}
+			}
+			for _, variable := range scope {
+				if reachesSuffix && depthOf[variable] < depth && !seen[variable] {
+					seen[variable] = true
+					frontier[depth] = append(frontier[depth], variable)
+				}
+			}
+		}
+		sort.Strings(frontier[depth])
+	}
+
+	cache := make(map[string]int)
+	assignment := make(map[string]int)
+	var compile func(depth int) int
+	compile = func(depth int) int {
+		if depth == len(problem.Variables) {
+			return 1
+		}
+		key := strconv.Itoa(depth)
+		for _, variable := range frontier[depth] {
+			key += "," + strconv.Itoa(assignment[variable])
+		}
+		if node, cached := cache[key]; cached {
+			return node
+		}
+
+		variable := problem.Variables[depth]
+		var values, children []int
+		for _, value := range problem.Domains[variable] {
+			assignment[variable] = value
+			consistent := true
+			for _, constraint := range watchers[variable] {
+				if !constraint.Satisfied(assignment) {
+					consistent = false
+					break
+				}
+			}
+			if consistent {
+				if child := compile(depth + 1); child != 0 {
+					values = append(values, value)
+					children = append(children, child)
+				}
+			}
+		}
+		delete(assignment, variable)
+
+		node := mdd.makeNode(values, children)
+		cache[key] = node
+		return node
+	}
+	mdd.Root = compile(0)
+	return mdd
+}
+
+// Returns the existing node with exactly these edges if there is one
+func (mdd *MDD) makeNode(values []int, children []int) int {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Sort(edgeSorter{values, children})
+	var key strings.Builder
+	for i := range values {
+		key.WriteString(strconv.Itoa(values[i]) + ":" + strconv.Itoa(children[i]) + ",")
+	}
+	if node, exists := mdd.unique[key.String()]; exists {
+		return node
+	}
+
+	count := new(big.Int)
+	for _, child := range children {
+		count.Add(count, mdd.nodes[child].count)
+	}
+	mdd.nodes = append(mdd.nodes, mddNode{values, children, count})
+	mdd.unique[key.String()] = len(mdd.nodes) - 1
+	return len(mdd.nodes) - 1
+}
+
+type edgeSorter struct {
+	values   []int
+	children []int
+}
+
+func (s edgeSorter) Len() int           { return len(s.values) }
+func (s edgeSorter) Less(i, j int) bool { return s.values[i] < s.values[j] }
+func (s edgeSorter) Swap(i, j int) {
+	s.values[i], s.values[j] = s.values[j], s.values[i]
+	s.children[i], s.children[j] = s.children[j], s.children[i]
+}
+
+// Number of nodes, terminals included
+func (mdd *MDD) Size() int {
+	return len(mdd.nodes)
+}
+
+func (mdd *MDD) Count() *big.Int {
+	return new(big.Int).Set(mdd.nodes[mdd.Root].count)
+}
+
+// Follows one edge per variable, so a membership test costs one binary search per layer
+func (mdd *MDD) Contains(assignment map[string]int) bool {
+	node := mdd.Root
+	for _, variable := range mdd.Variables {
+		value, assigned := assignment[variable]
+		if !assigned || node <= 1 {
+			return false
+		}
+		node = mdd.child(node, value)
+	}
+	return node == 1
+}
+
+func (mdd *MDD) child(node int, value int) int {
+	values := mdd.nodes[node].values
+	i := sort.SearchInts(values, value)
+	if i < len(values) && values[i] == value {
+		return mdd.nodes[node].children[i]
+	}
+	return 0
+}
+
+// Draws a solution uniformly at random by weighting each edge with the number of solutions below it.
+// Returns nil if the diagram is empty.
+func (mdd *MDD) Sample(random *rand.Rand) map[string]int {
+	if mdd.Root == 0 {
+		return nil
+	}
+	solution := make(map[string]int)
+	node := mdd.Root
+	for _, variable := range mdd.Variables {
+		pick := new(big.Int).Rand(random, mdd.nodes[node].count)
+		for i, child := range mdd.nodes[node].children {
+			if pick.Cmp(mdd.nodes[child].count) < 0 {
+				solution[variable] = mdd.nodes[node].values[i]
+				node = child
+				break
+			}
+			pick.Sub(pick, mdd.nodes[child].count)
+		}
+	}
+	return solution
+}
+
+// Expands up to max solutions (0 for all) in lexicographic order
+func (mdd *MDD) Solutions(max int) []map[string]int {
+	var solutions []map[string]int
+	path := make(map[string]int)
+	var walk func(node int, depth int) bool
+	walk = func(node int, depth int) bool {
+		if depth == len(mdd.Variables) {
+			solution := make(map[string]int, len(path))
+			for variable, value := range path {
+				solution[variable] = value
+			}
+			solutions = append(solutions, solution)
+			return max == 0 || len(solutions) < max
+		}
+		for i, child := range mdd.nodes[node].children {
+			path[mdd.Variables[depth]] = mdd.nodes[node].values[i]
+			if !walk(child, depth+1) {
+				return false
+			}
+		}
+		return true
+	}
+	if mdd.Root != 0 {
+		walk(mdd.Root, 0)
+	}
+	return solutions
+}
+
+// Builds the MDD of an explicit list of tuples over variables, sharing identical suffixes
+func NewTupleMDD(variables []string, tuples [][]int) *MDD {
+	mdd := &MDD{variables, 0, nil, make(map[string]int)}
+	mdd.nodes = []mddNode{{nil, nil, big.NewInt(0)}, {nil, nil, big.NewInt(1)}}
+
+	var build func(depth int, tuples [][]int) int
+	build = func(depth int, tuples [][]int) int {
+		if len(tuples) == 0 {
+			return 0
+		}
+		if depth == len(variables) {
+			return 1
+		}
+		groups := make(map[int][][]int)
+		var values []int
+		for _, tuple := range tuples {
+			if _, exists := groups[tuple[depth]]; !exists {
+				values = append(values, tuple[depth])
+			}
+			groups[tuple[depth]] = append(groups[tuple[depth]], tuple)
+		}
+		var children []int
+		for _, value := range values {
+			children = append(children, build(depth+1, groups[value]))
+		}
+		return mdd.makeNode(values, children)
+	}
+	mdd.Root = build(0, tuples)
+	return mdd
+}
+
+// Whether some path agrees with every assigned variable, treating unassigned ones as wildcards. Each layer is a
+// set of nodes, so the cost is bounded by the size of the diagram however many paths it holds.
+func (mdd *MDD) Consistent(assignment map[string]int) bool {
+	layer := map[int]bool{mdd.Root: true}
+	for _, variable := range mdd.Variables {
+		value, assigned := assignment[variable]
+		next := make(map[int]bool)
+		for node := range layer {
+			if assigned {
+				if child := mdd.child(node, value); child != 0 {
+					next[child] = true
+				}
+				continue
+			}
+			for _, child := range mdd.nodes[node].children {
+				next[child] = true
+			}
+		}
+		if len(next) == 0 {
+			return false
+		}
+		layer = next
+	}
+	return layer[1]
+}
+
+// Supported values of each variable given the domains: a value is supported if some path through the diagram
+// uses it while staying inside every domain. One pass down marks the reachable nodes, one pass up marks the nodes
+// that can still reach the true terminal, and the supported values are the edges between the two.
+func (mdd *MDD) Filter(domains map[string][]int) map[string][]int {
+	allowed := make([]map[int]bool, len(mdd.Variables))
+	for depth, variable := range mdd.Variables {
+		allowed[depth] = make(map[int]bool)
+		for _, value := range domains[variable] {
+			allowed[depth][value] = true
+		}
+	}
+
+	layers := []map[int]bool{{mdd.Root: mdd.Root != 0}}
+	for depth := range mdd.Variables {
+		next := make(map[int]bool)
+		for node := range layers[depth] {
+			for i, child := range mdd.nodes[node].children {
+				if allowed[depth][mdd.nodes[node].values[i]] {
+					next[child] = true
+				}
+			}
+		}
+		layers = append(layers, next)
+	}
+
+	alive := map[int]bool{1: layers[len(mdd.Variables)][1]}
+	supported := make(map[string][]int)
+	for depth := len(mdd.Variables) - 1; depth >= 0; depth-- {
+		seen := make(map[int]bool)
+		for node := range layers[depth] {
+			for i, child := range mdd.nodes[node].children {
+				value := mdd.nodes[node].values[i]
+				if allowed[depth][value] && alive[child] {
+					alive[node] = true
+					seen[value] = true
+				}
+			}
+		}
+		for _, value := range domains[mdd.Variables[depth]] {
+			if seen[value] {
+				supported[mdd.Variables[depth]] = append(supported[mdd.Variables[depth]], value)
+			}
+		}
+	}
+	return supported
+}
@@ -0,0 +1,21 @@
+package csp_test
+
+import (
+	"fmt"
+	"testing"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+func TestMDDMatchesEnumeration(t *testing.T) {
+	for name, problem := range randomInstances() {
+		want := enumerate(problem)
+		mdd := csp.CompileMDD(problem)
+		if count := mdd.Count(); !count.IsInt64() || count.Int64() != int64(len(want)) {
+			t.Errorf("%s: the diagram counts %s solutions, the solver found %d", name, count, len(want))
+		}
+		if got := solutionLines(problem, mdd.Solutions(0)); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s: the diagram expands to %d solutions, the solver found %d", name, len(got), len(want))
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package csp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Mermaid renderers choke on big diagrams, so search trees are cut off after this many nodes
+const mermaidTreeLimit = 300
+
+// The recorded search tree as a Mermaid flowchart, top down, solutions green and failed nodes red with what
+// failed them. Only the first mermaidTreeLimit nodes in search order are drawn.
+func ExportMermaidTree(problem *Problem, tree *SearchTree, w io.Writer) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "graph TD")
+	fmt.Fprintln(out, `  n0(("start"))`)
+	drawn := 0
+	var visit func(node *SearchTreeNode, id string)
+	visit = func(node *SearchTreeNode, id string) {
+		for i, child := range node.Children {
+			if drawn == mermaidTreeLimit {
+				return
+			}
+			drawn++
+			childID := fmt.Sprintf("%s_%d", id, i+1)
+			label := child.Variable + "=" + problem.FormatValue(child.Variable, child.Value)
+			class := ""
+			switch {
+			case child.Solution:
+				class = ":::solution"
+			case child.Outcome != NodeExtended:
+				class = ":::failed"
+				reason := child.Outcome.String()
+				if child.Violated != nil {
+					reason = describeConstraint(child.Violated)
+				}
+				label += "<br/>" + abbreviate(reason, 40)
+			}
+			fmt.Fprintf(out, "  %s --> %s[\"%s\"]%s\n", id, childID, mermaidText(label), class)
+			visit(child, childID)
+		}
+	}
+	visit(tree.Root, "n0")
+	if drawn < tree.Nodes {
+		fmt.Fprintf(out, "  more[\"… %d more nodes\"]\n", tree.Nodes-drawn)
+	}
+	fmt.Fprintln(out, "  classDef solution fill:#cfc,stroke:#080")
+	fmt.Fprintln(out, "  classDef failed fill:#fcc,stroke:#b00")
+	return out.Flush()
+}
+
+// The constraint graph as a Mermaid flowchart: a node per variable, an edge per binary constraint, and a small
+// node for every other constraint, linked to each variable it involves
+func ExportMermaidConstraintGraph(problem *Problem, w io.Writer) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "graph LR")
+	ids := make(map[string]string)
+	for i, variable := range problem.Variables {
+		ids[variable] = fmt.Sprintf("v%d", i+1)
+		fmt.Fprintf(out, "  %s[\"%s %s\"]\n", ids[variable], mermaidText(variable),
+			mermaidText(formatDomain(problem, variable, problem.Domains[variable])))
+	}
+	draw := func(prefix string, i int, constraint Constraint, dashed bool) {
+		scope := uniqueScope(constraint.Scope())
+		label := mermaidText(abbreviate(describeConstraint(constraint), 40))
+		arrow := "---"
+		if dashed {
+			arrow = "-.-"
+		}
+		if len(scope) == 2 {
+			fmt.Fprintf(out, "  %s %s|\"%s\"| %s\n", ids[scope[0]], arrow, label, ids[scope[1]])
+			return
+		}
+		id := fmt.Sprintf("%s%d", prefix, i+1)
+		fmt.Fprintf(out, "  %s([\"%s\"])\n", id, label)
+		for _, variable := range scope {
+			fmt.Fprintf(out, "  %s %s %s\n", id, arrow, ids[variable])
+		}
+	}
+	for i, constraint := range problem.Constraints {
+		draw("c", i, constraint, false)
+	}
+	// soft constraints dashed
+	for i, soft := range problem.SoftConstraints {
+		draw("s", i, soft.Constraint, true)
+	}
+	return out.Flush()
+}
+
+// Mermaid labels are quoted, so quotes become entities, and so do bars, which delimit edge labels
+func mermaidText(text string) string {
+	return strings.NewReplacer(`"`, "#quot;", "|", "#124;", "\n", " ").Replace(text)
+}
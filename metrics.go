@@ -0,0 +1,161 @@
+package csp
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// What the server counts about its jobs, written in the Prometheus text format at /metrics. Safe for concurrent
+// use.
+type ServerMetrics struct {
+	mutex     sync.Mutex
+	submitted int
+	rejected  int
+	queued    int
+	running   int
+	paused    int
+	finished  map[string]int // by status
+	stopped   int
+	timedOut  int
+	durations *Histogram
+	nodes     *Histogram
+}
+
+// Counts of observations at or below each bound, Prometheus style
+type Histogram struct {
+	Bounds []float64
+	counts []int
+	sum    float64
+	count  int
+}
+
+// ServerMetrics constructor
+func NewServerMetrics() *ServerMetrics {
+	return &ServerMetrics{
+		finished:  make(map[string]int),
+		durations: NewHistogram(0.001, 0.01, 0.1, 0.5, 1, 5, 10, 30, 60, 300),
+		nodes:     NewHistogram(10, 100, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8),
+	}
+}
+
+// Histogram constructor, the bounds in increasing order
+func NewHistogram(bounds ...float64) *Histogram {
+	return &Histogram{Bounds: bounds, counts: make([]int, len(bounds))}
+}
+
+func (histogram *Histogram) Observe(value float64) {
+	for i, bound := range histogram.Bounds {
+		if value <= bound {
+			histogram.counts[i]++
+		}
+	}
+	histogram.sum += value
+	histogram.count++
+}
+
+func (metrics *ServerMetrics) jobSubmitted() {
+	metrics.mutex.Lock()
+	metrics.submitted++
+	metrics.mutex.Unlock()
+}
+
+// A job joining the queue, or leaving it with a negative delta
+func (metrics *ServerMetrics) jobQueued(delta int) {
+	metrics.mutex.Lock()
+	metrics.queued += delta
+	metrics.mutex.Unlock()
+}
+
+// A job taking a slot, or taking one back after a pause
+func (metrics *ServerMetrics) jobStarted() {
+	metrics.mutex.Lock()
+	metrics.running++
+	metrics.mutex.Unlock()
+}
+
+// A job paused, leaving the running jobs if it had started and the queue if not
+func (metrics *ServerMetrics) jobPaused(started bool) {
+	metrics.mutex.Lock()
+	metrics.paused++
+	if started {
+		metrics.running--
+	}
+	metrics.mutex.Unlock()
+}
+
+// A paused job going back to the queue or a slot, which count it themselves
+func (metrics *ServerMetrics) jobResumed() {
+	metrics.mutex.Lock()
+	metrics.paused--
+	metrics.mutex.Unlock()
+}
+
+func (metrics *ServerMetrics) jobRejected() {
+	metrics.mutex.Lock()
+	metrics.rejected++
+	metrics.mutex.Unlock()
+}
+
+// A job done, started or not: one stopped while still queued never runs
+func (metrics *ServerMetrics) jobFinished(job *Job, started bool, elapsed time.Duration) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	if started {
+		metrics.running--
+	}
+	metrics.finished[job.Solver.Status.String()]++
+	switch {
+	case job.stopped:
+		metrics.stopped++
+	case job.Solver.Status == Unknown && job.Solver.Timeout > 0 && elapsed >= job.Solver.Timeout:
+		metrics.timedOut++
+	}
+	metrics.durations.Observe(elapsed.Seconds())
+	metrics.nodes.Observe(float64(job.Solver.Nodes))
+}
+
+// Every metric in the Prometheus text exposition format
+func (metrics *ServerMetrics) Export(w io.Writer) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	writeMetric(w, "csp_jobs_submitted_total", "counter", "Jobs accepted for solving.", metrics.submitted)
+	writeMetric(w, "csp_jobs_rejected_total", "counter", "Submissions refused for a bad model or parameters, or a full queue.",
+		metrics.rejected)
+	writeMetric(w, "csp_jobs_queued", "gauge", "Jobs waiting for a free slot.", metrics.queued)
+	writeMetric(w, "csp_jobs_running", "gauge", "Jobs being solved right now.", metrics.running)
+	writeMetric(w, "csp_jobs_paused", "gauge", "Jobs paused, which hold no slot.", metrics.paused)
+
+	fmt.Fprintln(w, "# HELP csp_jobs_finished_total Jobs finished, by how the solve ended.")
+	fmt.Fprintln(w, "# TYPE csp_jobs_finished_total counter")
+	var statuses []string
+	for _, status := range []SolveStatus{Unknown, Unsatisfiable, Satisfiable, Optimal} {
+		statuses = append(statuses, status.String())
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		fmt.Fprintf(w, "csp_jobs_finished_total{status=%q} %d\n", status, metrics.finished[status])
+	}
+
+	writeMetric(w, "csp_jobs_stopped_total", "counter", "Jobs stopped on request.", metrics.stopped)
+	writeMetric(w, "csp_jobs_timed_out_total", "counter", "Jobs cut off by their timeout.", metrics.timedOut)
+	metrics.durations.write(w, "csp_solve_duration_seconds", "Time from submission to the end of the solve.")
+	metrics.nodes.write(w, "csp_solve_nodes", "Search nodes explored per job.")
+}
+
+func writeMetric(w io.Writer, name string, kind string, help string, value int) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, kind, name, value)
+}
+
+func (histogram *Histogram) write(w io.Writer, name string, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for i, bound := range histogram.Bounds {
+		fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", name, strconv.FormatFloat(bound, 'g', -1, 64), histogram.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, histogram.count)
+	fmt.Fprintf(w, "%s_sum %s\n", name, strconv.FormatFloat(histogram.sum, 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count %d\n", name, histogram.count)
+}
@@ -0,0 +1,245 @@
+package csp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Writes the problem as a MiniZinc model. Variable names don't have to be MiniZinc identifiers, so the i-th
+// variable is called xi (from 1) and a comment at the top maps them back. The output item prints one "xi=value"
+// line per variable, which is what MiniZincBackend reads.
+func ExportMiniZinc(problem *Problem, w io.Writer) error {
+	names := make(map[string]string)
+	for i, variable := range problem.Variables {
+		names[variable] = fmt.Sprintf("x%d", i+1)
+	}
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, `include "globals.mzn";`)
+	for _, variable := range problem.Variables {
+		fmt.Fprintf(out, "%% %s = %s\n", names[variable], variable)
+	}
+	for _, variable := range problem.Variables {
+		domain := problem.Domains[variable]
+		low, high := domainBounds(domain)
+		switch {
+		case len(domain) == 0:
+			fmt.Fprintf(out, "var int: %s;\nconstraint false;\n", names[variable])
+		case high-low+1 == len(uniqueValues(domain)):
+			fmt.Fprintf(out, "var %d..%d: %s;\n", low, high, names[variable])
+		default:
+			fmt.Fprintf(out, "var {%s}: %s;\n", mznList(domain), names[variable])
+		}
+	}
+
+	for _, constraint := range problem.Constraints {
+		formula, err := mznFormula(problem, constraint, names)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "constraint %s;\n", formula)
+	}
+
+	var objective []string
+	for _, soft := range problem.SoftConstraints {
+		formula, err := mznFormula(problem, soft.Constraint, names)
+		if err != nil {
+			return err
+		}
+		objective = append(objective, fmt.Sprintf("%d * bool2int(not (%s))", soft.Weight, formula))
+	}
+	switch {
+	case problem.Objective != "" && len(objective) > 0:
+		// cost first, the objective only breaks ties, so a unit of cost has to outweigh its whole range
+		low, high := domainBounds(problem.Domains[problem.Objective])
+		sign := "+"
+		if problem.Maximizing {
+			sign = "-"
+		}
+		fmt.Fprintf(out, "solve minimize %d * (%s) %s %s;\n", high-low+1, strings.Join(objective, " + "), sign,
+			names[problem.Objective])
+	case len(objective) > 0:
+		fmt.Fprintf(out, "solve minimize %s;\n", strings.Join(objective, " + "))
+	case problem.Objective != "" && problem.Maximizing:
+		fmt.Fprintf(out, "solve maximize %s;\n", names[problem.Objective])
+	case problem.Objective != "":
+		fmt.Fprintf(out, "solve minimize %s;\n", names[problem.Objective])
+	default:
+		fmt.Fprintln(out, "solve satisfy;")
+	}
+
+	var items []string
+	for _, variable := range problem.Variables {
+		items = append(items, fmt.Sprintf(`"%s=\(%s)\n"`, names[variable], names[variable]))
+	}
+	fmt.Fprintf(out, "output [%s];\n", strings.Join(items, ", "))
+	return out.Flush()
+}
+
+func mznList(values []int) string {
+	items := make([]string, len(values))
+	for i, value := range values {
+		items[i] = strconv.Itoa(value)
+	}
+	return strings.Join(items, ", ")
+}
+
+func mznNames(variables []string, names map[string]string) string {
+	items := make([]string, len(variables))
+	for i, variable := range variables {
+		items[i] = names[variable]
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+func mznJoin(op string, terms []string, empty string) string {
+	if len(terms) == 0 {
+		return empty
+	}
+	return "(" + strings.Join(terms, " "+op+" ") + ")"
+}
+
+func mznFormula(problem *Problem, constraint Constraint, names map[string]string) (string, error) {
+	switch constraint := constraint.(type) {
+	case *AllDifferent:
+		return "alldifferent(" + mznNames(constraint.Variables, names) + ")", nil
+	case *NotEqual:
+		return fmt.Sprintf("%s != %s", names[constraint.First], names[constraint.Second]), nil
+	case *Linear:
+		var terms []string
+		for i, variable := range constraint.Variables {
+			terms = append(terms, fmt.Sprintf("%d * %s", constraint.Coefficients[i], names[variable]))
+		}
+		switch constraint.Operator {
+		case "=", "!=", "<", "<=", ">", ">=":
+			return fmt.Sprintf("%s %s %d", mznJoin("+", terms, "0"), constraint.Operator, constraint.Constant), nil
+		}
+		return "false", nil
+	case *Table:
+		return mznTable(constraint.Variables, constraint.Tuples, names), nil
+	case *Clause:
+		var terms []string
+		for _, literal := range constraint.Literals {
+			operator := "="
+			if literal.Negated {
+				operator = "!="
+			}
+			terms = append(terms, fmt.Sprintf("%s %s %d", names[literal.Variable], operator, literal.Value))
+		}
+		return mznJoin(`\/`, terms, "false"), nil
+	case *Inverse:
+		return fmt.Sprintf("inverse(%s, %s)", mznNames(constraint.Forward, names),
+			mznNames(constraint.Backward, names)), nil
+	case *Disjunctive:
+		// spelled out pairwise, MiniZinc's disjunctive lets zero length tasks sit inside others
+		var terms []string
+		for i := range constraint.Starts {
+			for j := i + 1; j < len(constraint.Starts); j++ {
+				first, second := names[constraint.Starts[i]], names[constraint.Starts[j]]
+				terms = append(terms, fmt.Sprintf("(%s + %d <= %s \\/ %s + %d <= %s)",
+					first, constraint.Durations[i], second, second, constraint.Durations[j], first))
+			}
+		}
+		return mznJoin(`/\`, terms, "true"), nil
+	case *Regular:
+		// unrolled with a state variable per position, the same way as for SMT-LIB
+		n := len(constraint.Variables)
+		terms := []string{fmt.Sprintf("q[0] = %d", constraint.Start)}
+		for i, variable := range constraint.Variables {
+			var steps []string
+			for state, transitions := range constraint.Transitions {
+				for _, value := range sortedKeys(transitions) {
+					steps = append(steps, fmt.Sprintf("(q[%d] = %d /\\ %s = %d /\\ q[%d] = %d)",
+						i, state, names[variable], value, i+1, transitions[value]))
+				}
+			}
+			terms = append(terms, mznJoin(`\/`, steps, "false"))
+		}
+		var accepting []string
+		for _, state := range sortedKeys(constraint.Accepting) {
+			if constraint.Accepting[state] {
+				accepting = append(accepting, fmt.Sprintf("q[%d] = %d", n, state))
+			}
+		}
+		terms = append(terms, mznJoin(`\/`, accepting, "false"))
+		return fmt.Sprintf("let { array[0..%d] of var int: q } in %s", n, mznJoin(`/\`, terms, "true")), nil
+	}
+
+	scope, tuples, ok := AllowedTuples(constraint, problem.Domains)
+	if !ok {
+		return "", fmt.Errorf("constraint over %v is too large to export as a table", constraint.Scope())
+	}
+	return mznTable(scope, tuples, names), nil
+}
+
+func mznTable(variables []string, tuples [][]int, names map[string]string) string {
+	if len(tuples) == 0 {
+		return "false"
+	}
+	var rows []string
+	for _, tuple := range tuples {
+		rows = append(rows, mznList(tuple))
+	}
+	return fmt.Sprintf("table(%s, [| %s |])", mznNames(variables, names), strings.Join(rows, " | "))
+}
+
+// Runs the minizinc command line tool on the exported model. MaxSolutions works as for the Solver; when
+// optimizing every improving solution is returned, best last.
+type MiniZincBackend struct {
+	Command      string // defaults to "minizinc"
+	Solver       string // passed as --solver when set, e.g. "gecode" or "chuffed"
+	MaxSolutions int
+}
+
+func (backend *MiniZincBackend) Solve(problem *Problem) (Result, error) {
+	command := backend.Command
+	if command == "" {
+		command = "minizinc"
+	}
+	var args []string
+	if backend.Solver != "" {
+		args = append(args, "--solver", backend.Solver)
+	}
+	if problem.Optimizing() || backend.MaxSolutions == 0 {
+		args = append(args, "-a")
+	} else {
+		args = append(args, "-n", strconv.Itoa(backend.MaxSolutions))
+	}
+	start := time.Now()
+	output, err := runExternal(func(w io.Writer) error { return ExportMiniZinc(problem, w) }, ".mzn", command, args...)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// solutions are separated by dashes, and a line of equals signs means the search finished
+	var result Result
+	solution := make(map[string]int)
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "----------":
+			result.Solutions = append(result.Solutions, solution)
+			solution = make(map[string]int)
+		case line == "==========" || line == "=====UNSATISFIABLE=====":
+			result.Complete = true
+		case strings.HasPrefix(line, "x") && strings.Contains(line, "="):
+			parts := strings.SplitN(line[1:], "=", 2)
+			index, errIndex := strconv.Atoi(parts[0])
+			value, errValue := strconv.Atoi(parts[1])
+			if errIndex != nil || errValue != nil || index < 1 || index > len(problem.Variables) {
+				return Result{}, fmt.Errorf("unexpected minizinc output: %s", line)
+			}
+			solution[problem.Variables[index-1]] = value
+		case strings.HasPrefix(line, "====="):
+			return Result{}, fmt.Errorf("minizinc: %s", line)
+		}
+	}
+	result.summarize(problem)
+	result.Stats.Elapsed = time.Since(start)
+	return result, nil
+}
@@ -0,0 +1,284 @@
+package csp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// JSON model files. Variables come in problem order, each with a domain, a [low, high] range, or labels making it
+// categorical. Every constraint names a registered type (see RegisterConstraint) and usually its variables, with
+// the rest of its fields passed to the factory as parameters; a weight makes it a soft constraint. A strength of
+// strong, medium or weak puts it in a ConstraintHierarchy, with a weight of 1 unless it says otherwise; soft
+// constraints without one are weak. A table can read its tuples from a CSV file instead, see ReadTableCSV, the
+// path relative to the working directory. A consistency (auto, check, forward, bounds or domain) sets how hard the
+// propagation engine works on a constraint, the model's own consistency being the default for the others.
+//
+//	{
+//	  "variables": [{"name": "A", "range": [1, 4]}, {"name": "B", "domain": [2, 3]}],
+//	  "constraints": [
+//	    {"type": "notequal", "variables": ["A", "B"]},
+//	    {"type": "linear", "variables": ["A", "B"], "coefficients": [1, 1], "operator": "<=", "constant": 5},
+//	    {"type": "expression", "expression": "|A - B| >= 2 || A == 4"},
+//	    {"type": "table", "variables": ["A"], "tuples": [[1], [2]], "weight": 3},
+//	    {"type": "table", "variables": ["A", "B"], "csv": "allowed.csv"},
+//	    {"type": "expression", "expression": "A + B == 6", "strength": "strong"},
+//	    {"type": "expression", "expression": "A * B != 6", "consistency": "forward"}
+//	  ],
+//	  "minimize": "A"
+//	}
+type modelFile struct {
+	Variables   []modelVariable          `json:"variables"`
+	Constraints []map[string]interface{} `json:"constraints"`
+	Minimize    string                   `json:"minimize"`
+	Maximize    string                   `json:"maximize"`
+	Consistency string                   `json:"consistency"`
+}
+
+type modelVariable struct {
+	Name   string   `json:"name"`
+	Domain []int    `json:"domain"`
+	Range  []int    `json:"range"`
+	Labels []string `json:"labels"`
+}
+
+// Reads a JSON model, building its constraints through the registry
+func LoadModel(r io.Reader) (*Problem, error) {
+	var model modelFile
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&model); err != nil {
+		return nil, err
+	}
+
+	problem := NewProblem()
+	for _, variable := range model.Variables {
+		if variable.Name == "" {
+			return nil, fmt.Errorf("variable without a name")
+		}
+		if _, exists := problem.Domains[variable.Name]; exists {
+			return nil, fmt.Errorf("variable %q declared twice", variable.Name)
+		}
+		switch {
+		case variable.Labels != nil:
+			problem.AddCategoricalVariable(variable.Name, variable.Labels)
+		case variable.Range != nil:
+			if len(variable.Range) != 2 {
+				return nil, fmt.Errorf("variable %q: range must be [low, high]", variable.Name)
+			}
+			problem.AddVariableRange(variable.Name, variable.Range[0], variable.Range[1])
+		default:
+			problem.AddVariable(variable.Name, variable.Domain)
+		}
+		if len(problem.Domains[variable.Name]) == 0 {
+			return nil, fmt.Errorf("variable %q has an empty domain", variable.Name)
+		}
+	}
+
+	if model.Consistency != "" {
+		level, err := ParseConsistencyLevel(model.Consistency)
+		if err != nil {
+			return nil, err
+		}
+		problem.DefaultConsistency = level
+	}
+	var hierarchy ConstraintHierarchy
+	for i, fields := range model.Constraints {
+		constraint, strength, weight, err := modelConstraint(problem, fields)
+		if err == nil && fields["consistency"] != nil {
+			name, _ := fields["consistency"].(string)
+			var level ConsistencyLevel
+			if level, err = ParseConsistencyLevel(name); err == nil {
+				problem.SetConsistency(constraint, level)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("constraint %d: %v", i+1, err)
+		}
+		hierarchy.Add(constraint, strength, weight)
+	}
+	if err := hierarchy.Apply(problem); err != nil {
+		return nil, err
+	}
+
+	if model.Minimize != "" && model.Maximize != "" {
+		return nil, fmt.Errorf("a model can't both minimize and maximize")
+	}
+	for _, objective := range []string{model.Minimize, model.Maximize} {
+		if _, exists := problem.Domains[objective]; objective != "" && !exists {
+			return nil, fmt.Errorf("unknown objective variable %q", objective)
+		}
+	}
+	if model.Minimize != "" {
+		problem.Minimize(model.Minimize)
+	}
+	if model.Maximize != "" {
+		problem.Maximize(model.Maximize)
+	}
+	return problem, nil
+}
+
+// Loads a model file, or standard input when the filename is -
+func LoadModelFile(filename string) (*Problem, error) {
+	if filename == "-" {
+		problem, err := LoadModel(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("standard input: %v", err)
+		}
+		return problem, nil
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	problem, err := LoadModel(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return problem, nil
+}
+
+// Splits a constraint's JSON fields into the registry call, returning the constraint, its strength and its weight
+// (0 for required)
+func modelConstraint(problem *Problem, fields map[string]interface{}) (Constraint, Strength, int, error) {
+	args := ConstraintArgs{Params: make(map[string]interface{})}
+	for key, value := range fields {
+		args.Params[key] = value
+	}
+	name, err := args.String("type")
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if args.Has("variables") {
+		if args.Variables, err = args.Strings("variables"); err != nil {
+			return nil, 0, 0, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	strength, weight := Required, 0
+	if args.Has("weight") {
+		if weight, err = args.Int("weight"); err != nil || weight < 1 {
+			return nil, 0, 0, fmt.Errorf("%s: weight must be a positive integer", name)
+		}
+		strength = Weak
+	}
+	if args.Has("strength") {
+		text, err := args.String("strength")
+		if err == nil {
+			strength, err = ParseStrength(text)
+		}
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("%s: %v", name, err)
+		}
+		switch {
+		case strength == Required && weight > 0:
+			return nil, 0, 0, fmt.Errorf("%s: a required constraint can't have a weight", name)
+		case strength != Required && weight == 0:
+			weight = 1
+		}
+	}
+	delete(args.Params, "type")
+	delete(args.Params, "variables")
+	delete(args.Params, "weight")
+	delete(args.Params, "strength")
+	delete(args.Params, "consistency")
+	var constraint Constraint
+	if name == "table" && args.Has("csv") {
+		// the tuples are in a file, and may use labels the registry can't see
+		filename, err := args.String("csv")
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("%s: %v", name, err)
+		}
+		if constraint, err = LoadTableCSV(problem, filename, args.Variables); err != nil {
+			return nil, 0, 0, fmt.Errorf("%s: %v", name, err)
+		}
+	} else if constraint, err = NewNamedConstraint(name, args); err != nil {
+		return nil, 0, 0, err
+	}
+	for _, variable := range constraint.Scope() {
+		if _, exists := problem.Domains[variable]; !exists {
+			return nil, 0, 0, fmt.Errorf("%s: unknown variable %q", name, variable)
+		}
+	}
+	return constraint, strength, weight, nil
+}
+
+// One line, "A=1 B=2 ...", in problem order with categorical values by label
+func FormatSolution(problem *Problem, solution map[string]int) string {
+	var fields []string
+	for _, variable := range problem.Variables {
+		if value, assigned := solution[variable]; assigned {
+			fields = append(fields, variable+"="+problem.FormatValue(variable, value))
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// A solver set up the way csp solve runs it, then configured by the options
+func NewModelSolver(problem *Problem, maxSolutions int, options ...SolverOption) *Solver {
+	defaults := []SolverOption{WithPropagation(), WithMaxSolutions(maxSolutions)}
+	solver := NewSolver(problem, append(defaults, options...)...)
+	solver.Sorted = true
+	return solver
+}
+
+// What SolveJSON takes besides the model, zero values meaning no limit and the default ordering
+type SolveOptions struct {
+	MaxSolutions int    `json:"maxSolutions"`
+	Timeout      string `json:"timeout"`  // a duration, such as 5s
+	Strategy     string `json:"strategy"` // a variable ordering, or auto to pick every setting, see Solver.AutoConfigure
+	Score        string `json:"score"`    // an expression ranking the solutions, see ParseScore
+	Top          int    `json:"top"`
+}
+
+// Solves a JSON model and answers its SolveResult as JSON, with a status of error when the model or the options
+// are bad. Prints nothing and touches no global state, so embedders such as the WebAssembly build can call it
+// from anywhere.
+func SolveJSON(model []byte, options SolveOptions) []byte {
+	result := solveModel(model, options)
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		encoded, _ = json.Marshal(SolveResult{Status: "error", Solutions: []map[string]interface{}{},
+			Error: err.Error()})
+	}
+	return encoded
+}
+
+func solveModel(model []byte, options SolveOptions) SolveResult {
+	failed := func(err error) SolveResult {
+		return SolveResult{Status: "error", Solutions: []map[string]interface{}{}, Error: err.Error()}
+	}
+	problem, err := LoadModel(bytes.NewReader(model))
+	if err != nil {
+		return failed(err)
+	}
+	solver := NewSolver(problem)
+	solver.Propagation = true
+	solver.Sorted = true
+	solver.MaxSolutions = options.MaxSolutions
+	if options.Timeout != "" {
+		if solver.Timeout, err = time.ParseDuration(options.Timeout); err != nil {
+			return failed(err)
+		}
+	}
+	if options.Strategy == "auto" {
+		solver.AutoConfigure = true
+	} else if options.Strategy != "" {
+		if solver.VariableOrdering, err = ParseVariableOrdering(options.Strategy); err != nil {
+			return failed(err)
+		}
+	}
+	if options.Score != "" {
+		if solver.Score, err = ParseScore(options.Score); err != nil {
+			return failed(err)
+		}
+		solver.TopK = options.Top
+	}
+	start := time.Now()
+	solver.Solve()
+	return NewSolveResult(solver, time.Since(start))
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// The constraints CheckConstraints hardcodes, as expressions
+var classic8Constraints = []string{
+	"A != B",
+	"C != D",
+	"C != E",
+	"E < D - 1",
+	"|F - B| == 1",
+	"C != F",
+	"D != F",
+	"|E - F| % 2 == 1",
+	"A > G",
+	"|G - C| == 1",
+	"D > G",
+	"G != F",
+	"A <= H",
+	"G < H",
+	"|H - C| % 2 == 0",
+	"H != D",
+	"E != H - 2",
+	"H != F",
+}
+
+// The original A-H puzzle written as a Problem: eight variables with the ClassicDomain values and the constraints the tree
+// search checks in CheckConstraints
+func Classic8() *csp.Problem {
+	problem := csp.NewProblem()
+	for depth := 1; depth <= csp.MaximumDepth; depth++ {
+		problem.AddVariableDomain(csp.LetterDepth[depth], csp.ClassicDomain)
+	}
+	for _, expression := range classic8Constraints {
+		problem.AddConstraint(csp.MustConstraint(expression))
+	}
+	return problem
+}
+
+// The solutions the tree search finds, with the plain ordering or the selection heuristic, as assignments
+func LegacyClassic8Solutions(heuristic bool) []map[string]int {
+	root := csp.Root{}
+	root.Depth = 1
+	last := "H"
+	if heuristic {
+		root.PopulateRoot("H")
+		last = "B"
+	} else {
+		root.PopulateRoot("A")
+	}
+	for i := 0; i < csp.MaximumDepth; i++ {
+		if heuristic {
+			root.GenerateTreeWithHeuristic()
+		} else {
+			root.GenerateTree()
+		}
+	}
+
+	var solutions []map[string]int
+	for _, path := range root.GeneratePaths() {
+		if path[len(path)-1].Variable.Letter != last || path[len(path)-1].Tombstone {
+			continue
+		}
+		solutions = append(solutions, csp.PathAssignment(path).Values)
+	}
+	return solutions
+}
+
+// Solves Classic8 and checks it against both legacy tree searches, printing the solutions and any disagreement.
+// Returns whether all three found exactly the same solutions.
+func CheckClassic8() bool {
+	problem := Classic8()
+	solver := csp.NewSolver(problem)
+	solver.Propagation = true
+	solutions := solver.Solve()
+	for _, solution := range solutions {
+		fmt.Println(csp.FormatSolution(problem, solution))
+	}
+
+	expected := solutionKeys(problem, solutions)
+	agree := true
+	for _, heuristic := range []bool{false, true} {
+		legacy := solutionKeys(problem, LegacyClassic8Solutions(heuristic))
+		ordering := "plain ordering"
+		if heuristic {
+			ordering = "selection heuristic"
+		}
+		if fmt.Sprint(legacy) != fmt.Sprint(expected) {
+			fmt.Printf("Mismatch with the %s: tree search found %v\n", ordering, legacy)
+			agree = false
+		}
+	}
+	if agree {
+		fmt.Printf("Solutions: %d, the same as both legacy tree searches\n", len(solutions))
+	}
+	return agree
+}
+
+// The solutions formatted one per string, sorted
+func solutionKeys(problem *csp.Problem, solutions []map[string]int) []string {
+	var keys []string
+	for _, solution := range solutions {
+		keys = append(keys, csp.FormatSolution(problem, solution))
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+func TestClassic8MatchesLegacyTreeSearch(t *testing.T) {
+	problem := Classic8()
+	for _, propagation := range []bool{false, true} {
+		solver := csp.NewSolver(problem)
+		solver.Propagation = propagation
+		solutions := solutionKeys(problem, solver.Solve())
+		if len(solutions) == 0 {
+			t.Fatalf("propagation %v: no solutions", propagation)
+		}
+		for _, heuristic := range []bool{false, true} {
+			legacy := solutionKeys(problem, LegacyClassic8Solutions(heuristic))
+			if fmt.Sprint(solutions) != fmt.Sprint(legacy) {
+				t.Errorf("propagation %v, heuristic %v: solver found %v, tree search %v", propagation, heuristic,
+					solutions, legacy)
+			}
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package models
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// Variable name for a graph vertex: V1, V2, ... using whatever vertex ids the edge list uses
+func VertexVariable(vertex int) string {
+	return "V" + strconv.Itoa(vertex)
+}
+
+// One variable per vertex that appears in the edge list, colored 1..k, with adjacent vertices forced apart.
+// Vertices are added in ascending id order. Self loops can never be colored, so they make the problem unsatisfiable.
+func GraphColoring(edges [][2]int, k int) *csp.Problem {
+	seen := make(map[int]bool)
+	var vertices []int
+	for _, edge := range edges {
+		for _, vertex := range edge {
+			if !seen[vertex] {
+				seen[vertex] = true
+				vertices = append(vertices, vertex)
+			}
+		}
+	}
+	sort.Ints(vertices)
+
+	problem := csp.NewProblem()
+	for _, vertex := range vertices {
+		problem.AddVariableRange(VertexVariable(vertex), 1, k)
+	}
+
+	added := make(map[[2]int]bool)
+	for _, edge := range edges {
+		u, v := edge[0], edge[1]
+		if u > v {
+			u, v = v, u
+		}
+		if added[[2]int{u, v}] {
+			continue
+		}
+		added[[2]int{u, v}] = true
+		problem.AddConstraint(csp.NewNotEqual(VertexVariable(u), VertexVariable(v)))
+	}
+	return problem
+}
+
+// Reads a DIMACS .col file: "c" lines are comments, "p edge <vertices> <edges>" is the header and every
+// "e <u> <v>" line is an edge. The header comes once, before any edge, and edges join vertices 1 to its vertex
+// count. Returns the declared vertex count along with the edges.
+func LoadDIMACSGraph(filename string) (int, [][2]int, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer file.Close()
+
+	vertexCount := -1
+	var edges [][2]int
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "c":
+		case "p":
+			if len(fields) != 4 {
+				return 0, nil, fmt.Errorf("%s:%d: malformed problem line", filename, lineNumber)
+			}
+			if vertexCount >= 0 {
+				return 0, nil, fmt.Errorf("%s:%d: second problem line", filename, lineNumber)
+			}
+			vertexCount, err = strconv.Atoi(fields[2])
+			if err != nil {
+				return 0, nil, fmt.Errorf("%s:%d: %v", filename, lineNumber, err)
+			}
+			if vertexCount < 0 {
+				return 0, nil, fmt.Errorf("%s:%d: negative vertex count", filename, lineNumber)
+			}
+		case "e":
+			if len(fields) != 3 {
+				return 0, nil, fmt.Errorf("%s:%d: malformed edge line", filename, lineNumber)
+			}
+			if vertexCount < 0 {
+				return 0, nil, fmt.Errorf("%s:%d: edge before the problem line", filename, lineNumber)
+			}
+			u, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return 0, nil, fmt.Errorf("%s:%d: %v", filename, lineNumber, err)
+			}
+			v, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return 0, nil, fmt.Errorf("%s:%d: %v", filename, lineNumber, err)
+			}
+			if u < 1 || v < 1 || u > vertexCount || v > vertexCount {
+				return 0, nil, fmt.Errorf("%s:%d: edge (%d, %d) outside vertices 1 to %d", filename, lineNumber, u, v,
+					vertexCount)
+			}
+			edges = append(edges, [2]int{u, v})
+		default:
+			return 0, nil, fmt.Errorf("%s:%d: unknown line type %q", filename, lineNumber, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, nil, err
+	}
+	if vertexCount < 0 {
+		return 0, nil, fmt.Errorf("%s: no problem line", filename)
+	}
+	return vertexCount, edges, nil
+}
+
+// Reads a Matrix Market coordinate file, as the SuiteSparse collection publishes graphs in, taking the matrix as
+// an adjacency matrix: every entry off the diagonal is an edge, whatever its value, and entries on the diagonal
+// are skipped. The matrix must be square; general and symmetric ones give the same edges, an entry of either
+// triangle standing for the edge both ways. Returns the vertex count, the matrix's size, along with the edges.
+func LoadMatrixMarketGraph(filename string) (int, [][2]int, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return 0, nil, err
+		}
+		return 0, nil, fmt.Errorf("%s: empty file", filename)
+	}
+	header := strings.Fields(strings.ToLower(scanner.Text()))
+	if len(header) != 5 || header[0] != "%%matrixmarket" || header[1] != "matrix" {
+		return 0, nil, fmt.Errorf("%s:1: not a Matrix Market matrix header", filename)
+	}
+	if header[2] != "coordinate" {
+		return 0, nil, fmt.Errorf("%s:1: only coordinate matrices are graphs, not %s", filename, header[2])
+	}
+
+	vertexCount, entries := -1, 0
+	var edges [][2]int
+	lineNumber := 1
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "%") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || vertexCount < 0 && len(fields) != 3 {
+			return 0, nil, fmt.Errorf("%s:%d: malformed line", filename, lineNumber)
+		}
+		// the size line is rows, columns and entries; an entry is row, column and maybe values
+		count := 2
+		if vertexCount < 0 {
+			count = 3
+		}
+		var numbers []int
+		for _, field := range fields[:count] {
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return 0, nil, fmt.Errorf("%s:%d: %v", filename, lineNumber, err)
+			}
+			numbers = append(numbers, n)
+		}
+		if vertexCount < 0 {
+			if numbers[0] != numbers[1] {
+				return 0, nil, fmt.Errorf("%s:%d: a %dx%d matrix isn't square", filename, lineNumber, numbers[0],
+					numbers[1])
+			}
+			vertexCount, entries = numbers[0], numbers[2]
+			continue
+		}
+		u, v := numbers[0], numbers[1]
+		if u < 1 || v < 1 || u > vertexCount || v > vertexCount {
+			return 0, nil, fmt.Errorf("%s:%d: entry (%d, %d) outside the matrix", filename, lineNumber, u, v)
+		}
+		entries--
+		if u != v {
+			edges = append(edges, [2]int{u, v})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, nil, err
+	}
+	if vertexCount < 0 {
+		return 0, nil, fmt.Errorf("%s: no size line", filename)
+	}
+	if entries != 0 {
+		return 0, nil, fmt.Errorf("%s: entry count doesn't match the size line", filename)
+	}
+	return vertexCount, edges, nil
+}
+
+// Reads a graph in the format its extension says: Matrix Market for .mtx, DIMACS otherwise
+func LoadGraph(filename string) (int, [][2]int, error) {
+	if strings.EqualFold(filepath.Ext(filename), ".mtx") {
+		return LoadMatrixMarketGraph(filename)
+	}
+	return LoadDIMACSGraph(filename)
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDIMACSGraphRejectsBadFiles(t *testing.T) {
+	for name, contents := range map[string]string{
+		"no problem line":       "e 1 2\n",
+		"two problem lines":     "p edge 2 1\np edge 3 1\ne 1 2\n",
+		"edge before header":    "e 1 2\np edge 2 1\n",
+		"edge past vertices":    "p edge 2 1\ne 1 7\n",
+		"vertex zero":           "p edge 2 1\ne 0 1\n",
+		"negative vertex count": "p edge -1 0\n",
+	} {
+		filename := filepath.Join(t.TempDir(), "graph.col")
+		if err := os.WriteFile(filename, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := LoadDIMACSGraph(filename); err == nil {
+			t.Errorf("%s: loaded without an error", name)
+		}
+	}
+	vertices, edges, err := LoadDIMACSGraph("../examples/myciel3.col")
+	if err != nil || vertices != 11 || len(edges) != 20 {
+		t.Errorf("myciel3 loaded as %d vertices and %d edges, %v", vertices, len(edges), err)
+	}
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// Carry out of a given column (0 is the rightmost) of a cryptarithm: carry1, carry2, ...
+func CarryVariable(column int) string {
+	return "carry" + strconv.Itoa(column+1)
+}
+
+// Parses an addition puzzle like "SEND+MORE=MONEY" into a Problem. Every letter is a digit 0..9, the letters are
+// AllDifferent and the first letter of any multi-letter word can't be 0.
+// Rather than one huge linear equation over every letter, the sum is split into columns joined by carry variables,
+// so each column can be checked as soon as its own letters are assigned. Variables are added column by column from
+// the right to take advantage of that.
+func Cryptarithm(equation string) (*csp.Problem, error) {
+	sides := strings.Split(strings.ToUpper(strings.Replace(equation, " ", "", -1)), "=")
+	if len(sides) != 2 {
+		return nil, fmt.Errorf("cryptarithm %q needs exactly one '='", equation)
+	}
+	addends := strings.Split(sides[0], "+")
+	result := sides[1]
+	for _, word := range append(addends, result) {
+		if word == "" {
+			return nil, fmt.Errorf("cryptarithm %q has an empty word", equation)
+		}
+		for _, letter := range word {
+			if letter < 'A' || letter > 'Z' {
+				return nil, fmt.Errorf("cryptarithm %q contains %q, only letters are allowed", equation, letter)
+			}
+		}
+		if len(word) > len(result) {
+			return nil, fmt.Errorf("cryptarithm %q: %s is longer than the result", equation, word)
+		}
+	}
+
+	leading := make(map[string]bool)
+	for _, word := range append(addends, result) {
+		if len(word) > 1 {
+			leading[word[:1]] = true
+		}
+	}
+
+	problem := csp.NewProblem()
+	var letters []string
+	addLetter := func(letter string) {
+		if _, exists := problem.Domains[letter]; exists {
+			return
+		}
+		if leading[letter] {
+			problem.AddVariableRange(letter, 1, 9)
+		} else {
+			problem.AddVariableRange(letter, 0, 9)
+		}
+		letters = append(letters, letter)
+	}
+
+	// column: sum(addend letters) + carry in - result letter - 10 * carry out = 0
+	for column := 0; column < len(result); column++ {
+		var variables []string
+		var coefficients []int
+		for _, word := range addends {
+			if column < len(word) {
+				letter := string(word[len(word)-1-column])
+				addLetter(letter)
+				variables = append(variables, letter)
+				coefficients = append(coefficients, 1)
+			}
+		}
+		if column > 0 {
+			variables = append(variables, CarryVariable(column-1))
+			coefficients = append(coefficients, 1)
+		}
+
+		letter := string(result[len(result)-1-column])
+		addLetter(letter)
+		variables = append(variables, letter)
+		coefficients = append(coefficients, -1)
+
+		// the last column can't carry anywhere
+		if column < len(result)-1 {
+			problem.AddVariableRange(CarryVariable(column), 0, len(addends)-1)
+			variables = append(variables, CarryVariable(column))
+			coefficients = append(coefficients, -10)
+		}
+		problem.AddConstraint(csp.NewLinear(variables, coefficients, "=", 0))
+	}
+
+	sort.Strings(letters)
+	problem.AddConstraint(csp.NewAllDifferent(letters...))
+	return problem, nil
+}
@@ -0,0 +1,5 @@
+// Package models builds csp Problems for classic puzzles and benchmarks: Sudoku and Killer Sudoku, N-Queens,
+// graph coloring, cryptarithms, Futoshiki, Kakuro, nonograms, job shops, Latin and magic squares, exam
+// timetables, the zebra puzzle and the original A-H puzzle, along with loaders for their usual file formats and
+// printers for their solutions.
+package models
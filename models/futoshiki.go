@@ -0,0 +1,206 @@
+package models
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// Smaller must hold a lower value than Larger. Cells are (row, col), 0-indexed.
+type Inequality struct {
+	Smaller [2]int
+	Larger  [2]int
+}
+
+// Futoshiki is a Latin square with some givens (0 for empty) and inequalities between cells
+func Futoshiki(grid [][]int, inequalities []Inequality) *csp.Problem {
+	n := len(grid)
+	problem := LatinSquare(n)
+	for row := range grid {
+		for col, value := range grid[row] {
+			if value != 0 {
+				problem.AddVariable(GridCell(row, col), []int{value})
+			}
+		}
+	}
+	for _, inequality := range inequalities {
+		smaller := GridCell(inequality.Smaller[0], inequality.Smaller[1])
+		larger := GridCell(inequality.Larger[0], inequality.Larger[1])
+		problem.AddConstraint(csp.NewLinear([]string{smaller, larger}, []int{1, -1}, "<", 0))
+	}
+	return problem
+}
+
+// The grid first, one row per line with digits for givens and . for blanks (separated by spaces), then one
+// inequality per line such as "R1C1 < R1C2" or "R2C3 > R3C3". Lines starting with # are skipped.
+func LoadFutoshiki(filename string) ([][]int, []Inequality, error) {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var grid [][]int
+	var inequalities []Inequality
+	for number, line := range strings.Split(string(contents), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		if len(fields) == 3 && (fields[1] == "<" || fields[1] == ">") {
+			first, err := ParseCell(fields[0])
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s:%d: %v", filename, number+1, err)
+			}
+			second, err := ParseCell(fields[2])
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s:%d: %v", filename, number+1, err)
+			}
+			if fields[1] == ">" {
+				first, second = second, first
+			}
+			inequalities = append(inequalities, Inequality{first, second})
+			continue
+		}
+
+		var row []int
+		for _, field := range fields {
+			if field == "." {
+				row = append(row, 0)
+				continue
+			}
+			value, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s:%d: invalid cell %q", filename, number+1, field)
+			}
+			row = append(row, value)
+		}
+		grid = append(grid, row)
+	}
+	for _, row := range grid {
+		if len(row) != len(grid) {
+			return nil, nil, fmt.Errorf("%s: grid must be square", filename)
+		}
+	}
+	return grid, inequalities, nil
+}
+
+// Parses a 1-indexed "R<row>C<col>" cell name into a 0-indexed (row, col)
+func ParseCell(name string) ([2]int, error) {
+	var row, col int
+	if _, err := fmt.Sscanf(name, "R%dC%d", &row, &col); err != nil || row < 1 || col < 1 {
+		return [2]int{}, fmt.Errorf("invalid cell %q", name)
+	}
+	return [2]int{row - 1, col - 1}, nil
+}
+
+// One square of a Kakuro grid. Black squares may carry the clue for the run of white squares below them (Down)
+// and to their right (Across); 0 means no clue.
+type KakuroCell struct {
+	White  bool
+	Down   int
+	Across int
+}
+
+// Every white square is 1..9, and every run of white squares is AllDifferent and adds up to its clue
+func Kakuro(grid [][]KakuroCell) *csp.Problem {
+	problem := csp.NewProblem()
+	for row := range grid {
+		for col, cell := range grid[row] {
+			if cell.White {
+				problem.AddVariableRange(GridCell(row, col), 1, 9)
+			}
+		}
+	}
+
+	for row := range grid {
+		for col, cell := range grid[row] {
+			if cell.White {
+				continue
+			}
+			if cell.Across > 0 {
+				var run []string
+				for next := col + 1; next < len(grid[row]) && grid[row][next].White; next++ {
+					run = append(run, GridCell(row, next))
+				}
+				problem.AddConstraint(csp.NewAllDifferent(run...))
+				problem.AddConstraint(csp.NewSum(run, "=", cell.Across))
+			}
+			if cell.Down > 0 {
+				var run []string
+				for next := row + 1; next < len(grid) && col < len(grid[next]) && grid[next][col].White; next++ {
+					run = append(run, GridCell(next, col))
+				}
+				problem.AddConstraint(csp.NewAllDifferent(run...))
+				problem.AddConstraint(csp.NewSum(run, "=", cell.Down))
+			}
+		}
+	}
+	return problem
+}
+
+// One grid row per line, squares separated by spaces: "." is white, "#" is black, and "down\across" is a black
+// square with clues, either side of the backslash left empty when there's no clue ("16\", "\7", "23\12").
+// Lines starting with // are comments, since # already means a black square.
+func LoadKakuro(filename string) ([][]KakuroCell, error) {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var grid [][]KakuroCell
+	for number, line := range strings.Split(string(contents), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "//") {
+			continue
+		}
+		var row []KakuroCell
+		for _, field := range fields {
+			switch {
+			case field == ".":
+				row = append(row, KakuroCell{White: true})
+			case field == "#":
+				row = append(row, KakuroCell{})
+			case strings.Contains(field, "\\"):
+				parts := strings.SplitN(field, "\\", 2)
+				var cell KakuroCell
+				for i, part := range parts {
+					if part == "" {
+						continue
+					}
+					clue, err := strconv.Atoi(part)
+					if err != nil {
+						return nil, fmt.Errorf("%s:%d: invalid clue %q", filename, number+1, field)
+					}
+					if i == 0 {
+						cell.Down = clue
+					} else {
+						cell.Across = clue
+					}
+				}
+				row = append(row, cell)
+			default:
+				return nil, fmt.Errorf("%s:%d: invalid square %q", filename, number+1, field)
+			}
+		}
+		grid = append(grid, row)
+	}
+	return grid, nil
+}
+
+func PrintKakuro(grid [][]KakuroCell, solution map[string]int) {
+	for row := range grid {
+		var line []string
+		for col, cell := range grid[row] {
+			if cell.White {
+				line = append(line, strconv.Itoa(solution[GridCell(row, col)]))
+			} else {
+				line = append(line, "#")
+			}
+		}
+		fmt.Println(strings.Join(line, " "))
+	}
+}
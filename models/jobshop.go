@@ -0,0 +1,136 @@
+package models
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// One step of a job: it has to run on Machine for Duration time units
+type Operation struct {
+	Machine  int
+	Duration int
+}
+
+// Start time variable for an operation, 1-indexed: J1.O1, J1.O2, ...
+func OperationVariable(job int, operation int) string {
+	return "J" + strconv.Itoa(job+1) + ".O" + strconv.Itoa(operation+1)
+}
+
+// Builds a job-shop scheduling problem: every job runs its operations in order, every machine handles one
+// operation at a time, and the makespan (when the last operation finishes) is minimized.
+// The makespan variable is added first, so the solver tries short schedules before long ones and the first
+// schedule it finds is already a good incumbent for branch and bound.
+func JobShop(jobs [][]Operation) *csp.Problem {
+	horizon := 0
+	lowerBound := 0
+	machineLoad := make(map[int]int)
+	for _, job := range jobs {
+		jobLength := 0
+		for _, operation := range job {
+			horizon += operation.Duration
+			jobLength += operation.Duration
+			machineLoad[operation.Machine] += operation.Duration
+		}
+		if jobLength > lowerBound {
+			lowerBound = jobLength
+		}
+	}
+	for _, load := range machineLoad {
+		if load > lowerBound {
+			lowerBound = load
+		}
+	}
+
+	problem := csp.NewProblem()
+	problem.AddVariableRange("makespan", lowerBound, horizon)
+	problem.Minimize("makespan")
+
+	machineStarts := make(map[int][]string)
+	machineDurations := make(map[int][]int)
+	for j, job := range jobs {
+		for o, operation := range job {
+			start := OperationVariable(j, o)
+			problem.AddVariableRange(start, 0, horizon-operation.Duration)
+			machineStarts[operation.Machine] = append(machineStarts[operation.Machine], start)
+			machineDurations[operation.Machine] = append(machineDurations[operation.Machine], operation.Duration)
+
+			if o > 0 {
+				// previous start + previous duration <= start
+				previous := OperationVariable(j, o-1)
+				problem.AddConstraint(csp.NewLinear([]string{previous, start}, []int{1, -1}, "<=", -job[o-1].Duration))
+			}
+		}
+		if len(job) > 0 {
+			last := len(job) - 1
+			// last start + last duration <= makespan
+			problem.AddConstraint(csp.NewLinear([]string{OperationVariable(j, last), "makespan"}, []int{1, -1}, "<=", -job[last].Duration))
+		}
+	}
+
+	// in machine order, since the order of the constraints steers the search
+	var machines []int
+	for machine := range machineStarts {
+		machines = append(machines, machine)
+	}
+	sort.Ints(machines)
+	for _, machine := range machines {
+		problem.AddConstraint(csp.NewDisjunctive(machineStarts[machine], machineDurations[machine]))
+	}
+	return problem
+}
+
+// Reads the usual OR-Library layout: a "<jobs> <machines>" header followed by one line per job of
+// "<machine> <duration>" pairs, machines numbered from 0. Lines starting with # are comments.
+func LoadJobShop(filename string) ([][]Operation, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var numbers [][]int
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var row []int
+		for _, field := range strings.Fields(line) {
+			number, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", filename, err)
+			}
+			row = append(row, number)
+		}
+		numbers = append(numbers, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(numbers) == 0 || len(numbers[0]) != 2 {
+		return nil, fmt.Errorf("%s: expected a \"<jobs> <machines>\" header", filename)
+	}
+	if len(numbers)-1 != numbers[0][0] {
+		return nil, fmt.Errorf("%s: header says %d jobs, found %d", filename, numbers[0][0], len(numbers)-1)
+	}
+
+	var jobs [][]Operation
+	for _, row := range numbers[1:] {
+		if len(row)%2 != 0 {
+			return nil, fmt.Errorf("%s: job line has an odd number of fields", filename)
+		}
+		var job []Operation
+		for i := 0; i < len(row); i += 2 {
+			job = append(job, Operation{row[i], row[i+1]})
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
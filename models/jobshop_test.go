@@ -0,0 +1,27 @@
+package models
+
+import (
+	"testing"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+func TestJobShopSearchIsReproducible(t *testing.T) {
+	jobs, err := LoadJobShop("../examples/jobshop.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var first *csp.Solver
+	for i := 0; i < 8; i++ {
+		solver := csp.NewSolver(JobShop(jobs), csp.WithHeuristic(csp.DomainOverWeightedDegree), csp.WithPropagation())
+		solver.Solve()
+		if first == nil {
+			first = solver
+			continue
+		}
+		if solver.Nodes != first.Nodes || solver.Failures != first.Failures || solver.BestCost != first.BestCost {
+			t.Fatalf("run %d took %d nodes and %d failures to reach %d, the first %d and %d to reach %d", i+1,
+				solver.Nodes, solver.Failures, solver.BestCost, first.Nodes, first.Failures, first.BestCost)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// Builds the automaton for one nonogram line, over 0 (empty) and 1 (filled). A clue like [3 1] is the pattern
+// 0* 111 0+ 1 0*. State i means the first i symbols of the mandatory part "111 0 1" have been read, which keeps the
+// automaton deterministic: optional zeros loop on states that sit at the start, between blocks, or at the end.
+func NonogramAutomaton(clue []int) ([]map[int]int, int, map[int]bool) {
+	var sequence []int
+	for i, block := range clue {
+		if i > 0 {
+			sequence = append(sequence, 0)
+		}
+		for j := 0; j < block; j++ {
+			sequence = append(sequence, 1)
+		}
+	}
+
+	transitions := make([]map[int]int, len(sequence)+1)
+	for state := range transitions {
+		transitions[state] = make(map[int]int)
+		if state < len(sequence) {
+			transitions[state][sequence[state]] = state + 1
+		}
+		if state == 0 || sequence[state-1] == 0 || state == len(sequence) {
+			if _, taken := transitions[state][0]; !taken {
+				transitions[state][0] = state
+			}
+		}
+	}
+	return transitions, 0, map[int]bool{len(sequence): true}
+}
+
+// One 0/1 variable per cell and a Regular constraint per row and column
+func Nonogram(rows [][]int, cols [][]int) *csp.Problem {
+	problem := csp.NewProblem()
+	for row := range rows {
+		for col := range cols {
+			problem.AddVariable(GridCell(row, col), []int{0, 1})
+		}
+	}
+
+	for row, clue := range rows {
+		var cells []string
+		for col := range cols {
+			cells = append(cells, GridCell(row, col))
+		}
+		transitions, start, accepting := NonogramAutomaton(clue)
+		problem.AddConstraint(csp.NewRegular(cells, transitions, start, accepting))
+	}
+	for col, clue := range cols {
+		var cells []string
+		for row := range rows {
+			cells = append(cells, GridCell(row, col))
+		}
+		transitions, start, accepting := NonogramAutomaton(clue)
+		problem.AddConstraint(csp.NewRegular(cells, transitions, start, accepting))
+	}
+	return problem
+}
+
+// A "rows:" line followed by one clue per row, then a "columns:" line followed by one clue per column. A clue is
+// its block lengths separated by spaces, with 0 for an empty line. Blank lines and lines starting with # are skipped.
+func LoadNonogram(filename string) ([][]int, [][]int, error) {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows, cols [][]int
+	var section *[][]int
+	for number, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case line == "rows:":
+			section = &rows
+			continue
+		case line == "columns:":
+			section = &cols
+			continue
+		case section == nil:
+			return nil, nil, fmt.Errorf("%s:%d: clue before any \"rows:\" or \"columns:\" line", filename, number+1)
+		}
+
+		var clue []int
+		for _, field := range strings.Fields(line) {
+			block, err := strconv.Atoi(field)
+			if err != nil || block < 0 {
+				return nil, nil, fmt.Errorf("%s:%d: invalid block %q", filename, number+1, field)
+			}
+			if block > 0 {
+				clue = append(clue, block)
+			}
+		}
+		*section = append(*section, clue)
+	}
+	if len(rows) == 0 || len(cols) == 0 {
+		return nil, nil, fmt.Errorf("%s: needs both row and column clues", filename)
+	}
+	return rows, cols, nil
+}
+
+func PrintNonogram(height int, width int, solution map[string]int) {
+	for row := 0; row < height; row++ {
+		var line strings.Builder
+		for col := 0; col < width; col++ {
+			if solution[GridCell(row, col)] == 1 {
+				line.WriteString("██")
+			} else {
+				line.WriteString(". ")
+			}
+		}
+		fmt.Println(line.String())
+	}
+}
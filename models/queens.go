@@ -0,0 +1,48 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// Variable name for the queen in a given row, 1-indexed: Q1 .. Qn. Its value is the column the queen sits in.
+func QueenVariable(row int) string {
+	return "Q" + strconv.Itoa(row+1)
+}
+
+// One variable per row means two queens can never share a row, so only columns and diagonals need constraints
+func NQueens(n int) *csp.Problem {
+	problem := csp.NewProblem()
+	var queens []string
+	for row := 0; row < n; row++ {
+		problem.AddVariableRange(QueenVariable(row), 1, n)
+		queens = append(queens, QueenVariable(row))
+	}
+	problem.AddConstraint(csp.NewAllDifferent(queens...))
+
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			distance := j - i
+			problem.AddConstraint(csp.NewPredicate(func(values []int) bool {
+				return csp.AbsoluteValue(values[0]-values[1]) != distance
+			}, QueenVariable(i), QueenVariable(j)))
+		}
+	}
+	return problem
+}
+
+func PrintBoard(n int, solution map[string]int) {
+	for row := 0; row < n; row++ {
+		line := make([]string, n)
+		for col := 0; col < n; col++ {
+			line[col] = "."
+			if solution[QueenVariable(row)] == col+1 {
+				line[col] = "Q"
+			}
+		}
+		fmt.Println(strings.Join(line, " "))
+	}
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// Variable name for a cell of a square grid, 1-indexed: R1C1 .. RnCn
+func GridCell(row int, col int) string {
+	return "R" + strconv.Itoa(row+1) + "C" + strconv.Itoa(col+1)
+}
+
+// n x n grid of 1..n with every row and column AllDifferent
+func LatinSquare(n int) *csp.Problem {
+	problem := csp.NewProblem()
+	for row := 0; row < n; row++ {
+		for col := 0; col < n; col++ {
+			problem.AddVariableRange(GridCell(row, col), 1, n)
+		}
+	}
+	for i := 0; i < n; i++ {
+		var rowCells, colCells []string
+		for j := 0; j < n; j++ {
+			rowCells = append(rowCells, GridCell(i, j))
+			colCells = append(colCells, GridCell(j, i))
+		}
+		problem.AddConstraint(csp.NewAllDifferent(rowCells...))
+		problem.AddConstraint(csp.NewAllDifferent(colCells...))
+	}
+	return problem
+}
+
+// n x n grid holding each of 1..n*n exactly once, where every row, column and both main diagonals add up to the
+// magic constant n(n*n+1)/2
+func MagicSquare(n int) *csp.Problem {
+	problem := csp.NewProblem()
+	var cells []string
+	for row := 0; row < n; row++ {
+		for col := 0; col < n; col++ {
+			problem.AddVariableRange(GridCell(row, col), 1, n*n)
+			cells = append(cells, GridCell(row, col))
+		}
+	}
+	problem.AddConstraint(csp.NewAllDifferent(cells...))
+
+	magic := n * (n*n + 1) / 2
+	var diagonal, antiDiagonal []string
+	for i := 0; i < n; i++ {
+		var rowCells, colCells []string
+		for j := 0; j < n; j++ {
+			rowCells = append(rowCells, GridCell(i, j))
+			colCells = append(colCells, GridCell(j, i))
+		}
+		problem.AddConstraint(csp.NewSum(rowCells, "=", magic))
+		problem.AddConstraint(csp.NewSum(colCells, "=", magic))
+		diagonal = append(diagonal, GridCell(i, i))
+		antiDiagonal = append(antiDiagonal, GridCell(i, n-1-i))
+	}
+	problem.AddConstraint(csp.NewSum(diagonal, "=", magic))
+	problem.AddConstraint(csp.NewSum(antiDiagonal, "=", magic))
+	return problem
+}
+
+func PrintGrid(n int, solution map[string]int) {
+	width := 1
+	for _, value := range solution {
+		if len(strconv.Itoa(value)) > width {
+			width = len(strconv.Itoa(value))
+		}
+	}
+	for row := 0; row < n; row++ {
+		var line []string
+		for col := 0; col < n; col++ {
+			line = append(line, fmt.Sprintf("%*d", width, solution[GridCell(row, col)]))
+		}
+		fmt.Println(strings.Join(line, " "))
+	}
+}
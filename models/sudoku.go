@@ -0,0 +1,157 @@
+package models
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+// Variable name for a Sudoku cell, 1-indexed to match how puzzles are usually described: R1C1 .. R9C9
+func SudokuCell(row int, col int) string {
+	return GridCell(row, col)
+}
+
+// Builds a Problem out of a 9x9 grid, where 0 marks an empty cell. Givens get a single value domain, every other
+// cell gets 1..9, and each row, column and 3x3 box is AllDifferent.
+func Sudoku(grid [9][9]int) *csp.Problem {
+	return KillerSudoku(grid, nil)
+}
+
+// A Killer Sudoku cage: its cells hold different digits that add up to Sum. Cells are (row, col), 0-indexed.
+type Cage struct {
+	Cells [][2]int
+	Sum   int
+}
+
+// Sudoku plus cages. Killer puzzles usually come with an empty grid, but givens are allowed too.
+// Givens are added first so the solver assigns them before any empty cell, otherwise an empty cell happily takes a
+// value that a given further along its row already owns and the conflict only surfaces much deeper in the search.
+// After that come the cells cage by cage, so each cage sum gets checked as soon as its last cell is filled in.
+func KillerSudoku(grid [9][9]int, cages []Cage) *csp.Problem {
+	problem := csp.NewProblem()
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			if grid[row][col] != 0 {
+				problem.AddVariable(SudokuCell(row, col), []int{grid[row][col]})
+			}
+		}
+	}
+	addEmpty := func(row int, col int) {
+		if _, exists := problem.Domains[SudokuCell(row, col)]; !exists {
+			problem.AddVariableRange(SudokuCell(row, col), 1, 9)
+		}
+	}
+	for _, cage := range cages {
+		for _, cell := range cage.Cells {
+			addEmpty(cell[0], cell[1])
+		}
+	}
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			addEmpty(row, col)
+		}
+	}
+
+	for i := 0; i < 9; i++ {
+		var rowCells, colCells, boxCells []string
+		for j := 0; j < 9; j++ {
+			rowCells = append(rowCells, SudokuCell(i, j))
+			colCells = append(colCells, SudokuCell(j, i))
+			boxCells = append(boxCells, SudokuCell((i/3)*3+j/3, (i%3)*3+j%3))
+		}
+		problem.AddConstraint(csp.NewAllDifferent(rowCells...))
+		problem.AddConstraint(csp.NewAllDifferent(colCells...))
+		problem.AddConstraint(csp.NewAllDifferent(boxCells...))
+	}
+
+	for _, cage := range cages {
+		var cells []string
+		for _, cell := range cage.Cells {
+			cells = append(cells, SudokuCell(cell[0], cell[1]))
+		}
+		problem.AddConstraint(csp.NewAllDifferent(cells...))
+		problem.AddConstraint(csp.NewSum(cells, "=", cage.Sum))
+	}
+	return problem
+}
+
+// Accepts the usual text layouts: digits for givens, 0 or . for blanks, anything else (spaces, newlines, box
+// drawing) is ignored. There must be exactly 81 cells.
+func ParseSudoku(text string) ([9][9]int, error) {
+	var grid [9][9]int
+	cells := 0
+	for _, char := range text {
+		if char != '.' && (char < '0' || char > '9') {
+			continue
+		}
+		if cells == 81 {
+			return grid, fmt.Errorf("sudoku has more than 81 cells")
+		}
+		if char != '.' {
+			grid[cells/9][cells%9] = int(char - '0')
+		}
+		cells++
+	}
+	if cells != 81 {
+		return grid, fmt.Errorf("sudoku has %d cells, expected 81", cells)
+	}
+	return grid, nil
+}
+
+func LoadSudoku(filename string) ([9][9]int, error) {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return [9][9]int{}, err
+	}
+	return ParseSudoku(string(contents))
+}
+
+// One cage per line: the sum followed by its cells, e.g. "15 R1C1 R1C2 R2C1". Blank lines and lines starting
+// with # are skipped.
+func LoadCages(filename string) ([]Cage, error) {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var cages []Cage
+	for number, line := range strings.Split(string(contents), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		sum, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", filename, number+1, err)
+		}
+		cage := Cage{nil, sum}
+		for _, field := range fields[1:] {
+			cell, err := ParseCell(field)
+			if err != nil || cell[0] > 8 || cell[1] > 8 {
+				return nil, fmt.Errorf("%s:%d: invalid cell %q", filename, number+1, field)
+			}
+			cage.Cells = append(cage.Cells, cell)
+		}
+		cages = append(cages, cage)
+	}
+	return cages, nil
+}
+
+func PrintSudoku(solution map[string]int) {
+	for row := 0; row < 9; row++ {
+		if row > 0 && row%3 == 0 {
+			fmt.Println("------+-------+------")
+		}
+		var line []string
+		for col := 0; col < 9; col++ {
+			if col > 0 && col%3 == 0 {
+				line = append(line, "|")
+			}
+			line = append(line, strconv.Itoa(solution[SudokuCell(row, col)]))
+		}
+		fmt.Println(strings.Join(line, " "))
+	}
+}
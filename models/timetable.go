@@ -0,0 +1,102 @@
+package models
+
+import (
+	"fmt"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+type Exam struct {
+	Name     string
+	Students int
+}
+
+type Room struct {
+	Name     string
+	Capacity int
+}
+
+// Two exams sharing Students students can't be in the same slot, and ideally not in back to back slots either
+type ExamConflict struct {
+	First    int
+	Second   int
+	Students int
+}
+
+// Slot and room variables for an exam, e.g. Maths.slot and Maths.room
+func ExamSlotVariable(exam Exam) string {
+	return exam.Name + ".slot"
+}
+
+func ExamRoomVariable(exam Exam) string {
+	return exam.Name + ".room"
+}
+
+// Builds an exam timetabling problem. Hard constraints: conflicting exams are in different slots, an exam only
+// goes in a room big enough for it, and a room holds one exam per slot. The soft constraints spread the load:
+// conflicting exams in consecutive slots cost one point per shared student, so the solver minimizes the number
+// of students sitting exams back to back.
+func ExamTimetable(exams []Exam, rooms []Room, slots int, conflicts []ExamConflict) *csp.Problem {
+	problem := csp.NewProblem()
+	var roomNames []string
+	for _, room := range rooms {
+		roomNames = append(roomNames, room.Name)
+	}
+
+	for _, exam := range exams {
+		problem.AddVariableRange(ExamSlotVariable(exam), 1, slots)
+
+		var fits []int
+		for r, room := range rooms {
+			if room.Capacity >= exam.Students {
+				fits = append(fits, r+1)
+			}
+		}
+		problem.AddCategoricalVariable(ExamRoomVariable(exam), roomNames)
+		problem.AddVariable(ExamRoomVariable(exam), fits)
+	}
+
+	for i := range exams {
+		for j := i + 1; j < len(exams); j++ {
+			problem.AddConstraint(csp.NewPredicate(func(values []int) bool {
+				return values[0] != values[1] || values[2] != values[3]
+			}, ExamSlotVariable(exams[i]), ExamSlotVariable(exams[j]), ExamRoomVariable(exams[i]), ExamRoomVariable(exams[j])))
+		}
+	}
+
+	for _, conflict := range conflicts {
+		first, second := ExamSlotVariable(exams[conflict.First]), ExamSlotVariable(exams[conflict.Second])
+		problem.AddConstraint(csp.NewNotEqual(first, second))
+		problem.AddSoftConstraint(csp.NewPredicate(func(values []int) bool {
+			return csp.AbsoluteValue(values[0]-values[1]) != 1
+		}, first, second), conflict.Students)
+	}
+	return problem
+}
+
+// Small built-in instance for `csp demo timetable`
+func DemoTimetable() ([]Exam, []Room, int, []ExamConflict) {
+	exams := []Exam{
+		{"Maths", 120}, {"Physics", 60}, {"Chemistry", 45}, {"Biology", 80},
+		{"History", 30}, {"French", 25}, {"Economics", 70}, {"Art", 20},
+	}
+	rooms := []Room{{"Hall", 150}, {"Lab", 60}, {"Seminar", 30}}
+	conflicts := []ExamConflict{
+		{0, 1, 50}, {0, 2, 30}, {1, 2, 25}, {0, 6, 40}, {2, 3, 35},
+		{3, 7, 10}, {4, 5, 12}, {4, 6, 8}, {5, 7, 5}, {1, 6, 15},
+	}
+	return exams, rooms, 4, conflicts
+}
+
+func PrintTimetable(problem *csp.Problem, exams []Exam, slots int, solution map[string]int) {
+	for slot := 1; slot <= slots; slot++ {
+		fmt.Printf("Slot %d:", slot)
+		for _, exam := range exams {
+			if solution[ExamSlotVariable(exam)] == slot {
+				room := ExamRoomVariable(exam)
+				fmt.Printf(" %s (%s)", exam.Name, problem.FormatValue(room, solution[room]))
+			}
+		}
+		fmt.Println()
+	}
+}
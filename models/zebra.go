@@ -0,0 +1,83 @@
+package models
+
+import (
+	"fmt"
+
+	csp "github.com/GSGerritsen/go-csp"
+)
+
+var zebraCategories = [][]string{
+	{"Englishman", "Spaniard", "Ukrainian", "Norwegian", "Japanese"},
+	{"Red", "Green", "Ivory", "Yellow", "Blue"},
+	{"Coffee", "Tea", "Milk", "OrangeJuice", "Water"},
+	{"OldGold", "Kools", "Chesterfield", "LuckyStrike", "Parliament"},
+	{"Dog", "Snails", "Fox", "Horse", "Zebra"},
+}
+
+var zebraCategoryNames = []string{"Nationality", "Color", "Drink", "Smoke", "Pet"}
+
+// Variable holding which item of a category lives in a given house, e.g. House3.Drink
+func ZebraHouseVariable(house int, category int) string {
+	return fmt.Sprintf("House%d.%s", house+1, zebraCategoryNames[category])
+}
+
+// The classic Zebra puzzle (Life International, 1962). Every item (Englishman, Red, Zebra, ...) is a variable
+// holding the house it's in, 1..5, and every house has one categorical variable per category naming the item
+// inside it. The two views are channelled together with Inverse, so clues can be written against whichever view
+// is more natural; "next to" and "right of" clues are Table constraints.
+func Zebra() *csp.Problem {
+	problem := csp.NewProblem()
+	for _, items := range zebraCategories {
+		for _, item := range items {
+			problem.AddVariableRange(item, 1, 5)
+		}
+		problem.AddConstraint(csp.NewAllDifferent(items...))
+	}
+	for category, items := range zebraCategories {
+		var houses []string
+		for house := 0; house < 5; house++ {
+			problem.AddCategoricalVariable(ZebraHouseVariable(house, category), items)
+			houses = append(houses, ZebraHouseVariable(house, category))
+		}
+		problem.AddConstraint(csp.NewInverse(items, houses))
+	}
+
+	var nextTo, rightOf [][]int
+	for house := 1; house < 5; house++ {
+		nextTo = append(nextTo, []int{house, house + 1}, []int{house + 1, house})
+		rightOf = append(rightOf, []int{house + 1, house})
+	}
+	same := func(a string, b string) {
+		problem.AddConstraint(csp.NewLinear([]string{a, b}, []int{1, -1}, "=", 0))
+	}
+	neighbours := func(a string, b string) {
+		problem.AddConstraint(csp.NewTable([]string{a, b}, nextTo))
+	}
+
+	same("Englishman", "Red")
+	same("Spaniard", "Dog")
+	same("Coffee", "Green")
+	same("Ukrainian", "Tea")
+	problem.AddConstraint(csp.NewTable([]string{"Green", "Ivory"}, rightOf))
+	same("OldGold", "Snails")
+	same("Kools", "Yellow")
+	problem.AddConstraint(csp.NewTable([]string{"Milk"}, [][]int{{3}}))
+	problem.AddConstraint(csp.NewTable([]string{"Norwegian"}, [][]int{{1}}))
+	neighbours("Chesterfield", "Fox")
+	neighbours("Kools", "Horse")
+	same("LuckyStrike", "OrangeJuice")
+	same("Japanese", "Parliament")
+	neighbours("Norwegian", "Blue")
+	return problem
+}
+
+func PrintZebra(problem *csp.Problem, solution map[string]int) {
+	for house := 0; house < 5; house++ {
+		fmt.Printf("House %d:", house+1)
+		for category := range zebraCategories {
+			variable := ZebraHouseVariable(house, category)
+			fmt.Printf(" %s", problem.FormatValue(variable, solution[variable]))
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,84 @@
+package csp
+
+import "math/rand"
+
+// A change local search can make to the assignment: Variables[i] takes Values[i]. A move can change any number of
+// variables at once, two nurses trading shifts or a whole row rotating, which single value changes could only get
+// to through worse assignments in between.
+type Move struct {
+	Variables []string
+	Values    []int
+}
+
+// Where local search can go from the current assignment. Moves proposes some, typically around a variable that's
+// in conflict, and the search takes the best of them. Proposing a move that changes nothing is allowed and never
+// taken. Implementations get the Conflicts to read the assignment and conflict counts from, and mustn't change it.
+type Neighborhood interface {
+	Moves(conflicts *Conflicts, random *rand.Rand) []Move
+}
+
+// Moves a random conflicted variable to each of its other values: plain min-conflicts, and the default
+type ChangeValue struct{}
+
+func (ChangeValue) Moves(conflicts *Conflicts, random *rand.Rand) []Move {
+	conflicted := conflicts.Conflicted()
+	if len(conflicted) == 0 {
+		return nil
+	}
+	variable := conflicted[random.Intn(len(conflicted))]
+	var moves []Move
+	for _, value := range conflicts.Problem.Domains[variable] {
+		if value != conflicts.Value(variable) {
+			moves = append(moves, Move{[]string{variable}, []int{value}})
+		}
+	}
+	return moves
+}
+
+// Swaps the values of a random conflicted variable and each other variable, when both domains allow it. On
+// permutation models, an AllDifferent over as many values as variables, it keeps a permutation a permutation.
+type SwapValues struct{}
+
+func (SwapValues) Moves(conflicts *Conflicts, random *rand.Rand) []Move {
+	conflicted := conflicts.Conflicted()
+	if len(conflicted) == 0 {
+		return nil
+	}
+	variable := conflicted[random.Intn(len(conflicted))]
+	value := conflicts.Value(variable)
+	var moves []Move
+	for _, other := range conflicts.Problem.Variables {
+		otherValue := conflicts.Value(other)
+		if otherValue == value || !containsValue(conflicts.Problem.Domains[other], value) ||
+			!containsValue(conflicts.Problem.Domains[variable], otherValue) {
+			continue
+		}
+		moves = append(moves, Move{[]string{variable, other}, []int{otherValue, value}})
+	}
+	return moves
+}
+
+// How the number of broken constraints would change if the move was made, negative being better
+func (conflicts *Conflicts) MoveDelta(move Move) int {
+	if len(move.Variables) == 1 {
+		return conflicts.Delta(move.Variables[0], move.Values[0])
+	}
+	before := conflicts.violations
+	undo := conflicts.Apply(move)
+	delta := conflicts.violations - before
+	conflicts.Apply(undo)
+	return delta
+}
+
+// Makes the move, returning the move that takes it back
+func (conflicts *Conflicts) Apply(move Move) Move {
+	undo := Move{make([]string, 0, len(move.Variables)), make([]int, 0, len(move.Values))}
+	for i := len(move.Variables) - 1; i >= 0; i-- {
+		undo.Variables = append(undo.Variables, move.Variables[i])
+		undo.Values = append(undo.Values, conflicts.assignment[move.Variables[i]])
+	}
+	for i, variable := range move.Variables {
+		conflicts.Set(variable, move.Values[i])
+	}
+	return undo
+}
@@ -0,0 +1,118 @@
+package csp
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Local search from many starts at once, Workers of them at a time (NumCPU when 0), each with the settings of
+// Search and a seed of its own, Search.Seed plus the start's number. The first round starts from scratch; the
+// starts after it from the best assignment found so far, their own seeds taking them elsewhere from there. The
+// first start to find a solution stops all of them. MaxSteps and Timeout are limits on all the starts together,
+// 0 meaning unlimited, on top of whatever Search sets for each one.
+//
+// Started counts the starts that ran, Steps their steps; Best is the assignment breaking the fewest constraints
+// any of them saw, BestViolations how many.
+type MultiStart struct {
+	Search   *LocalSearch
+	Starts   int
+	Workers  int
+	MaxSteps int
+	Timeout  time.Duration
+
+	Started        int
+	Steps          int
+	Best           map[string]int
+	BestViolations int
+
+	mutex   sync.Mutex
+	steps   int64
+	stopped int32
+}
+
+// MultiStart constructor
+func NewMultiStart(search *LocalSearch, starts int) *MultiStart {
+	return &MultiStart{Search: search, Starts: starts}
+}
+
+// Stops every start, from another goroutine
+func (multi *MultiStart) Interrupt() {
+	atomic.StoreInt32(&multi.stopped, 1)
+}
+
+// Runs the starts until one finds a solution, they've all run or a limit runs out, returning Best and whether
+// it's a solution
+func (multi *MultiStart) Solve() (map[string]int, bool) {
+	workers := multi.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	deadline := time.Now().Add(multi.Timeout)
+	multi.Started, multi.Steps = 0, 0
+	multi.Best, multi.BestViolations = nil, 0
+	atomic.StoreInt64(&multi.steps, 0)
+	atomic.StoreInt32(&multi.stopped, 0)
+	done := func() bool {
+		return atomic.LoadInt32(&multi.stopped) != 0 || multi.Timeout > 0 && time.Now().After(deadline) ||
+			multi.MaxSteps > 0 && atomic.LoadInt64(&multi.steps) >= int64(multi.MaxSteps)
+	}
+
+	next := make(chan int)
+	var wait sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for start := range next {
+				multi.run(start, start >= workers, deadline)
+			}
+		}()
+	}
+	for start := 0; start < multi.Starts && !done(); start++ {
+		next <- start
+		multi.Started++
+	}
+	close(next)
+	wait.Wait()
+	return multi.Best, multi.Best != nil && multi.BestViolations == 0
+}
+
+// One start, from the best assignment so far when shared is set
+func (multi *MultiStart) run(start int, shared bool, deadline time.Time) {
+	search := *multi.Search
+	search.Seed += int64(start)
+	if multi.Timeout > 0 {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return
+		}
+		if search.Timeout == 0 || remaining < search.Timeout {
+			search.Timeout = remaining
+		}
+	}
+	multi.mutex.Lock()
+	if shared && multi.Best != nil {
+		search.Initial = multi.Best
+	}
+	multi.mutex.Unlock()
+	search.stop = func() bool {
+		return atomic.LoadInt32(&multi.stopped) != 0 ||
+			multi.MaxSteps > 0 && atomic.AddInt64(&multi.steps, 1) > int64(multi.MaxSteps)
+	}
+	if multi.MaxSteps == 0 {
+		defer func() { atomic.AddInt64(&multi.steps, int64(search.Steps)) }()
+	}
+
+	best, solved := search.Solve()
+	multi.mutex.Lock()
+	defer multi.mutex.Unlock()
+	multi.Steps += search.Steps
+	if best != nil && (multi.Best == nil || search.BestViolations < multi.BestViolations) {
+		multi.Best, multi.BestViolations = best, search.BestViolations
+	}
+	if solved {
+		atomic.StoreInt32(&multi.stopped, 1)
+	}
+}
@@ -0,0 +1,63 @@
+package csp
+
+import "sort"
+
+// For ObjectiveOrdering: which way each variable's values push the objective, 1 where bigger values make for a
+// better objective and -1 where smaller ones do. The objective itself goes the way it's optimized. A variable
+// sharing a linear equation with it, as A and B do in S == 3*A - 2*B, goes whichever way moves the objective the
+// right way. Variables in no such equation, or in equations pulling opposite ways, are left out.
+func (solver *Solver) objectiveDirections() map[string]int {
+	objective := solver.Problem.Objective
+	if objective == "" {
+		return nil
+	}
+	better := -1
+	if solver.Problem.Maximizing {
+		better = 1
+	}
+	directions := map[string]int{objective: better}
+	conflicting := make(map[string]bool)
+	for _, constraint := range solver.Problem.Constraints {
+		linear, ok := constraint.(*Linear)
+		if !ok || (linear.Operator != "=" && linear.Operator != "==") {
+			continue
+		}
+		coefficients := make(map[string]int)
+		for i, variable := range linear.Variables {
+			coefficients[variable] += linear.Coefficients[i]
+		}
+		if coefficients[objective] == 0 {
+			continue
+		}
+		for variable, coefficient := range coefficients {
+			if variable == objective || coefficient == 0 {
+				continue
+			}
+			// the objective moves by -coefficient/coefficients[objective] for every step up of the variable
+			direction := better
+			if (coefficient > 0) == (coefficients[objective] > 0) {
+				direction = -better
+			}
+			if previous, seen := directions[variable]; seen && previous != direction {
+				conflicting[variable] = true
+			}
+			directions[variable] = direction
+		}
+	}
+	for variable := range conflicting {
+		delete(directions, variable)
+	}
+	return directions
+}
+
+// The domain sorted best first for the objective, see objectiveDirections
+func objectiveOrder(domain []int, direction int) []int {
+	ordered := append([]int(nil), domain...)
+	sort.Slice(ordered, func(i, j int) bool {
+		if direction > 0 {
+			return ordered[i] > ordered[j]
+		}
+		return ordered[i] < ordered[j]
+	})
+	return ordered
+}
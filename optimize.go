@@ -0,0 +1,64 @@
+package csp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// How the solver optimizes the soft constraint cost. Whichever it is, the objective breaks ties between
+// assignments of the same cost.
+type OptimizationStrategy int
+
+const (
+	BranchAndBound OptimizationStrategy = iota // the search itself, pruning what can't beat the incumbent
+	CoreGuided                                 // relaxing unsatisfiable cores, see coreGuidedSearch
+	RussianDoll                                // solving ever bigger tails of the variables, see russianDollSearch
+)
+
+var optimizationStrategyNames = []string{"bnb", "core", "rds"}
+
+func (strategy OptimizationStrategy) String() string {
+	if int(strategy) < len(optimizationStrategyNames) {
+		return optimizationStrategyNames[strategy]
+	}
+	return fmt.Sprintf("OptimizationStrategy(%d)", int(strategy))
+}
+
+// "bnb", "core" or "rds"
+func ParseOptimizationStrategy(name string) (OptimizationStrategy, error) {
+	for i, known := range optimizationStrategyNames {
+		if name == known {
+			return OptimizationStrategy(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown optimization strategy %q, expected one of %s", name,
+		strings.Join(optimizationStrategyNames, ", "))
+}
+
+// Solves for the best assignment with the strategy given, which stays the solver's Optimization after. A problem
+// without soft constraints only has its objective to optimize, and always gets branch and bound.
+func (solver *Solver) Optimize(strategy OptimizationStrategy) Result {
+	solver.Optimization = strategy
+	solver.Solve()
+	return solver.Result()
+}
+
+// Once the strategy has found the optimal cost, the best objective among the assignments of that cost, by branch
+// and bound with the cost capped. Best is left alone if a limit runs out before anything better turns up.
+func (solver *Solver) optimizeObjectiveAtCost() {
+	problem := solver.Problem
+	capped := solver.relaxedProblem(nil, []Constraint{&costLimit{problem.SoftConstraints, solver.BestCost}}, nil)
+	capped.Objective, capped.Maximizing = problem.Objective, problem.Maximizing
+	search := solver.coreSolver(capped)
+	if search == nil {
+		solver.stopped = true
+		return
+	}
+	search.Solve()
+	solver.absorb(search)
+	if search.Best != nil {
+		solver.Best = search.Best
+		solver.BestObjective = search.BestObjective
+	}
+	solver.stopped = search.Status == Unknown
+}
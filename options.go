@@ -0,0 +1,68 @@
+package csp
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Configures a Solver in NewSolver, so new settings can come along without changing how solvers get made
+type SolverOption func(solver *Solver)
+
+// Stops the search after the timeout, see Solver.Timeout
+func WithTimeout(timeout time.Duration) SolverOption {
+	return func(solver *Solver) { solver.Timeout = timeout }
+}
+
+// Picks the variable to branch on next by the ordering, see VariableOrdering
+func WithHeuristic(ordering VariableOrdering) SolverOption {
+	return func(solver *Solver) { solver.VariableOrdering = ordering }
+}
+
+// Stops after this many solutions, 0 meaning all of them
+func WithMaxSolutions(maxSolutions int) SolverOption {
+	return func(solver *Solver) { solver.MaxSolutions = maxSolutions }
+}
+
+// Solves independent parts of the problem separately, on goroutines of their own when parallel is true, see
+// Solver.Decompose
+func WithParallelism(parallel bool) SolverOption {
+	return func(solver *Solver) {
+		solver.Decompose = true
+		solver.Parallel = parallel
+	}
+}
+
+// Runs the propagation engine after every assignment, see Solver.Propagation
+func WithPropagation() SolverOption {
+	return func(solver *Solver) { solver.Propagation = true }
+}
+
+// Optimizes the soft constraint cost with the strategy, see OptimizationStrategy
+func WithOptimization(strategy OptimizationStrategy) SolverOption {
+	return func(solver *Solver) { solver.Optimization = strategy }
+}
+
+// Picks the search settings from the model, see Solver.AutoConfigure
+func WithAutoConfiguration() SolverOption {
+	return func(solver *Solver) { solver.AutoConfigure = true }
+}
+
+// Lets the scorer pick the branches, falling back on the orderings when it takes longer than timeout, see
+// Solver.BranchScorer
+func WithBranchScorer(scorer BranchScorer, timeout time.Duration) SolverOption {
+	return func(solver *Solver) {
+		solver.BranchScorer = scorer
+		solver.BranchTimeout = timeout
+	}
+}
+
+// Stops the search after this many nodes, see Solver.MaxNodes
+func WithMaxNodes(maxNodes int) SolverOption {
+	return func(solver *Solver) { solver.MaxNodes = maxNodes }
+}
+
+// Traces every solve with the tracer, see Solver.Tracer
+func WithTracer(tracer trace.Tracer) SolverOption {
+	return func(solver *Solver) { solver.Tracer = tracer }
+}
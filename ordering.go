@@ -0,0 +1,83 @@
+package csp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// How the search picks the variable to branch on next
+type VariableOrdering int
+
+const (
+	LexicographicOrder       VariableOrdering = iota // the order of Problem.Variables
+	MinimumRemainingValues                           // smallest current domain first, fail first
+	DomainOverWeightedDegree                         // smallest domain relative to how often its constraints failed
+)
+
+var variableOrderingNames = []string{"lex", "mrv", "domwdeg"}
+
+func (ordering VariableOrdering) String() string {
+	if int(ordering) < len(variableOrderingNames) {
+		return variableOrderingNames[ordering]
+	}
+	return fmt.Sprintf("VariableOrdering(%d)", int(ordering))
+}
+
+// "lex", "mrv" or "domwdeg"
+func ParseVariableOrdering(name string) (VariableOrdering, error) {
+	for i, known := range variableOrderingNames {
+		if name == known {
+			return VariableOrdering(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown variable ordering %q, expected one of %s", name,
+		strings.Join(variableOrderingNames, ", "))
+}
+
+// The variable to assign at this depth. Ties go to the variable first in problem order, so every ordering is
+// deterministic.
+func (solver *Solver) nextVariable(depth int, assignment map[string]int, domains map[string][]int) string {
+	if solver.VariableOrdering == LexicographicOrder {
+		return solver.Problem.Variables[depth]
+	}
+	best, bestScore := "", 0.0
+	for _, variable := range solver.Problem.Variables {
+		if _, assigned := assignment[variable]; assigned {
+			continue
+		}
+		score := float64(len(domains[variable]))
+		if solver.VariableOrdering == DomainOverWeightedDegree {
+			score /= solver.weightedDegree(variable, assignment)
+		}
+		if best == "" || score < bestScore {
+			best, bestScore = variable, score
+		}
+	}
+	return best
+}
+
+// The weights of the constraints on variable that still involve some other unassigned variable. Weights start
+// at 1 and grow by 1 every time their constraint fails a node, so variables in trouble spots get picked early.
+func (solver *Solver) weightedDegree(variable string, assignment map[string]int) float64 {
+	degree := 0.0
+	for _, i := range solver.watchers[variable] {
+		for _, other := range solver.Problem.Constraints[i].Scope() {
+			if _, assigned := assignment[other]; !assigned && other != variable {
+				degree += solver.weights[i]
+				break
+			}
+		}
+	}
+	// a variable with nothing left to constrain it can go whenever, after the ones that still matter
+	if degree == 0 {
+		return 0.5
+	}
+	return degree
+}
+
+// Bumps the weight of the constraint that failed a node, -1 for a failure no single constraint is to blame for
+func (solver *Solver) blame(i int) {
+	if i >= 0 && solver.weights != nil {
+		solver.weights[i]++
+	}
+}
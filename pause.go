@@ -0,0 +1,48 @@
+package csp
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Holds the search at its next node until Resume, from any goroutine. Nothing is lost: the search carries on from
+// where it was, and the time spent paused doesn't count towards Timeout. A solver paused between solves holds its
+// next Solve at the first node. Interrupt ends a pause as well as the search.
+func (solver *Solver) Pause() {
+	solver.mutex.Lock()
+	if solver.resumed == nil {
+		solver.resumed = make(chan struct{})
+		atomic.StoreInt32(&solver.paused, 1)
+	}
+	solver.mutex.Unlock()
+}
+
+func (solver *Solver) Resume() {
+	solver.mutex.Lock()
+	if solver.resumed != nil {
+		close(solver.resumed)
+		solver.resumed = nil
+		atomic.StoreInt32(&solver.paused, 0)
+	}
+	solver.mutex.Unlock()
+}
+
+func (solver *Solver) Paused() bool {
+	return atomic.LoadInt32(&solver.paused) != 0
+}
+
+// Blocks until Resume, publishing a snapshot first so watchers see where the search stopped
+func (solver *Solver) waitWhilePaused() {
+	solver.mutex.RLock()
+	resumed := solver.resumed
+	solver.mutex.RUnlock()
+	if resumed == nil {
+		return
+	}
+	solver.publish()
+	pausedAt := time.Now()
+	<-resumed
+	if solver.Timeout > 0 {
+		solver.deadline = solver.deadline.Add(time.Since(pausedAt))
+	}
+}
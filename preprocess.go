@@ -0,0 +1,221 @@
+package csp
+
+import "fmt"
+
+// How much filtering to do on the domains before the search starts. Each level is stronger, and slower, than
+// the one before it.
+type PreprocessLevel int
+
+const (
+	NoPreprocessing PreprocessLevel = iota
+	ArcConsistency                  // remove values with no support in some constraint
+	Shaving                         // singleton arc consistency, but only on the bounds of each domain
+	SAC                             // singleton arc consistency on every value
+)
+
+var preprocessLevelNames = []string{"none", "arc", "shaving", "sac"}
+
+func (level PreprocessLevel) String() string {
+	if level >= 0 && int(level) < len(preprocessLevelNames) {
+		return preprocessLevelNames[level]
+	}
+	return fmt.Sprintf("PreprocessLevel(%d)", int(level))
+}
+
+// "none", "arc", "shaving" or "sac"
+func ParsePreprocessLevel(name string) (PreprocessLevel, error) {
+	for level, known := range preprocessLevelNames {
+		if name == known {
+			return PreprocessLevel(level), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown preprocessing level %q, expected none, arc, shaving or sac", name)
+}
+
+// Returns the filtered domains, or nil if some domain was wiped out, which proves the problem has no solution
+func Preprocess(problem *Problem, level PreprocessLevel) map[string][]int {
+	domains := make(map[string][]int)
+	for _, variable := range problem.Variables {
+		domains[variable] = append([]int(nil), problem.Domains[variable]...)
+	}
+	if level == NoPreprocessing {
+		return domains
+	}
+	if !EnforceArcConsistency(problem, domains) {
+		return nil
+	}
+	if level == ArcConsistency {
+		return domains
+	}
+	if !EnforceSingletonConsistency(problem, domains, level == Shaving) {
+		return nil
+	}
+	return domains
+}
+
+// Constraints that can work out all their supported values in one go, instead of HasSupport searching for a
+// support value by value. Filter returns the supported values of every scope variable, or false when the
+// constraint can't do it after all and the generic search should be used.
+type DomainFilter interface {
+	Filter(domains map[string][]int) (map[string][]int, bool)
+}
+
+// Repeatedly removes every value that can't be extended to a satisfying tuple of some constraint, using the
+// current domains of the other variables, until nothing changes. Returns false on a domain wipe out.
+func EnforceArcConsistency(problem *Problem, domains map[string][]int) bool {
+	for changed := true; changed; {
+		changed = false
+		for _, constraint := range problem.Constraints {
+			var filtered map[string][]int
+			useFilter := false
+			if filter, ok := constraint.(DomainFilter); ok {
+				filtered, useFilter = filter.Filter(domains)
+			}
+			for _, variable := range uniqueScope(constraint.Scope()) {
+				var supported []int
+				if useFilter {
+					supported = filtered[variable]
+				} else {
+					for _, value := range domains[variable] {
+						if HasSupport(constraint, variable, value, domains) {
+							supported = append(supported, value)
+						}
+					}
+				}
+				if len(supported) == 0 {
+					return false
+				}
+				if len(supported) < len(domains[variable]) {
+					domains[variable] = supported
+					changed = true
+				}
+			}
+		}
+	}
+	return true
+}
+
+// AC-3, the classic arc consistency algorithm for binary problems: only the arcs pointing at a variable whose
+// domain just shrank get revisited, instead of sweeping every constraint again. Returns false on a domain wipe
+// out, and an error if some constraint has more than two variables; HiddenVariableEncoding or DualEncoding turn
+// such problems into binary ones first.
+func EnforceAC3(problem *Problem, domains map[string][]int) (bool, error) {
+	type arc struct {
+		variable   string
+		constraint Constraint
+	}
+	var queue []arc
+	arcs := make(map[string][]arc)
+	for _, constraint := range problem.Constraints {
+		scope := uniqueScope(constraint.Scope())
+		if len(scope) > 2 {
+			return false, fmt.Errorf("AC-3 needs binary constraints, this one is over %v", scope)
+		}
+		for _, variable := range scope {
+			queue = append(queue, arc{variable, constraint})
+			for _, other := range scope {
+				if other != variable {
+					arcs[other] = append(arcs[other], arc{variable, constraint})
+				}
+			}
+		}
+	}
+
+	queued := make(map[arc]bool)
+	for _, pending := range queue {
+		queued[pending] = true
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		queued[current] = false
+		var supported []int
+		for _, value := range domains[current.variable] {
+			if HasSupport(current.constraint, current.variable, value, domains) {
+				supported = append(supported, value)
+			}
+		}
+		if len(supported) == 0 {
+			return false, nil
+		}
+		if len(supported) == len(domains[current.variable]) {
+			continue
+		}
+		domains[current.variable] = supported
+		for _, next := range arcs[current.variable] {
+			if !queued[next] && next.constraint != current.constraint {
+				queued[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return true, nil
+}
+
+// Whether variable=value can be extended to a complete assignment of the constraint's scope that satisfies it.
+// Since constraints reject partial assignments as early as they can, this is a small search of its own.
+func HasSupport(constraint Constraint, variable string, value int, domains map[string][]int) bool {
+	var others []string
+	for _, other := range uniqueScope(constraint.Scope()) {
+		if other != variable {
+			others = append(others, other)
+		}
+	}
+	assignment := map[string]int{variable: value}
+	if !constraint.Satisfied(assignment) {
+		return false
+	}
+
+	var extend func(position int) bool
+	extend = func(position int) bool {
+		if position == len(others) {
+			return true
+		}
+		for _, candidate := range domains[others[position]] {
+			assignment[others[position]] = candidate
+			if constraint.Satisfied(assignment) && extend(position+1) {
+				return true
+			}
+		}
+		delete(assignment, others[position])
+		return false
+	}
+	return extend(0)
+}
+
+// Tries each value in turn as if it were assigned, and removes it when arc consistency then wipes out some
+// domain. Removing a value can make other values fail, so this repeats until nothing changes. With boundsOnly
+// (shaving) just the smallest and largest value of each domain are tried, and a shaved bound exposes the next one.
+func EnforceSingletonConsistency(problem *Problem, domains map[string][]int, boundsOnly bool) bool {
+	for changed := true; changed; {
+		changed = false
+		for _, variable := range problem.Variables {
+			var kept []int
+			for i, value := range domains[variable] {
+				if boundsOnly && i != 0 && i != len(domains[variable])-1 {
+					kept = append(kept, value)
+					continue
+				}
+				trial := make(map[string][]int, len(domains))
+				for other, values := range domains {
+					trial[other] = values
+				}
+				trial[variable] = []int{value}
+				if EnforceArcConsistency(problem, trial) {
+					kept = append(kept, value)
+				}
+			}
+			if len(kept) == 0 {
+				return false
+			}
+			if len(kept) < len(domains[variable]) {
+				domains[variable] = kept
+				if !EnforceArcConsistency(problem, domains) {
+					return false
+				}
+				changed = true
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,416 @@
+package csp
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// A problem simplified by Presolve, along with what was done to it. Problem is nil when presolving already
+// proved there is no solution. Restore turns solutions of Problem back into solutions of the original.
+type Presolved struct {
+	Problem *Problem
+	Report  PresolveReport
+
+	original       *Problem
+	representative map[string]string
+}
+
+// Model size before and after presolving, and what each step accounted for
+type PresolveReport struct {
+	Before     ModelSize
+	After      ModelSize
+	Merged     int // variables folded into a variable they had to equal
+	Entailed   int // constraints every remaining combination of values satisfies
+	Cliques    int // AllDifferent constraints put together from pairwise !=
+	Replaced   int // != constraints those cliques took over
+	Infeasible bool
+}
+
+// SearchSpace is the log10 of the product of the domain sizes
+type ModelSize struct {
+	Variables    int
+	Constraints  int
+	DomainValues int
+	SearchSpace  float64
+}
+
+// Simplifies the problem before search: arc consistency shrinks the domains, variables tied together by x - y = 0
+// are merged into one, constraints that hold whatever values are left get dropped, and groups of variables that
+// are pairwise != get a single AllDifferent instead, which propagates much better. Soft constraints and the
+// objective are kept, with merged variables renamed. The original problem isn't touched.
+func Presolve(problem *Problem) *Presolved {
+	presolved := &Presolved{original: problem, representative: make(map[string]string)}
+	presolved.Report.Before = measure(problem)
+	domains := Preprocess(problem, ArcConsistency)
+	if domains == nil {
+		presolved.Report.Infeasible = true
+		return presolved
+	}
+
+	// merge variables forced equal, keeping the first in problem order, or the objective, as the representative
+	index := make(map[string]int)
+	for i, variable := range problem.Variables {
+		index[variable] = i
+	}
+	equal := newUnionFind(len(problem.Variables))
+	equalities := make(map[Constraint]bool)
+	for _, constraint := range problem.Constraints {
+		if first, second, ok := equality(constraint); ok {
+			equal.union(index[first], index[second])
+			equalities[constraint] = true
+		}
+	}
+	for _, class := range equal.classes() {
+		representative := problem.Variables[class[0]]
+		for _, i := range class {
+			if problem.Variables[i] == problem.Objective {
+				representative = problem.Objective
+			}
+		}
+		for _, i := range class {
+			if variable := problem.Variables[i]; variable != representative {
+				presolved.representative[variable] = representative
+				domains[representative] = intersect(domains[representative], domains[variable])
+				presolved.Report.Merged++
+			}
+		}
+	}
+
+	simplified := &Problem{
+		Domains:            make(map[string][]int),
+		Labels:             problem.Labels,
+		Objective:          problem.Objective,
+		Maximizing:         problem.Maximizing,
+		DefaultConsistency: problem.DefaultConsistency,
+	}
+	for _, variable := range problem.Variables {
+		if _, merged := presolved.representative[variable]; !merged {
+			simplified.AddVariable(variable, domains[variable])
+		}
+	}
+	for _, constraint := range problem.Constraints {
+		if !equalities[constraint] {
+			renamed := presolved.rename(constraint)
+			simplified.AddConstraint(renamed)
+			if level, set := problem.Consistency[constraint]; set {
+				simplified.SetConsistency(renamed, level)
+			}
+		}
+	}
+	for _, soft := range problem.SoftConstraints {
+		simplified.AddSoftConstraint(presolved.rename(soft.Constraint), soft.Weight)
+	}
+	// merging can shrink domains, and those shrunk domains can make more values lose their support
+	infeasible := false
+	for _, variable := range simplified.Variables {
+		infeasible = infeasible || len(simplified.Domains[variable]) == 0
+	}
+	if infeasible || !EnforceArcConsistency(simplified, simplified.Domains) {
+		presolved.Report.Infeasible = true
+		return presolved
+	}
+
+	var kept []Constraint
+	for _, constraint := range simplified.Constraints {
+		if Entailed(constraint, simplified.Domains) {
+			presolved.Report.Entailed++
+		} else {
+			kept = append(kept, constraint)
+		}
+	}
+	simplified.Constraints = presolved.findCliques(simplified, kept)
+	remaining := make(map[Constraint]bool)
+	for _, constraint := range simplified.Constraints {
+		remaining[constraint] = true
+	}
+	for constraint := range simplified.Consistency {
+		if !remaining[constraint] {
+			delete(simplified.Consistency, constraint)
+		}
+	}
+
+	presolved.Problem = simplified
+	presolved.Report.After = measure(simplified)
+	return presolved
+}
+
+// The two variables of a constraint that says a*x - a*y = 0
+func equality(constraint Constraint) (string, string, bool) {
+	linear, ok := constraint.(*Linear)
+	if !ok || len(linear.Variables) != 2 || linear.Variables[0] == linear.Variables[1] || linear.Constant != 0 ||
+		(linear.Operator != "=" && linear.Operator != "==") {
+		return "", "", false
+	}
+	if linear.Coefficients[0] == 0 || linear.Coefficients[0] != -linear.Coefficients[1] {
+		return "", "", false
+	}
+	return linear.Variables[0], linear.Variables[1], true
+}
+
+// The values of first that are also in second, in first's order
+func intersect(first []int, second []int) []int {
+	var common []int
+	for _, value := range first {
+		if containsValue(second, value) {
+			common = append(common, value)
+		}
+	}
+	return common
+}
+
+func (presolved *Presolved) name(variable string) string {
+	if representative, merged := presolved.representative[variable]; merged {
+		return representative
+	}
+	return variable
+}
+
+// The constraint over the representatives of its variables. The constraints the propagation engine and the
+// exporters know get rewritten; anything else is wrapped.
+func (presolved *Presolved) rename(constraint Constraint) Constraint {
+	touched := false
+	for _, variable := range constraint.Scope() {
+		touched = touched || presolved.name(variable) != variable
+	}
+	if !touched {
+		return constraint
+	}
+
+	switch constraint := constraint.(type) {
+	case *AllDifferent:
+		var variables []string
+		for _, variable := range constraint.Variables {
+			variables = append(variables, presolved.name(variable))
+		}
+		return NewAllDifferent(variables...)
+	case *NotEqual:
+		return NewNotEqual(presolved.name(constraint.First), presolved.name(constraint.Second))
+	case *Linear:
+		// merged variables add their coefficients together
+		var variables []string
+		coefficients := make(map[string]int)
+		for i, variable := range constraint.Variables {
+			variable = presolved.name(variable)
+			if _, seen := coefficients[variable]; !seen {
+				variables = append(variables, variable)
+			}
+			coefficients[variable] += constraint.Coefficients[i]
+		}
+		renamed := NewLinear(nil, nil, constraint.Operator, constraint.Constant)
+		for _, variable := range variables {
+			if coefficients[variable] != 0 {
+				renamed.Variables = append(renamed.Variables, variable)
+				renamed.Coefficients = append(renamed.Coefficients, coefficients[variable])
+			}
+		}
+		return renamed
+	}
+	return &renamedConstraint{constraint, presolved}
+}
+
+// A constraint whose merged variables are read through their representative
+type renamedConstraint struct {
+	Constraint
+	presolved *Presolved
+}
+
+func (renamed *renamedConstraint) Scope() []string {
+	var scope []string
+	for _, variable := range renamed.Constraint.Scope() {
+		scope = append(scope, renamed.presolved.name(variable))
+	}
+	return uniqueScope(scope)
+}
+
+func (renamed *renamedConstraint) Satisfied(assignment map[string]int) bool {
+	original := make(map[string]int)
+	for _, variable := range renamed.Constraint.Scope() {
+		if value, assigned := assignment[renamed.presolved.name(variable)]; assigned {
+			original[variable] = value
+		}
+	}
+	return renamed.Constraint.Satisfied(original)
+}
+
+// Whether every combination of values from the domains satisfies the constraint, so it can be left out. Linear,
+// NotEqual and AllDifferent are worked out from their bounds and domains; anything else has its forbidden
+// tuples listed, and counts as not entailed when there are too many combinations to do that.
+func Entailed(constraint Constraint, domains map[string][]int) bool {
+	switch constraint := constraint.(type) {
+	case *NotEqual:
+		return constraint.First != constraint.Second &&
+			len(intersect(domains[constraint.First], domains[constraint.Second])) == 0
+	case *AllDifferent:
+		for i, first := range constraint.Variables {
+			for _, second := range constraint.Variables[i+1:] {
+				if first == second || len(intersect(domains[first], domains[second])) > 0 {
+					return false
+				}
+			}
+		}
+		return true
+	case *Linear:
+		low, high := 0, 0
+		for i, variable := range constraint.Variables {
+			smallest, largest := domainBounds(domains[variable])
+			coefficient := constraint.Coefficients[i]
+			if coefficient < 0 {
+				smallest, largest = largest, smallest
+			}
+			low += coefficient * smallest
+			high += coefficient * largest
+		}
+		switch constraint.Operator {
+		case "=", "==":
+			return low == constraint.Constant && high == constraint.Constant
+		case "!=":
+			return constraint.Constant < low || constraint.Constant > high
+		case "<":
+			return high < constraint.Constant
+		case "<=":
+			return high <= constraint.Constant
+		case ">":
+			return low > constraint.Constant
+		case ">=":
+			return low >= constraint.Constant
+		}
+		return false
+	}
+	_, forbidden, ok := ForbiddenTuples(constraint, domains)
+	return ok && len(forbidden) == 0
+}
+
+// Greedily picks out groups of at least three variables that are all pairwise != and replaces their != constraints
+// with one AllDifferent. Cliques are grown from the variable with the most != left, trying its neighbours in the
+// same order, and never share a != with each other. Returns the constraints to keep followed by the new ones.
+func (presolved *Presolved) findCliques(problem *Problem, constraints []Constraint) []Constraint {
+	position := make(map[string]int)
+	for i, variable := range problem.Variables {
+		position[variable] = i
+	}
+	adjacent := make(map[string]map[string][]*NotEqual)
+	connect := func(from string, to string, constraint *NotEqual) {
+		if adjacent[from] == nil {
+			adjacent[from] = make(map[string][]*NotEqual)
+		}
+		adjacent[from][to] = append(adjacent[from][to], constraint)
+	}
+	for _, constraint := range constraints {
+		if notEqual, ok := constraint.(*NotEqual); ok && notEqual.First != notEqual.Second {
+			connect(notEqual.First, notEqual.Second, notEqual)
+			connect(notEqual.Second, notEqual.First, notEqual)
+		}
+	}
+	byDegree := func(variables []string) {
+		sort.Slice(variables, func(i, j int) bool {
+			if len(adjacent[variables[i]]) != len(adjacent[variables[j]]) {
+				return len(adjacent[variables[i]]) > len(adjacent[variables[j]])
+			}
+			return position[variables[i]] < position[variables[j]]
+		})
+	}
+
+	replaced := make(map[Constraint]bool)
+	var cliques []Constraint
+	for found := true; found; {
+		found = false
+		var vertices []string
+		for variable, neighbours := range adjacent {
+			if len(neighbours) >= 2 {
+				vertices = append(vertices, variable)
+			}
+		}
+		byDegree(vertices)
+		for _, start := range vertices {
+			var neighbours []string
+			for neighbour := range adjacent[start] {
+				neighbours = append(neighbours, neighbour)
+			}
+			byDegree(neighbours)
+			clique := []string{start}
+			for _, candidate := range neighbours {
+				inClique := true
+				for _, member := range clique {
+					_, connected := adjacent[candidate][member]
+					inClique = inClique && connected
+				}
+				if inClique {
+					clique = append(clique, candidate)
+				}
+			}
+			if len(clique) < 3 {
+				continue
+			}
+
+			for i, first := range clique {
+				for _, second := range clique[i+1:] {
+					for _, constraint := range adjacent[first][second] {
+						replaced[constraint] = true
+						presolved.Report.Replaced++
+					}
+					delete(adjacent[first], second)
+					delete(adjacent[second], first)
+				}
+			}
+			sort.Slice(clique, func(i, j int) bool { return position[clique[i]] < position[clique[j]] })
+			cliques = append(cliques, NewAllDifferent(clique...))
+			presolved.Report.Cliques++
+			found = true
+			// degrees changed, so start over from the new busiest variable
+			break
+		}
+	}
+
+	var kept []Constraint
+	for _, constraint := range constraints {
+		if !replaced[constraint] {
+			kept = append(kept, constraint)
+		}
+	}
+	return append(kept, cliques...)
+}
+
+// The solution of the original problem behind a solution of the presolved one: merged variables take their
+// representative's value
+func (presolved *Presolved) Restore(solution map[string]int) map[string]int {
+	if solution == nil {
+		return nil
+	}
+	restored := make(map[string]int)
+	for _, variable := range presolved.original.Variables {
+		if value, assigned := solution[presolved.name(variable)]; assigned {
+			restored[variable] = value
+		}
+	}
+	return restored
+}
+
+func measure(problem *Problem) ModelSize {
+	size := ModelSize{Variables: len(problem.Variables), Constraints: len(problem.Constraints)}
+	for _, variable := range problem.Variables {
+		size.DomainValues += len(problem.Domains[variable])
+		size.SearchSpace += math.Log10(float64(len(problem.Domains[variable])))
+	}
+	return size
+}
+
+// A before/after table followed by what each step did
+func (report PresolveReport) String() string {
+	if report.Infeasible {
+		return "presolve proved the problem infeasible"
+	}
+	lines := []string{fmt.Sprintf("%-14s %10s %10s", "", "before", "after")}
+	row := func(name string, before int, after int) {
+		lines = append(lines, fmt.Sprintf("%-14s %10d %10d", name, before, after))
+	}
+	row("variables", report.Before.Variables, report.After.Variables)
+	row("constraints", report.Before.Constraints, report.After.Constraints)
+	row("domain values", report.Before.DomainValues, report.After.DomainValues)
+	lines = append(lines, fmt.Sprintf("%-14s %10s %10s", "search space",
+		fmt.Sprintf("10^%.1f", report.Before.SearchSpace), fmt.Sprintf("10^%.1f", report.After.SearchSpace)))
+	lines = append(lines, fmt.Sprintf("merged variables: %d, entailed constraints: %d, cliques: %d (replacing %d !=)",
+		report.Merged, report.Entailed, report.Cliques, report.Replaced))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,64 @@
+package csp_test
+
+import (
+	"fmt"
+	"testing"
+
+	csp "github.com/GSGerritsen/go-csp"
+	"github.com/GSGerritsen/go-csp/gen"
+)
+
+func TestPresolveKeepsConsistencyLevels(t *testing.T) {
+	problem := csp.NewProblem()
+	for _, variable := range []string{"x", "y", "z"} {
+		problem.AddVariableRange(variable, 0, 9)
+	}
+	problem.DefaultConsistency = csp.BoundsConsistency
+	problem.AddConstraint(csp.NewLinear([]string{"x", "y"}, []int{1, -1}, "=", 0))
+	// merging y into x renames this one
+	sum := csp.NewLinear([]string{"y", "z"}, []int{1, 1}, "<=", 12)
+	problem.AddConstraint(sum)
+	problem.SetConsistency(sum, csp.DomainConsistency)
+
+	simplified := csp.Presolve(problem).Problem
+	if simplified.DefaultConsistency != csp.BoundsConsistency {
+		t.Errorf("default level %v, want bounds", simplified.DefaultConsistency)
+	}
+	if len(simplified.Constraints) != 1 {
+		t.Fatalf("presolved to %d constraints, want the renamed sum", len(simplified.Constraints))
+	}
+	if level := simplified.ConsistencyOf(simplified.Constraints[0]); level != csp.DomainConsistency {
+		t.Errorf("the renamed sum is at %v, want domain", level)
+	}
+}
+
+// The random instances with something for each presolve step: X1 = X2 to merge, and X3, X4, X5 pairwise != to
+// make a clique of
+func TestPresolveMatchesEnumeration(t *testing.T) {
+	for name, problem := range randomInstances() {
+		x := func(i int) string { return gen.RandomVariable(i - 1) }
+		problem.AddConstraint(csp.NewLinear([]string{x(1), x(2)}, []int{1, -1}, "=", 0))
+		problem.AddConstraint(csp.NewNotEqual(x(3), x(4)))
+		problem.AddConstraint(csp.NewNotEqual(x(4), x(5)))
+		problem.AddConstraint(csp.NewNotEqual(x(3), x(5)))
+		want := enumerate(problem)
+
+		presolved := csp.Presolve(problem)
+		if presolved.Report.Infeasible {
+			if len(want) > 0 {
+				t.Errorf("%s: presolve found it infeasible, the solver found %d solutions", name, len(want))
+			}
+			continue
+		}
+		var restored []map[string]int
+		for _, solution := range csp.NewSolver(presolved.Problem).Solve() {
+			restored = append(restored, presolved.Restore(solution))
+		}
+		if got := solutionLines(problem, restored); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s: the presolved problem has %d solutions, the original %d", name, len(got), len(want))
+		}
+		if presolved.Report.Merged == 0 {
+			t.Errorf("%s: X1 = X2 wasn't merged", name)
+		}
+	}
+}
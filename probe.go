@@ -0,0 +1,63 @@
+package csp
+
+import "math/rand"
+
+// Dives from the root ProbeDives times before the search proper, never backtracking: each variable in turn gets
+// a value, the first dive taking the first value in order and the others random ones, until an assignment fails
+// or every variable has a value. The dives count as nodes and failures like any other. What they teach the
+// search: the constraints that failed them get their weights bumped for DomainOverWeightedDegree, the values of
+// the deepest dive are tried first, and a complete assignment beating the incumbent becomes the incumbent, so
+// optimization starts out with a bound.
+func (solver *Solver) probe() {
+	solver.probed = nil
+	if solver.ProbeDives <= 0 {
+		return
+	}
+	span := solver.startSpan("csp.probe", Attr("csp.dives", solver.ProbeDives))
+	random := rand.New(rand.NewSource(solver.ProbeSeed))
+	deepest, improved := 0, 0
+	for dive := 0; dive < solver.ProbeDives && !solver.limitReached(); dive++ {
+		assignment, complete := solver.dive(random, dive == 0)
+		if len(assignment) > deepest || solver.probed == nil {
+			deepest = len(assignment)
+			solver.probed = assignment
+		}
+		if complete && solver.Problem.Optimizing() && solver.bounded(assignment, solver.Problem.Cost(assignment), nil) {
+			solver.Best = assignment
+			solver.BestObjective = assignment[solver.Problem.Objective]
+			solver.BestCost = solver.Problem.Cost(assignment)
+			solver.Solutions = append(solver.Solutions, assignment)
+			improved++
+			solver.publish()
+		}
+	}
+	span.SetAttributes(Attr("csp.deepest", deepest), Attr("csp.incumbents", improved))
+	span.End()
+}
+
+// One dive, returning how far it got and whether that's every variable
+func (solver *Solver) dive(random *rand.Rand, greedy bool) (map[string]int, bool) {
+	assignment := make(map[string]int)
+	domains := solver.domains
+	for depth := range solver.Problem.Variables {
+		variable := solver.nextVariable(depth, assignment, domains)
+		values := solver.valueOrder(variable, domains[variable])
+		value := values[0]
+		if !greedy {
+			value = values[random.Intn(len(values))]
+		}
+		solver.Nodes++
+		assignment[variable] = value
+		childDomains, propagated := solver.propagate(domains, variable, value)
+		if !propagated {
+			solver.blame(solver.engine.Failed)
+		}
+		if !propagated || solver.violated(variable, assignment) != nil {
+			solver.Failures++
+			delete(assignment, variable)
+			return assignment, false
+		}
+		domains = childDomains
+	}
+	return assignment, true
+}
@@ -0,0 +1,125 @@
+package csp
+
+import "strconv"
+
+// A Problem is a generic CSP: named variables, each with its own finite domain, and the constraints between them.
+// Variables are kept in the order they were added, which is also the order the solver assigns them in.
+// Labels is only filled in for categorical variables, where value i stands for Labels[variable][i-1].
+// Setting an Objective turns the problem into an optimization problem over that variable's value.
+// Soft constraints may be violated, at the price of their weight; solutions then minimize the total price paid.
+// Constraints are propagated at their level in Consistency, or DefaultConsistency.
+type Problem struct {
+	Variables          []string
+	Domains            map[string][]int
+	Constraints        []Constraint
+	Labels             map[string][]string
+	Objective          string
+	Maximizing         bool
+	SoftConstraints    []SoftConstraint
+	DefaultConsistency ConsistencyLevel
+	Consistency        map[Constraint]ConsistencyLevel
+}
+
+type SoftConstraint struct {
+	Constraint Constraint
+	Weight     int
+}
+
+// Problem constructor
+func NewProblem() *Problem {
+	return &Problem{nil, make(map[string][]int), nil, make(map[string][]string), "", false, nil, AutoConsistency, nil}
+}
+
+// Adding a variable that already exists just replaces its domain
+func (problem *Problem) AddVariable(name string, domain []int) {
+	if _, exists := problem.Domains[name]; !exists {
+		problem.Variables = append(problem.Variables, name)
+	}
+	values := make([]int, len(domain))
+	copy(values, domain)
+	problem.Domains[name] = values
+}
+
+// The domain's current values; later changes to it don't reach the problem
+func (problem *Problem) AddVariableDomain(name string, domain *Domain) {
+	problem.AddVariable(name, domain.Values())
+}
+
+// Convenience for the common case of a contiguous domain, low..high inclusive
+func (problem *Problem) AddVariableRange(name string, low int, high int) {
+	var domain []int
+	for value := low; value <= high; value++ {
+		domain = append(domain, value)
+	}
+	problem.AddVariable(name, domain)
+}
+
+func (problem *Problem) AddConstraint(constraint Constraint) {
+	problem.Constraints = append(problem.Constraints, constraint)
+}
+
+// Constraints are compared by identity, so this takes the same pointer that was added. Reports whether it was there.
+func (problem *Problem) RemoveConstraint(constraint Constraint) bool {
+	for i, existing := range problem.Constraints {
+		if existing == constraint {
+			problem.Constraints = append(problem.Constraints[:i:i], problem.Constraints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Adding every constraint as soft with weight 1 gives Max-CSP
+func (problem *Problem) AddSoftConstraint(constraint Constraint, weight int) {
+	problem.SoftConstraints = append(problem.SoftConstraints, SoftConstraint{constraint, weight})
+}
+
+// Total weight of the soft constraints a (complete) assignment violates
+func (problem *Problem) Cost(assignment map[string]int) int {
+	cost := 0
+	for _, soft := range problem.SoftConstraints {
+		if !soft.Constraint.Satisfied(assignment) {
+			cost += soft.Weight
+		}
+	}
+	return cost
+}
+
+// Whether the solver should keep improving on solutions instead of just collecting them
+func (problem *Problem) Optimizing() bool {
+	return problem.Objective != "" || len(problem.SoftConstraints) > 0
+}
+
+// A categorical variable takes one of a list of named values. Internally those are just 1..len(labels), so every
+// constraint works on them unchanged; the labels only matter when printing.
+func (problem *Problem) AddCategoricalVariable(name string, labels []string) {
+	problem.AddVariableRange(name, 1, len(labels))
+	problem.Labels[name] = append([]string(nil), labels...)
+}
+
+// Human readable value, using the label for categorical variables
+func (problem *Problem) FormatValue(variable string, value int) string {
+	labels := problem.Labels[variable]
+	if value >= 1 && value <= len(labels) {
+		return labels[value-1]
+	}
+	return strconv.Itoa(value)
+}
+
+func (problem *Problem) Minimize(variable string) {
+	problem.Objective = variable
+	problem.Maximizing = false
+}
+
+func (problem *Problem) Maximize(variable string) {
+	problem.Objective = variable
+	problem.Maximizing = true
+}
+
+// Whether value is strictly better than current for the problem's objective
+func (problem *Problem) Improves(value int, current int) bool {
+	if problem.Maximizing {
+		return value > current
+	}
+	return value < current
+}
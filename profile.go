@@ -0,0 +1,85 @@
+package csp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"runtime/trace"
+	"sync"
+)
+
+// Output files for the profiles main can take of a run, empty for the ones not asked for
+type Profiling struct {
+	CPUProfile    string // --cpuprofile, for go tool pprof
+	MemoryProfile string // --memprofile, the heap when the command finishes
+	Trace         string // --trace, for go tool trace
+}
+
+// Starts the CPU profile and the execution trace, returning what stops them and writes the heap profile. Only
+// the first call to stop does anything.
+func (profiling Profiling) Start() (stop func(), err error) {
+	var stops []func()
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			for i := len(stops) - 1; i >= 0; i-- {
+				stops[i]()
+			}
+		})
+	}
+	if profiling.CPUProfile != "" {
+		file, err := os.Create(profiling.CPUProfile)
+		if err != nil {
+			return stop, err
+		}
+		if err := runtimepprof.StartCPUProfile(file); err != nil {
+			file.Close()
+			return stop, err
+		}
+		stops = append(stops, func() {
+			runtimepprof.StopCPUProfile()
+			file.Close()
+		})
+	}
+	if profiling.Trace != "" {
+		file, err := os.Create(profiling.Trace)
+		if err != nil {
+			return stop, err
+		}
+		if err := trace.Start(file); err != nil {
+			file.Close()
+			return stop, err
+		}
+		stops = append(stops, func() {
+			trace.Stop()
+			file.Close()
+		})
+	}
+	if profiling.MemoryProfile != "" {
+		stops = append([]func(){func() {
+			file, err := os.Create(profiling.MemoryProfile)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return
+			}
+			defer file.Close()
+			runtime.GC()
+			if err := runtimepprof.WriteHeapProfile(file); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}}, stops...)
+	}
+	return stop, nil
+}
+
+// The net/http/pprof handlers under /debug/pprof/, for profiling a running server with go tool pprof
+func registerPprof(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+}
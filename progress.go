@@ -0,0 +1,78 @@
+package csp
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// How far along a solve is. Fraction is how much of the search tree is behind it, from 0 to 1, worked out from
+// where the search stands: at every level of the path it's on, the values already done count for their share of
+// the level, assuming the subtrees are all about as big. With a TreeEstimate from an earlier solve it's the nodes
+// so far over the estimated nodes instead. Either is the fraction of a search for every solution, so a search that
+// stops at the first one, or gets pruned by bounds, is usually done sooner. Remaining extrapolates from the time
+// so far, 0 until there's anything to go on.
+type ProgressEvent struct {
+	Fraction       float64
+	Nodes          int
+	NodesPerSecond float64
+	Elapsed        time.Duration
+	Remaining      time.Duration
+}
+
+// Such as "[#####...............] 25.0%, 12000 nodes/s, about 1m30s left"
+func (event ProgressEvent) String() string {
+	const width = 20
+	done := int(event.Fraction * width)
+	bar := "[" + strings.Repeat("#", done) + strings.Repeat(".", width-done) + "]"
+	left := "time left unknown"
+	if event.Remaining > 0 {
+		left = fmt.Sprintf("about %v left", event.Remaining.Round(time.Second))
+	}
+	return fmt.Sprintf("%s %.1f%%, %.0f nodes/s, %s", bar, 100*event.Fraction, event.NodesPerSecond, left)
+}
+
+// Calls OnProgress if there is one and ProgressInterval has passed since it was last called
+func (solver *Solver) reportProgress() {
+	if solver.OnProgress == nil {
+		return
+	}
+	now := time.Now()
+	if now.Sub(solver.lastProgress) < solver.ProgressInterval {
+		return
+	}
+	solver.lastProgress = now
+	elapsed := now.Sub(solver.started)
+	event := ProgressEvent{Fraction: solver.exploredFraction(), Nodes: solver.Nodes, Elapsed: elapsed}
+	if solver.Estimate.Nodes > 0 {
+		event.Fraction = float64(solver.Nodes) / solver.Estimate.Nodes
+	}
+	if event.Fraction > 1 {
+		event.Fraction = 1
+	}
+	if elapsed > 0 {
+		event.NodesPerSecond = float64(solver.Nodes) / elapsed.Seconds()
+	}
+	if event.Fraction > 0 {
+		if remaining := float64(elapsed) * (1 - event.Fraction) / event.Fraction; remaining < math.MaxInt64 {
+			event.Remaining = time.Duration(remaining)
+		}
+	}
+	solver.OnProgress(event)
+}
+
+// The share of the tree left of the path the search is on
+func (solver *Solver) exploredFraction() float64 {
+	fraction, share := 0.0, 1.0
+	for _, level := range solver.frontier {
+		if len(level.values) == 0 {
+			break
+		}
+		share /= float64(len(level.values))
+		if level.next > 0 {
+			fraction += float64(level.next-1) * share
+		}
+	}
+	return fraction
+}
@@ -0,0 +1,619 @@
+package csp
+
+import "container/heap"
+
+// What happened to a domain. Propagators subscribe to the kinds of change that can give them something new to do.
+type DomainEvent int
+
+const (
+	ValueRemoved  DomainEvent = 1 << iota // any value went
+	MinRaised                             // the smallest value went
+	MaxLowered                            // the largest value went
+	Assigned                              // a single value is left
+	BoundsChanged = MinRaised | MaxLowered
+	AnyEvent      = ValueRemoved | BoundsChanged | Assigned
+)
+
+// A Propagator prunes values from the domains of its constraint's scope that can't be part of any solution.
+// Propagate returns false when some domain gets wiped out. Cheap propagators should have a lower Priority so that
+// they run (and maybe fail) before the expensive ones get a turn.
+type Propagator interface {
+	Propagate(store *DomainStore) bool
+	Subscriptions() DomainEvent
+	Priority() int
+}
+
+// Propagators over many variables, like long clauses, can choose to only be woken by a few of them. The engine
+// starts them off watching InitialWatches, and they move their watches with DomainStore.WatchOnly while
+// propagating. Watches aren't undone on backtracking, so a propagator must only ever watch variables whose
+// changes it needs to hear about in any state it could backtrack to, the way two watched literals work in SAT.
+type WatchingPropagator interface {
+	Propagator
+	InitialWatches() []string
+}
+
+// Propagators that only care about some of their Subscriptions on each variable, like a linear inequality, which
+// only one bound of each term can tighten. The engine asks once, when it's built.
+type SelectivePropagator interface {
+	Propagator
+	SubscriptionsTo(variable string) DomainEvent
+}
+
+// Propagators that can work from what changed rather than rescanning whole domains. For them the engine keeps the
+// values each variable lost since they last ran, which they get at with DomainStore.Delta.
+type IncrementalPropagator interface {
+	Propagator
+	Incremental() bool
+}
+
+// The current domains during propagation. Every change goes through Restrict, which is how the engine finds out
+// which propagators to wake up.
+type DomainStore struct {
+	Domains map[string][]int
+	engine  *PropagationEngine
+	current int
+	delta   map[string][]int // for the running propagator, nil when it has to look at everything
+}
+
+func (store *DomainStore) Values(variable string) []int {
+	return store.Domains[variable]
+}
+
+func (store *DomainStore) Min(variable string) int {
+	return store.Domains[variable][0]
+}
+
+func (store *DomainStore) Max(variable string) int {
+	values := store.Domains[variable]
+	return values[len(values)-1]
+}
+
+func (store *DomainStore) Fixed(variable string) bool {
+	return len(store.Domains[variable]) == 1
+}
+
+// Shrinks a domain to the values keep returns true for. Domains are replaced, never edited in place, so a copy of
+// the map taken before propagation is a cheap snapshot to backtrack to. Returns false if nothing is left.
+func (store *DomainStore) Restrict(variable string, keep func(value int) bool) bool {
+	old := store.Domains[variable]
+	var kept []int
+	for _, value := range old {
+		if keep(value) {
+			kept = append(kept, value)
+		}
+	}
+	if len(kept) == len(old) {
+		return true
+	}
+	store.Domains[variable] = kept
+	if len(kept) == 0 {
+		return false
+	}
+
+	event := ValueRemoved
+	if kept[0] != old[0] {
+		event |= MinRaised
+	}
+	if kept[len(kept)-1] != old[len(old)-1] {
+		event |= MaxLowered
+	}
+	if len(kept) == 1 {
+		event |= Assigned
+	}
+	if store.engine != nil {
+		store.engine.recordDelta(variable, old, kept)
+		store.engine.notify(variable, event)
+	}
+	return true
+}
+
+// The values variable lost since the running IncrementalPropagator last ran, oldest first. known is false when the
+// engine can't say, the first time a propagator runs in a Fixpoint, and then the whole domain needs looking at.
+func (store *DomainStore) Delta(variable string) (removed []int, known bool) {
+	if store.delta == nil {
+		return nil, false
+	}
+	return store.delta[variable], true
+}
+
+func (store *DomainStore) Remove(variable string, value int) bool {
+	return store.Restrict(variable, func(other int) bool { return other != value })
+}
+
+// Replaces the running propagator's watches, see WatchingPropagator
+func (store *DomainStore) WatchOnly(variables ...string) {
+	if store.engine == nil || store.engine.watches[store.current] == nil {
+		return
+	}
+	watches := make(map[string]bool)
+	for _, variable := range variables {
+		watches[variable] = true
+	}
+	store.engine.watches[store.current] = watches
+}
+
+// Runs propagators until none of them has anything left to prune. Propagators wait in a priority queue, and get
+// (re)scheduled whenever a variable they watch has an event they subscribed to.
+type PropagationEngine struct {
+	Problem      *Problem
+	Propagations int
+	Failed       int // index of the constraint whose propagator last failed, -1 if the last failure had none
+
+	propagators []Propagator
+	subscribers map[string][]int
+	watches     map[int]map[string]bool
+	selective   map[int]map[string]DomainEvent // the SelectivePropagators' subscriptions to each variable
+	queue       propagationQueue
+	queued      []bool
+	incremental []int                    // indices of the IncrementalPropagators
+	deltas      map[int]map[string][]int // what each of them hasn't seen yet, nil until it has run once
+}
+
+// PropagationEngine constructor. Constraints get their propagators from propagatorFor, at the consistency level
+// the problem has for them.
+func NewPropagationEngine(problem *Problem) *PropagationEngine {
+	engine := &PropagationEngine{Problem: problem, Failed: -1, subscribers: make(map[string][]int),
+		watches: make(map[int]map[string]bool), selective: make(map[int]map[string]DomainEvent)}
+	for i, constraint := range problem.Constraints {
+		propagator := propagatorFor(constraint, problem.ConsistencyOf(constraint))
+		if watching, ok := propagator.(WatchingPropagator); ok {
+			engine.watches[i] = make(map[string]bool)
+			for _, variable := range watching.InitialWatches() {
+				engine.watches[i][variable] = true
+			}
+		}
+		if incremental, ok := propagator.(IncrementalPropagator); ok && incremental.Incremental() {
+			engine.incremental = append(engine.incremental, i)
+		}
+		engine.propagators = append(engine.propagators, propagator)
+		selective, isSelective := propagator.(SelectivePropagator)
+		if isSelective {
+			engine.selective[i] = make(map[string]DomainEvent)
+		}
+		for _, variable := range uniqueScope(constraint.Scope()) {
+			engine.subscribers[variable] = append(engine.subscribers[variable], i)
+			if isSelective {
+				engine.selective[i][variable] = selective.SubscriptionsTo(variable)
+			}
+		}
+	}
+	engine.queued = make([]bool, len(engine.propagators))
+	engine.deltas = make(map[int]map[string][]int)
+	return engine
+}
+
+// Propagates everything from scratch
+func (engine *PropagationEngine) Fixpoint(domains map[string][]int) bool {
+	for _, i := range engine.incremental {
+		engine.deltas[i] = nil
+	}
+	for i := range engine.propagators {
+		engine.schedule(i)
+	}
+	return engine.run(domains)
+}
+
+// Narrows variable down to value and propagates the consequences
+func (engine *PropagationEngine) Assign(domains map[string][]int, variable string, value int) bool {
+	// the domains were at a fixpoint, so only what goes from here on is news to anyone
+	for _, i := range engine.incremental {
+		engine.deltas[i] = make(map[string][]int)
+	}
+	store := &DomainStore{domains, engine, -1, nil}
+	engine.Failed = -1
+	if !store.Restrict(variable, func(other int) bool { return other == value }) {
+		engine.clear()
+		return false
+	}
+	return engine.run(domains)
+}
+
+func (engine *PropagationEngine) run(domains map[string][]int) bool {
+	store := &DomainStore{domains, engine, -1, nil}
+	for engine.queue.Len() > 0 {
+		i := heap.Pop(&engine.queue).(queuedPropagator).index
+		engine.queued[i] = false
+		engine.Propagations++
+		store.current = i
+		store.delta = nil
+		if delta, incremental := engine.deltas[i]; incremental {
+			store.delta = delta
+			engine.deltas[i] = make(map[string][]int)
+		}
+		if !engine.propagators[i].Propagate(store) {
+			engine.Failed = i
+			engine.clear()
+			return false
+		}
+	}
+	return true
+}
+
+// Adds the values that went from old to the deltas of the incremental propagators on variable
+func (engine *PropagationEngine) recordDelta(variable string, old []int, kept []int) {
+	if len(engine.incremental) == 0 {
+		return
+	}
+	for _, i := range engine.subscribers[variable] {
+		delta := engine.deltas[i]
+		if delta == nil {
+			continue
+		}
+		next := 0
+		for _, value := range old {
+			if next < len(kept) && kept[next] == value {
+				next++
+			} else {
+				delta[variable] = append(delta[variable], value)
+			}
+		}
+	}
+}
+
+func (engine *PropagationEngine) notify(variable string, event DomainEvent) {
+	for _, i := range engine.subscribers[variable] {
+		if watches := engine.watches[i]; watches != nil && !watches[variable] {
+			continue
+		}
+		subscriptions := engine.propagators[i].Subscriptions()
+		if selective := engine.selective[i]; selective != nil {
+			subscriptions = selective[variable]
+		}
+		if subscriptions&event != 0 {
+			engine.schedule(i)
+		}
+	}
+}
+
+func (engine *PropagationEngine) schedule(i int) {
+	if !engine.queued[i] {
+		engine.queued[i] = true
+		heap.Push(&engine.queue, queuedPropagator{i, engine.propagators[i].Priority()})
+	}
+}
+
+func (engine *PropagationEngine) clear() {
+	for engine.queue.Len() > 0 {
+		engine.queued[heap.Pop(&engine.queue).(queuedPropagator).index] = false
+	}
+}
+
+type queuedPropagator struct {
+	index    int
+	priority int
+}
+
+// Lowest priority first, then first come first served
+type propagationQueue []queuedPropagator
+
+func (queue propagationQueue) Len() int { return len(queue) }
+func (queue propagationQueue) Less(i, j int) bool {
+	if queue[i].priority != queue[j].priority {
+		return queue[i].priority < queue[j].priority
+	}
+	return queue[i].index < queue[j].index
+}
+func (queue propagationQueue) Swap(i, j int)       { queue[i], queue[j] = queue[j], queue[i] }
+func (queue *propagationQueue) Push(x interface{}) { *queue = append(*queue, x.(queuedPropagator)) }
+func (queue *propagationQueue) Pop() interface{} {
+	old := *queue
+	item := old[len(old)-1]
+	*queue = old[:len(old)-1]
+	return item
+}
+
+//-------------- PROPAGATORS -------------------//
+
+// Fallback for constraints that don't know how to propagate themselves. Once at most one variable of the scope is
+// left open this is forward checking, which is always cheap. Before that it does full arc consistency, but only
+// when the scope's domains are small enough for the support search to be affordable. A Level other than
+// AutoConsistency changes that: ForwardChecking stops at forward checking, BoundsConsistency only looks for
+// supports of each domain's ends, and DomainConsistency does arc consistency whatever the domains' size.
+type GenericPropagator struct {
+	Constraint Constraint
+	Level      ConsistencyLevel
+}
+
+const genericPropagationLimit = 4096
+
+func (propagator *GenericPropagator) Subscriptions() DomainEvent { return ValueRemoved }
+func (propagator *GenericPropagator) Priority() int              { return 3 }
+
+func (propagator *GenericPropagator) Propagate(store *DomainStore) bool {
+	scope := uniqueScope(propagator.Constraint.Scope())
+	level := propagator.Level
+	if filter, ok := propagator.Constraint.(DomainFilter); ok && (level == AutoConsistency || level == DomainConsistency) {
+		if supported, ok := filter.Filter(store.Domains); ok {
+			for _, variable := range scope {
+				allowed := make(map[int]bool)
+				for _, value := range supported[variable] {
+					allowed[value] = true
+				}
+				if !store.Restrict(variable, func(value int) bool { return allowed[value] }) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+
+	var open []string
+	size := 1
+	for _, variable := range scope {
+		if !store.Fixed(variable) {
+			open = append(open, variable)
+		}
+		if size <= genericPropagationLimit {
+			size *= len(store.Values(variable))
+		}
+	}
+	switch {
+	case len(open) > 1 && level == ForwardChecking:
+		return true
+	case len(open) > 1 && level == AutoConsistency && size > genericPropagationLimit:
+		return true
+	case len(open) > 1 && level == BoundsConsistency:
+		return propagator.bounds(store, scope)
+	}
+	for _, variable := range scope {
+		if !store.Restrict(variable, func(value int) bool {
+			return HasSupport(propagator.Constraint, variable, value, store.Domains)
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// Drops values from either end of each domain until the ends have supports
+func (propagator *GenericPropagator) bounds(store *DomainStore, scope []string) bool {
+	for _, variable := range scope {
+		for !HasSupport(propagator.Constraint, variable, store.Min(variable), store.Domains) {
+			if !store.Remove(variable, store.Min(variable)) {
+				return false
+			}
+		}
+		for !HasSupport(propagator.Constraint, variable, store.Max(variable), store.Domains) {
+			if !store.Remove(variable, store.Max(variable)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Once either side is fixed, its value goes from the other side
+func (constraint *NotEqual) Subscriptions() DomainEvent { return Assigned }
+func (constraint *NotEqual) Priority() int              { return 0 }
+
+func (constraint *NotEqual) Propagate(store *DomainStore) bool {
+	if store.Fixed(constraint.First) && !store.Remove(constraint.Second, store.Min(constraint.First)) {
+		return false
+	}
+	if store.Fixed(constraint.Second) && !store.Remove(constraint.First, store.Min(constraint.Second)) {
+		return false
+	}
+	return true
+}
+
+// Forward checking: every fixed variable's value goes from all the others. Only the variables that got fixed since
+// the last run have anything new to take away, so those are all it looks at when the engine keeps a delta.
+func (constraint *AllDifferent) Subscriptions() DomainEvent { return Assigned }
+func (constraint *AllDifferent) Priority() int              { return 1 }
+func (constraint *AllDifferent) Incremental() bool          { return true }
+
+func (constraint *AllDifferent) Propagate(store *DomainStore) bool {
+	var worklist []string
+	for _, variable := range constraint.Variables {
+		if !store.Fixed(variable) {
+			continue
+		}
+		if removed, known := store.Delta(variable); !known || len(removed) > 0 {
+			worklist = append(worklist, variable)
+		}
+	}
+	for len(worklist) > 0 {
+		fixed := worklist[len(worklist)-1]
+		worklist = worklist[:len(worklist)-1]
+		value := store.Min(fixed)
+		for _, other := range constraint.Variables {
+			if other == fixed || len(store.Values(other)) == 0 {
+				continue
+			}
+			before := len(store.Values(other))
+			if !store.Remove(other, value) {
+				return false
+			}
+			if before == 2 && store.Fixed(other) {
+				worklist = append(worklist, other)
+			}
+		}
+	}
+	return true
+}
+
+// Bounds propagation: each term is squeezed by the smallest and largest values the rest of the sum can take
+func (constraint *Linear) Subscriptions() DomainEvent { return BoundsChanged }
+func (constraint *Linear) Priority() int              { return 2 }
+
+// A sum with an upper limit can only be tightened by a term's smallest value going up, which is its variable's
+// min or, with a negative coefficient, its max; a lower limit the other way round. != waits for the search.
+func (constraint *Linear) SubscriptionsTo(variable string) DomainEvent {
+	coefficients, _ := constraint.terms()
+	lower, upper, ok := constraint.limits()
+	if !ok || coefficients[variable] == 0 {
+		return 0
+	}
+	raises, lowers := MinRaised, MaxLowered // what moves the term's smallest and largest value
+	if coefficients[variable] < 0 {
+		raises, lowers = MaxLowered, MinRaised
+	}
+	var events DomainEvent
+	if upper != noLimit {
+		events |= raises
+	}
+	if lower != -noLimit {
+		events |= lowers
+	}
+	return events
+}
+
+// Beyond any sum a model can reach
+const noLimit = 1 << 62
+
+// The coefficient of each variable, a variable mentioned twice being one term with the coefficients added up, and
+// the variables in order of first mention
+func (constraint *Linear) terms() (map[string]int, []string) {
+	coefficients := make(map[string]int)
+	var variables []string
+	for i, variable := range constraint.Variables {
+		if _, seen := coefficients[variable]; !seen {
+			variables = append(variables, variable)
+		}
+		coefficients[variable] += constraint.Coefficients[i]
+	}
+	return coefficients, variables
+}
+
+// The range the sum has to lie in, noLimit on an open side. ok is false for !=, which doesn't give one.
+func (constraint *Linear) limits() (lower int, upper int, ok bool) {
+	switch constraint.Operator {
+	case "=", "==":
+		return constraint.Constant, constraint.Constant, true
+	case "<=":
+		return -noLimit, constraint.Constant, true
+	case "<":
+		return -noLimit, constraint.Constant - 1, true
+	case ">=":
+		return constraint.Constant, noLimit, true
+	case ">":
+		return constraint.Constant + 1, noLimit, true
+	}
+	return 0, 0, false
+}
+
+func (constraint *Linear) Propagate(store *DomainStore) bool {
+	coefficients, variables := constraint.terms()
+	termMin := func(variable string) int {
+		if coefficients[variable] >= 0 {
+			return coefficients[variable] * store.Min(variable)
+		}
+		return coefficients[variable] * store.Max(variable)
+	}
+	termMax := func(variable string) int {
+		if coefficients[variable] >= 0 {
+			return coefficients[variable] * store.Max(variable)
+		}
+		return coefficients[variable] * store.Min(variable)
+	}
+
+	lower, upper, ok := constraint.limits()
+	if !ok {
+		// != can only prune once everything else is fixed, which the search's checks already cover
+		return true
+	}
+
+	for changed := true; changed; {
+		changed = false
+		sumMin, sumMax := 0, 0
+		for _, variable := range variables {
+			sumMin += termMin(variable)
+			sumMax += termMax(variable)
+		}
+		if sumMin > upper || sumMax < lower {
+			return false
+		}
+		for _, variable := range variables {
+			coefficient := coefficients[variable]
+			if coefficient == 0 {
+				continue
+			}
+			// coefficient * x must lie within [lower - (sumMax - termMax), upper - (sumMin - termMin)]
+			termLow := lower - (sumMax - termMax(variable))
+			termHigh := upper - (sumMin - termMin(variable))
+			low, high := divCeil(termLow, coefficient), divFloor(termHigh, coefficient)
+			if coefficient < 0 {
+				low, high = divCeil(termHigh, coefficient), divFloor(termLow, coefficient)
+			}
+			minBefore, maxBefore := termMin(variable), termMax(variable)
+			if !store.Restrict(variable, func(value int) bool { return value >= low && value <= high }) {
+				return false
+			}
+			// another pass only helps if a sum the limits are checked against moved
+			if upper != noLimit && termMin(variable) != minBefore ||
+				lower != -noLimit && termMax(variable) != maxBefore {
+				changed = true
+			}
+		}
+	}
+	return true
+}
+
+// Integer division rounding towards negative and positive infinity, for bounds that can be negative
+func divFloor(a int, b int) int {
+	quotient := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		quotient--
+	}
+	return quotient
+}
+
+func divCeil(a int, b int) int {
+	quotient := a / b
+	if (a%b != 0) && ((a < 0) == (b < 0)) {
+		quotient++
+	}
+	return quotient
+}
+
+// Two watched variables: the clause only needs waking when one of the two literals it relies on might have become
+// false. When that happens it looks for other literals that can still be true to watch instead; with just one left
+// that literal is forced, and with none the clause fails.
+func (constraint *Clause) Subscriptions() DomainEvent { return ValueRemoved }
+func (constraint *Clause) Priority() int              { return 0 }
+
+func (constraint *Clause) InitialWatches() []string {
+	var watches []string
+	for _, literal := range constraint.Literals {
+		if len(watches) == 2 {
+			break
+		}
+		if len(watches) == 0 || watches[0] != literal.Variable {
+			watches = append(watches, literal.Variable)
+		}
+	}
+	return watches
+}
+
+func (constraint *Clause) Propagate(store *DomainStore) bool {
+	var open []Literal
+	var watches []string
+	for _, literal := range constraint.Literals {
+		switch literal.Status(store.Values(literal.Variable)) {
+		case LiteralTrue:
+			return true
+		case LiteralOpen:
+			open = append(open, literal)
+			if len(watches) < 2 && (len(watches) == 0 || watches[0] != literal.Variable) {
+				watches = append(watches, literal.Variable)
+			}
+		}
+	}
+	if len(open) == 0 {
+		return false
+	}
+	if len(watches) == 2 {
+		store.WatchOnly(watches...)
+		return true
+	}
+
+	// every open literal is on the same variable: at least one of them has to hold
+	return store.Restrict(watches[0], func(value int) bool {
+		for _, literal := range open {
+			if literal.Holds(value) {
+				return true
+			}
+		}
+		return false
+	})
+}
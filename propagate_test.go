@@ -0,0 +1,29 @@
+package csp
+
+import "testing"
+
+// x + y <= 12 can only be tightened by a smallest value going up, so losing a largest value mustn't wake it
+func TestLinearWakesOnTighteningBounds(t *testing.T) {
+	problem := NewProblem()
+	problem.AddVariableRange("x", 0, 9)
+	problem.AddVariableRange("y", 0, 9)
+	problem.AddConstraint(NewLinear([]string{"x", "y"}, []int{1, 1}, "<=", 12))
+	engine := NewPropagationEngine(problem)
+	domains := map[string][]int{"x": problem.Domains["x"], "y": problem.Domains["y"]}
+	if !engine.Fixpoint(domains) {
+		t.Fatal("fixpoint failed")
+	}
+
+	before := engine.Propagations
+	if !engine.Assign(domains, "x", 0) || engine.Propagations != before {
+		t.Errorf("lowering x's max ran %d propagations, want none", engine.Propagations-before)
+	}
+	domains["x"] = problem.Domains["x"]
+	before = engine.Propagations
+	if !engine.Assign(domains, "x", 9) || engine.Propagations == before {
+		t.Error("raising x's min didn't propagate")
+	}
+	if max := domains["y"][len(domains["y"])-1]; max != 3 {
+		t.Errorf("y's max is %d after x = 9, want 3", max)
+	}
+}
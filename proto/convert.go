@@ -0,0 +1,269 @@
+package csppb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	csp "github.com/GSGerritsen/go-csp"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// The JSON model file's layout, see csp.LoadModel
+type jsonModel struct {
+	Variables   []jsonVariable           `json:"variables"`
+	Constraints []map[string]interface{} `json:"constraints"`
+	Minimize    string                   `json:"minimize,omitempty"`
+	Maximize    string                   `json:"maximize,omitempty"`
+	Consistency string                   `json:"consistency,omitempty"`
+}
+
+type jsonVariable struct {
+	Name   string   `json:"name"`
+	Domain []int64  `json:"domain,omitempty"`
+	Range  []int64  `json:"range,omitempty"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+// The fields of a constraint that have their own field in the message, everything else being a param
+var constraintFields = []string{"type", "variables", "weight", "strength", "consistency"}
+
+// The Problem message of a JSON model file
+func ProblemFromJSON(model []byte) (*Problem, error) {
+	var decoded jsonModel
+	decoder := json.NewDecoder(bytes.NewReader(model))
+	decoder.DisallowUnknownFields()
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+	problem := &Problem{Minimize: decoded.Minimize, Maximize: decoded.Maximize, Consistency: decoded.Consistency}
+	for _, variable := range decoded.Variables {
+		message := &Variable{Name: variable.Name}
+		switch {
+		case variable.Labels != nil:
+			message.Values = &Variable_Labels{&Labels{Labels: variable.Labels}}
+		case variable.Range != nil:
+			if len(variable.Range) != 2 {
+				return nil, fmt.Errorf("variable %q: range must be [low, high]", variable.Name)
+			}
+			message.Values = &Variable_Range{&Range{Low: variable.Range[0], High: variable.Range[1]}}
+		default:
+			message.Values = &Variable_Domain{&Domain{Values: variable.Domain}}
+		}
+		problem.Variables = append(problem.Variables, message)
+	}
+	for i, fields := range decoded.Constraints {
+		constraint, err := constraintFromJSON(fields)
+		if err != nil {
+			return nil, fmt.Errorf("constraint %d: %v", i+1, err)
+		}
+		problem.Constraints = append(problem.Constraints, constraint)
+	}
+	return problem, nil
+}
+
+func constraintFromJSON(fields map[string]interface{}) (*Constraint, error) {
+	constraint := &Constraint{}
+	var ok bool
+	if constraint.Type, ok = fields["type"].(string); !ok {
+		return nil, fmt.Errorf("missing type")
+	}
+	if variables, exists := fields["variables"].([]interface{}); exists {
+		for _, variable := range variables {
+			name, ok := variable.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: variables must be names", constraint.Type)
+			}
+			constraint.Variables = append(constraint.Variables, name)
+		}
+	}
+	if weight, exists := fields["weight"].(json.Number); exists {
+		var err error
+		if constraint.Weight, err = weight.Int64(); err != nil {
+			return nil, fmt.Errorf("%s: weight must be an integer", constraint.Type)
+		}
+	}
+	constraint.Strength, _ = fields["strength"].(string)
+	constraint.Consistency, _ = fields["consistency"].(string)
+
+	params := make(map[string]interface{})
+	for key, value := range fields {
+		params[key] = value
+	}
+	for _, field := range constraintFields {
+		delete(params, field)
+	}
+	if len(params) > 0 {
+		// structpb wants float64 for numbers, which holds any integer a model would sensibly use
+		var err error
+		if constraint.Params, err = structpb.NewStruct(plainNumbers(params).(map[string]interface{})); err != nil {
+			return nil, fmt.Errorf("%s: %v", constraint.Type, err)
+		}
+	}
+	return constraint, nil
+}
+
+// value with every json.Number turned into a float64
+func plainNumbers(value interface{}) interface{} {
+	switch value := value.(type) {
+	case json.Number:
+		number, _ := value.Float64()
+		return number
+	case []interface{}:
+		plain := make([]interface{}, len(value))
+		for i, element := range value {
+			plain[i] = plainNumbers(element)
+		}
+		return plain
+	case map[string]interface{}:
+		plain := make(map[string]interface{}, len(value))
+		for key, element := range value {
+			plain[key] = plainNumbers(element)
+		}
+		return plain
+	}
+	return value
+}
+
+// The JSON model file the message stands for, which csp.LoadModel reads
+func (problem *Problem) ModelJSON() ([]byte, error) {
+	model := jsonModel{Variables: []jsonVariable{}, Constraints: []map[string]interface{}{},
+		Minimize: problem.GetMinimize(), Maximize: problem.GetMaximize(), Consistency: problem.GetConsistency()}
+	for _, variable := range problem.GetVariables() {
+		decoded := jsonVariable{Name: variable.GetName()}
+		switch values := variable.GetValues().(type) {
+		case *Variable_Labels:
+			decoded.Labels = values.Labels.GetLabels()
+		case *Variable_Range:
+			decoded.Range = []int64{values.Range.GetLow(), values.Range.GetHigh()}
+		case *Variable_Domain:
+			decoded.Domain = values.Domain.GetValues()
+		}
+		model.Variables = append(model.Variables, decoded)
+	}
+	for _, constraint := range problem.GetConstraints() {
+		fields := constraint.GetParams().AsMap()
+		fields["type"] = constraint.GetType()
+		if constraint.GetVariables() != nil {
+			fields["variables"] = constraint.GetVariables()
+		}
+		if constraint.GetWeight() != 0 {
+			fields["weight"] = constraint.GetWeight()
+		}
+		if constraint.GetStrength() != "" {
+			fields["strength"] = constraint.GetStrength()
+		}
+		if constraint.GetConsistency() != "" {
+			fields["consistency"] = constraint.GetConsistency()
+		}
+		model.Constraints = append(model.Constraints, fields)
+	}
+	return json.Marshal(model)
+}
+
+// Builds the model the message stands for, see csp.LoadModel
+func (problem *Problem) Load() (*csp.Problem, error) {
+	model, err := problem.ModelJSON()
+	if err != nil {
+		return nil, err
+	}
+	return csp.LoadModel(bytes.NewReader(model))
+}
+
+// The options for csp.SolveJSON
+func (options *SolveOptions) Options() csp.SolveOptions {
+	return csp.SolveOptions{MaxSolutions: int(options.GetMaxSolutions()), Timeout: options.GetTimeout(),
+		Strategy: options.GetStrategy(), Score: options.GetScore(), Top: int(options.GetTop())}
+}
+
+// The SolveOptions message of csp.SolveOptions
+func NewSolveOptions(options csp.SolveOptions) *SolveOptions {
+	return &SolveOptions{MaxSolutions: int64(options.MaxSolutions), Timeout: options.Timeout,
+		Strategy: options.Strategy, Score: options.Score, Top: int64(options.Top)}
+}
+
+// The SolveResult message of a csp.SolveResult. A missing score becomes NaN, which Result turns back into a missing
+// one.
+func NewSolveResult(result csp.SolveResult) (*SolveResult, error) {
+	message := &SolveResult{Model: result.Model, Status: result.Status, Nodes: int64(result.Nodes),
+		Failures: int64(result.Failures), Seconds: result.Seconds, Error: result.Error}
+	for _, solution := range result.Solutions {
+		values := make(map[string]*Value, len(solution))
+		for variable, value := range solution {
+			switch value := value.(type) {
+			case int:
+				values[variable] = &Value{Value: &Value_Number{int64(value)}}
+			case float64:
+				values[variable] = &Value{Value: &Value_Number{int64(value)}}
+			case string:
+				values[variable] = &Value{Value: &Value_Label{value}}
+			default:
+				return nil, fmt.Errorf("variable %q has a value of type %T", variable, value)
+			}
+		}
+		message.Solutions = append(message.Solutions, &Solution{Values: values})
+	}
+	for _, score := range result.Scores {
+		if score == nil {
+			message.Scores = append(message.Scores, math.NaN())
+		} else {
+			message.Scores = append(message.Scores, *score)
+		}
+	}
+	if result.Objective != nil {
+		objective := int64(*result.Objective)
+		message.Objective = &objective
+	}
+	if result.Cost != nil {
+		cost := int64(*result.Cost)
+		message.Cost = &cost
+	}
+	if estimate := result.Estimate; estimate != nil {
+		message.Estimate = &TreeEstimate{Probes: int64(estimate.Probes), Nodes: estimate.Nodes,
+			StdErr: estimate.StdErr, Solutions: estimate.Solutions, Depth: estimate.Depth, Seconds: estimate.Seconds}
+	}
+	return message, nil
+}
+
+// The csp.SolveResult the message stands for, with the values of a solution as ints and labels
+func (result *SolveResult) Result() csp.SolveResult {
+	converted := csp.SolveResult{Model: result.GetModel(), Status: result.GetStatus(),
+		Solutions: []map[string]interface{}{}, Nodes: int(result.GetNodes()), Failures: int(result.GetFailures()),
+		Seconds: result.GetSeconds(), Error: result.GetError()}
+	for _, solution := range result.GetSolutions() {
+		values := make(map[string]interface{}, len(solution.GetValues()))
+		for variable, value := range solution.GetValues() {
+			switch value := value.GetValue().(type) {
+			case *Value_Number:
+				values[variable] = int(value.Number)
+			case *Value_Label:
+				values[variable] = value.Label
+			}
+		}
+		converted.Solutions = append(converted.Solutions, values)
+	}
+	for _, score := range result.GetScores() {
+		score := score
+		if math.IsInf(score, 0) || math.IsNaN(score) {
+			converted.Scores = append(converted.Scores, nil)
+		} else {
+			converted.Scores = append(converted.Scores, &score)
+		}
+	}
+	if result.Objective != nil {
+		objective := int(result.GetObjective())
+		converted.Objective = &objective
+	}
+	if result.Cost != nil {
+		cost := int(result.GetCost())
+		converted.Cost = &cost
+	}
+	if estimate := result.GetEstimate(); estimate != nil {
+		converted.Estimate = &csp.TreeEstimate{Probes: int(estimate.GetProbes()), Nodes: estimate.GetNodes(),
+			StdErr: estimate.GetStdErr(), Solutions: estimate.GetSolutions(), Depth: estimate.GetDepth(),
+			Seconds: estimate.GetSeconds()}
+	}
+	return converted
+}
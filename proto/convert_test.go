@@ -0,0 +1,77 @@
+package csppb
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+
+	csp "github.com/GSGerritsen/go-csp"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestModelRoundTripSolvesTheSame(t *testing.T) {
+	model, err := os.ReadFile("../examples/model.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	problem, err := ProblemFromJSON(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wire, err := proto.Marshal(problem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &Problem{}
+	if err := proto.Unmarshal(wire, decoded); err != nil {
+		t.Fatal(err)
+	}
+	roundTripped, err := decoded.ModelJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decoded.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	solve := func(model []byte) csp.SolveResult {
+		var result csp.SolveResult
+		if err := json.Unmarshal(csp.SolveJSON(model, csp.SolveOptions{}), &result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	want, got := solve(model), solve(roundTripped)
+	if want.Status != got.Status || !reflect.DeepEqual(want.Solutions, got.Solutions) {
+		t.Errorf("the round-tripped model solved to %s %v, the original to %s %v", got.Status, got.Solutions,
+			want.Status, want.Solutions)
+	}
+}
+
+func TestSolveResultRoundTrip(t *testing.T) {
+	score, cost, objective := 2.5, 3, -4
+	result := csp.SolveResult{Model: "m", Status: "optimal",
+		Solutions: []map[string]interface{}{{"A": 1, "Shift": "late"}, {"A": 2, "Shift": "early"}},
+		Scores:    []*float64{&score, nil}, Objective: &objective, Cost: &cost, Nodes: 10, Failures: 4, Seconds: 0.5,
+		Estimate: &csp.TreeEstimate{Probes: 3, Nodes: 12.5, StdErr: 1, Solutions: 2, Depth: 4, Seconds: 0.1}}
+	message, err := NewSolveResult(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !math.IsNaN(message.Scores[1]) {
+		t.Errorf("a missing score became %v, want NaN", message.Scores[1])
+	}
+	wire, err := proto.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &SolveResult{}
+	if err := proto.Unmarshal(wire, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.Result(); !reflect.DeepEqual(got, result) {
+		t.Errorf("round trip gave %+v, want %+v", got, result)
+	}
+}
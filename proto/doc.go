@@ -0,0 +1,5 @@
+// Package csppb holds the protocol buffer messages of csp.proto, generated by protoc-gen-go, and conversions
+// between them and the JSON models and SolveResults the csp package reads and writes.
+package csppb
+
+//go:generate protoc -I.. --go_out=.. --go_opt=paths=source_relative ../proto/csp.proto
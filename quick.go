@@ -0,0 +1,45 @@
+package csp
+
+import "math/big"
+
+// One-call solving for when the defaults will do. Every helper propagates and branches on the smallest domain
+// first; options come after those and can change them, or set limits.
+
+// A solution, the best one when the problem has something to optimize, or false when there's none. A limit set
+// by the options stopping the search first also gives false; a Solver's Status tells the two apart.
+func SolveOne(problem *Problem, options ...SolverOption) (map[string]int, bool) {
+	solver := quickSolver(problem, options)
+	solver.MaxSolutions = 1
+	solutions := solver.Solve()
+	if problem.Optimizing() {
+		return solver.Best, solver.Best != nil
+	}
+	if len(solutions) == 0 {
+		return nil, false
+	}
+	return solutions[0], true
+}
+
+// Every solution of the hard constraints, or the first WithMaxSolutions of them, sorted by value in the order of
+// the problem's variables. Objectives and soft constraints are left out, so this lists the feasible assignments,
+// not just the best; see SolveOne for that.
+func AllSolutions(problem *Problem, options ...SolverOption) []map[string]int {
+	if problem.Optimizing() {
+		feasibility := *problem
+		feasibility.Objective, feasibility.SoftConstraints = "", nil
+		problem = &feasibility
+	}
+	solver := quickSolver(problem, options)
+	solver.Sorted = true
+	return solver.Solve()
+}
+
+// How many solutions the hard constraints have, counted without listing them, see Counter
+func CountSolutions(problem *Problem) *big.Int {
+	return NewCounter(problem).Count()
+}
+
+func quickSolver(problem *Problem, options []SolverOption) *Solver {
+	defaults := []SolverOption{WithPropagation(), WithHeuristic(MinimumRemainingValues)}
+	return NewSolver(problem, append(defaults, options...)...)
+}
@@ -0,0 +1,37 @@
+package csp_test
+
+import (
+	"fmt"
+	"sort"
+
+	csp "github.com/GSGerritsen/go-csp"
+	"github.com/GSGerritsen/go-csp/gen"
+)
+
+// Small random binary instances, from loose and satisfiable to tight and mostly not, for cross-checking the
+// engines that don't search the way the Solver does
+func randomInstances() map[string]*csp.Problem {
+	instances := make(map[string]*csp.Problem)
+	for seed := int64(1); seed <= 6; seed++ {
+		for _, tightness := range []float64{0.3, 0.45, 0.6} {
+			n, d, density := 5+int(seed)%3, 3+int(seed)%2, 0.3+0.1*float64(seed%4)
+			name := fmt.Sprintf("n=%d d=%d density=%.1f tightness=%.1f seed=%d", n, d, density, tightness, seed)
+			instances[name] = gen.RandomBinaryCSP(n, d, density, tightness, seed)
+		}
+	}
+	return instances
+}
+
+// Every solution the plain Solver finds, as sorted FormatSolution lines
+func enumerate(problem *csp.Problem) []string {
+	return solutionLines(problem, csp.NewSolver(problem).Solve())
+}
+
+func solutionLines(problem *csp.Problem, solutions []map[string]int) []string {
+	lines := []string{}
+	for _, solution := range solutions {
+		lines = append(lines, csp.FormatSolution(problem, solution))
+	}
+	sort.Strings(lines)
+	return lines
+}
@@ -0,0 +1,107 @@
+package csp
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// Scores a complete assignment for Solver.Score, higher being better
+type ScoreFunc func(solution map[string]int) float64
+
+// A score written as an infix expression over the variables, with the same syntax as an expression constraint,
+// like "2*A + B" or "-|A - B|". An assignment it can't evaluate, dividing by zero say, scores -Inf.
+func ParseScore(source string) (ScoreFunc, error) {
+	tokens, err := tokenizeExpression(source)
+	if err != nil {
+		return nil, err
+	}
+	root, err := (&expressionParser{source: source, tokens: tokens}).parse()
+	if err != nil {
+		return nil, err
+	}
+	return func(solution map[string]int) float64 {
+		value, ok := root.evaluate(solution)
+		if !ok {
+			return math.Inf(-1)
+		}
+		return float64(value)
+	}, nil
+}
+
+type rankedSolution struct {
+	solution map[string]int
+	score    float64
+	found    int
+}
+
+// The best solutions so far, the worst of them on top so that it's the first to go when the heap is full. Of two
+// solutions with the same score the one found first ranks higher.
+type solutionHeap struct {
+	limit     int // 0 for no limit
+	found     int
+	solutions []rankedSolution
+}
+
+func (ranked *solutionHeap) Len() int { return len(ranked.solutions) }
+func (ranked *solutionHeap) Less(i, j int) bool {
+	return ranked.better(ranked.solutions[j], ranked.solutions[i])
+}
+func (ranked *solutionHeap) Swap(i, j int) {
+	ranked.solutions[i], ranked.solutions[j] = ranked.solutions[j], ranked.solutions[i]
+}
+func (ranked *solutionHeap) Push(x interface{}) {
+	ranked.solutions = append(ranked.solutions, x.(rankedSolution))
+}
+func (ranked *solutionHeap) Pop() interface{} {
+	last := ranked.solutions[len(ranked.solutions)-1]
+	ranked.solutions = ranked.solutions[:len(ranked.solutions)-1]
+	return last
+}
+
+func (ranked *solutionHeap) better(a, b rankedSolution) bool {
+	if a.score != b.score {
+		return a.score > b.score
+	}
+	return a.found < b.found
+}
+
+func (ranked *solutionHeap) add(solution map[string]int, score float64) {
+	candidate := rankedSolution{solution, score, ranked.found}
+	ranked.found++
+	switch {
+	case ranked.limit == 0 || ranked.Len() < ranked.limit:
+		heap.Push(ranked, candidate)
+	case ranked.better(candidate, ranked.solutions[0]):
+		ranked.solutions[0] = candidate
+		heap.Fix(ranked, 0)
+	}
+}
+
+// The solutions kept, best first, with their scores
+func (ranked *solutionHeap) sorted() ([]map[string]int, []float64) {
+	kept := append([]rankedSolution(nil), ranked.solutions...)
+	sort.Slice(kept, func(i, j int) bool { return ranked.better(kept[i], kept[j]) })
+	solutions := make([]map[string]int, len(kept))
+	scores := make([]float64, len(kept))
+	for i, entry := range kept {
+		solutions[i], scores[i] = entry.solution, entry.score
+	}
+	return solutions, scores
+}
+
+// Whether Solve ranks its solutions, see Solver.Score
+func (solver *Solver) ranking() bool {
+	return solver.Score != nil && !solver.Problem.Optimizing()
+}
+
+// Puts Solutions in their final order once the search is over: the ranked solutions best first with their Scores,
+// or sorted for Sorted. Snapshots may still be reading the old slice, so it gets replaced rather than sorted in place.
+func (solver *Solver) orderSolutions() {
+	switch {
+	case solver.ranked != nil:
+		solver.Solutions, solver.Scores = solver.ranked.sorted()
+	case solver.Sorted && !solver.Problem.Optimizing():
+		solver.Solutions = SortSolutions(solver.Problem, solver.Solutions)
+	}
+}
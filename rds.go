@@ -0,0 +1,153 @@
+package csp
+
+import "math"
+
+// Russian doll search over the soft constraint cost. The variables are taken in problem order and the tails of
+// that order, the last variable alone, then the last two, and so on, are solved one after the other, each by
+// branch and bound over its own variables and the constraints entirely inside it. A partial assignment of a tail
+// then costs at least what it breaks so far plus the optimum of the smaller tail it leaves unassigned, which is
+// already known, and that bound is far stronger than the cost so far alone. The optimum of the longest tail, the
+// whole problem, is the answer. Each tail tries the optimal values of the one before first, and stops as soon as
+// it matches that one's optimum, which it can't beat.
+//
+// The order is fixed and there's no propagation, as in the original algorithm, so it does best on models whose
+// constraints only link variables close together in the order, such as chains and bands.
+func (solver *Solver) russianDollSearch() {
+	problem := solver.Problem
+	n := len(problem.Variables)
+	if n == 0 {
+		solver.Best = make(map[string]int)
+		solver.BestCost = problem.Cost(solver.Best)
+		solver.Solutions = []map[string]int{solver.Best}
+		return
+	}
+	position := make(map[string]int, n)
+	for k, variable := range problem.Variables {
+		position[variable] = k
+	}
+	doll := &russianDoll{solver: solver, bounds: make([]int, n+1), hard: make([][]int, n), soft: make([][]int, n)}
+	for i, constraint := range problem.Constraints {
+		first, _ := scopeSpan(constraint, position)
+		doll.hardFirst = append(doll.hardFirst, first)
+		for _, variable := range uniqueScope(constraint.Scope()) {
+			doll.hard[position[variable]] = append(doll.hard[position[variable]], i)
+		}
+	}
+	for i, soft := range problem.SoftConstraints {
+		first, last := scopeSpan(soft.Constraint, position)
+		if last < 0 {
+			last = n - 1 // broken or not whatever the values, so it's counted once, by the last variable
+		}
+		doll.softFirst = append(doll.softFirst, first)
+		doll.soft[last] = append(doll.soft[last], i)
+	}
+
+	for start := n - 1; start >= 0; start-- {
+		doll.start = start
+		doll.assignment = make(map[string]int, n-start)
+		doll.best, doll.bestCost, doll.done = nil, math.MaxInt, false
+		doll.search(start, 0)
+		if solver.stopped || doll.best == nil {
+			return // out of time, or the tail, and so the problem, has no solution
+		}
+		doll.bounds[start] = doll.bestCost
+		doll.hint = doll.best
+	}
+	solver.Best = doll.best
+	solver.BestCost = doll.bestCost
+	solver.BestObjective = doll.best[problem.Objective]
+	if problem.Objective != "" {
+		solver.optimizeObjectiveAtCost()
+	}
+	solver.Solutions = []map[string]int{solver.Best}
+}
+
+// The branch and bound of one tail of the variables, from start on
+type russianDoll struct {
+	solver     *Solver
+	start      int
+	bounds     []int          // the optimal cost of every tail solved so far, by where it starts, 0 for the empty one
+	hint       map[string]int // the optimal assignment of the tail one shorter
+	hard       [][]int        // the hard constraints on the variable at each position
+	hardFirst  []int          // where each hard constraint's scope starts
+	soft       [][]int        // the soft constraints whose scope ends at each position
+	softFirst  []int          // where each soft constraint's scope starts
+	assignment map[string]int
+	best       map[string]int
+	bestCost   int
+	done       bool // no better than the best so far is possible
+}
+
+func (doll *russianDoll) search(k int, cost int) {
+	solver := doll.solver
+	problem := solver.Problem
+	if k == len(problem.Variables) {
+		doll.best, doll.bestCost = copyAssignment(doll.assignment), cost
+		doll.done = cost <= doll.bounds[doll.start+1]
+		return
+	}
+	variable := problem.Variables[k]
+	for _, value := range doll.values(variable) {
+		if doll.done || solver.limitReached() {
+			return
+		}
+		solver.Nodes++
+		doll.assignment[variable] = value
+		if !doll.consistent(k) {
+			solver.Failures++
+			continue
+		}
+		added := 0
+		for _, i := range doll.soft[k] {
+			if doll.softFirst[i] >= doll.start && !problem.SoftConstraints[i].Constraint.Satisfied(doll.assignment) {
+				added += problem.SoftConstraints[i].Weight
+			}
+		}
+		if cost+added+doll.bounds[k+1] >= doll.bestCost {
+			solver.Failures++
+			continue
+		}
+		doll.search(k+1, cost+added)
+	}
+	delete(doll.assignment, variable)
+}
+
+// The variable's values, the one it has in the shorter tail's optimum first
+func (doll *russianDoll) values(variable string) []int {
+	domain := doll.solver.Problem.Domains[variable]
+	hinted, ok := doll.hint[variable]
+	if !ok || !containsValue(domain, hinted) {
+		return domain
+	}
+	values := append(make([]int, 0, len(domain)), hinted)
+	for _, value := range domain {
+		if value != hinted {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Whether the hard constraints inside the tail on the variable at position k still hold
+func (doll *russianDoll) consistent(k int) bool {
+	for _, i := range doll.hard[k] {
+		if doll.hardFirst[i] >= doll.start && !doll.solver.Problem.Constraints[i].Satisfied(doll.assignment) {
+			return false
+		}
+	}
+	return true
+}
+
+// The positions of the first and last variables of the constraint's scope
+func scopeSpan(constraint Constraint, position map[string]int) (first int, last int) {
+	first, last = math.MaxInt, -1
+	for _, variable := range constraint.Scope() {
+		if position[variable] < first {
+			first = position[variable]
+		}
+		if position[variable] > last {
+			last = position[variable]
+		}
+	}
+	return first, last
+}
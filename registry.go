@@ -0,0 +1,434 @@
+package csp
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Builds a constraint from the arguments a model file or script gives it
+type ConstraintFactory func(args ConstraintArgs) (Constraint, error)
+
+// What a named constraint is built from: the variables it constrains, and whatever else the constraint type needs
+// by parameter name. Numbers may be Go ints or the float64s encoding/json produces, and lists may be typed slices
+// or []interface{}; the accessors convert, and fail on anything that doesn't fit.
+type ConstraintArgs struct {
+	Variables []string
+	Params    map[string]interface{}
+}
+
+var constraintFactories = make(map[string]ConstraintFactory)
+
+// Makes the constraint type available under name to every frontend that builds constraints by name (LoadModel and
+// friends). Registering the same name twice, or a nil factory, panics.
+func RegisterConstraint(name string, factory ConstraintFactory) {
+	if factory == nil {
+		panic("csp: RegisterConstraint factory is nil")
+	}
+	if _, exists := constraintFactories[name]; exists {
+		panic("csp: RegisterConstraint called twice for " + name)
+	}
+	constraintFactories[name] = factory
+}
+
+// Builds a constraint of a registered type
+func NewNamedConstraint(name string, args ConstraintArgs) (Constraint, error) {
+	factory, exists := constraintFactories[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown constraint type %q", name)
+	}
+	constraint, err := factory(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return constraint, nil
+}
+
+// Every registered constraint type, sorted
+func RegisteredConstraints() []string {
+	var names []string
+	for name := range constraintFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Builds a propagator for a constraint, or answers nil to pass on it
+type PropagatorFactory func(constraint Constraint) Propagator
+
+type namedPropagatorFactory struct {
+	name    string
+	factory PropagatorFactory
+}
+
+var propagatorFactories []namedPropagatorFactory
+
+// Has the propagation engine prune the constraints factory answers for with its propagators, which get queued,
+// woken and prioritised like any built in one. Constraints of their own types can simply implement Propagator;
+// this is for bringing stronger or domain specific propagation to constraints defined elsewhere. Factories are
+// asked in the order they were registered, before a constraint's own propagator. Registering the same name twice,
+// or a nil factory, panics.
+func RegisterPropagator(name string, factory PropagatorFactory) {
+	if factory == nil {
+		panic("csp: RegisterPropagator factory is nil")
+	}
+	for _, registered := range propagatorFactories {
+		if registered.name == name {
+			panic("csp: RegisterPropagator called twice for " + name)
+		}
+	}
+	propagatorFactories = append(propagatorFactories, namedPropagatorFactory{name, factory})
+}
+
+// Every registered propagator, in the order they're asked
+func RegisteredPropagators() []string {
+	var names []string
+	for _, registered := range propagatorFactories {
+		names = append(names, registered.name)
+	}
+	return names
+}
+
+// The propagator the engine uses for a constraint. At AutoConsistency that's a registered one, the constraint's
+// own, or the generic one; the other levels get the generic propagator working at that level.
+func propagatorFor(constraint Constraint, level ConsistencyLevel) Propagator {
+	switch level {
+	case CheckOnly:
+		return checkOnlyPropagator{}
+	case ForwardChecking, BoundsConsistency, DomainConsistency:
+		return &GenericPropagator{constraint, level}
+	}
+	for _, registered := range propagatorFactories {
+		if propagator := registered.factory(constraint); propagator != nil {
+			return propagator
+		}
+	}
+	if propagator, ok := constraint.(Propagator); ok {
+		return propagator
+	}
+	return &GenericPropagator{constraint, AutoConsistency}
+}
+
+func (args ConstraintArgs) Has(name string) bool {
+	_, exists := args.Params[name]
+	return exists
+}
+
+func (args ConstraintArgs) Int(name string) (int, error) {
+	value, exists := args.Params[name]
+	if !exists {
+		return 0, fmt.Errorf("missing parameter %q", name)
+	}
+	number, ok := toInt(value)
+	if !ok {
+		return 0, fmt.Errorf("parameter %q must be an integer", name)
+	}
+	return number, nil
+}
+
+func (args ConstraintArgs) String(name string) (string, error) {
+	value, exists := args.Params[name]
+	if !exists {
+		return "", fmt.Errorf("missing parameter %q", name)
+	}
+	text, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("parameter %q must be a string", name)
+	}
+	return text, nil
+}
+
+func (args ConstraintArgs) Ints(name string) ([]int, error) {
+	items, err := args.list(name)
+	if err != nil {
+		return nil, err
+	}
+	numbers := make([]int, len(items))
+	for i, item := range items {
+		var ok bool
+		if numbers[i], ok = toInt(item); !ok {
+			return nil, fmt.Errorf("parameter %q must be a list of integers", name)
+		}
+	}
+	return numbers, nil
+}
+
+func (args ConstraintArgs) Strings(name string) ([]string, error) {
+	items, err := args.list(name)
+	if err != nil {
+		return nil, err
+	}
+	texts := make([]string, len(items))
+	for i, item := range items {
+		var ok bool
+		if texts[i], ok = item.(string); !ok {
+			return nil, fmt.Errorf("parameter %q must be a list of strings", name)
+		}
+	}
+	return texts, nil
+}
+
+func (args ConstraintArgs) Bools(name string) ([]bool, error) {
+	items, err := args.list(name)
+	if err != nil {
+		return nil, err
+	}
+	flags := make([]bool, len(items))
+	for i, item := range items {
+		var ok bool
+		if flags[i], ok = item.(bool); !ok {
+			return nil, fmt.Errorf("parameter %q must be a list of booleans", name)
+		}
+	}
+	return flags, nil
+}
+
+// A list of integer lists, like a table's tuples
+func (args ConstraintArgs) Tuples(name string) ([][]int, error) {
+	rows, err := args.list(name)
+	if err != nil {
+		return nil, err
+	}
+	tuples := make([][]int, len(rows))
+	for i, row := range rows {
+		tuple, err := (ConstraintArgs{Params: map[string]interface{}{name: row}}).Ints(name)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q must be a list of integer lists", name)
+		}
+		tuples[i] = tuple
+	}
+	return tuples, nil
+}
+
+func (args ConstraintArgs) list(name string) ([]interface{}, error) {
+	value, exists := args.Params[name]
+	if !exists {
+		return nil, fmt.Errorf("missing parameter %q", name)
+	}
+	switch value := value.(type) {
+	case []interface{}:
+		return value, nil
+	case []int:
+		items := make([]interface{}, len(value))
+		for i, item := range value {
+			items[i] = item
+		}
+		return items, nil
+	case []string:
+		items := make([]interface{}, len(value))
+		for i, item := range value {
+			items[i] = item
+		}
+		return items, nil
+	case []bool:
+		items := make([]interface{}, len(value))
+		for i, item := range value {
+			items[i] = item
+		}
+		return items, nil
+	case [][]int:
+		items := make([]interface{}, len(value))
+		for i, item := range value {
+			items[i] = item
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("parameter %q must be a list", name)
+}
+
+func toInt(value interface{}) (int, bool) {
+	switch value := value.(type) {
+	case int:
+		return value, true
+	case float64:
+		if value != math.Trunc(value) || math.Abs(value) > math.MaxInt32 {
+			return 0, false
+		}
+		return int(value), true
+	}
+	return 0, false
+}
+
+// Fails unless the constraint has exactly as many entries in a list parameter as it has variables
+func sameLength(args ConstraintArgs, name string, length int) error {
+	if length != len(args.Variables) {
+		return fmt.Errorf("parameter %q has %d entries for %d variables", name, length, len(args.Variables))
+	}
+	return nil
+}
+
+func init() {
+	RegisterConstraint("alldifferent", func(args ConstraintArgs) (Constraint, error) {
+		return NewAllDifferent(args.Variables...), nil
+	})
+	RegisterConstraint("notequal", func(args ConstraintArgs) (Constraint, error) {
+		if len(args.Variables) != 2 {
+			return nil, fmt.Errorf("needs exactly two variables")
+		}
+		return NewNotEqual(args.Variables[0], args.Variables[1]), nil
+	})
+	linear := func(args ConstraintArgs, coefficients []int) (Constraint, error) {
+		operator, err := args.String("operator")
+		if err != nil {
+			return nil, err
+		}
+		if !validOperator(operator) {
+			return nil, fmt.Errorf("unknown operator %q", operator)
+		}
+		constant, err := args.Int("constant")
+		if err != nil {
+			return nil, err
+		}
+		return NewLinear(args.Variables, coefficients, operator, constant), nil
+	}
+	RegisterConstraint("linear", func(args ConstraintArgs) (Constraint, error) {
+		coefficients, err := args.Ints("coefficients")
+		if err != nil {
+			return nil, err
+		}
+		if err := sameLength(args, "coefficients", len(coefficients)); err != nil {
+			return nil, err
+		}
+		return linear(args, coefficients)
+	})
+	RegisterConstraint("sum", func(args ConstraintArgs) (Constraint, error) {
+		coefficients := make([]int, len(args.Variables))
+		for i := range coefficients {
+			coefficients[i] = 1
+		}
+		return linear(args, coefficients)
+	})
+	RegisterConstraint("table", func(args ConstraintArgs) (Constraint, error) {
+		tuples, err := args.Tuples("tuples")
+		if err != nil {
+			return nil, err
+		}
+		for _, tuple := range tuples {
+			if err := sameLength(args, "tuples", len(tuple)); err != nil {
+				return nil, err
+			}
+		}
+		return NewTable(args.Variables, tuples), nil
+	})
+	RegisterConstraint("inverse", func(args ConstraintArgs) (Constraint, error) {
+		backward, err := args.Strings("backward")
+		if err != nil {
+			return nil, err
+		}
+		if err := sameLength(args, "backward", len(backward)); err != nil {
+			return nil, err
+		}
+		return NewInverse(args.Variables, backward), nil
+	})
+	RegisterConstraint("disjunctive", func(args ConstraintArgs) (Constraint, error) {
+		durations, err := args.Ints("durations")
+		if err != nil {
+			return nil, err
+		}
+		if err := sameLength(args, "durations", len(durations)); err != nil {
+			return nil, err
+		}
+		return NewDisjunctive(args.Variables, durations), nil
+	})
+	// transitions are [state, value, next state] triples
+	RegisterConstraint("regular", func(args ConstraintArgs) (Constraint, error) {
+		triples, err := args.Tuples("transitions")
+		if err != nil {
+			return nil, err
+		}
+		start, err := args.Int("start")
+		if err != nil {
+			return nil, err
+		}
+		final, err := args.Ints("accepting")
+		if err != nil {
+			return nil, err
+		}
+		var transitions []map[int]int
+		for _, triple := range triples {
+			if len(triple) != 3 || triple[0] < 0 || triple[2] < 0 {
+				return nil, fmt.Errorf("transitions must be [state, value, next state] with states from 0")
+			}
+			// every state the automaton can reach needs its row, even an empty one
+			for len(transitions) <= triple[0] || len(transitions) <= triple[2] {
+				transitions = append(transitions, make(map[int]int))
+			}
+			transitions[triple[0]][triple[1]] = triple[2]
+		}
+		if start < 0 || start >= len(transitions) {
+			return nil, fmt.Errorf("start state %d has no transitions", start)
+		}
+		accepting := make(map[int]bool)
+		for _, state := range final {
+			accepting[state] = true
+		}
+		return NewRegular(args.Variables, transitions, start, accepting), nil
+	})
+	// one literal per variable: variable = values[i], or != when negated[i]
+	RegisterConstraint("clause", func(args ConstraintArgs) (Constraint, error) {
+		values, err := args.Ints("values")
+		if err != nil {
+			return nil, err
+		}
+		if err := sameLength(args, "values", len(values)); err != nil {
+			return nil, err
+		}
+		negated := make([]bool, len(values))
+		if args.Has("negated") {
+			if negated, err = args.Bools("negated"); err != nil {
+				return nil, err
+			}
+			if err := sameLength(args, "negated", len(negated)); err != nil {
+				return nil, err
+			}
+		}
+		var literals []Literal
+		for i, variable := range args.Variables {
+			literals = append(literals, Literal{variable, values[i], negated[i]})
+		}
+		return NewClause(literals...), nil
+	})
+	// reference values line up with the variables
+	RegisterConstraint("hammingdistance", func(args ConstraintArgs) (Constraint, error) {
+		values, err := args.Ints("reference")
+		if err != nil {
+			return nil, err
+		}
+		if err := sameLength(args, "reference", len(values)); err != nil {
+			return nil, err
+		}
+		minimum, err := args.Int("minimum")
+		if err != nil {
+			return nil, err
+		}
+		reference := make(map[string]int)
+		for i, variable := range args.Variables {
+			reference[variable] = values[i]
+		}
+		return NewHammingDistance(args.Variables, reference, minimum), nil
+	})
+	// an infix expression, see ParseConstraint; its variables are whatever it mentions
+	RegisterConstraint("expression", func(args ConstraintArgs) (Constraint, error) {
+		source, err := args.String("expression")
+		if err != nil {
+			return nil, err
+		}
+		return ParseConstraint(source)
+	})
+	RegisterConstraint("valueprecedence", func(args ConstraintArgs) (Constraint, error) {
+		values, err := args.Ints("values")
+		if err != nil {
+			return nil, err
+		}
+		return NewValuePrecedence(args.Variables, values), nil
+	})
+}
+
+func validOperator(operator string) bool {
+	switch operator {
+	case "=", "==", "!=", "<", "<=", ">", ">=":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package csp
+
+// Solution repair for dynamic problems: the model changed after a solution was put to use (a new constraint, a
+// shrunk domain) and the new solution should disturb as little of the old one as possible, say when rescheduling
+// after a machine breaks down. Every variable keeping its previous value becomes a soft constraint of weight 1, so
+// the optimal cost is the number of variables that had to change. Variables previous doesn't mention are free.
+// Soft constraints the problem already had still count, on top of the changes.
+func RepairProblem(problem *Problem, previous map[string]int) *Problem {
+	repaired := &Problem{
+		Variables:          problem.Variables,
+		Domains:            problem.Domains,
+		Constraints:        problem.Constraints,
+		Labels:             problem.Labels,
+		Objective:          problem.Objective,
+		Maximizing:         problem.Maximizing,
+		SoftConstraints:    append([]SoftConstraint(nil), problem.SoftConstraints...),
+		DefaultConsistency: problem.DefaultConsistency,
+		Consistency:        problem.Consistency,
+	}
+	for _, variable := range problem.Variables {
+		if value, assigned := previous[variable]; assigned {
+			repaired.AddSoftConstraint(NewClause(Literal{variable, value, false}), 1)
+		}
+	}
+	return repaired
+}
+
+// Finds the solution of the changed problem closest to previous, returning it with the number of variables whose
+// value changed, or nil if the changed problem has no solution at all
+func Repair(problem *Problem, previous map[string]int) (map[string]int, int) {
+	solver := NewSolver(problem)
+	solver.Propagation = true
+	return solver.NearestSolution(previous)
+}
+
+// The solution at the smallest Hamming distance from target, along with that distance. target doesn't have to be
+// feasible, or even use values from the domains; the variables it leaves out can be anything. Runs as branch and
+// bound over RepairProblem with the solver's settings, using target as the hint so the first solution found tends
+// to be close already. The solver's own Solutions and statistics are left alone.
+func (solver *Solver) NearestSolution(target map[string]int) (map[string]int, int) {
+	search := solver.derived(RepairProblem(solver.Problem, target))
+	search.Hint = target
+	search.Solve()
+	if search.Best == nil {
+		return nil, 0
+	}
+	distance := 0
+	for variable, value := range target {
+		if current, exists := search.Best[variable]; exists && current != value {
+			distance++
+		}
+	}
+	return search.Best, distance
+}
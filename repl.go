@@ -0,0 +1,275 @@
+package csp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const replHelp = `Commands:
+  var NAME LOW..HIGH        integer variable with a range of values
+  var NAME V1 V2 ...        integer variable with the values listed, or categorical if they aren't numbers
+  add EXPRESSION            constraint, e.g. add |F - B| == 1 (see ParseConstraint)
+  retract N                 removes constraint N
+  list                      variables and numbered constraints
+  domains                   domains after arc consistency
+  solve [N]                 up to N solutions (default 1, 0 for all)
+  whatif EXPRESSION         solves with one more constraint, leaving the session's model as it is
+  count [VARIABLE]          number of solutions, or of solutions with each value of the variable
+  load model.json           replaces the session with a model file
+  help, quit`
+
+// One line of the session's model, numbered so it can be retracted
+type replConstraint struct {
+	number     int
+	source     string
+	constraint Constraint
+}
+
+type replSession struct {
+	problem     *Problem
+	constraints []replConstraint
+	next        int
+	out         io.Writer
+}
+
+// Reads commands from in until it runs out or gets quit, building up a problem a constraint at a time. Errors are
+// reported and the session carries on.
+func REPL(in io.Reader, out io.Writer) {
+	session := &replSession{problem: NewProblem(), next: 1, out: out}
+	fmt.Fprintln(out, `Type "help" for the commands.`)
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "csp> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		command, rest := line, ""
+		if space := strings.IndexAny(line, " \t"); space >= 0 {
+			command, rest = line[:space], strings.TrimSpace(line[space+1:])
+		}
+		if command == "quit" || command == "exit" {
+			return
+		}
+		if err := session.run(command, rest); err != nil {
+			fmt.Fprintln(out, "error:", err)
+		}
+	}
+}
+
+func (session *replSession) run(command string, rest string) error {
+	switch command {
+	case "help":
+		fmt.Fprintln(session.out, replHelp)
+	case "var":
+		return session.declare(strings.Fields(rest))
+	case "add":
+		return session.add(rest)
+	case "retract":
+		return session.retract(rest)
+	case "list":
+		session.list()
+	case "domains":
+		session.domains()
+	case "solve":
+		return session.solve(rest)
+	case "whatif":
+		return session.whatIf(rest)
+	case "count":
+		return session.count(rest)
+	case "load":
+		problem, err := LoadModelFile(rest)
+		if err != nil {
+			return err
+		}
+		session.problem = problem
+		session.constraints = nil
+		session.next = 1
+		for _, constraint := range problem.Constraints {
+			session.remember(describeConstraint(constraint), constraint)
+		}
+		session.list()
+	default:
+		return fmt.Errorf("unknown command %q, try help", command)
+	}
+	return nil
+}
+
+func (session *replSession) declare(fields []string) error {
+	if len(fields) < 2 {
+		return fmt.Errorf("usage: var NAME LOW..HIGH or var NAME V1 V2 ...")
+	}
+	name := fields[0]
+	if bounds := strings.Split(fields[1], ".."); len(fields) == 2 && len(bounds) == 2 {
+		low, errLow := strconv.Atoi(bounds[0])
+		high, errHigh := strconv.Atoi(bounds[1])
+		if errLow != nil || errHigh != nil || low > high {
+			return fmt.Errorf("invalid range %q", fields[1])
+		}
+		session.problem.AddVariableRange(name, low, high)
+		return nil
+	}
+	var values []int
+	for _, field := range fields[1:] {
+		value, err := strconv.Atoi(field)
+		if err != nil {
+			session.problem.AddCategoricalVariable(name, fields[1:])
+			return nil
+		}
+		values = append(values, value)
+	}
+	session.problem.AddVariable(name, values)
+	return nil
+}
+
+func (session *replSession) add(source string) error {
+	constraint, err := ParseConstraint(source)
+	if err != nil {
+		return err
+	}
+	if err := session.declared(constraint); err != nil {
+		return err
+	}
+	session.problem.AddConstraint(constraint)
+	number := session.remember(source, constraint)
+	fmt.Fprintf(session.out, "[%d] %s\n", number, source)
+	return nil
+}
+
+func (session *replSession) declared(constraint Constraint) error {
+	for _, variable := range constraint.Scope() {
+		if _, exists := session.problem.Domains[variable]; !exists {
+			return fmt.Errorf("unknown variable %q, declare it with var first", variable)
+		}
+	}
+	return nil
+}
+
+func (session *replSession) remember(source string, constraint Constraint) int {
+	session.constraints = append(session.constraints, replConstraint{session.next, source, constraint})
+	session.next++
+	return session.next - 1
+}
+
+func (session *replSession) retract(argument string) error {
+	number, err := strconv.Atoi(argument)
+	if err != nil {
+		return fmt.Errorf("usage: retract N")
+	}
+	for i, entry := range session.constraints {
+		if entry.number == number {
+			session.problem.RemoveConstraint(entry.constraint)
+			session.constraints = append(session.constraints[:i], session.constraints[i+1:]...)
+			fmt.Fprintf(session.out, "retracted [%d] %s\n", number, entry.source)
+			return nil
+		}
+	}
+	return fmt.Errorf("no constraint [%d]", number)
+}
+
+func (session *replSession) list() {
+	for _, variable := range session.problem.Variables {
+		fmt.Fprintf(session.out, "%s ∈ %s\n", variable, formatDomain(session.problem, variable,
+			session.problem.Domains[variable]))
+	}
+	for _, entry := range session.constraints {
+		fmt.Fprintf(session.out, "[%d] %s\n", entry.number, entry.source)
+	}
+}
+
+func (session *replSession) domains() {
+	domains := Preprocess(session.problem, ArcConsistency)
+	if domains == nil {
+		fmt.Fprintln(session.out, "arc consistency wipes out a domain: no solutions")
+		return
+	}
+	for _, variable := range session.problem.Variables {
+		fmt.Fprintf(session.out, "%s ∈ %s\n", variable, formatDomain(session.problem, variable, domains[variable]))
+	}
+}
+
+func (session *replSession) solve(argument string) error {
+	solver := NewSolver(session.problem)
+	solver.Propagation = true
+	solver.MaxSolutions = 1
+	if argument != "" {
+		limit, err := strconv.Atoi(argument)
+		if err != nil || limit < 0 {
+			return fmt.Errorf("usage: solve [N]")
+		}
+		solver.MaxSolutions = limit
+	}
+	solutions := solver.Solve()
+	if len(solutions) == 0 {
+		fmt.Fprintln(session.out, "no solution")
+	}
+	for _, solution := range solutions {
+		fmt.Fprintln(session.out, FormatSolution(session.problem, solution))
+	}
+	fmt.Fprintf(session.out, "(%d nodes, %d failures)\n", solver.Nodes, solver.Failures)
+	return nil
+}
+
+func (session *replSession) count(variable string) error {
+	if variable == "" {
+		fmt.Fprintln(session.out, NewCounter(session.problem).Count())
+		return nil
+	}
+	if _, exists := session.problem.Domains[variable]; !exists {
+		return fmt.Errorf("unknown variable %q", variable)
+	}
+	counts := NewCounter(session.problem).ValueCounts(variable)
+	for _, value := range session.problem.Domains[variable] {
+		fmt.Fprintf(session.out, "%s=%s: %s\n", variable, session.problem.FormatValue(variable, value), counts[value])
+	}
+	return nil
+}
+
+func (session *replSession) whatIf(source string) error {
+	constraint, err := ParseConstraint(source)
+	if err != nil {
+		return err
+	}
+	if err := session.declared(constraint); err != nil {
+		return err
+	}
+	solver := NewSolver(session.problem)
+	solver.Propagation = true
+	solver.MaxSolutions = 1
+	hypothetical := solver.Clone()
+	hypothetical.Problem.AddConstraint(constraint)
+	before, after := solver.Solve(), hypothetical.Solve()
+	switch {
+	case len(after) > 0:
+		fmt.Fprintln(session.out, FormatSolution(session.problem, after[0]))
+	case len(before) > 0:
+		fmt.Fprintln(session.out, "no solution with it, though there is one without")
+	default:
+		fmt.Fprintln(session.out, "no solution, with or without it")
+	}
+	return nil
+}
+
+// "{1, 2, 3}", with labels for categorical variables
+func formatDomain(problem *Problem, variable string, domain []int) string {
+	var values []string
+	for _, value := range domain {
+		values = append(values, problem.FormatValue(variable, value))
+	}
+	return "{" + strings.Join(values, ", ") + "}"
+}
+
+// The expression for constraints that came from one, otherwise the type and scope
+func describeConstraint(constraint Constraint) string {
+	if stringer, ok := constraint.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("%s over %v", strings.TrimPrefix(fmt.Sprintf("%T", constraint), "*main."), constraint.Scope())
+}
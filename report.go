@@ -0,0 +1,258 @@
+package csp
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Solutions a report lists before it just counts the rest
+const reportSolutionLimit = 200
+
+// Everything a report shows about one solve
+type SolveReport struct {
+	Title       string
+	Problem     *Problem
+	Solutions   []map[string]int
+	Status      SolveStatus
+	Nodes       int
+	Failures    int
+	Elapsed     time.Duration
+	Progress    []ProgressSample // nodes explored over time
+	Prunes      map[string]int   // failed nodes by the constraint responsible, "propagation" when propagation was
+	Objective   int
+	Cost        int
+	Optimizing  bool
+	GeneratedAt time.Time
+}
+
+type ProgressSample struct {
+	Elapsed time.Duration
+	Nodes   int
+}
+
+// Solves with the solver's settings, sampling the progress and counting which constraints cut off branches. The
+// solver's OnNode is taken over for the duration.
+func BuildReport(title string, solver *Solver) *SolveReport {
+	report := &SolveReport{Title: title, Problem: solver.Problem, Prunes: make(map[string]int)}
+	previous := solver.OnNode
+	defer func() { solver.OnNode = previous }()
+	start := time.Now()
+	nodes := 0
+	solver.OnNode = func(event SearchEvent) {
+		if event.Outcome == NodeSolution {
+			return
+		}
+		nodes++
+		if nodes%256 == 0 {
+			report.Progress = append(report.Progress, ProgressSample{time.Since(start), nodes})
+		}
+		switch event.Outcome {
+		case NodeFailedConstraint:
+			report.Prunes[describeConstraint(event.Violated)]++
+		case NodeFailedPropagation:
+			report.Prunes["propagation"]++
+		case NodeFailedBound:
+			report.Prunes["bound"]++
+		}
+	}
+	solver.Solve()
+	report.Elapsed = time.Since(start)
+	report.Progress = append(report.Progress, ProgressSample{report.Elapsed, nodes})
+
+	report.Solutions = solver.Solutions
+	report.Status = solver.Status
+	report.Nodes = solver.Nodes
+	report.Failures = solver.Failures
+	report.Optimizing = solver.Problem.Optimizing()
+	if report.Optimizing && solver.Best != nil {
+		report.Solutions = []map[string]int{solver.Best}
+		report.Objective = solver.BestObjective
+		report.Cost = solver.BestCost
+	}
+	report.GeneratedAt = time.Now()
+	return report
+}
+
+// A standalone page, styles and charts inline, nothing fetched from anywhere
+func (report *SolveReport) WriteHTML(w io.Writer) error {
+	return reportTemplate.Execute(w, report)
+}
+
+type reportCount struct {
+	Name  string
+	Count int
+}
+
+// Constraint types and how many of each
+func (report *SolveReport) ConstraintTypes() []reportCount {
+	counts := make(map[string]int)
+	for _, constraint := range report.Problem.Constraints {
+		counts[strings.TrimPrefix(fmt.Sprintf("%T", constraint), "*main.")]++
+	}
+	return sortedCounts(counts)
+}
+
+func (report *SolveReport) SearchSpace() string {
+	return fmt.Sprintf("10^%.1f", measure(report.Problem).SearchSpace)
+}
+
+func (report *SolveReport) Domain(variable string) string {
+	return formatDomain(report.Problem, variable, report.Problem.Domains[variable])
+}
+
+// The solutions the table shows, each as its values in problem order
+func (report *SolveReport) Rows() [][]string {
+	var rows [][]string
+	for i, solution := range report.Solutions {
+		if i == reportSolutionLimit {
+			break
+		}
+		var row []string
+		for _, variable := range report.Problem.Variables {
+			row = append(row, report.Problem.FormatValue(variable, solution[variable]))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func (report *SolveReport) Hidden() int {
+	if len(report.Solutions) <= reportSolutionLimit {
+		return 0
+	}
+	return len(report.Solutions) - reportSolutionLimit
+}
+
+// Nodes over time as an SVG polyline
+func (report *SolveReport) ProgressChart() template.HTML {
+	const width, height = 600.0, 200.0
+	last := report.Progress[len(report.Progress)-1]
+	duration := float64(last.Elapsed)
+	if duration == 0 {
+		duration = 1
+	}
+	nodes := float64(last.Nodes)
+	if nodes == 0 {
+		nodes = 1
+	}
+	points := []string{fmt.Sprintf("0,%.1f", height)}
+	for _, sample := range report.Progress {
+		points = append(points, fmt.Sprintf("%.1f,%.1f", float64(sample.Elapsed)/duration*width,
+			height-float64(sample.Nodes)/nodes*height))
+	}
+	return template.HTML(fmt.Sprintf(`<svg width="%.0f" height="%.0f" viewBox="-40 -10 %.0f %.0f">`+
+		`<polyline fill="none" stroke="#36c" stroke-width="2" points="%s"/>`+
+		`<line x1="0" y1="%.0f" x2="%.0f" y2="%.0f" stroke="#999"/><line x1="0" y1="0" x2="0" y2="%.0f" stroke="#999"/>`+
+		`<text x="-5" y="5" text-anchor="end" font-size="11">%d</text>`+
+		`<text x="%.0f" y="%.0f" text-anchor="end" font-size="11">%v</text></svg>`,
+		width+60, height+40, width+60, height+40, strings.Join(points, " "), height, width, height, height,
+		last.Nodes, width, height+20, last.Elapsed.Round(time.Microsecond)))
+}
+
+// Failed nodes per constraint as SVG bars, the fifteen biggest
+func (report *SolveReport) PruneChart() template.HTML {
+	counts := sortedCounts(report.Prunes)
+	if len(counts) > 15 {
+		counts = counts[:15]
+	}
+	if len(counts) == 0 {
+		return template.HTML("<p>No failed nodes.</p>")
+	}
+	const barWidth, barHeight = 300.0, 18.0
+	var bars []string
+	for i, count := range counts {
+		y := float64(i) * (barHeight + 4)
+		bars = append(bars, fmt.Sprintf(`<text x="0" y="%.0f" font-size="11">%s</text>`+
+			`<rect x="310" y="%.0f" width="%.1f" height="%.0f" fill="#c33"/>`+
+			`<text x="%.1f" y="%.0f" font-size="11">%d</text>`,
+			y+13, template.HTMLEscapeString(abbreviate(count.Name, 48)), y,
+			float64(count.Count)/float64(counts[0].Count)*barWidth, barHeight,
+			315+float64(count.Count)/float64(counts[0].Count)*barWidth, y+13, count.Count))
+	}
+	return template.HTML(fmt.Sprintf(`<svg width="680" height="%.0f">%s</svg>`,
+		float64(len(counts))*(barHeight+4), strings.Join(bars, "")))
+}
+
+func abbreviate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return string(runes[:length-1]) + "…"
+}
+
+// Largest count first, then by name
+func sortedCounts(counts map[string]int) []reportCount {
+	var sorted []reportCount
+	for name, count := range counts {
+		sorted = append(sorted, reportCount{name, count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+td, th { border: 1px solid #ccc; padding: 3px 8px; text-align: left; }
+th { background: #f3f3f3; }
+.solutions td { font-family: monospace; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Generated {{.GeneratedAt.Format "2006-01-02 15:04:05"}}</p>
+
+<h2>Model</h2>
+<table>
+<tr><th>Variables</th><td>{{len .Problem.Variables}}</td></tr>
+<tr><th>Constraints</th><td>{{len .Problem.Constraints}}{{range .ConstraintTypes}}, {{.Count}} {{.Name}}{{end}}</td></tr>
+<tr><th>Soft constraints</th><td>{{len .Problem.SoftConstraints}}</td></tr>
+<tr><th>Search space</th><td>{{.SearchSpace}}</td></tr>
+{{if .Problem.Objective}}<tr><th>Objective</th><td>{{if .Problem.Maximizing}}maximize{{else}}minimize{{end}} {{.Problem.Objective}}</td></tr>{{end}}
+</table>
+<details><summary>Domains</summary>
+<table>{{range .Problem.Variables}}<tr><th>{{.}}</th><td>{{$.Domain .}}</td></tr>{{end}}</table>
+</details>
+
+<h2>Statistics</h2>
+<table>
+<tr><th>Status</th><td>{{.Status}}</td></tr>
+<tr><th>Solutions</th><td>{{len .Solutions}}</td></tr>
+{{if .Optimizing}}<tr><th>Best objective</th><td>{{.Objective}}</td></tr><tr><th>Cost</th><td>{{.Cost}}</td></tr>{{end}}
+<tr><th>Nodes</th><td>{{.Nodes}}</td></tr>
+<tr><th>Failures</th><td>{{.Failures}}</td></tr>
+<tr><th>Time</th><td>{{.Elapsed}}</td></tr>
+</table>
+
+<h2>Nodes over time</h2>
+{{.ProgressChart}}
+
+<h2>Prunes per constraint</h2>
+{{.PruneChart}}
+
+<h2>{{if .Optimizing}}Best solution{{else}}Solutions{{end}}</h2>
+{{if .Solutions}}
+<table class="solutions">
+<tr>{{range .Problem.Variables}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+{{if .Hidden}}<p>… and {{.Hidden}} more</p>{{end}}
+{{else}}<p>None.</p>{{end}}
+</body>
+</html>
+`))
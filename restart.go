@@ -0,0 +1,90 @@
+package csp
+
+import (
+	"encoding/json"
+	"os"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Runs the search, from the root again every time it hits the restart cutoff. The cutoff grows by half after
+// every restart, so some run eventually gets to finish and the search stays complete.
+func (solver *Solver) restartingSearch() {
+	cutoff := 0
+	if solver.RestartCutoff > 0 && (solver.MaxSolutions == 1 || solver.Problem.Optimizing()) && !solver.ranking() {
+		cutoff = solver.RestartCutoff
+	}
+	for {
+		solver.restarting = false
+		solver.restartAt = 0
+		if cutoff > 0 {
+			solver.restartAt = solver.Failures + cutoff
+		}
+		assignment := NewAssignment()
+		solver.search(&assignment, 0, solver.domains)
+		if !solver.restarting {
+			return
+		}
+		solver.Restarts++
+		solver.searchSpan.AddEvent("csp.restart", trace.WithAttributes(Attr("csp.nodes", solver.Nodes),
+			Attr("csp.failures", solver.Failures), Attr("csp.cutoff", cutoff)))
+		solver.decayWeights()
+		cutoff += cutoff/2 + 1
+	}
+}
+
+// Shrinks what each weight has learned, its part above the initial 1, by WeightDecay
+func (solver *Solver) decayWeights() {
+	for i, weight := range solver.weights {
+		if weight > 1 {
+			solver.weights[i] = 1 + (weight-1)*(1-solver.WeightDecay)
+		}
+	}
+}
+
+// The weights DomainOverWeightedDegree starts from, InitialWeights where they name the constraint and 1 elsewhere
+func (solver *Solver) startingWeights() []float64 {
+	weights := make([]float64, len(solver.Problem.Constraints))
+	for i, constraint := range solver.Problem.Constraints {
+		weights[i] = 1
+		if weight, known := solver.InitialWeights[describeConstraint(constraint)]; known && weight > 0 {
+			weights[i] = weight
+		}
+	}
+	return weights
+}
+
+// The constraint weights the last solve learned, by constraint as describeConstraint has it, for InitialWeights
+// of a later solve. Constraints reading the same share a weight, the highest of theirs. Nil unless the solve used
+// DomainOverWeightedDegree.
+func (solver *Solver) Weights() map[string]float64 {
+	if solver.weights == nil {
+		return nil
+	}
+	weights := make(map[string]float64, len(solver.weights))
+	for i, weight := range solver.weights {
+		key := describeConstraint(solver.Problem.Constraints[i])
+		if weight > weights[key] {
+			weights[key] = weight
+		}
+	}
+	return weights
+}
+
+// Reads weights SaveWeights wrote
+func LoadWeights(filename string) (map[string]float64, error) {
+	encoded, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var weights map[string]float64
+	if err := json.Unmarshal(encoded, &weights); err != nil {
+		return nil, err
+	}
+	return weights, nil
+}
+
+// Writes weights as a JSON object from constraint to weight
+func SaveWeights(filename string, weights map[string]float64) error {
+	return WriteJSONFile(filename, weights)
+}
@@ -0,0 +1,65 @@
+package csp
+
+import (
+	"math/big"
+	"math/rand"
+)
+
+// Returns k solutions drawn uniformly at random (with replacement) from the whole solution set, instead of the
+// lexicographically first ones depth-first search would find. The same seed always gives the same sample.
+// Under the hood this counts the completions of every candidate value with the #CSP counter and picks values with
+// probability proportional to those counts, so the sample is exactly uniform, not just approximately. Returns nil
+// when there are no solutions. Only hard constraints are taken into account.
+func (solver *Solver) SampleSolutions(k int, seed int64) []map[string]int {
+	counter := NewCounter(solver.Problem)
+	if counter.Count().Sign() == 0 {
+		return nil
+	}
+	random := rand.New(rand.NewSource(seed))
+
+	var samples []map[string]int
+	for len(samples) < k {
+		assignment := make(map[string]int)
+		for _, component := range counter.graph.components(solver.Problem.Variables, assignment) {
+			counter.sampleComponent(component, assignment, random)
+		}
+		samples = append(samples, assignment)
+	}
+	solver.Nodes += counter.Nodes
+	return samples
+}
+
+// Assigns every variable of the component, which must have at least one completion under the current assignment
+func (counter *Counter) sampleComponent(variables []string, assignment map[string]int, random *rand.Rand) {
+	if len(variables) == 0 {
+		return
+	}
+	variable := variables[0]
+	rest := variables[1:]
+
+	total := new(big.Int)
+	weights := make([]*big.Int, len(counter.Problem.Domains[variable]))
+	for i, value := range counter.Problem.Domains[variable] {
+		weights[i] = new(big.Int)
+		assignment[variable] = value
+		if counter.consistent(variable, assignment) {
+			weights[i].SetInt64(1)
+			for _, component := range counter.graph.components(rest, assignment) {
+				weights[i].Mul(weights[i], counter.countComponent(component, assignment))
+			}
+		}
+		total.Add(total, weights[i])
+	}
+
+	pick := new(big.Int).Rand(random, total)
+	for i, value := range counter.Problem.Domains[variable] {
+		if pick.Cmp(weights[i]) < 0 {
+			assignment[variable] = value
+			break
+		}
+		pick.Sub(pick, weights[i])
+	}
+	for _, component := range counter.graph.components(rest, assignment) {
+		counter.sampleComponent(component, assignment, random)
+	}
+}
@@ -0,0 +1,218 @@
+package csp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A propositional encoding of a problem, the direct encoding: one Boolean per variable and value, true when the
+// variable takes that value. Each variable takes exactly one of its values, and every constraint is the list of
+// clauses ruling out the tuples it forbids, except for the few (NotEqual, AllDifferent, Clause) that map onto
+// clauses directly.
+type CNF struct {
+	Problem   *Problem
+	Booleans  int
+	Clauses   [][]int
+	offsets   map[string]int
+	positions map[string]map[int]int
+}
+
+// Fails on constraints whose scope is too large to list the forbidden tuples of, or on optimization problems
+func EncodeCNF(problem *Problem) (*CNF, error) {
+	if problem.Optimizing() {
+		return nil, fmt.Errorf("the SAT encoding only handles satisfaction problems")
+	}
+	cnf := &CNF{Problem: problem, offsets: make(map[string]int), positions: make(map[string]map[int]int)}
+	for _, variable := range problem.Variables {
+		domain := problem.Domains[variable]
+		cnf.offsets[variable] = cnf.Booleans
+		cnf.positions[variable] = make(map[int]int)
+		var atLeastOne []int
+		for i, value := range domain {
+			cnf.positions[variable][value] = i
+			atLeastOne = append(atLeastOne, cnf.Booleans+i+1)
+			for j := 0; j < i; j++ {
+				cnf.Clauses = append(cnf.Clauses, []int{-(cnf.Booleans + j + 1), -(cnf.Booleans + i + 1)})
+			}
+		}
+		cnf.Clauses = append(cnf.Clauses, atLeastOne)
+		cnf.Booleans += len(domain)
+	}
+
+	for _, constraint := range problem.Constraints {
+		switch constraint := constraint.(type) {
+		case *NotEqual:
+			cnf.differ([]string{constraint.First, constraint.Second})
+		case *AllDifferent:
+			cnf.differ(constraint.Variables)
+		case *Clause:
+			var clause []int
+			satisfied := false
+			for _, literal := range constraint.Literals {
+				boolean, inDomain := cnf.Boolean(literal.Variable, literal.Value)
+				switch {
+				case !inDomain && literal.Negated:
+					satisfied = true
+				case !inDomain:
+				case literal.Negated:
+					clause = append(clause, -boolean)
+				default:
+					clause = append(clause, boolean)
+				}
+			}
+			if !satisfied {
+				cnf.Clauses = append(cnf.Clauses, clause)
+			}
+		default:
+			scope, tuples, ok := ForbiddenTuples(constraint, problem.Domains)
+			if !ok {
+				return nil, fmt.Errorf("constraint over %v is too large to encode", constraint.Scope())
+			}
+			for _, tuple := range tuples {
+				clause := make([]int, len(scope))
+				for i, variable := range scope {
+					boolean, _ := cnf.Boolean(variable, tuple[i])
+					clause[i] = -boolean
+				}
+				cnf.Clauses = append(cnf.Clauses, clause)
+			}
+		}
+	}
+	return cnf, nil
+}
+
+// The Boolean standing for variable = value, and false if value isn't in the variable's domain at all
+func (cnf *CNF) Boolean(variable string, value int) (int, bool) {
+	position, exists := cnf.positions[variable][value]
+	if !exists {
+		return 0, false
+	}
+	return cnf.offsets[variable] + position + 1, true
+}
+
+// No two of the variables may share a value
+func (cnf *CNF) differ(variables []string) {
+	for i := range variables {
+		for j := i + 1; j < len(variables); j++ {
+			for _, value := range cnf.Problem.Domains[variables[i]] {
+				first, _ := cnf.Boolean(variables[i], value)
+				if second, shared := cnf.Boolean(variables[j], value); shared {
+					cnf.Clauses = append(cnf.Clauses, []int{-first, -second})
+				}
+			}
+		}
+	}
+}
+
+// Turns a model, the list of literals a SAT solver reports, back into an assignment
+func (cnf *CNF) Decode(model []int) map[string]int {
+	assignment := make(map[string]int)
+	for _, literal := range model {
+		if literal <= 0 {
+			continue
+		}
+		for _, variable := range cnf.Problem.Variables {
+			offset := cnf.offsets[variable]
+			domain := cnf.Problem.Domains[variable]
+			if literal > offset && literal <= offset+len(domain) {
+				assignment[variable] = domain[literal-offset-1]
+				break
+			}
+		}
+	}
+	return assignment
+}
+
+// DIMACS CNF, the input format every SAT solver reads
+func (cnf *CNF) Write(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "p cnf %d %d\n", cnf.Booleans, len(cnf.Clauses))
+	for _, clause := range cnf.Clauses {
+		for _, literal := range clause {
+			fmt.Fprintf(out, "%d ", literal)
+		}
+		fmt.Fprintln(out, "0")
+	}
+	return out.Flush()
+}
+
+// Runs a SAT solver binary on the direct encoding. The solver has to print its answer in the SAT competition
+// format ("s SATISFIABLE" and "v" lines), as kissat, cadical and glucose do. To get more than one solution the
+// solver is simply rerun with a clause blocking each solution found so far.
+type SATBackend struct {
+	Command      string
+	Args         []string
+	MaxSolutions int
+}
+
+func (backend *SATBackend) Solve(problem *Problem) (Result, error) {
+	cnf, err := EncodeCNF(problem)
+	if err != nil {
+		return Result{}, err
+	}
+	start := time.Now()
+	var result Result
+	for backend.MaxSolutions == 0 || len(result.Solutions) < backend.MaxSolutions {
+		output, err := runExternal(cnf.Write, ".cnf", backend.Command, backend.Args...)
+		if err != nil {
+			return result, err
+		}
+		model, satisfiable, err := parseSATOutput(output)
+		if err != nil {
+			return result, err
+		}
+		if !satisfiable {
+			result.Complete = true
+			break
+		}
+		solution := cnf.Decode(model)
+		result.Solutions = append(result.Solutions, solution)
+		var blocking []int
+		for _, variable := range problem.Variables {
+			boolean, _ := cnf.Boolean(variable, solution[variable])
+			blocking = append(blocking, -boolean)
+		}
+		cnf.Clauses = append(cnf.Clauses, blocking)
+	}
+	result.summarize(problem)
+	result.Stats.Elapsed = time.Since(start)
+	return result, nil
+}
+
+func parseSATOutput(output []byte) ([]int, bool, error) {
+	var model []int
+	status := ""
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	scanner.Buffer(nil, len(output)+1)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "s":
+			status = strings.Join(fields[1:], " ")
+		case "v":
+			for _, field := range fields[1:] {
+				literal, err := strconv.Atoi(field)
+				if err != nil {
+					return nil, false, fmt.Errorf("unexpected SAT solver output: %s", scanner.Text())
+				}
+				if literal != 0 {
+					model = append(model, literal)
+				}
+			}
+		}
+	}
+	switch status {
+	case "SATISFIABLE":
+		return model, true, nil
+	case "UNSATISFIABLE":
+		return nil, false, nil
+	}
+	return nil, false, fmt.Errorf("SAT solver gave no answer (status %q)", status)
+}
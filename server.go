@@ -0,0 +1,909 @@
+package csp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/websocket"
+)
+
+// Node events kept per job, and likewise solution and bound events. Past these the search carries on, but
+// watchers only get the statistics and, once it's done, the result.
+const maxJobEvents = 20000
+
+// Solves models posted over HTTP in the background, streaming each search to whoever watches it over a websocket
+// or as server-sent events. The dashboard at / posts a model and draws the search tree, the domains and the
+// statistics as they come over a websocket.
+//
+//	POST   /jobs?delay=10ms&timeout=30s
+//	                            body is a JSON model (see LoadModel), answers {"id": n}
+//	GET    /jobs                every job, in order of submission
+//	GET    /jobs/{id}           status and statistics so far, and the result once done
+//	GET    /jobs/{id}/socket    websocket of the job's events, replayed from the start for late subscribers,
+//	                            then the latest statistics
+//	GET    /jobs/{id}/events    the same events as a server-sent event stream
+//	POST   /jobs/{id}/stop      interrupts the search
+//	POST   /jobs/{id}/pause     holds the search where it is, giving up its running slot
+//	POST   /jobs/{id}/resume    carries on with a paused search, once there's a slot for it
+//	DELETE /jobs/{id}           stops the job if need be and forgets it
+//	GET    /metrics             job counters and histograms for Prometheus
+//	GET    /debug/pprof/        runtime profiles, see net/http/pprof
+//
+// With a Tracer every job's solve is traced, under the span of a traceparent header on the POST if there is one.
+// With a Store jobs outlive the process, see Restore. Failures to save a job that's already running go to ErrorLog.
+//
+// The limits keep one big model from starving everyone else, zero meaning no limit. Past MaxRunning jobs wait in
+// a queue for their turn, and past MaxQueued more submissions are turned away with 429 Too Many Requests. Paused
+// jobs don't count as running, and resuming one queues it again when every slot is taken. A job's
+// timeout is capped at MaxTimeout, which is also the timeout of jobs that didn't ask for one. MaxMemory stops a job
+// once the heap grows past it; the heap is shared by every job, so it protects the process rather than measuring
+// any one job. Models longer than MaxModelBytes are turned away with 413 Request Entity Too Large before they're
+// read any further.
+type Server struct {
+	Metrics  *ServerMetrics
+	Tracer   trace.Tracer
+	Store    JobStore
+	ErrorLog *log.Logger
+
+	MaxRunning    int
+	MaxQueued     int
+	MaxTimeout    time.Duration
+	MaxNodes      int
+	MaxMemory     uint64
+	MaxModelBytes int64
+
+	mutex   sync.Mutex
+	jobs    map[int]*Job
+	next    int
+	running int
+	queue   []*Job
+}
+
+// One model being solved, or solved already. Events are JSON objects with a "type" of node, solution, stats, bound
+// or done, bound events coming from branch and bound, see BoundEvent. Solution events carry the solutions found
+// since the last one, and only the done event lists them all. Node, solution and bound events are logged for
+// replay, up to maxJobEvents each; stats events only matter until the next one, so only the latest is kept.
+type Job struct {
+	ID      int
+	Problem *Problem
+	Solver  *Solver
+	Started time.Time
+	Delay   time.Duration
+	server  *Server
+	record  JobRecord
+
+	mutex     sync.Mutex
+	changed   *sync.Cond
+	events    []string // the log
+	logged    int      // solution and bound events in it
+	latest    string   // stats or done event
+	version   int      // of latest
+	nodes     int
+	published int // solutions sent in solution events
+	done      bool
+	stopped   bool // on request rather than by a limit
+	deleted   bool
+	restored  bool // finished before a restart, so only the record knows how it went
+
+	// guarded by the server's mutex
+	launched bool // run has been called
+	holding  bool // counts towards MaxRunning
+	parked   bool // paused, and out of both the running jobs and the queue
+	finished bool // the search is over, so there's nothing left to pause
+}
+
+// Server constructor
+func NewServer() *Server {
+	return &Server{Metrics: NewServerMetrics(), ErrorLog: log.New(os.Stderr, "", log.LstdFlags),
+		jobs: make(map[int]*Job), next: 1}
+}
+
+// Brings back the jobs in the store: finished ones with their results, the rest solved again from the start
+func (server *Server) Restore() error {
+	records, err := server.Store.Load()
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		problem, err := LoadModel(bytes.NewReader(record.Model))
+		if err != nil {
+			return fmt.Errorf("job %d: %v", record.ID, err)
+		}
+		if record.ID >= server.next {
+			server.next = record.ID + 1
+		}
+		if record.State != "done" {
+			record.State = "queued"
+		}
+		job := server.newJob(record, problem, context.Background())
+		if record.State == "done" {
+			job.restored = true
+			job.report(job.status(), true)
+			continue
+		}
+		server.Metrics.jobSubmitted()
+		server.schedule(job, true)
+	}
+	return nil
+}
+
+func (server *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, dashboardHTML)
+	})
+	mux.HandleFunc("/jobs", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			server.list(w)
+			return
+		}
+		server.submit(w, r)
+	})
+	mux.HandleFunc("/jobs/", server.job)
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		server.Metrics.Export(w)
+	})
+	registerPprof(mux)
+	return mux
+}
+
+func (server *Server) submit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST a JSON model", http.StatusMethodNotAllowed)
+		return
+	}
+	body := r.Body
+	if server.MaxModelBytes > 0 {
+		body = http.MaxBytesReader(w, r.Body, server.MaxModelBytes)
+	}
+	model, err := io.ReadAll(body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		server.Metrics.jobRejected()
+		http.Error(w, fmt.Sprintf("models are limited to %d bytes", tooLarge.Limit),
+			http.StatusRequestEntityTooLarge)
+		return
+	}
+	var problem *Problem
+	if err == nil {
+		problem, err = LoadModel(bytes.NewReader(model))
+	}
+	if err != nil {
+		server.Metrics.jobRejected()
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var delay, timeout time.Duration
+	if text := r.URL.Query().Get("delay"); text != "" {
+		if delay, err = time.ParseDuration(text); err != nil || delay < 0 || delay > time.Second {
+			server.Metrics.jobRejected()
+			http.Error(w, "delay must be a duration up to 1s", http.StatusBadRequest)
+			return
+		}
+	}
+	if text := r.URL.Query().Get("timeout"); text != "" {
+		if timeout, err = time.ParseDuration(text); err != nil || timeout <= 0 {
+			server.Metrics.jobRejected()
+			http.Error(w, "timeout must be a positive duration", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if server.MaxTimeout > 0 && (timeout == 0 || timeout > server.MaxTimeout) {
+		timeout = server.MaxTimeout
+	}
+
+	server.mutex.Lock()
+	record := JobRecord{ID: server.next, State: "queued", Model: model, Delay: delay, Timeout: timeout,
+		Submitted: time.Now()}
+	server.next++
+	server.mutex.Unlock()
+	parent := propagation.TraceContext{}.Extract(context.Background(), propagation.HeaderCarrier(r.Header))
+	job := server.newJob(record, problem, parent)
+	job.mutex.Lock()
+	err = job.save()
+	job.mutex.Unlock()
+	if err != nil {
+		server.forget(job)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !server.schedule(job, false) {
+		server.forget(job)
+		server.Metrics.jobRejected()
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "too many jobs waiting, try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	server.Metrics.jobSubmitted()
+	writeJSON(w, map[string]int{"id": job.ID})
+}
+
+func (server *Server) newJob(record JobRecord, problem *Problem, trace context.Context) *Job {
+	job := &Job{ID: record.ID, Problem: problem, Solver: NewSolver(problem), Started: time.Now(),
+		Delay: record.Delay, server: server, record: record}
+	job.Solver.Timeout = record.Timeout
+	job.Solver.Sorted = true
+	job.Solver.MaxNodes = server.MaxNodes
+	job.Solver.MaxMemory = server.MaxMemory
+	job.Solver.Tracer = server.Tracer
+	job.Solver.TraceContext = trace
+	job.changed = sync.NewCond(&job.mutex)
+	server.mutex.Lock()
+	server.jobs[job.ID] = job
+	server.mutex.Unlock()
+	return job
+}
+
+// Runs the job if there's room, or queues it for when there is, false when the queue is full. Jobs brought back
+// by Restore are queued past MaxQueued, since turning them away would lose them.
+func (server *Server) schedule(job *Job, restoring bool) bool {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	if server.MaxRunning > 0 && server.running >= server.MaxRunning {
+		if server.MaxQueued > 0 && len(server.queue) >= server.MaxQueued && !restoring {
+			return false
+		}
+		server.queue = append(server.queue, job)
+		server.Metrics.jobQueued(1)
+		return true
+	}
+	server.start(job)
+	return true
+}
+
+// Takes a slot and runs the job, or carries on with its search if it was paused, giving the slot to the next in
+// the queue when done. Called with the server's mutex held.
+func (server *Server) start(job *Job) {
+	server.running++
+	job.holding = true
+	job.Solver.Resume()
+	if job.launched {
+		server.Metrics.jobStarted()
+		return
+	}
+	job.launched = true
+	go func() {
+		job.run()
+		server.mutex.Lock()
+		defer server.mutex.Unlock()
+		if job.holding {
+			job.holding = false
+			server.running--
+			server.fill()
+		}
+	}()
+}
+
+// Starts queued jobs while there are free slots. Called with the server's mutex held.
+func (server *Server) fill() {
+	for len(server.queue) > 0 && (server.MaxRunning == 0 || server.running < server.MaxRunning) {
+		next := server.queue[0]
+		server.queue = server.queue[1:]
+		server.Metrics.jobQueued(-1)
+		server.start(next)
+	}
+}
+
+// Holds the job's search and parks it, giving its slot to the next in the queue, or taking it out of the queue
+// if it hadn't started yet
+func (server *Server) pause(job *Job) {
+	job.Solver.Pause()
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	switch {
+	case job.parked, job.finished:
+		return
+	case job.holding:
+		job.holding = false
+		server.running--
+		server.fill()
+	case !server.unqueue(job):
+		// done already
+		return
+	}
+	job.parked = true
+	server.Metrics.jobPaused(job.launched)
+}
+
+// Schedules a parked job again: it carries on at once if there's a free slot, and queues up for one if not.
+// Restored jobs, and forced ones that are about to be stopped, skip the MaxQueued check.
+func (server *Server) resume(job *Job, force bool) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	if !job.parked {
+		return
+	}
+	job.parked = false
+	server.Metrics.jobResumed()
+	switch {
+	case force && job.launched:
+		// finishes without a slot, since it only has to notice it's been stopped
+		job.Solver.Resume()
+		server.Metrics.jobStarted()
+	case force:
+		server.start(job)
+	case server.MaxRunning > 0 && server.running >= server.MaxRunning:
+		server.queue = append(server.queue, job)
+		server.Metrics.jobQueued(1)
+	default:
+		server.start(job)
+	}
+}
+
+// Takes the job out of the queue, false if it wasn't waiting there
+func (server *Server) dequeue(job *Job) bool {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return server.unqueue(job)
+}
+
+// dequeue with the server's mutex held
+func (server *Server) unqueue(job *Job) bool {
+	for i, queued := range server.queue {
+		if queued == job {
+			server.queue = append(server.queue[:i], server.queue[i+1:]...)
+			server.Metrics.jobQueued(-1)
+			return true
+		}
+	}
+	return false
+}
+
+// Drops a job that never got going
+func (server *Server) forget(job *Job) {
+	server.mutex.Lock()
+	delete(server.jobs, job.ID)
+	server.mutex.Unlock()
+	if server.Store != nil {
+		server.Store.Delete(job.ID)
+	}
+}
+
+// Saves the job's record unless it was deleted. Called with the job's mutex held, which DELETE takes too, so a
+// deleted job can't be written back to the store after it's gone from there.
+func (job *Job) save() error {
+	if job.server.Store == nil || job.deleted {
+		return nil
+	}
+	return job.server.Store.Save(job.record)
+}
+
+// GET /jobs
+func (server *Server) list(w http.ResponseWriter) {
+	server.mutex.Lock()
+	var jobs []*Job
+	for _, job := range server.jobs {
+		jobs = append(jobs, job)
+	}
+	server.mutex.Unlock()
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+	summaries := []map[string]interface{}{}
+	for _, job := range jobs {
+		job.mutex.Lock()
+		record := job.record
+		job.mutex.Unlock()
+		summary := map[string]interface{}{"id": job.ID, "state": record.State, "submitted": record.Submitted}
+		if record.Result != nil {
+			summary["status"] = record.Result.Status
+		}
+		summaries = append(summaries, summary)
+	}
+	writeJSON(w, summaries)
+}
+
+// Routes /jobs/{id} and what's below it
+func (server *Server) job(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/jobs/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	server.mutex.Lock()
+	job := server.jobs[id]
+	server.mutex.Unlock()
+	if err != nil || job == nil || len(parts) > 2 {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, job.status())
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		job.mutex.Lock()
+		job.stopped = true
+		job.deleted = true
+		if server.Store != nil {
+			err = server.Store.Delete(id)
+		}
+		job.mutex.Unlock()
+		job.Solver.Interrupt()
+		server.resume(job, true)
+		server.dequeue(job)
+		server.mutex.Lock()
+		delete(server.jobs, id)
+		server.mutex.Unlock()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case len(parts) == 1:
+		http.NotFound(w, r)
+	case parts[1] == "events" && r.Method == http.MethodGet:
+		job.stream(w, r)
+	case parts[1] == "socket" && r.Method == http.MethodGet:
+		job.socket(w, r)
+	case parts[1] == "stop" && r.Method == http.MethodPost:
+		job.mutex.Lock()
+		job.stopped = true
+		job.mutex.Unlock()
+		job.Solver.Interrupt()
+		server.resume(job, true)
+		if server.dequeue(job) {
+			// finished without ever searching
+			go job.run()
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case parts[1] == "pause" && r.Method == http.MethodPost:
+		server.pause(job)
+		job.publishStatus()
+		w.WriteHeader(http.StatusNoContent)
+	case parts[1] == "resume" && r.Method == http.MethodPost:
+		server.resume(job, false)
+		job.publishStatus()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (job *Job) run() {
+	job.mutex.Lock()
+	stopped := job.stopped
+	job.Started = time.Now()
+	job.record.State = "running"
+	if !stopped {
+		job.server.Metrics.jobStarted()
+		if err := job.save(); err != nil {
+			job.server.ErrorLog.Printf("saving job %d: %v", job.ID, err)
+		}
+	}
+	job.mutex.Unlock()
+
+	solver := job.Solver
+	solver.Propagation = true
+	solver.OnNode = func(event SearchEvent) {
+		job.node(event)
+		if job.Delay > 0 {
+			time.Sleep(job.Delay)
+		}
+	}
+	solver.OnBound = func(event BoundEvent) {
+		bound := map[string]interface{}{"type": "bound", "id": job.ID, "nodes": event.Nodes,
+			"incumbent": event.Incumbent, "cost": event.Cost, "costBound": event.CostBound}
+		if event.HasObjective {
+			bound["objective"], bound["objectiveBound"] = event.Objective, event.ObjectiveBound
+		}
+		if event.Incumbent {
+			bound["gap"] = event.Gap
+		}
+		job.publish(bound)
+	}
+	if !stopped {
+		solver.Solve()
+	}
+	elapsed := time.Since(job.Started)
+	job.server.mutex.Lock()
+	job.finished = true
+	// a pause since the search ended took the job out of the running ones already
+	counted := !stopped && !job.parked
+	if job.parked {
+		job.parked = false
+		job.server.Metrics.jobResumed()
+	}
+	job.server.mutex.Unlock()
+	result := NewSolveResult(solver, elapsed)
+	var solutions []string
+	for _, solution := range solver.Solutions {
+		solutions = append(solutions, FormatSolution(job.Problem, solution))
+	}
+	job.mutex.Lock()
+	job.server.Metrics.jobFinished(job, counted, elapsed)
+	job.record.State = "done"
+	job.record.Result = &result
+	job.record.SolutionList = solutions
+	// the result is still there to be had from memory if saving fails
+	if err := job.save(); err != nil {
+		job.server.ErrorLog.Printf("saving job %d: %v", job.ID, err)
+	}
+	job.mutex.Unlock()
+	job.report(job.status(), true)
+}
+
+func (job *Job) node(event SearchEvent) {
+	job.nodes++
+	if job.nodes <= maxJobEvents {
+		fields := map[string]interface{}{
+			"type":    "node",
+			"depth":   event.Depth,
+			"outcome": event.Outcome.String(),
+		}
+		if event.Outcome != NodeSolution {
+			fields["variable"] = event.Variable
+			fields["value"] = job.Problem.FormatValue(event.Variable, event.Value)
+		}
+		if event.Violated != nil {
+			fields["violated"] = describeConstraint(event.Violated)
+		}
+		if event.Domains != nil {
+			domains := make(map[string]string)
+			for variable, domain := range event.Domains {
+				domains[variable] = formatDomain(job.Problem, variable, domain)
+			}
+			fields["domains"] = domains
+		}
+		job.publish(fields)
+	}
+	if event.Outcome == NodeSolution {
+		job.publishSolutions()
+	}
+	if job.nodes%256 == 0 {
+		job.publishStatus()
+	}
+}
+
+// Sends the solutions found since the last solution event. Only called by the goroutine running the search, which
+// is what makes reading the Solver's solutions safe.
+func (job *Job) publishSolutions() {
+	var solutions []string
+	for _, solution := range job.Solver.Solutions[job.published:] {
+		solutions = append(solutions, FormatSolution(job.Problem, solution))
+	}
+	job.published = len(job.Solver.Solutions)
+	if len(solutions) > 0 {
+		job.publish(map[string]interface{}{"type": "solution", "id": job.ID, "solutions": solutions})
+	}
+}
+
+// Statistics so far, the same shape for the stats and done events, the done event also listing every solution.
+// Safe while the search runs.
+func (job *Job) status() map[string]interface{} {
+	job.mutex.Lock()
+	record, restored, started := job.record, job.restored, job.Started
+	job.mutex.Unlock()
+	if restored {
+		return map[string]interface{}{
+			"type":         "done",
+			"id":           job.ID,
+			"running":      false,
+			"nodes":        record.Result.Nodes,
+			"failures":     record.Result.Failures,
+			"solutions":    len(record.SolutionList),
+			"elapsed":      time.Duration(record.Result.Seconds * float64(time.Second)).String(),
+			"status":       record.Result.Status,
+			"solutionList": record.SolutionList,
+			"result":       record.Result,
+		}
+	}
+	snapshot := job.Solver.Snapshot()
+	status := map[string]interface{}{
+		"type":      "stats",
+		"id":        job.ID,
+		"running":   snapshot.Running,
+		"nodes":     snapshot.Nodes,
+		"failures":  snapshot.Failures,
+		"solutions": len(snapshot.Solutions),
+		"elapsed":   time.Since(started).String(),
+	}
+	if record.State == "queued" {
+		status["queued"] = true
+		status["elapsed"] = "0s"
+	}
+	if job.Solver.Paused() && record.State != "done" {
+		status["paused"] = true
+	}
+	if record.State == "done" {
+		status["type"] = "done"
+		status["status"] = snapshot.Status.String()
+		status["solutionList"] = record.SolutionList
+	}
+	if record.Result != nil {
+		status["elapsed"] = time.Duration(record.Result.Seconds * float64(time.Second)).String()
+		status["result"] = record.Result
+	}
+	return status
+}
+
+// Adds a node, solution or bound event to the log, unless there are maxJobEvents of its kind there already
+func (job *Job) publish(event map[string]interface{}) {
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+	if event["type"] != "node" {
+		if job.logged >= maxJobEvents {
+			return
+		}
+		job.logged++
+	}
+	job.events = append(job.events, string(encoded))
+	job.changed.Broadcast()
+}
+
+// Replaces the statistics watchers get, for good when done
+func (job *Job) report(status map[string]interface{}, done bool) {
+	encoded, err := json.Marshal(status)
+	if err != nil {
+		return
+	}
+	job.mutex.Lock()
+	job.latest = string(encoded)
+	job.version++
+	job.done = job.done || done
+	job.mutex.Unlock()
+	job.changed.Broadcast()
+}
+
+// Tells the job's watchers how it stands, unless it's done and they've been told for good
+func (job *Job) publishStatus() {
+	job.mutex.Lock()
+	done := job.done
+	job.mutex.Unlock()
+	if !done {
+		job.report(job.status(), false)
+	}
+}
+
+// Writes the logged events so far and the latest statistics, then the new ones as they arrive, until the job is
+// done or the client goes away
+// GET /jobs/{id}/events, the job's events as server-sent events
+func (job *Job) stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	job.follow(r.Context(), func(events []string) error {
+		for _, event := range events {
+			fmt.Fprintf(w, "data: %s\n\n", event)
+		}
+		flusher.Flush()
+		return nil
+	})
+}
+
+// GET /jobs/{id}/socket, the job's events as websocket text messages, one event each. The dashboard watches
+// jobs this way; anything the client sends is ignored, and closing the connection unsubscribes.
+func (job *Job) socket(w http.ResponseWriter, r *http.Request) {
+	websocket.Handler(func(conn *websocket.Conn) {
+		// a hijacked connection has no request context to end, so reading is how a hang-up gets noticed
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		go func() {
+			defer cancel()
+			var ignored string
+			for websocket.Message.Receive(conn, &ignored) == nil {
+			}
+		}()
+		job.follow(ctx, func(events []string) error {
+			for _, event := range events {
+				if err := websocket.Message.Send(conn, event); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}).ServeHTTP(w, r)
+}
+
+// Passes the job's events to send as they come, replaying the log from the start and adding the latest stats or
+// done event whenever it changes, until the job is done, ctx ends or send fails
+func (job *Job) follow(ctx context.Context, send func(events []string) error) {
+	gone := ctx.Done()
+	go func() {
+		<-gone
+		job.changed.Broadcast()
+	}()
+	sent, seen := 0, 0
+	for {
+		job.mutex.Lock()
+		for sent == len(job.events) && seen == job.version && !job.done && ctx.Err() == nil {
+			job.changed.Wait()
+		}
+		pending := job.events[sent:len(job.events):len(job.events)]
+		latest, version, done := job.latest, job.version, job.done
+		job.mutex.Unlock()
+		if ctx.Err() != nil {
+			return
+		}
+		sent += len(pending)
+		if version != seen {
+			pending = append(pending, latest)
+			seen = version
+		}
+		if send(pending) != nil {
+			return
+		}
+		// the log is complete once the job is done
+		if done {
+			return
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+const dashboardHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>csp dashboard</title>
+<style>
+body { font-family: sans-serif; margin: 1em; display: grid; grid-template-columns: 28em 1fr; gap: 1em; }
+textarea { width: 100%; height: 22em; font-family: monospace; font-size: 12px; }
+#tree, #tree ul { list-style: none; padding-left: 1.2em; margin: 0; }
+#tree { font-family: monospace; font-size: 12px; max-height: 80vh; overflow: auto; }
+.extended { color: #333; }
+.failed { color: #b00; text-decoration: line-through; }
+.solution { color: #080; font-weight: bold; }
+table { border-collapse: collapse; font-family: monospace; font-size: 12px; }
+td { border: 1px solid #ccc; padding: 2px 6px; }
+.stats span { margin-right: 1em; }
+</style>
+</head>
+<body>
+<div>
+<h3>Model</h3>
+<textarea id="model">{
+  "variables": [
+    {"name": "A", "range": [1, 4]}, {"name": "B", "range": [1, 4]},
+    {"name": "C", "range": [1, 4]}, {"name": "D", "range": [1, 4]}
+  ],
+  "constraints": [
+    {"type": "alldifferent", "variables": ["A", "B", "C", "D"]},
+    {"type": "expression", "expression": "|A - B| != 1 && |B - C| != 1 && |C - D| != 1"},
+    {"type": "expression", "expression": "A < D"}
+  ]
+}</textarea>
+<p>Delay per node <select id="delay"><option>0s</option><option>10ms</option><option selected>100ms</option><option>500ms</option></select>
+<button id="solve">Solve</button> <button id="pause">Pause</button> <button id="stop">Stop</button></p>
+<p id="error" style="color: #b00"></p>
+<h3>Statistics</h3>
+<div class="stats" id="stats"></div>
+<p id="gap"></p>
+<h3>Domains</h3>
+<table id="domains"></table>
+<h3>Solutions</h3>
+<div id="solutions" style="font-family: monospace; font-size: 12px"></div>
+</div>
+<div>
+<h3>Search tree</h3>
+<ul id="tree"></ul>
+</div>
+<script>
+var socket = null, job = null;
+function text(id, value) { document.getElementById(id).textContent = value; }
+document.getElementById("solve").onclick = function () {
+  if (socket) socket.close();
+  text("error", "");
+  document.getElementById("tree").innerHTML = "";
+  document.getElementById("domains").innerHTML = "";
+  document.getElementById("solutions").innerHTML = "";
+  text("gap", "");
+  var delay = document.getElementById("delay").value;
+  fetch("/jobs?delay=" + delay, {method: "POST", body: document.getElementById("model").value})
+    .then(function (response) {
+      if (!response.ok) return response.text().then(function (message) { throw new Error(message); });
+      return response.json();
+    })
+    .then(function (created) { job = created.id; watch(job); })
+    .catch(function (error) { text("error", error.message); });
+};
+document.getElementById("pause").onclick = function () {
+  if (job === null) return;
+  var button = document.getElementById("pause"), pausing = button.textContent === "Pause";
+  fetch("/jobs/" + job + (pausing ? "/pause" : "/resume"), {method: "POST"});
+  button.textContent = pausing ? "Resume" : "Pause";
+};
+document.getElementById("stop").onclick = function () {
+  if (job !== null) fetch("/jobs/" + job + "/stop", {method: "POST"});
+};
+function watch(id) {
+  var levels = [document.getElementById("tree")], last = [];
+  socket = new WebSocket((location.protocol === "https:" ? "wss://" : "ws://") + location.host + "/jobs/" + id +
+    "/socket");
+  socket.onmessage = function (message) {
+    var event = JSON.parse(message.data);
+    if (event.type === "bound") {
+      if (event.gap !== undefined) text("gap", "gap: " + (100 * event.gap).toFixed(1) + "%");
+      return;
+    }
+    if (event.type === "solution") {
+      event.solutions.forEach(addSolution);
+      return;
+    }
+    if (event.type === "node") {
+      if (event.outcome === "solution") {
+        if (last[event.depth - 1]) last[event.depth - 1].className = "solution";
+        return;
+      }
+      var parent = levels[event.depth];
+      if (!parent) return;
+      var item = document.createElement("li");
+      item.textContent = event.variable + "=" + event.value;
+      if (event.outcome === "extended") {
+        item.className = "extended";
+        var children = document.createElement("ul");
+        item.appendChild(children);
+        levels[event.depth + 1] = children;
+        showDomains(event.domains);
+      } else {
+        item.className = "failed";
+        item.title = event.outcome + (event.violated ? ": " + event.violated : "");
+        levels.length = event.depth + 1;
+      }
+      last[event.depth] = item;
+      parent.appendChild(item);
+      item.scrollIntoView({block: "nearest"});
+      return;
+    }
+    var stats = document.getElementById("stats");
+    stats.innerHTML = "";
+    ["nodes", "failures", "solutions", "elapsed", "status", "paused"].forEach(function (key) {
+      if (event[key] === undefined) return;
+      var span = document.createElement("span");
+      span.textContent = key + ": " + event[key];
+      stats.appendChild(span);
+    });
+    if (event.type === "done") {
+      document.getElementById("solutions").innerHTML = "";
+      (event.solutionList || []).forEach(addSolution);
+      socket.close();
+    }
+  };
+}
+function addSolution(solution) {
+  var line = document.createElement("div");
+  line.textContent = solution;
+  document.getElementById("solutions").appendChild(line);
+}
+function showDomains(domains) {
+  var table = document.getElementById("domains");
+  table.innerHTML = "";
+  Object.keys(domains).sort().forEach(function (variable) {
+    var row = table.insertRow();
+    row.insertCell().textContent = variable;
+    row.insertCell().textContent = domains[variable];
+  });
+}
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,276 @@
+package csp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// A model of n unconstrained binary variables, with 2^n solutions
+func binaryModel(n int) string {
+	var variables []string
+	for i := 0; i < n; i++ {
+		variables = append(variables, fmt.Sprintf(`{"name": "x%d", "range": [0, 1]}`, i))
+	}
+	return `{"variables": [` + strings.Join(variables, ", ") + `], "constraints": []}`
+}
+
+// Posts the model, with the query string if any, and returns the job's id
+func submitJob(t *testing.T, url string, query string, model string) int {
+	t.Helper()
+	response, err := http.Post(url+"/jobs"+query, "application/json", strings.NewReader(model))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	var created struct{ ID int }
+	if err := json.NewDecoder(response.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	return created.ID
+}
+
+// Every event of the job's stream, up to and including the done event
+func jobEvents(t *testing.T, url string, id int) []map[string]interface{} {
+	t.Helper()
+	response, err := http.Get(fmt.Sprintf("%s/jobs/%d/events", url, id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	var events []map[string]interface{}
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Buffer(nil, 64<<20)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var event map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, event)
+		if event["type"] == "done" {
+			return events
+		}
+	}
+	t.Fatalf("stream ended without a done event: %v", scanner.Err())
+	return nil
+}
+
+func TestServerEventsStaySmall(t *testing.T) {
+	server := NewServer()
+	web := httptest.NewServer(server.Handler())
+	defer web.Close()
+	const variables = 15 // more solutions than maxJobEvents
+	id := submitJob(t, web.URL, "", binaryModel(variables))
+
+	events := jobEvents(t, web.URL, id)
+	streamed := make(map[string]bool)
+	for _, event := range events {
+		switch event["type"] {
+		case "stats":
+			if _, listed := event["solutionList"]; listed {
+				t.Fatal("a stats event lists the solutions")
+			}
+		case "solution":
+			for _, solution := range event["solutions"].([]interface{}) {
+				if streamed[solution.(string)] {
+					t.Fatalf("solution %v sent twice", solution)
+				}
+				streamed[solution.(string)] = true
+			}
+		}
+	}
+	done := events[len(events)-1]
+	if listed := len(done["solutionList"].([]interface{})); listed != 1<<variables {
+		t.Fatalf("the done event lists %d solutions, want %d", listed, 1<<variables)
+	}
+	if len(streamed) == 0 || len(streamed) > maxJobEvents {
+		t.Fatalf("%d solutions streamed, want some and at most %d", len(streamed), maxJobEvents)
+	}
+
+	job := server.jobs[id]
+	job.mutex.Lock()
+	logged := len(job.events)
+	job.mutex.Unlock()
+	if logged > 2*maxJobEvents {
+		t.Fatalf("%d events kept for replay, want at most %d", logged, 2*maxJobEvents)
+	}
+	// a late subscriber gets the same log and the final result
+	if replayed := jobEvents(t, web.URL, id); len(replayed) != logged+1 {
+		t.Fatalf("replay has %d events, want the %d logged and the done event", len(replayed), logged)
+	}
+}
+
+// The job's status, as GET /jobs/{id} has it
+func jobStatus(t *testing.T, url string, id int) map[string]interface{} {
+	t.Helper()
+	response, err := http.Get(fmt.Sprintf("%s/jobs/%d", url, id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	var status map[string]interface{}
+	if err := json.NewDecoder(response.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	return status
+}
+
+func postJob(t *testing.T, url string, id int, action string) {
+	t.Helper()
+	response, err := http.Post(fmt.Sprintf("%s/jobs/%d/%s", url, id, action), "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+}
+
+func TestPausedJobGivesUpItsSlot(t *testing.T) {
+	server := NewServer()
+	server.MaxRunning = 1
+	web := httptest.NewServer(server.Handler())
+	defer web.Close()
+
+	slow := submitJob(t, web.URL, "?delay=1ms", binaryModel(20))
+	deadline := time.Now().Add(10 * time.Second)
+	for jobStatus(t, web.URL, slow)["nodes"].(float64) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the first job never started")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	postJob(t, web.URL, slow, "pause")
+
+	// with the only slot given up, the next job runs to the end
+	quick := submitJob(t, web.URL, "", binaryModel(3))
+	if done := jobEvents(t, web.URL, quick); done[len(done)-1]["status"] != "satisfiable" {
+		t.Fatalf("the job after the paused one ended %v", done[len(done)-1]["status"])
+	}
+	var metrics strings.Builder
+	server.Metrics.Export(&metrics)
+	if !strings.Contains(metrics.String(), "csp_jobs_paused 1\n") {
+		t.Fatalf("the paused job isn't counted:\n%s", metrics.String())
+	}
+
+	postJob(t, web.URL, slow, "resume")
+	if status := jobStatus(t, web.URL, slow); status["paused"] != nil || status["queued"] != nil {
+		t.Fatalf("resumed job with a free slot is still held: %v", status)
+	}
+	postJob(t, web.URL, slow, "stop")
+	jobEvents(t, web.URL, slow)
+	metrics.Reset()
+	server.Metrics.Export(&metrics)
+	for _, gauge := range []string{"csp_jobs_paused 0\n", "csp_jobs_running 0\n", "csp_jobs_queued 0\n"} {
+		if !strings.Contains(metrics.String(), gauge) {
+			t.Fatalf("want %q once every job is done:\n%s", gauge, metrics.String())
+		}
+	}
+}
+
+func TestDeletedJobStaysOutOfTheStore(t *testing.T) {
+	store, err := NewFileJobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer()
+	server.Store = store
+	web := httptest.NewServer(server.Handler())
+	defer web.Close()
+
+	id := submitJob(t, web.URL, "?delay=1ms", binaryModel(20))
+	server.mutex.Lock()
+	job := server.jobs[id]
+	server.mutex.Unlock()
+	request, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/jobs/%d", web.URL, id), nil)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	// the search notices the interrupt and saves its result, which mustn't bring the job back
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		job.mutex.Lock()
+		state := job.record.State
+		job.mutex.Unlock()
+		if state == "done" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the deleted job never finished")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	records, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 0 {
+		t.Fatalf("the store still has %+v", records)
+	}
+}
+
+func TestOversizedModelIsTurnedAway(t *testing.T) {
+	server := NewServer()
+	server.MaxModelBytes = 1 << 10
+	web := httptest.NewServer(server.Handler())
+	defer web.Close()
+
+	response, err := http.Post(web.URL+"/jobs", "application/json", strings.NewReader(binaryModel(100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("a model past MaxModelBytes got %s", response.Status)
+	}
+	if id := submitJob(t, web.URL, "", binaryModel(3)); id == 0 {
+		t.Fatal("a small model was turned away")
+	}
+}
+
+func TestSocketStreamsTheSameEvents(t *testing.T) {
+	web := httptest.NewServer(NewServer().Handler())
+	defer web.Close()
+
+	id := submitJob(t, web.URL, "", binaryModel(4))
+	conn, err := websocket.Dial(fmt.Sprintf("ws%s/jobs/%d/socket", strings.TrimPrefix(web.URL, "http"), id), "",
+		web.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	var events []map[string]interface{}
+	for {
+		var message string
+		if err := websocket.Message.Receive(conn, &message); err != nil {
+			t.Fatalf("socket closed without a done event: %v", err)
+		}
+		var event map[string]interface{}
+		if err := json.Unmarshal([]byte(message), &event); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, event)
+		if event["type"] == "done" {
+			break
+		}
+	}
+	if streamed := jobEvents(t, web.URL, id); !reflect.DeepEqual(events, streamed) {
+		t.Fatalf("the socket sent %v, the event stream %v", events, streamed)
+	}
+	if done := events[len(events)-1]; done["solutions"] != float64(16) {
+		t.Fatalf("done event %v, want 16 solutions", done)
+	}
+}
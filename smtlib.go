@@ -0,0 +1,299 @@
+package csp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Writes the problem as an SMT-LIB2 script over integers (QF_LIA), so an answer can be cross-checked with Z3 or
+// cvc5. Every variable becomes an Int restricted to its domain and every constraint one assertion. Constraints
+// that only exist as Go code (Predicate, or any type this file doesn't know) are expanded into the table of
+// tuples they allow, which only works while their scope is small.
+// Objectives and soft constraints use Z3's minimize/maximize and assert-soft extensions, which other solvers
+// may reject.
+func ExportSMTLIB(problem *Problem, w io.Writer) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "(set-logic QF_LIA)")
+	for _, variable := range problem.Variables {
+		fmt.Fprintf(out, "(declare-fun %s () Int)\n", smtSymbol(variable))
+	}
+	for _, variable := range problem.Variables {
+		fmt.Fprintf(out, "(assert %s)\n", smtDomain(variable, problem.Domains[variable]))
+	}
+
+	auxiliary := 0
+	for _, constraint := range problem.Constraints {
+		declarations, formula, err := smtFormula(problem, constraint, &auxiliary)
+		if err != nil {
+			return err
+		}
+		for _, declaration := range declarations {
+			fmt.Fprintln(out, declaration)
+		}
+		fmt.Fprintf(out, "(assert %s)\n", formula)
+	}
+	for _, soft := range problem.SoftConstraints {
+		declarations, formula, err := smtFormula(problem, soft.Constraint, &auxiliary)
+		if err != nil {
+			return err
+		}
+		for _, declaration := range declarations {
+			fmt.Fprintln(out, declaration)
+		}
+		fmt.Fprintf(out, "(assert-soft %s :weight %d)\n", formula, soft.Weight)
+	}
+	if problem.Objective != "" {
+		direction := "minimize"
+		if problem.Maximizing {
+			direction = "maximize"
+		}
+		fmt.Fprintf(out, "(%s %s)\n", direction, smtSymbol(problem.Objective))
+	}
+
+	fmt.Fprintln(out, "(check-sat)")
+	var symbols []string
+	for _, variable := range problem.Variables {
+		symbols = append(symbols, smtSymbol(variable))
+	}
+	if len(symbols) > 0 {
+		fmt.Fprintf(out, "(get-value (%s))\n", strings.Join(symbols, " "))
+	}
+	return out.Flush()
+}
+
+// Variable names like "Q[3]" aren't valid simple symbols, so everything gets quoted
+func smtSymbol(variable string) string {
+	return "|" + strings.Replace(variable, "|", "_", -1) + "|"
+}
+
+// SMT-LIB has no negative literals, -5 is written (- 5)
+func smtInt(value int) string {
+	if value < 0 {
+		return fmt.Sprintf("(- %d)", -value)
+	}
+	return fmt.Sprint(value)
+}
+
+func smtDomain(variable string, domain []int) string {
+	if len(domain) == 0 {
+		return "false"
+	}
+	low, high := domainBounds(domain)
+	symbol := smtSymbol(variable)
+	if high-low+1 == len(uniqueValues(domain)) {
+		return fmt.Sprintf("(and (<= %s %s) (<= %s %s))", smtInt(low), symbol, symbol, smtInt(high))
+	}
+	var terms []string
+	for _, value := range domain {
+		terms = append(terms, fmt.Sprintf("(= %s %s)", symbol, smtInt(value)))
+	}
+	return smtJoin("or", terms)
+}
+
+func uniqueValues(values []int) map[int]bool {
+	unique := make(map[int]bool)
+	for _, value := range values {
+		unique[value] = true
+	}
+	return unique
+}
+
+// (op a b ...) with the degenerate cases SMT-LIB doesn't accept spelled out
+func smtJoin(op string, terms []string) string {
+	switch {
+	case len(terms) == 0 && op == "and":
+		return "true"
+	case len(terms) == 0 && op == "or":
+		return "false"
+	case len(terms) == 0 && op == "+":
+		return "0"
+	case len(terms) == 1:
+		return terms[0]
+	}
+	return "(" + op + " " + strings.Join(terms, " ") + ")"
+}
+
+// The formula for one constraint, plus the declarations of any auxiliary variables it needs
+func smtFormula(problem *Problem, constraint Constraint, auxiliary *int) ([]string, string, error) {
+	switch constraint := constraint.(type) {
+	case *AllDifferent:
+		var symbols []string
+		for _, variable := range uniqueScope(constraint.Variables) {
+			symbols = append(symbols, smtSymbol(variable))
+		}
+		if len(symbols) < len(constraint.Variables) {
+			// a variable listed twice can never differ from itself
+			return nil, "false", nil
+		}
+		if len(symbols) < 2 {
+			return nil, "true", nil
+		}
+		return nil, "(distinct " + strings.Join(symbols, " ") + ")", nil
+	case *NotEqual:
+		return nil, fmt.Sprintf("(not (= %s %s))", smtSymbol(constraint.First), smtSymbol(constraint.Second)), nil
+	case *Linear:
+		var terms []string
+		for i, variable := range constraint.Variables {
+			terms = append(terms, fmt.Sprintf("(* %s %s)", smtInt(constraint.Coefficients[i]), smtSymbol(variable)))
+		}
+		return nil, smtCompare(smtJoin("+", terms), constraint.Operator, smtInt(constraint.Constant)), nil
+	case *Table:
+		return nil, smtTable(constraint.Variables, constraint.Tuples), nil
+	case *Clause:
+		var terms []string
+		for _, literal := range constraint.Literals {
+			term := fmt.Sprintf("(= %s %s)", smtSymbol(literal.Variable), smtInt(literal.Value))
+			if literal.Negated {
+				term = "(not " + term + ")"
+			}
+			terms = append(terms, term)
+		}
+		return nil, smtJoin("or", terms), nil
+	case *Inverse:
+		var terms []string
+		for i, forward := range constraint.Forward {
+			for j, backward := range constraint.Backward {
+				terms = append(terms, fmt.Sprintf("(= (= %s %d) (= %s %d))",
+					smtSymbol(forward), j+1, smtSymbol(backward), i+1))
+			}
+		}
+		return nil, smtJoin("and", terms), nil
+	case *Disjunctive:
+		var terms []string
+		for i := range constraint.Starts {
+			for j := i + 1; j < len(constraint.Starts); j++ {
+				first, second := smtSymbol(constraint.Starts[i]), smtSymbol(constraint.Starts[j])
+				terms = append(terms, fmt.Sprintf("(or (<= (+ %s %s) %s) (<= (+ %s %s) %s))",
+					first, smtInt(constraint.Durations[i]), second, second, smtInt(constraint.Durations[j]), first))
+			}
+		}
+		return nil, smtJoin("and", terms), nil
+	case *Regular:
+		return smtRegular(constraint, auxiliary)
+	}
+
+	scope, tuples, ok := AllowedTuples(constraint, problem.Domains)
+	if !ok {
+		return nil, "", fmt.Errorf("constraint over %v is too large to export as a table", constraint.Scope())
+	}
+	return nil, smtTable(scope, tuples), nil
+}
+
+func smtCompare(left string, operator string, right string) string {
+	switch operator {
+	case "!=":
+		return fmt.Sprintf("(not (= %s %s))", left, right)
+	case "=", "<", "<=", ">", ">=":
+		return fmt.Sprintf("(%s %s %s)", operator, left, right)
+	}
+	return "false"
+}
+
+func smtTable(variables []string, tuples [][]int) string {
+	var rows []string
+	for _, tuple := range tuples {
+		var terms []string
+		for i, variable := range variables {
+			terms = append(terms, fmt.Sprintf("(= %s %s)", smtSymbol(variable), smtInt(tuple[i])))
+		}
+		rows = append(rows, smtJoin("and", terms))
+	}
+	return smtJoin("or", rows)
+}
+
+// The automaton is unrolled with one state variable per position: state 0 is Start, every variable's value has
+// to be a transition out of the state before it, and the last state has to be accepting
+func smtRegular(constraint *Regular, auxiliary *int) ([]string, string, error) {
+	*auxiliary++
+	var declarations, states []string
+	for i := 0; i <= len(constraint.Variables); i++ {
+		states = append(states, smtSymbol(fmt.Sprintf("regular%d_state%d", *auxiliary, i)))
+		declarations = append(declarations, fmt.Sprintf("(declare-fun %s () Int)", states[i]))
+	}
+	terms := []string{fmt.Sprintf("(= %s %d)", states[0], constraint.Start)}
+	for i, variable := range constraint.Variables {
+		var steps []string
+		for state, transitions := range constraint.Transitions {
+			for _, value := range sortedKeys(transitions) {
+				steps = append(steps, fmt.Sprintf("(and (= %s %d) (= %s %s) (= %s %d))",
+					states[i], state, smtSymbol(variable), smtInt(value), states[i+1], transitions[value]))
+			}
+		}
+		terms = append(terms, smtJoin("or", steps))
+	}
+	var accepting []string
+	for _, state := range sortedKeys(constraint.Accepting) {
+		if constraint.Accepting[state] {
+			accepting = append(accepting, fmt.Sprintf("(= %s %d)", states[len(states)-1], state))
+		}
+	}
+	terms = append(terms, smtJoin("or", accepting))
+	return declarations, smtJoin("and", terms), nil
+}
+
+// Map iteration order is random, exports shouldn't be
+func sortedKeys(m interface{}) []int {
+	var keys []int
+	switch m := m.(type) {
+	case map[int]int:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[int]bool:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// Tables bigger than this aren't worth writing out
+const exportTableLimit = 100000
+
+// Lists every combination of the scope's values the constraint accepts, for exporting constraints that have no
+// direct counterpart in the target format. Fails if the scope's domains multiply out past exportTableLimit.
+func AllowedTuples(constraint Constraint, domains map[string][]int) ([]string, [][]int, bool) {
+	return tuplesWhere(constraint, domains, true)
+}
+
+// The opposite of AllowedTuples, every combination the constraint rejects
+func ForbiddenTuples(constraint Constraint, domains map[string][]int) ([]string, [][]int, bool) {
+	return tuplesWhere(constraint, domains, false)
+}
+
+func tuplesWhere(constraint Constraint, domains map[string][]int, satisfied bool) ([]string, [][]int, bool) {
+	scope := uniqueScope(constraint.Scope())
+	size := 1
+	for _, variable := range scope {
+		size *= len(domains[variable])
+		if size > exportTableLimit {
+			return nil, nil, false
+		}
+	}
+	var tuples [][]int
+	assignment := make(map[string]int)
+	var walk func(i int)
+	walk = func(i int) {
+		if i == len(scope) {
+			if constraint.Satisfied(assignment) == satisfied {
+				tuple := make([]int, len(scope))
+				for j, variable := range scope {
+					tuple[j] = assignment[variable]
+				}
+				tuples = append(tuples, tuple)
+			}
+			return
+		}
+		for _, value := range domains[scope[i]] {
+			assignment[scope[i]] = value
+			walk(i + 1)
+		}
+		delete(assignment, scope[i])
+	}
+	walk(0)
+	return scope, tuples, true
+}
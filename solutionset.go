@@ -0,0 +1,155 @@
+package csp
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Solutions compared by value: two assignments with the same variables and the same values are the same member,
+// whichever solve found them. Sets from separate solves, say two scenarios of one model, can be compared with the
+// set operations, which leave their operands alone and return a new set. Members keep the order they were added in.
+type SolutionSet struct {
+	members []map[string]int
+	index   map[string]int // position in members by key
+}
+
+// SolutionSet constructor, with solutions as the first members
+func NewSolutionSet(solutions ...map[string]int) *SolutionSet {
+	set := &SolutionSet{index: make(map[string]int)}
+	for _, solution := range solutions {
+		set.Add(solution)
+	}
+	return set
+}
+
+// Adds a copy of solution, reporting whether it wasn't a member yet
+func (set *SolutionSet) Add(solution map[string]int) bool {
+	key := assignmentKey(solution)
+	if _, member := set.index[key]; member {
+		return false
+	}
+	set.index[key] = len(set.members)
+	set.members = append(set.members, copyAssignment(solution))
+	return true
+}
+
+func (set *SolutionSet) Contains(solution map[string]int) bool {
+	_, member := set.index[assignmentKey(solution)]
+	return member
+}
+
+func (set *SolutionSet) Len() int {
+	return len(set.members)
+}
+
+// The members in the order they were added. They must not be modified.
+func (set *SolutionSet) Solutions() []map[string]int {
+	return set.members
+}
+
+// The members of either set, this one's first
+func (set *SolutionSet) Union(other *SolutionSet) *SolutionSet {
+	union := NewSolutionSet(set.members...)
+	for _, solution := range other.members {
+		union.Add(solution)
+	}
+	return union
+}
+
+// The members of this set that are also in other
+func (set *SolutionSet) Intersection(other *SolutionSet) *SolutionSet {
+	intersection := NewSolutionSet()
+	for _, solution := range set.members {
+		if other.Contains(solution) {
+			intersection.Add(solution)
+		}
+	}
+	return intersection
+}
+
+// The members of this set that aren't in other
+func (set *SolutionSet) Difference(other *SolutionSet) *SolutionSet {
+	difference := NewSolutionSet()
+	for _, solution := range set.members {
+		if !other.Contains(solution) {
+			difference.Add(solution)
+		}
+	}
+	return difference
+}
+
+// Every member cut down to the variables given, members that only differed elsewhere becoming one. A member
+// without some of the variables keeps the ones it has.
+func (set *SolutionSet) Project(variables ...string) *SolutionSet {
+	projection := NewSolutionSet()
+	for _, solution := range set.members {
+		projected := make(map[string]int, len(variables))
+		for _, variable := range variables {
+			if value, assigned := solution[variable]; assigned {
+				projected[variable] = value
+			}
+		}
+		projection.Add(projected)
+	}
+	return projection
+}
+
+// How many members give the variable each of its values, members without it not counting
+func (set *SolutionSet) ValueCounts(variable string) map[int]int {
+	counts := make(map[int]int)
+	for _, solution := range set.members {
+		if value, assigned := solution[variable]; assigned {
+			counts[value]++
+		}
+	}
+	return counts
+}
+
+// The solutions found so far cut down to the variables given, see SolutionSet.Project
+func (solver *Solver) Project(variables ...string) *SolutionSet {
+	return NewSolutionSet(solver.Solutions...).Project(variables...)
+}
+
+// How many of the solutions found so far give the variable each of its values. With MaxSolutions 0 that's every
+// solution; Counter.ValueCounts gets the same numbers without enumerating them.
+func (solver *Solver) ValueCounts(variable string) map[int]int {
+	return NewSolutionSet(solver.Solutions...).ValueCounts(variable)
+}
+
+// A sorted copy of the solutions, compared value by value in the order of the problem's variables, an unassigned
+// variable coming before any value
+func SortSolutions(problem *Problem, solutions []map[string]int) []map[string]int {
+	sorted := append([]map[string]int(nil), solutions...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		for _, variable := range problem.Variables {
+			a, aAssigned := sorted[i][variable]
+			b, bAssigned := sorted[j][variable]
+			switch {
+			case aAssigned != bAssigned:
+				return !aAssigned
+			case a != b:
+				return a < b
+			}
+		}
+		return false
+	})
+	return sorted
+}
+
+// The same for any two assignments with the same variables and values, whatever order the map iterates in
+func assignmentKey(assignment map[string]int) string {
+	variables := make([]string, 0, len(assignment))
+	for variable := range assignment {
+		variables = append(variables, variable)
+	}
+	sort.Strings(variables)
+	var key strings.Builder
+	for _, variable := range variables {
+		key.WriteString(strconv.Quote(variable))
+		key.WriteByte('=')
+		key.WriteString(strconv.Itoa(assignment[variable]))
+		key.WriteByte(' ')
+	}
+	return key.String()
+}
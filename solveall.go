@@ -0,0 +1,69 @@
+package csp
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Solves independent problems, say thousands of generated puzzles, on a pool of workers goroutines (NumCPU of them
+// when workers is 0), each problem with a solver of its own made with the options. The results come back in the
+// order of problems. Cancelling ctx, or its deadline passing, interrupts the solves in progress and skips the ones
+// not started yet, all of which end up with Status Unknown, and the error is then ctx's.
+func SolveAll(ctx context.Context, problems []*Problem, workers int, options ...SolverOption) ([]Result, error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	results := make([]Result, len(problems))
+	next := make(chan int)
+	var wait sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for i := range next {
+				results[i] = solveWithContext(ctx, NewSolver(problems[i], options...))
+			}
+		}()
+	}
+feed:
+	for i := range problems {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wait.Wait()
+	return results, ctx.Err()
+}
+
+// Solves, interrupting the solver if ctx is cancelled first and stopping by ctx's deadline
+func solveWithContext(ctx context.Context, solver *Solver) Result {
+	if ctx.Err() != nil {
+		return Result{}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		// a Timeout of 0 would mean no limit at all
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return Result{}
+		}
+		if solver.Timeout == 0 || remaining < solver.Timeout {
+			solver.Timeout = remaining
+		}
+	}
+	solved := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			solver.Interrupt()
+		case <-solved:
+		}
+	}()
+	solver.Solve()
+	close(solved)
+	return solver.Result()
+}
@@ -0,0 +1,31 @@
+package csp
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSolveAllExpiredDeadline(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	results, err := SolveAll(ctx, []*Problem{wideProblem(), wideProblem()}, 1)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	for i, result := range results {
+		if result.Status != Unknown || len(result.Solutions) > 0 {
+			t.Errorf("problem %d ran past an expired deadline: %d solutions with status %v", i,
+				len(result.Solutions), result.Status)
+		}
+	}
+}
+
+func TestSolveAllCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := SolveAll(ctx, []*Problem{wideProblem()}, 1)
+	if err != context.Canceled || results[0].Status != Unknown {
+		t.Fatalf("got status %v and error %v after cancelling", results[0].Status, err)
+	}
+}
@@ -0,0 +1,909 @@
+package csp
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Depth-first counterpart to the Root tree. Instead of materializing every layer of the search space, the Solver
+// keeps a single partial assignment and backtracks out of it as soon as a constraint is violated, which is what
+// lets it handle problems far bigger than the 8 variable puzzle.
+//
+// Concurrency: a Solver runs one Solve at a time, and only the goroutine running it touches the exported fields
+// until it returns. Other goroutines, say a web handler reporting on a long solve, must go through Snapshot,
+// which is safe to call at any time. Configuring the solver while it runs isn't supported; starting a second Solve
+// while one is running panics.
+type Solver struct {
+	Problem      *Problem
+	MaxSolutions int // 0 means find all solutions
+	Solutions    []map[string]int
+	Nodes        int
+	Failures     int
+
+	// Optimization mode only: every solution found is an improvement on the previous one, so Solutions ends up
+	// holding the sequence of incumbents and Best is the last of them. Soft constraint cost is minimized first,
+	// and the objective breaks ties.
+	Best          map[string]int
+	BestObjective int
+	BestCost      int
+
+	// Solve independent parts of the problem separately, optionally each on its own goroutine
+	Decompose bool
+	Parallel  bool
+
+	PreprocessLevel PreprocessLevel
+
+	// Run the propagation engine after every assignment, pruning the domains of the variables still to come
+	Propagation  bool
+	Propagations int
+
+	// Compile every solution of the hard constraints into Diagram instead of listing them one by one
+	Compile bool
+	Diagram *MDD
+
+	// Search AND/OR style, solving independent parts of the residual problem separately and caching their results
+	// by context, see andOrSearch. It finds a single solution, the best when optimizing. It doesn't propagate
+	// below the root, so it's for models that fall apart into pieces; dense ones do better with the plain search.
+	// ContextHits counts the subproblems the cache answered.
+	AndOr       bool
+	ContextHits int
+
+	// Resource limits, 0 meaning unlimited. Hitting one stops the search with Status Unknown, keeping whatever
+	// solutions or incumbent it had found by then. MaxMemory is in bytes of Go heap and only sampled every
+	// snapshotInterval nodes, so it's a budget rather than a hard cap.
+	MaxNodes    int
+	MaxFailures int
+	MaxMemory   uint64
+	Status      SolveStatus
+
+	// Stops the search the same way as the limits above. Interrupt does it from another goroutine.
+	Timeout time.Duration
+
+	// When the search gets stopped before finding any solution, fill in Closest with the deepest consistent
+	// partial assignment reached, completed greedily with whatever values break the fewest constraints, and
+	// ClosestViolations with the number of constraints it breaks
+	Anytime           bool
+	Closest           map[string]int
+	ClosestViolations int
+
+	// Value ordering: a variable's value in Hint is tried first, say last week's roster when building this
+	// week's. With PhaseSaving the solver also remembers the last value each variable had in a consistent
+	// assignment, across Solve calls too, and tries that before anything else, which makes re-solving a slightly
+	// changed model mostly retrace the previous solution.
+	Hint        map[string]int
+	PhaseSaving bool
+
+	// Optimization: try the values that make for a better objective first, for the objective and the variables
+	// a linear equation ties to it, see objectiveDirections. Good incumbents early tighten the bound sooner.
+	ObjectiveOrdering bool
+
+	// Optimization: asked at every node where the objective is still open how good it could get, see Bounder.
+	// BoundPrunes counts the nodes it cut.
+	Bounder     Bounder
+	BoundPrunes int
+
+	// How to optimize the soft constraint cost, see OptimizationStrategy. With CoreGuided, Cores counts the cores
+	// found and CoreBound adds up what they cost, a lower bound on the optimal cost even when the search gets
+	// stopped.
+	Optimization OptimizationStrategy
+	Cores        int
+	CoreBound    int
+
+	// Detect interchangeable variables and values and solve with lex-leader constraints that keep one solution out
+	// of every symmetric family, see BreakSymmetries. Symmetries reports what was found.
+	SymmetryBreaking bool
+	Symmetries       Symmetries
+
+	// Pick the ordering, restarts, preprocessing, propagation, decomposition and optimization strategy from what
+	// the model looks like, see ModelFeatures.Recommend, overriding those fields at the start of every Solve.
+	// Configuration is what got picked.
+	AutoConfigure bool
+	Configuration Configuration
+
+	// Which variable to branch on next, see VariableOrdering
+	VariableOrdering VariableOrdering
+
+	// Let an outside scorer pick the branches, see BranchScorer. It gets every value of the BranchCandidates
+	// variables the ordering ranks first, 0 meaning all of them, and BranchTimeout to answer in, 0 meaning no limit
+	// of its own. When it fails or runs out of time the node falls back on the orderings, counted in
+	// BranchFallbacks, and after branchScorerGiveUp fallbacks in a row the rest of the solve does without it.
+	BranchScorer     BranchScorer
+	BranchCandidates int
+	BranchTimeout    time.Duration
+	BranchFallbacks  int
+
+	// Start the search over from the root after RestartCutoff failures, 0 meaning never, with a cutoff half as
+	// big again every time so the search stays complete. Restarts let DomainOverWeightedDegree put what it learned
+	// from the failures to use near the root. Every restart takes WeightDecay, a fraction, off what each weight
+	// learned, so recent failures count for more than old ones. Only searches for a single solution or an optimum
+	// restart, since enumerating would find the same solutions again. Restarts counts them.
+	RestartCutoff int
+	WeightDecay   float64
+	Restarts      int
+
+	// Remember the outcome of every constraint check by the values of the constraint's scope, so sibling subtrees
+	// assigning the same values don't check the same thing again. It pays off for expensive constraints and
+	// costs a little for cheap ones. CacheHits and CacheMisses count the checks answered from the cache and not.
+	CheckCache  bool
+	CacheHits   int
+	CacheMisses int
+
+	// Instead of searching, walk EstimateProbes random paths from the root to estimate how big the search tree is,
+	// see TreeEstimate, which is all Estimate tells; the solve ends with Status Unknown unless preprocessing or
+	// propagation at the root proved the problem unsatisfiable. EstimateSeed seeds the paths. The estimate stays
+	// for the solves after, for OnProgress.
+	EstimateProbes int
+	EstimateSeed   int64
+	Estimate       TreeEstimate
+
+	// Dive ProbeDives times before searching, to learn weights, values and a first bound, see probe. ProbeSeed
+	// seeds the random dives.
+	ProbeDives int
+	ProbeSeed  int64
+
+	// Weights for DomainOverWeightedDegree to start from instead of 1, keyed the way Weights exports them, so a
+	// solve can pick up where a solve of a similar model left off
+	InitialWeights map[string]float64
+
+	// Rank the solutions by Score, best first, instead of leaving them in the order the search found them, and
+	// keep only the TopK best when it's set. Those are kept in a heap while the search runs, so picking the ten
+	// best of a million solutions takes memory for ten; Solutions only fills in once the search is done, with
+	// Scores alongside. MaxSolutions still counts every solution found. Optimization ranks solutions its own way
+	// and ignores Score.
+	Score  ScoreFunc
+	TopK   int
+	Scores []float64
+
+	// Branch and bound only: OnBound hears about every new incumbent, and every snapshotInterval nodes about the
+	// bounds on what the search has left when they've moved, see BoundEvent. The search stops once the gap is
+	// down to MaxGap, a fraction, with Status Satisfiable, or Optimal if the gap is 0.
+	OnBound func(event BoundEvent)
+	MaxGap  float64
+
+	// Sort Solutions once the search is done, lexicographically by value in the order of the problem's variables,
+	// whatever order the variable ordering, restarts or decomposition found them in, so two runs can be diffed.
+	// Only the solutions found get sorted: with MaxSolutions set they needn't be the lexicographically first ones.
+	// Optimization and Score have orders of their own and ignore it.
+	Sorted bool
+
+	// Hears how far along the search is, see ProgressEvent, every snapshotInterval nodes once ProgressInterval has
+	// passed since it last did. Like OnNode it runs on the goroutine doing the search.
+	OnProgress       func(event ProgressEvent)
+	ProgressInterval time.Duration
+
+	// Called at every node the search visits, for watching it work: dashboards, tree explorers, tracing. It runs
+	// on the goroutine doing the search and slows it down accordingly, so leave it nil unless something is
+	// watching. With Decompose and Parallel it may be called from several goroutines at once.
+	OnNode func(event SearchEvent)
+
+	// OpenTelemetry spans for the phases of every solve: the solve as a whole, symmetry breaking, preprocessing,
+	// root propagation, the search, and each component when decomposing. TraceContext is the parent of the solve's
+	// own span, say that of the request asking for it.
+	Tracer       trace.Tracer
+	TraceContext context.Context
+
+	domains        map[string][]int
+	engine         *PropagationEngine
+	watchers       map[string][]int // constraint indices by variable
+	weights        []float64        // by constraint, for DomainOverWeightedDegree
+	softWatchers   map[string][]int
+	softViolated   []bool
+	ranked         *solutionHeap
+	restartAt      int // failure count to restart at, 0 for none
+	branchFailures int // fallbacks from BranchScorer in a row
+	restarting     bool
+	stopped        bool
+	deadline       time.Time
+	interrupted    int32
+	paused         int32
+	resumed        chan struct{} // closed by Resume, nil unless paused
+	deepest        map[string]int
+	phases         map[string]int
+	probed         map[string]int // values from the deepest probing dive
+	directions     map[string]int // for ObjectiveOrdering
+	checkCache     []map[string]bool
+	andOrGraph     *constraintGraph
+	andOrCache     map[string]andOrResult
+	checkKey       []byte
+	frontier       []frontierLevel // the levels of the search in progress, for the bounds
+	lastBound      BoundEvent
+	gapClosed      bool
+	maxDepth       int
+	traceContext   context.Context
+	solveSpan      trace.Span
+	searchSpan     trace.Span
+
+	mutex        sync.RWMutex
+	running      bool
+	snapshot     SolverSnapshot
+	started      time.Time
+	lastProgress time.Time
+	elapsed      time.Duration
+}
+
+// What other goroutines get to see of a solve. Solutions is shared with the solver, but the solutions in it are
+// never modified once found and the solver only ever appends past the snapshot's length, so reading it is safe.
+// Nodes and Failures are refreshed every snapshotInterval nodes while running, and exact once it has finished.
+type SolverSnapshot struct {
+	Running       bool
+	Solutions     []map[string]int
+	Nodes         int
+	Failures      int
+	Best          map[string]int
+	BestObjective int
+	BestCost      int
+	Status        SolveStatus
+}
+
+const snapshotInterval = 1024
+
+// One node of the search: Variable was just given Value at Depth (0 for the first variable) and this is how it
+// went. Violated is the constraint the assignment broke when that's why it failed. Domains are the domains below
+// the node when it was extended; they must not be modified. Once every variable has a value a NodeSolution event
+// follows, one deeper and with no variable.
+type SearchEvent struct {
+	Depth    int
+	Variable string
+	Value    int
+	Outcome  NodeOutcome
+	Violated Constraint
+	Domains  map[string][]int
+}
+
+type NodeOutcome int
+
+const (
+	NodeExtended          NodeOutcome = iota // consistent, the search goes deeper
+	NodeFailedPropagation                    // propagation wiped out some domain
+	NodeFailedConstraint                     // a constraint on the assigned variables broke
+	NodeFailedBound                          // can't beat the incumbent
+	NodeSolution
+)
+
+func (outcome NodeOutcome) String() string {
+	switch outcome {
+	case NodeExtended:
+		return "extended"
+	case NodeFailedPropagation:
+		return "propagation failure"
+	case NodeFailedConstraint:
+		return "constraint violated"
+	case NodeFailedBound:
+		return "bounded"
+	}
+	return "solution"
+}
+
+// How a solve ended. Unknown means a resource limit stopped it before it could tell.
+type SolveStatus int
+
+const (
+	Unknown SolveStatus = iota
+	Unsatisfiable
+	Satisfiable
+	Optimal
+)
+
+func (status SolveStatus) String() string {
+	switch status {
+	case Unsatisfiable:
+		return "unsatisfiable"
+	case Satisfiable:
+		return "satisfiable"
+	case Optimal:
+		return "optimal"
+	}
+	return "unknown"
+}
+
+// Solver constructor, with options applied in order. Every option just sets fields, which can also be set directly.
+func NewSolver(problem *Problem, options ...SolverOption) *Solver {
+	solver := &Solver{Problem: problem}
+	for _, option := range options {
+		option(solver)
+	}
+	return solver
+}
+
+// A fresh solver for a problem derived from this one, with the same search settings and limits
+func (solver *Solver) derived(problem *Problem) *Solver {
+	derived := NewSolver(problem)
+	derived.Propagation = solver.Propagation
+	derived.PreprocessLevel = solver.PreprocessLevel
+	derived.MaxNodes = solver.MaxNodes
+	derived.MaxFailures = solver.MaxFailures
+	derived.MaxMemory = solver.MaxMemory
+	derived.Timeout = solver.Timeout
+	derived.VariableOrdering = solver.VariableOrdering
+	derived.BranchScorer = solver.BranchScorer
+	derived.BranchCandidates = solver.BranchCandidates
+	derived.BranchTimeout = solver.BranchTimeout
+	derived.RestartCutoff = solver.RestartCutoff
+	derived.WeightDecay = solver.WeightDecay
+	derived.InitialWeights = solver.InitialWeights
+	derived.ProbeDives = solver.ProbeDives
+	derived.ProbeSeed = solver.ProbeSeed
+	derived.CheckCache = solver.CheckCache
+	derived.AndOr = solver.AndOr
+	derived.Sorted = solver.Sorted
+	derived.MaxGap = solver.MaxGap
+	derived.ObjectiveOrdering = solver.ObjectiveOrdering
+	derived.Bounder = solver.Bounder
+	derived.Optimization = solver.Optimization
+	derived.Tracer = solver.Tracer
+	derived.TraceContext = solver.TraceContext
+	return derived
+}
+
+// Runs the search from scratch, returning every solution found (up to MaxSolutions). When the problem has an
+// objective or soft constraints this is branch and bound instead: the search keeps going after each solution,
+// only accepting assignments that beat the incumbent, until the best one has been proven optimal.
+func (solver *Solver) Solve() []map[string]int {
+	solver.mutex.Lock()
+	if solver.running {
+		solver.mutex.Unlock()
+		panic("csp: Solve called on a Solver that is already solving")
+	}
+	solver.running = true
+	solver.started = time.Now()
+	solver.lastProgress = solver.started
+	solver.snapshot = SolverSnapshot{Running: true}
+	solver.mutex.Unlock()
+	solver.solveSpan = solver.startSolveSpan(Attr("csp.variables", len(solver.Problem.Variables)),
+		Attr("csp.constraints", len(solver.Problem.Constraints)))
+	solver.searchSpan = noSpan
+	defer solver.finish()
+	if solver.AutoConfigure {
+		span := solver.startSpan("csp.auto_configure")
+		solver.Configuration = AnalyzeModel(solver.Problem).Recommend()
+		solver.Configuration.Apply(solver)
+		span.SetAttributes(Attr("csp.configuration", solver.Configuration.String()))
+		span.End()
+	}
+	if solver.SymmetryBreaking {
+		span := solver.startSpan("csp.symmetry_breaking")
+		original := solver.Problem
+		solver.Problem, solver.Symmetries = BreakSymmetries(original)
+		span.SetAttributes(Attr("csp.constraints_added", len(solver.Problem.Constraints)-len(original.Constraints)))
+		span.End()
+		defer func() { solver.Problem = original }()
+	}
+
+	solver.Solutions = nil
+	solver.Scores = nil
+	solver.ranked = nil
+	if solver.ranking() {
+		solver.ranked = &solutionHeap{limit: solver.TopK}
+	}
+	solver.Best = nil
+	solver.BestObjective = 0
+	solver.BestCost = 0
+	solver.Nodes = 0
+	solver.Failures = 0
+	solver.Restarts = 0
+	solver.BoundPrunes = 0
+	solver.BranchFallbacks, solver.branchFailures = 0, 0
+	solver.Cores, solver.CoreBound = 0, 0
+	solver.Status = Unknown
+	solver.stopped = false
+	solver.gapClosed = false
+	solver.lastBound = BoundEvent{}
+	solver.frontier = solver.frontier[:0]
+	solver.Closest = nil
+	solver.ClosestViolations = 0
+	solver.deepest = nil
+	solver.maxDepth = 0
+	if solver.phases == nil {
+		solver.phases = make(map[string]int)
+	}
+	if solver.Timeout > 0 {
+		solver.deadline = time.Now().Add(solver.Timeout)
+	}
+	if solver.Compile {
+		span := solver.startSpan("csp.compile")
+		solver.Diagram = CompileMDD(solver.Problem)
+		span.End()
+		return nil
+	}
+	if solver.Decompose && solver.EstimateProbes == 0 {
+		if components := solver.Problem.Components(); len(components) > 1 {
+			solver.solveSpan.SetAttributes(Attr("csp.components", len(components)))
+			solver.Solutions = solver.solveComponents(components)
+			if solver.ranked != nil {
+				for _, solution := range solver.Solutions {
+					solver.ranked.add(solution, solver.Score(solution))
+				}
+			}
+			solver.orderSolutions()
+			return solver.Solutions
+		}
+	}
+	if solver.Optimization != BranchAndBound && len(solver.Problem.SoftConstraints) > 0 && solver.EstimateProbes == 0 {
+		span := solver.startSpan("csp.optimize", Attr("csp.strategy", solver.Optimization.String()))
+		switch solver.Optimization {
+		case CoreGuided:
+			solver.coreGuidedSearch()
+			span.SetAttributes(Attr("csp.cores", solver.Cores), Attr("csp.core_bound", solver.CoreBound))
+		case RussianDoll:
+			solver.russianDollSearch()
+		}
+		span.End()
+		return solver.Solutions
+	}
+
+	// counting domain values is only worth it when someone is looking
+	tracing := solver.Tracer != nil
+	span := solver.startSpan("csp.preprocess", Attr("csp.level", int(solver.PreprocessLevel)))
+	if tracing {
+		span.SetAttributes(Attr("csp.domain_values", measure(solver.Problem).DomainValues))
+	}
+	solver.domains = Preprocess(solver.Problem, solver.PreprocessLevel)
+	span.SetAttributes(Attr("csp.wiped_out", solver.domains == nil))
+	if tracing && solver.domains != nil {
+		span.SetAttributes(Attr("csp.domain_values_after", domainValues(solver.domains)))
+	}
+	span.End()
+	if solver.domains == nil {
+		return nil
+	}
+	solver.engine = nil
+	solver.Propagations = 0
+	if solver.Propagation {
+		span := solver.startSpan("csp.propagate_root")
+		solver.engine = NewPropagationEngine(solver.Problem)
+		consistent := solver.engine.Fixpoint(solver.domains)
+		span.SetAttributes(Attr("csp.propagations", solver.engine.Propagations), Attr("csp.consistent", consistent))
+		if tracing {
+			span.SetAttributes(Attr("csp.domain_values_after", domainValues(solver.domains)))
+		}
+		span.End()
+		if !consistent {
+			solver.Propagations = solver.engine.Propagations
+			return nil
+		}
+	}
+	solver.watchers = make(map[string][]int)
+	for i, constraint := range solver.Problem.Constraints {
+		for _, variable := range uniqueScope(constraint.Scope()) {
+			solver.watchers[variable] = append(solver.watchers[variable], i)
+		}
+	}
+	solver.checkCache = nil
+	solver.CacheHits, solver.CacheMisses = 0, 0
+	if solver.CheckCache {
+		solver.checkCache = make([]map[string]bool, len(solver.Problem.Constraints))
+	}
+	solver.weights = nil
+	if solver.VariableOrdering == DomainOverWeightedDegree {
+		solver.weights = solver.startingWeights()
+	}
+	solver.directions = nil
+	if solver.ObjectiveOrdering {
+		solver.directions = solver.objectiveDirections()
+	}
+	solver.softWatchers = make(map[string][]int)
+	solver.softViolated = make([]bool, len(solver.Problem.SoftConstraints))
+	for i, soft := range solver.Problem.SoftConstraints {
+		for _, variable := range uniqueScope(soft.Constraint.Scope()) {
+			solver.softWatchers[variable] = append(solver.softWatchers[variable], i)
+		}
+	}
+	if solver.EstimateProbes > 0 {
+		solver.estimate()
+		// nothing got decided
+		solver.stopped = true
+		return nil
+	}
+	if solver.AndOr {
+		span := solver.startSpan("csp.and_or_search")
+		solver.andOrSearch()
+		span.SetAttributes(Attr("csp.nodes", solver.Nodes), Attr("csp.context_hits", solver.ContextHits))
+		span.End()
+		solver.andOrCache = nil
+		return solver.Solutions
+	}
+
+	solver.probe()
+	solver.searchSpan = solver.startSpan("csp.search")
+	solver.restartingSearch()
+	if solver.Problem.Optimizing() && !solver.stopped {
+		// nothing left to look at, so the bounds meet at the optimum
+		solver.reportBound()
+	}
+	solver.orderSolutions()
+	if solver.engine != nil {
+		solver.Propagations = solver.engine.Propagations
+	}
+	solver.searchSpan.SetAttributes(Attr("csp.nodes", solver.Nodes), Attr("csp.failures", solver.Failures),
+		Attr("csp.max_depth", solver.maxDepth), Attr("csp.propagations", solver.Propagations))
+	if solver.CheckCache {
+		solver.searchSpan.SetAttributes(Attr("csp.cache_hits", solver.CacheHits),
+			Attr("csp.cache_misses", solver.CacheMisses))
+	}
+	solver.searchSpan.End()
+	return solver.Solutions
+}
+
+// Safe to call from any goroutine, whether or not a solve is running
+func (solver *Solver) Snapshot() SolverSnapshot {
+	solver.mutex.RLock()
+	defer solver.mutex.RUnlock()
+	return solver.snapshot
+}
+
+// Only called by the goroutine running Solve
+func (solver *Solver) publish() {
+	solver.mutex.Lock()
+	solver.snapshot = SolverSnapshot{
+		Running:       solver.running,
+		Solutions:     solver.Solutions[:len(solver.Solutions):len(solver.Solutions)],
+		Nodes:         solver.Nodes,
+		Failures:      solver.Failures,
+		Best:          solver.Best,
+		BestObjective: solver.BestObjective,
+		BestCost:      solver.BestCost,
+		Status:        solver.Status,
+	}
+	solver.mutex.Unlock()
+}
+
+// Stops a running solve as soon as the search notices, which is within a node, or when none is running the next
+// one, so an interrupt racing with the start of Solve isn't lost. Safe to call from any goroutine.
+func (solver *Solver) Interrupt() {
+	atomic.StoreInt32(&solver.interrupted, 1)
+	solver.Resume()
+}
+
+func (solver *Solver) finish() {
+	// this solve has answered any interrupt, the next one starts afresh
+	atomic.StoreInt32(&solver.interrupted, 0)
+	if solver.Anytime && solver.stopped && len(solver.Solutions) == 0 {
+		solver.Closest, solver.ClosestViolations = solver.complete(solver.deepest)
+	}
+	switch {
+	case solver.gapClosed && solver.lastBound.Gap == 0:
+		solver.Status = Optimal
+	case solver.gapClosed:
+		solver.Status = Satisfiable
+	case solver.stopped:
+		solver.Status = Unknown
+	case solver.Compile && solver.Diagram.Count().Sign() > 0, !solver.Problem.Optimizing() && len(solver.Solutions) > 0:
+		solver.Status = Satisfiable
+	case solver.Best != nil:
+		solver.Status = Optimal
+	default:
+		solver.Status = Unsatisfiable
+	}
+	solver.solveSpan.SetAttributes(Attr("csp.status", solver.Status.String()),
+		Attr("csp.solutions", len(solver.Solutions)), Attr("csp.nodes", solver.Nodes),
+		Attr("csp.failures", solver.Failures))
+	solver.solveSpan.End()
+	solver.elapsed = time.Since(solver.started)
+	solver.mutex.Lock()
+	solver.running = false
+	solver.mutex.Unlock()
+	solver.publish()
+}
+
+// The last solve as a Result, like the other backends give. Solutions stopping at MaxSolutions leave the rest of
+// the search space unexplored, so the result is only Complete when the search ran out of places to look.
+func (solver *Solver) Result() Result {
+	found := len(solver.Solutions)
+	if solver.ranked != nil {
+		found = solver.ranked.found
+	}
+	complete := solver.Status == Unsatisfiable || solver.Status == Optimal ||
+		solver.Status == Satisfiable && (solver.MaxSolutions == 0 || found < solver.MaxSolutions)
+	return Result{
+		Status:        solver.Status,
+		Solutions:     solver.Solutions,
+		BestObjective: solver.BestObjective,
+		BestCost:      solver.BestCost,
+		Complete:      complete,
+		Stats: Stats{Nodes: solver.Nodes, Failures: solver.Failures, Propagations: solver.Propagations,
+			Restarts: solver.Restarts, Elapsed: solver.elapsed},
+	}
+}
+
+// Returns false once enough solutions have been found, which unwinds the whole recursion
+func (solver *Solver) search(assignment *Assignment, cost int, domains map[string][]int) bool {
+	depth := assignment.Depth
+	if depth == len(solver.Problem.Variables) {
+		solution := make(map[string]int, len(assignment.Values))
+		for variable, value := range assignment.Values {
+			solution[variable] = value
+		}
+		found := len(solver.Solutions) + 1
+		if solver.ranked != nil {
+			solver.ranked.add(solution, solver.Score(solution))
+			found = solver.ranked.found
+		} else {
+			solver.Solutions = append(solver.Solutions, solution)
+		}
+		if solver.OnNode != nil {
+			solver.OnNode(SearchEvent{Depth: depth, Outcome: NodeSolution})
+		}
+		if solver.Problem.Optimizing() {
+			solver.Best = solution
+			solver.BestObjective = solution[solver.Problem.Objective]
+			solver.BestCost = cost
+			solver.searchSpan.AddEvent("csp.incumbent", trace.WithAttributes(Attr("csp.objective", solver.BestObjective),
+				Attr("csp.cost", cost), Attr("csp.nodes", solver.Nodes)))
+			solver.publish()
+			solver.reportBound()
+			return true
+		}
+		if found == 1 {
+			solver.searchSpan.AddEvent("csp.first_solution", trace.WithAttributes(Attr("csp.nodes", solver.Nodes)))
+		}
+		solver.publish()
+		return solver.MaxSolutions == 0 || found < solver.MaxSolutions
+	}
+
+	if depth > solver.maxDepth {
+		solver.maxDepth = depth
+	}
+	variable, values := solver.branch(depth, assignment.Values, domains)
+	level := len(solver.frontier)
+	solver.frontier = append(solver.frontier, frontierLevel{variable, values, 0, domains, cost})
+	for i, value := range values {
+		solver.frontier[level].next = i + 1
+		if solver.limitReached() {
+			solver.frontier = solver.frontier[:level]
+			return false
+		}
+		solver.Nodes++
+		if solver.Nodes%snapshotInterval == 0 {
+			solver.publish()
+			solver.reportProgress()
+			if solver.Problem.Optimizing() {
+				solver.reportBound()
+			}
+		}
+		assignment.Assign(variable, value)
+		newlyViolated, added := solver.violateSoft(variable, assignment.Values)
+		childDomains, propagated := solver.propagate(domains, variable, value)
+		var violated Constraint
+		if propagated {
+			violated = solver.violated(variable, assignment.Values)
+		}
+		outcome := NodeExtended
+		switch {
+		case !propagated:
+			outcome = NodeFailedPropagation
+			solver.blame(solver.engine.Failed)
+		case violated != nil:
+			outcome = NodeFailedConstraint
+		case !solver.bounded(assignment.Values, cost+added, childDomains):
+			outcome = NodeFailedBound
+		}
+		if solver.OnNode != nil {
+			event := SearchEvent{depth, variable, value, outcome, violated, nil}
+			if outcome == NodeExtended {
+				event.Domains = childDomains
+			}
+			solver.OnNode(event)
+		}
+		if outcome == NodeExtended {
+			if solver.PhaseSaving {
+				solver.phases[variable] = value
+			}
+			if solver.Anytime && depth+1 > len(solver.deepest) {
+				solver.deepest = make(map[string]int, depth+1)
+				for assigned, value := range assignment.Values {
+					solver.deepest[assigned] = value
+				}
+			}
+			if !solver.search(assignment, cost+added, childDomains) {
+				solver.restoreSoft(newlyViolated)
+				assignment.Undo()
+				solver.frontier = solver.frontier[:level]
+				return false
+			}
+		} else {
+			solver.Failures++
+		}
+		solver.restoreSoft(newlyViolated)
+		assignment.Undo()
+	}
+	solver.frontier = solver.frontier[:level]
+	return true
+}
+
+// The domain with the saved phase first, the hint second and the probed value third, when they're in it; the rest
+// keeps its order, or goes best first for the objective with ObjectiveOrdering
+func (solver *Solver) valueOrder(variable string, domain []int) []int {
+	if direction := solver.directions[variable]; direction != 0 {
+		domain = objectiveOrder(domain, direction)
+	}
+	var preferred []int
+	if phase, saved := solver.phases[variable]; saved && solver.PhaseSaving {
+		preferred = append(preferred, phase)
+	}
+	if hint, hinted := solver.Hint[variable]; hinted {
+		preferred = append(preferred, hint)
+	}
+	if probed, dived := solver.probed[variable]; dived {
+		preferred = append(preferred, probed)
+	}
+	if len(preferred) == 0 {
+		return domain
+	}
+	var front []int
+	for _, value := range preferred {
+		if containsValue(domain, value) && !containsValue(front, value) {
+			front = append(front, value)
+		}
+	}
+	ordered := append(make([]int, 0, len(domain)), front...)
+	for _, value := range domain {
+		if !containsValue(front, value) {
+			ordered = append(ordered, value)
+		}
+	}
+	return ordered
+}
+
+func containsValue(values []int, value int) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether a resource limit has been hit, or the restart cutoff. Once a limit has, the search is over for good.
+func (solver *Solver) limitReached() bool {
+	if atomic.LoadInt32(&solver.paused) != 0 {
+		solver.waitWhilePaused()
+	}
+	switch {
+	case solver.stopped:
+	case atomic.LoadInt32(&solver.interrupted) != 0:
+		solver.stopped = true
+	case solver.Timeout > 0 && solver.Nodes%64 == 0 && time.Now().After(solver.deadline):
+		solver.stopped = true
+	case solver.MaxNodes > 0 && solver.Nodes >= solver.MaxNodes:
+		solver.stopped = true
+	case solver.MaxFailures > 0 && solver.Failures >= solver.MaxFailures:
+		solver.stopped = true
+	case solver.MaxMemory > 0 && solver.Nodes%snapshotInterval == 0:
+		var memory runtime.MemStats
+		runtime.ReadMemStats(&memory)
+		solver.stopped = memory.HeapAlloc > solver.MaxMemory
+	}
+	if !solver.stopped && solver.restartAt > 0 && solver.Failures >= solver.restartAt {
+		solver.restarting = true
+	}
+	return solver.stopped || solver.restarting
+}
+
+// Greedily extends a partial assignment to a complete one, giving each remaining variable in turn the value that
+// breaks the fewest of the constraints it's in. Returns the result along with how many constraints it breaks.
+func (solver *Solver) complete(partial map[string]int) (map[string]int, int) {
+	assignment := make(map[string]int, len(solver.Problem.Variables))
+	for variable, value := range partial {
+		assignment[variable] = value
+	}
+	involved := make(map[string][]Constraint)
+	for _, constraint := range solver.Problem.Constraints {
+		for _, variable := range uniqueScope(constraint.Scope()) {
+			involved[variable] = append(involved[variable], constraint)
+		}
+	}
+	for _, variable := range solver.Problem.Variables {
+		if _, assigned := assignment[variable]; assigned || len(solver.Problem.Domains[variable]) == 0 {
+			continue
+		}
+		best, bestViolations := 0, -1
+		for _, value := range solver.Problem.Domains[variable] {
+			assignment[variable] = value
+			violations := 0
+			for _, constraint := range involved[variable] {
+				if !constraint.Satisfied(assignment) {
+					violations++
+				}
+			}
+			if bestViolations == -1 || violations < bestViolations {
+				best, bestViolations = value, violations
+			}
+		}
+		assignment[variable] = best
+	}
+
+	violations := 0
+	for _, constraint := range solver.Problem.Constraints {
+		if !constraint.Satisfied(assignment) {
+			violations++
+		}
+	}
+	return assignment, violations
+}
+
+// With propagation on, returns the domains the rest of the search under variable=value should use, and false if
+// propagation already proved there's nothing there. Without it the domains are passed along untouched.
+func (solver *Solver) propagate(domains map[string][]int, variable string, value int) (map[string][]int, bool) {
+	if solver.engine == nil {
+		return domains, true
+	}
+	child := make(map[string][]int, len(domains))
+	for other, values := range domains {
+		child[other] = values
+	}
+	// branch and bound: only objective values that beat the incumbent are worth keeping
+	if solver.Best != nil && solver.Problem.Objective != "" && solver.Problem.SoftConstraints == nil {
+		store := &DomainStore{child, nil, -1, nil}
+		if !store.Restrict(solver.Problem.Objective, func(objective int) bool {
+			return solver.Problem.Improves(objective, solver.BestObjective)
+		}) {
+			solver.engine.Failed = -1 // the bound's doing, not a constraint's
+			return nil, false
+		}
+	}
+	return child, solver.engine.Assign(child, variable, value)
+}
+
+// The first constraint the assignment breaks, or nil. Only the constraints involving the variable that was just
+// assigned can have changed their mind. The one found takes the blame for the failure.
+func (solver *Solver) violated(variable string, assignment map[string]int) Constraint {
+	for _, i := range solver.watchers[variable] {
+		if !solver.satisfied(i, assignment) {
+			solver.blame(i)
+			return solver.Problem.Constraints[i]
+		}
+	}
+	return nil
+}
+
+// Whether this node can still lead to something better than the incumbent. Constraints only ever go from
+// satisfied to violated as more variables get assigned, so the cost so far is a lower bound on the final cost.
+// The objective might have been assigned long before the incumbent was found, so it gets checked at every node,
+// and the Bounder gets asked about it when it's still open. Domains are the node's, nil for a complete assignment.
+func (solver *Solver) bounded(assignment map[string]int, cost int, domains map[string][]int) bool {
+	if solver.Best == nil {
+		return true
+	}
+	if cost > solver.BestCost {
+		return false
+	}
+	if solver.Problem.Objective == "" {
+		return cost < solver.BestCost
+	}
+	if objective, assigned := assignment[solver.Problem.Objective]; assigned && cost == solver.BestCost {
+		return solver.Problem.Improves(objective, solver.BestObjective)
+	}
+	if solver.Bounder != nil && domains != nil && cost == solver.BestCost {
+		bound, ok := solver.Bounder.ObjectiveBound(solver.Problem, assignment, domains)
+		if ok && !solver.Problem.Improves(bound, solver.BestObjective) {
+			solver.BoundPrunes++
+			return false
+		}
+	}
+	return true
+}
+
+// Marks the soft constraints the new assignment breaks, returning them along with their total weight
+func (solver *Solver) violateSoft(variable string, assignment map[string]int) ([]int, int) {
+	var newlyViolated []int
+	added := 0
+	for _, i := range solver.softWatchers[variable] {
+		if !solver.softViolated[i] && !solver.Problem.SoftConstraints[i].Constraint.Satisfied(assignment) {
+			solver.softViolated[i] = true
+			newlyViolated = append(newlyViolated, i)
+			added += solver.Problem.SoftConstraints[i].Weight
+		}
+	}
+	return newlyViolated, added
+}
+
+func (solver *Solver) restoreSoft(newlyViolated []int) {
+	for _, i := range newlyViolated {
+		solver.softViolated[i] = false
+	}
+}
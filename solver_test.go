@@ -0,0 +1,41 @@
+package csp
+
+import "testing"
+
+// Four unconstrained variables of ten values, ten thousand solutions
+func wideProblem() *Problem {
+	problem := NewProblem()
+	for _, variable := range []string{"a", "b", "c", "d"} {
+		problem.AddVariableRange(variable, 0, 9)
+	}
+	return problem
+}
+
+func TestInterruptBeforeSolve(t *testing.T) {
+	solver := NewSolver(wideProblem())
+	solver.Interrupt()
+	if solutions := solver.Solve(); len(solutions) == 10000 || solver.Status != Unknown {
+		t.Fatalf("interrupted before it started, the solve found %d solutions with status %v", len(solutions),
+			solver.Status)
+	}
+	if solutions := solver.Solve(); len(solutions) != 10000 || solver.Status != Satisfiable {
+		t.Fatalf("the interrupt carried over to the next solve: %d solutions with status %v", len(solutions),
+			solver.Status)
+	}
+}
+
+func TestAssignmentUndoesInOrder(t *testing.T) {
+	assignment := NewAssignment()
+	assignment.Assign("a", 1)
+	assignment.Assign("b", 2)
+	assignment.Undo()
+	if _, assigned := assignment.Value("b"); assigned || assignment.Depth != 1 {
+		t.Fatalf("after undoing b, got %v at depth %d", assignment.Values, assignment.Depth)
+	}
+	assignment.Assign("c", 3)
+	assignment.Undo()
+	assignment.Undo()
+	if len(assignment.Values) != 0 || assignment.Depth != 0 {
+		t.Fatalf("after undoing everything, got %v at depth %d", assignment.Values, assignment.Depth)
+	}
+}
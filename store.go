@@ -0,0 +1,99 @@
+package csp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Where a Server keeps its jobs so they survive a restart. Records are saved whenever a job changes state; on
+// startup finished jobs come back with their results and unfinished ones, queued or running, get solved again.
+type JobStore interface {
+	Save(record JobRecord) error
+	Load() ([]JobRecord, error)
+	Delete(id int) error
+}
+
+// What a store keeps of a job
+type JobRecord struct {
+	ID           int             `json:"id"`
+	State        string          `json:"state"` // queued, running or done
+	Model        json.RawMessage `json:"model"`
+	Delay        time.Duration   `json:"delay"`
+	Timeout      time.Duration   `json:"timeout"`
+	Submitted    time.Time       `json:"submitted"`
+	Result       *SolveResult    `json:"result,omitempty"`
+	SolutionList []string        `json:"solutionList,omitempty"` // the solutions as FormatSolution has them
+}
+
+// A JobStore keeping each job as a JSON file in a directory, written to a temporary file first and renamed into
+// place, so a crash never leaves half a record
+type FileJobStore struct {
+	Dir string
+}
+
+// FileJobStore constructor, creating the directory if need be
+func NewFileJobStore(dir string) (*FileJobStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileJobStore{dir}, nil
+}
+
+func (store *FileJobStore) filename(id int) string {
+	return filepath.Join(store.Dir, fmt.Sprintf("job-%d.json", id))
+}
+
+func (store *FileJobStore) Save(record JobRecord) error {
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	temporary := store.filename(record.ID) + ".tmp"
+	if err := os.WriteFile(temporary, encoded, 0644); err != nil {
+		return err
+	}
+	return os.Rename(temporary, store.filename(record.ID))
+}
+
+// Every record, by ID
+func (store *FileJobStore) Load() ([]JobRecord, error) {
+	entries, err := os.ReadDir(store.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var records []JobRecord
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "job-") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "job-"), ".json")); err != nil {
+			continue
+		}
+		encoded, err := os.ReadFile(filepath.Join(store.Dir, name))
+		if err != nil {
+			return nil, err
+		}
+		var record JobRecord
+		if err := json.Unmarshal(encoded, &record); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	return records, nil
+}
+
+func (store *FileJobStore) Delete(id int) error {
+	err := os.Remove(store.filename(id))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
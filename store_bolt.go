@@ -0,0 +1,83 @@
+//go:build !(js && wasm)
+
+package csp
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// A JobStore keeping every job in one bbolt database file, a key per job. Writes are transactions, so a crash
+// never leaves half a record, and the file is locked while open, so a second server can't share the store.
+// Not available under WebAssembly, which bbolt doesn't build for.
+type BoltJobStore struct {
+	db *bolt.DB
+}
+
+var jobsBucket = []byte("jobs")
+
+// BoltJobStore constructor, creating the file if need be. Gives up after a second if another process has it open.
+func NewBoltJobStore(path string) (*BoltJobStore, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if err == bolt.ErrTimeout {
+		return nil, fmt.Errorf("%s is open in another process", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(jobsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltJobStore{db}, nil
+}
+
+// Big endian, so the keys sort by ID
+func jobKey(id int) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(id))
+}
+
+func (store *BoltJobStore) Save(record JobRecord) error {
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return store.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).Put(jobKey(record.ID), encoded)
+	})
+}
+
+// Every record, by ID
+func (store *BoltJobStore) Load() ([]JobRecord, error) {
+	var records []JobRecord
+	err := store.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).ForEach(func(key []byte, encoded []byte) error {
+			var record JobRecord
+			if err := json.Unmarshal(encoded, &record); err != nil {
+				return fmt.Errorf("job %d: %v", binary.BigEndian.Uint64(key), err)
+			}
+			records = append(records, record)
+			return nil
+		})
+	})
+	return records, err
+}
+
+func (store *BoltJobStore) Delete(id int) error {
+	return store.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).Delete(jobKey(id))
+	})
+}
+
+// Releases the file for other processes
+func (store *BoltJobStore) Close() error {
+	return store.db.Close()
+}
@@ -0,0 +1,53 @@
+//go:build !(js && wasm)
+
+package csp
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestJobStores(t *testing.T) {
+	dir := t.TempDir()
+	files, err := NewFileJobStore(filepath.Join(dir, "jobs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := NewBoltJobStore(filepath.Join(dir, "jobs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := NewBoltJobStore(filepath.Join(dir, "jobs.db")); err == nil {
+		t.Error("opened the database a second time while it was open")
+	}
+
+	for name, store := range map[string]JobStore{"files": files, "bolt": db} {
+		records := []JobRecord{
+			{ID: 2, State: "queued", Model: json.RawMessage(`{"variables":[]}`)},
+			{ID: 10, State: "done", Model: json.RawMessage(`{}`), SolutionList: []string{"x=1"}},
+			{ID: 3, State: "running", Model: json.RawMessage(`{}`)},
+		}
+		for _, record := range records {
+			if err := store.Save(record); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+		records[1].State = "queued"
+		if err := store.Save(records[1]); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := store.Delete(3); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		loaded, err := store.Load()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if want := records[:2]; !reflect.DeepEqual(loaded, want) {
+			t.Errorf("%s: loaded %+v, want %+v", name, loaded, want)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package csp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Interchangeable variables and values found by DetectSymmetries. Every class is fully interchangeable: any
+// permutation of its members maps solutions onto solutions.
+type Symmetries struct {
+	VariableClasses [][]string
+	ValueClasses    [][]int
+}
+
+// Looks for pairs of variables, and pairs of values, that can be swapped without changing the problem: swapping
+// them everywhere maps every constraint onto a constraint of the problem. Those swaps generate the full
+// permutation group of each class they connect. Constraints only Go code can make sense of (Predicate and the
+// like) are assumed to block any swap that touches them, so the result is sound but may miss symmetries.
+// The objective variable never takes part, and neither do values when there is an objective.
+func DetectSymmetries(problem *Problem) Symmetries {
+	constraints := append([]Constraint(nil), problem.Constraints...)
+	for _, soft := range problem.SoftConstraints {
+		constraints = append(constraints, &weightedConstraint{soft})
+	}
+	swaps := &symmetryCheck{problem: problem, constraints: constraints, involved: make(map[string][]int)}
+	for i, constraint := range constraints {
+		swaps.keys = append(swaps.keys, swaps.key(constraint, i, nil, nil))
+		for _, variable := range uniqueScope(constraint.Scope()) {
+			swaps.involved[variable] = append(swaps.involved[variable], i)
+		}
+	}
+
+	var symmetries Symmetries
+	// only variables with the same domain and the same number of constraints can possibly be swapped
+	var candidates []string
+	for _, variable := range problem.Variables {
+		if variable != problem.Objective {
+			candidates = append(candidates, variable)
+		}
+	}
+	variables := newUnionFind(len(candidates))
+	for i, x := range candidates {
+		for j := i + 1; j < len(candidates); j++ {
+			y := candidates[j]
+			if variables.find(i) == variables.find(j) || len(swaps.involved[x]) != len(swaps.involved[y]) ||
+				fmt.Sprint(problem.Domains[x]) != fmt.Sprint(problem.Domains[y]) {
+				continue
+			}
+			indices := append(append([]int(nil), swaps.involved[x]...), swaps.involved[y]...)
+			if swaps.invariant(indices, map[string]string{x: y, y: x}, nil) {
+				variables.union(i, j)
+			}
+		}
+	}
+	for _, class := range variables.classes() {
+		var names []string
+		for _, i := range class {
+			names = append(names, candidates[i])
+		}
+		symmetries.VariableClasses = append(symmetries.VariableClasses, names)
+	}
+
+	if problem.Objective != "" {
+		return symmetries
+	}
+	// values have to be in exactly the same domains
+	valueSet := make(map[int]bool)
+	for _, variable := range problem.Variables {
+		for _, value := range problem.Domains[variable] {
+			valueSet[value] = true
+		}
+	}
+	valueList := sortedKeys(valueSet)
+	everything := make([]int, len(constraints))
+	for i := range everything {
+		everything[i] = i
+	}
+	values := newUnionFind(len(valueList))
+	for i, a := range valueList {
+		for j := i + 1; j < len(valueList); j++ {
+			b := valueList[j]
+			if values.find(i) == values.find(j) {
+				continue
+			}
+			sameDomains := true
+			for _, variable := range problem.Variables {
+				domain := problem.Domains[variable]
+				sameDomains = sameDomains && containsValue(domain, a) == containsValue(domain, b)
+			}
+			if sameDomains && swaps.invariant(everything, nil, map[int]int{a: b, b: a}) {
+				values.union(i, j)
+			}
+		}
+	}
+	for _, class := range values.classes() {
+		var members []int
+		for _, i := range class {
+			members = append(members, valueList[i])
+		}
+		symmetries.ValueClasses = append(symmetries.ValueClasses, members)
+	}
+	return symmetries
+}
+
+// Copy of the problem with lex-leader constraints added for its symmetries: within a variable class the values
+// are non-decreasing in variable order, and within a value class each value has to appear before the next one
+// does. Both hold for the lexicographically smallest solution of every symmetric family (in the problem's
+// variable order), so together they still leave at least one solution of each family.
+func BreakSymmetries(problem *Problem) (*Problem, Symmetries) {
+	symmetries := DetectSymmetries(problem)
+	broken := &Problem{
+		Variables:          problem.Variables,
+		Domains:            problem.Domains,
+		Constraints:        append([]Constraint(nil), problem.Constraints...),
+		Labels:             problem.Labels,
+		Objective:          problem.Objective,
+		Maximizing:         problem.Maximizing,
+		SoftConstraints:    problem.SoftConstraints,
+		DefaultConsistency: problem.DefaultConsistency,
+		Consistency:        problem.Consistency,
+	}
+	for _, class := range symmetries.VariableClasses {
+		for i := 0; i+1 < len(class); i++ {
+			broken.AddConstraint(NewLinear([]string{class[i], class[i+1]}, []int{1, -1}, "<=", 0))
+		}
+	}
+	for _, class := range symmetries.ValueClasses {
+		broken.AddConstraint(NewValuePrecedence(problem.Variables, class))
+	}
+	return broken, symmetries
+}
+
+// Soft constraints only map onto soft constraints of the same weight
+type weightedConstraint struct {
+	SoftConstraint
+}
+
+func (soft *weightedConstraint) Scope() []string {
+	return soft.Constraint.Scope()
+}
+
+func (soft *weightedConstraint) Satisfied(assignment map[string]int) bool {
+	return soft.Constraint.Satisfied(assignment)
+}
+
+type symmetryCheck struct {
+	problem     *Problem
+	constraints []Constraint
+	keys        []string
+	involved    map[string][]int
+}
+
+// Whether renaming the variables and values of the constraints at indices gives back the same set of constraints.
+// The indices have to cover every constraint the renaming touches; duplicates are fine.
+func (swaps *symmetryCheck) invariant(indices []int, variables map[string]string, values map[int]int) bool {
+	original := make(map[string]int)
+	mapped := make(map[string]int)
+	seen := make(map[int]bool)
+	for _, i := range indices {
+		if seen[i] {
+			continue
+		}
+		seen[i] = true
+		key := swaps.key(swaps.constraints[i], i, variables, values)
+		if key == "" {
+			return false
+		}
+		original[swaps.keys[i]]++
+		mapped[key]++
+	}
+	for key, count := range original {
+		if mapped[key] != count {
+			return false
+		}
+	}
+	return true
+}
+
+// A string identifying the constraint after renaming, equal for constraints that mean the same thing, or "" when
+// the constraint's type isn't understood and the renaming would change it. Missing entries map to themselves.
+func (swaps *symmetryCheck) key(constraint Constraint, index int, variables map[string]string, values map[int]int) string {
+	rename := func(variable string) string {
+		if renamed, exists := variables[variable]; exists {
+			variable = renamed
+		}
+		return fmt.Sprintf("%q", variable)
+	}
+	revalue := func(value int) int {
+		if revalued, exists := values[value]; exists {
+			return revalued
+		}
+		return value
+	}
+	// whether a value swap can reach any of the variables
+	touched := false
+	for _, variable := range constraint.Scope() {
+		for value := range values {
+			touched = touched || containsValue(swaps.problem.Domains[variable], value)
+		}
+	}
+
+	switch constraint := constraint.(type) {
+	case *weightedConstraint:
+		if key := swaps.key(constraint.Constraint, index, variables, values); key != "" {
+			return fmt.Sprintf("soft %d %s", constraint.Weight, key)
+		}
+		return ""
+	case *AllDifferent:
+		return "alldifferent " + sortedNames(constraint.Variables, rename)
+	case *NotEqual:
+		return "notequal " + sortedNames([]string{constraint.First, constraint.Second}, rename)
+	case *Clause:
+		var literals []string
+		for _, literal := range constraint.Literals {
+			literals = append(literals, fmt.Sprintf("%s %v %d", rename(literal.Variable), literal.Negated,
+				revalue(literal.Value)))
+		}
+		sort.Strings(literals)
+		return "clause " + strings.Join(literals, ",")
+	case *Table:
+		// columns in name order, so the same table listing its variables differently still matches
+		order := make([]int, len(constraint.Variables))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			return rename(constraint.Variables[order[i]]) < rename(constraint.Variables[order[j]])
+		})
+		var names, rows []string
+		for _, column := range order {
+			names = append(names, rename(constraint.Variables[column]))
+		}
+		for _, tuple := range constraint.Tuples {
+			var row []string
+			for _, column := range order {
+				row = append(row, fmt.Sprint(revalue(tuple[column])))
+			}
+			rows = append(rows, strings.Join(row, " "))
+		}
+		sort.Strings(rows)
+		return "table " + strings.Join(names, ",") + ": " + strings.Join(rows, ",")
+	case *Linear:
+		// swapping values changes a sum
+		if touched {
+			return ""
+		}
+		var terms []string
+		for i, variable := range constraint.Variables {
+			terms = append(terms, fmt.Sprintf("%s*%d", rename(variable), constraint.Coefficients[i]))
+		}
+		sort.Strings(terms)
+		return fmt.Sprintf("linear %s %s %d", strings.Join(terms, "+"), constraint.Operator, constraint.Constant)
+	}
+
+	// anything else is only left alone by renamings that don't reach it
+	if touched {
+		return ""
+	}
+	for _, variable := range constraint.Scope() {
+		if _, renamed := variables[variable]; renamed {
+			return ""
+		}
+	}
+	return fmt.Sprintf("opaque %d", index)
+}
+
+func sortedNames(variables []string, rename func(string) string) string {
+	var names []string
+	for _, variable := range variables {
+		names = append(names, rename(variable))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+type unionFind []int
+
+func newUnionFind(n int) unionFind {
+	parent := make(unionFind, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	return parent
+}
+
+func (parent unionFind) find(i int) int {
+	if parent[i] != i {
+		parent[i] = parent.find(parent[i])
+	}
+	return parent[i]
+}
+
+func (parent unionFind) union(i int, j int) {
+	parent[parent.find(j)] = parent.find(i)
+}
+
+// The classes with at least two members, each in ascending order
+func (parent unionFind) classes() [][]int {
+	members := make(map[int][]int)
+	var roots []int
+	for i := range parent {
+		root := parent.find(i)
+		if len(members[root]) == 0 {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], i)
+	}
+	var classes [][]int
+	for _, root := range roots {
+		if len(members[root]) > 1 {
+			classes = append(classes, members[root])
+		}
+	}
+	return classes
+}
@@ -0,0 +1,170 @@
+package csp
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Table constraints from data kept elsewhere: a CSV file or a database query, each row an allowed tuple. Columns
+// are matched to the problem's variables by name, and a cell holds a number, or the label of a categorical
+// variable's value.
+
+// Reads a table from CSV whose first row names the columns. With no variables every column is one, in order;
+// otherwise the columns named after the variables make up the table and the rest are ignored.
+func ReadTableCSV(problem *Problem, r io.Reader, variables []string) (*Table, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("no header row")
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	columns, variables, err := tableColumns(problem, header, variables)
+	if err != nil {
+		return nil, err
+	}
+	var tuples [][]int
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		tuple := make([]int, len(columns))
+		for i, column := range columns {
+			if tuple[i], err = tableValue(problem, variables[i], record[column]); err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+		}
+		tuples = append(tuples, tuple)
+	}
+	return NewTable(variables, tuples), nil
+}
+
+// Reads a table from a CSV file, see ReadTableCSV
+func LoadTableCSV(problem *Problem, filename string, variables []string) (*Table, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	table, err := ReadTableCSV(problem, file, variables)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return table, nil
+}
+
+// Runs a query and makes a table of its rows, the columns named after variables, with AS where the database's
+// names differ: SELECT cpu AS Processor, board AS Motherboard FROM compatible. Integer columns are taken as they
+// are and text ones like CSV cells; NULLs are an error.
+func QueryTable(ctx context.Context, db *sql.DB, problem *Problem, query string, args ...interface{}) (*Table,
+	error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	_, variables, err := tableColumns(problem, names, nil)
+	if err != nil {
+		return nil, err
+	}
+	cells := make([]interface{}, len(names))
+	pointers := make([]interface{}, len(names))
+	for i := range cells {
+		pointers[i] = &cells[i]
+	}
+	var tuples [][]int
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		tuple := make([]int, len(cells))
+		for i, cell := range cells {
+			var err error
+			switch cell := cell.(type) {
+			case int64:
+				tuple[i] = int(cell)
+			case []byte:
+				tuple[i], err = tableValue(problem, variables[i], string(cell))
+			case string:
+				tuple[i], err = tableValue(problem, variables[i], cell)
+			case nil:
+				err = fmt.Errorf("%s is NULL", variables[i])
+			default:
+				err = fmt.Errorf("%s: %T isn't a value", variables[i], cell)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %v", len(tuples)+1, err)
+			}
+		}
+		tuples = append(tuples, tuple)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return NewTable(variables, tuples), nil
+}
+
+// The header's index for each of the variables, all of the header when there are none, checking every one is a
+// variable of the problem
+func tableColumns(problem *Problem, header []string, variables []string) ([]int, []string, error) {
+	if variables == nil {
+		variables = header
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		if _, exists := index[name]; exists {
+			return nil, nil, fmt.Errorf("column %q appears twice", name)
+		}
+		index[name] = i
+	}
+	columns := make([]int, len(variables))
+	for i, variable := range variables {
+		column, exists := index[variable]
+		if !exists {
+			return nil, nil, fmt.Errorf("no column for %q", variable)
+		}
+		if _, exists := problem.Domains[variable]; !exists {
+			return nil, nil, fmt.Errorf("unknown variable %q", variable)
+		}
+		columns[i] = column
+	}
+	return columns, variables, nil
+}
+
+// A cell's value: the label's value for categorical variables, the number otherwise
+func tableValue(problem *Problem, variable string, cell string) (int, error) {
+	cell = strings.TrimSpace(cell)
+	for i, label := range problem.Labels[variable] {
+		if label == cell {
+			return i + 1, nil
+		}
+	}
+	value, err := strconv.Atoi(cell)
+	if err != nil {
+		if labels := problem.Labels[variable]; labels != nil {
+			return 0, fmt.Errorf("%s has no label %q", variable, cell)
+		}
+		return 0, fmt.Errorf("%s: %q isn't a number", variable, cell)
+	}
+	return value, nil
+}
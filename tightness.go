@@ -0,0 +1,166 @@
+package csp
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"text/tabwriter"
+)
+
+// How hard a model's constraints are, worked out before solving. A constraint's Tightness is the fraction of the
+// tuples of its scope's domains it forbids: 0 forbids nothing, 1 everything. It's counted exactly when there are
+// at most tightnessExactLimit tuples, estimated from Samples random ones otherwise.
+//
+// A variable's Constrainedness is how many bits its constraints take out of its domain's: the sum of
+// -log2(1 - tightness) over the constraints on it over log2 of its domain size. Kappa is the same over the whole
+// model, Gent et al's measure; around 1 is where random models are hardest, well above 1 they're likely to have no
+// solution. Branching on the most constrained variables first usually pays.
+type TightnessReport struct {
+	Constraints []ConstraintTightness
+	Variables   []VariableTightness
+	Kappa       float64
+	Samples     int
+}
+
+type ConstraintTightness struct {
+	Constraint Constraint
+	Tuples     float64
+	Tightness  float64
+	Sampled    bool
+}
+
+type VariableTightness struct {
+	Variable        string
+	Domain          int
+	Degree          int
+	Constrainedness float64
+}
+
+// Scopes with up to this many tuples are counted exactly
+const tightnessExactLimit = 1 << 16
+
+// A constraint forbidding every tuple takes this many bits, rather than infinitely many
+const tightnessMaxBits = 64
+
+// Analyzes the hard constraints, tightest constraints and most constrained variables first, sampling big scopes
+// with the given number of samples
+func AnalyzeTightness(problem *Problem, samples int) *TightnessReport {
+	report := &TightnessReport{Samples: samples}
+	random := rand.New(rand.NewSource(1))
+	bits := make(map[string]float64)
+	degree := make(map[string]int)
+	total := 0.0
+	for _, constraint := range problem.Constraints {
+		row := constraintTightness(problem, constraint, samples, random)
+		report.Constraints = append(report.Constraints, row)
+		removed := tightnessMaxBits * 1.0
+		if row.Tightness < 1 {
+			removed = math.Min(-math.Log2(1-row.Tightness), tightnessMaxBits)
+		}
+		total += removed
+		for _, variable := range constraint.Scope() {
+			bits[variable] += removed
+			degree[variable]++
+		}
+	}
+
+	domainBits := 0.0
+	for _, variable := range problem.Variables {
+		size := len(problem.Domains[variable])
+		row := VariableTightness{Variable: variable, Domain: size, Degree: degree[variable]}
+		if size > 1 {
+			row.Constrainedness = bits[variable] / math.Log2(float64(size))
+			domainBits += math.Log2(float64(size))
+		} else if bits[variable] > 0 {
+			row.Constrainedness = math.Inf(1)
+		}
+		report.Variables = append(report.Variables, row)
+	}
+	if domainBits > 0 {
+		report.Kappa = total / domainBits
+	}
+
+	sort.SliceStable(report.Constraints, func(i, j int) bool {
+		return report.Constraints[i].Tightness > report.Constraints[j].Tightness
+	})
+	sort.SliceStable(report.Variables, func(i, j int) bool {
+		a, b := report.Variables[i], report.Variables[j]
+		if a.Constrainedness != b.Constrainedness {
+			return a.Constrainedness > b.Constrainedness
+		}
+		return a.Degree > b.Degree
+	})
+	return report
+}
+
+func constraintTightness(problem *Problem, constraint Constraint, samples int,
+	random *rand.Rand) ConstraintTightness {
+	scope := constraint.Scope()
+	row := ConstraintTightness{Constraint: constraint, Tuples: 1}
+	for _, variable := range scope {
+		row.Tuples *= float64(len(problem.Domains[variable]))
+	}
+	if row.Tuples == 0 {
+		row.Tightness = 1
+		return row
+	}
+	assignment := make(map[string]int, len(scope))
+	forbidden, tried := 0, 0
+	if row.Tuples <= tightnessExactLimit {
+		var enumerate func(i int)
+		enumerate = func(i int) {
+			if i == len(scope) {
+				tried++
+				if !constraint.Satisfied(assignment) {
+					forbidden++
+				}
+				return
+			}
+			for _, value := range problem.Domains[scope[i]] {
+				assignment[scope[i]] = value
+				enumerate(i + 1)
+			}
+		}
+		enumerate(0)
+	} else {
+		row.Sampled = true
+		for ; tried < samples; tried++ {
+			for _, variable := range scope {
+				domain := problem.Domains[variable]
+				assignment[variable] = domain[random.Intn(len(domain))]
+			}
+			if !constraint.Satisfied(assignment) {
+				forbidden++
+			}
+		}
+	}
+	if tried > 0 {
+		row.Tightness = float64(forbidden) / float64(tried)
+	}
+	return row
+}
+
+// Two tables, the constraints then the variables, after a line with Kappa
+func (report *TightnessReport) Print(w io.Writer) {
+	fmt.Fprintf(w, "Kappa: %.3f (%d constraints, %d variables)\n", report.Kappa, len(report.Constraints),
+		len(report.Variables))
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "constraint\ttuples\ttightness\t")
+	for _, row := range report.Constraints {
+		tightness := fmt.Sprintf("%.3f", row.Tightness)
+		if row.Sampled {
+			tightness += fmt.Sprintf(" (%d samples)", report.Samples)
+		}
+		fmt.Fprintf(table, "%s\t%.4g\t%s\t\n", describeConstraint(row.Constraint), row.Tuples, tightness)
+	}
+	table.Flush()
+	fmt.Fprintln(w)
+	table = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "variable\tdomain\tdegree\tconstrainedness\t")
+	for _, row := range report.Variables {
+		fmt.Fprintf(table, "%s\t%d\t%d\t%.3f\t\n", row.Variable, row.Domain, row.Degree, row.Constrainedness)
+	}
+	table.Flush()
+}
@@ -0,0 +1,61 @@
+package csp
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// An attribute for a span, typed by its value. Anything that isn't a number, string or bool is formatted.
+func Attr(key string, value interface{}) attribute.KeyValue {
+	switch value := value.(type) {
+	case int:
+		return attribute.Int(key, value)
+	case int64:
+		return attribute.Int64(key, value)
+	case float64:
+		return attribute.Float64(key, value)
+	case bool:
+		return attribute.Bool(key, value)
+	case string:
+		return attribute.String(key, value)
+	}
+	return attribute.String(key, fmt.Sprint(value))
+}
+
+// What the solver does when there's no tracer
+var noSpan = trace.SpanFromContext(context.Background())
+
+// A span of the solve in progress, a child of its csp.solve span
+func (solver *Solver) startSpan(name string, attributes ...attribute.KeyValue) trace.Span {
+	if solver.Tracer == nil {
+		return noSpan
+	}
+	_, span := solver.Tracer.Start(solver.traceContext, name, trace.WithAttributes(attributes...))
+	return span
+}
+
+// The csp.solve span, a child of TraceContext's, which the spans of the phases go under
+func (solver *Solver) startSolveSpan(attributes ...attribute.KeyValue) trace.Span {
+	if solver.Tracer == nil {
+		return noSpan
+	}
+	parent := solver.TraceContext
+	if parent == nil {
+		parent = context.Background()
+	}
+	var span trace.Span
+	solver.traceContext, span = solver.Tracer.Start(parent, "csp.solve", trace.WithAttributes(attributes...))
+	return span
+}
+
+// Values left across all domains
+func domainValues(domains map[string][]int) int {
+	total := 0
+	for _, domain := range domains {
+		total += len(domain)
+	}
+	return total
+}
@@ -0,0 +1,48 @@
+package csp
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSpansContinueTheTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	solver := NewSolver(wideProblem(), WithMaxSolutions(1))
+	solver.Tracer = provider.Tracer("test")
+	solver.TraceContext = propagation.TraceContext{}.Extract(context.Background(), propagation.HeaderCarrier(header))
+	solver.Solve()
+
+	spans := recorder.Ended()
+	var solve sdktrace.ReadOnlySpan
+	for _, span := range spans {
+		if span.Name() == "csp.solve" {
+			solve = span
+		}
+	}
+	if solve == nil {
+		t.Fatalf("no csp.solve span among %d", len(spans))
+	}
+	if parent := solve.Parent().SpanID().String(); parent != "00f067aa0ba902b7" {
+		t.Errorf("csp.solve has parent %s, want the traceparent's span", parent)
+	}
+	children := 0
+	for _, span := range spans {
+		if trace := span.SpanContext().TraceID().String(); trace != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("%s is in trace %s, not the traceparent's", span.Name(), trace)
+		}
+		if span.Parent().SpanID() == solve.SpanContext().SpanID() {
+			children++
+		}
+	}
+	if children == 0 {
+		t.Error("no spans under csp.solve")
+	}
+}
@@ -0,0 +1,271 @@
+package csp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Picks the best of several configurations for a set of instances, one or more, by racing them: every round
+// runs the configurations still in the race on every instance with a time limit, keeps the better half and
+// doubles the limit, until one is left. Configurations are ranked by how many instances they solved, then by their
+// total time, an unsolved instance counting as the round's limit; an instance solved once isn't run again. The
+// first round's limit is chosen so that all the rounds together about fit in Budget; the race stops when the
+// next round wouldn't, the standings of the last full round deciding.
+//
+// Each run solves for MaxSolutions, 0 meaning all of them, or the optimum when the instance has something to
+// optimize. Standings has every configuration, the winner first, Rounds how many rounds got run and Runs how
+// many solves.
+type Race struct {
+	Instances    []*Problem
+	Candidates   []Configuration
+	Budget       time.Duration
+	MaxSolutions int
+
+	Standings []RaceStanding
+	Rounds    int
+	Runs      int
+}
+
+// How a configuration did in the last round it was raced in
+type RaceStanding struct {
+	Configuration Configuration
+	Rounds        int
+	Solved        int
+	Time          time.Duration
+}
+
+type raceRun struct {
+	elapsed time.Duration
+	solved  bool
+}
+
+// Race constructor, with a Budget of a minute and runs stopping at the first solution
+func NewRace(instances []*Problem, candidates []Configuration) *Race {
+	return &Race{Instances: instances, Candidates: candidates, Budget: time.Minute, MaxSolutions: 1}
+}
+
+// Runs the race, returning the winner with a reason saying how it won. That's the first candidate when not even
+// one round fit in the budget.
+func (race *Race) Run() Configuration {
+	race.Standings, race.Rounds, race.Runs = nil, 0, 0
+	if len(race.Candidates) == 0 {
+		return Configuration{}
+	}
+	rounds := 1
+	for n := len(race.Candidates); n > 1; n = (n + 1) / 2 {
+		rounds++
+	}
+	// every round costs about the same, half the runs at twice the limit
+	limit := race.Budget / time.Duration(len(race.Candidates)*len(race.Instances)*rounds)
+	if limit < time.Millisecond {
+		limit = time.Millisecond
+	}
+	deadline := time.Now().Add(race.Budget)
+	runs := make([][]raceRun, len(race.Candidates))
+	standings := make([]RaceStanding, len(race.Candidates))
+	alive := make([]int, len(race.Candidates))
+	for i, candidate := range race.Candidates {
+		runs[i] = make([]raceRun, len(race.Instances))
+		standings[i].Configuration = candidate
+		alive[i] = i
+	}
+
+	for {
+		complete := true
+		for _, i := range alive {
+			for j, instance := range race.Instances {
+				if runs[i][j].solved {
+					continue
+				}
+				if time.Until(deadline) < limit {
+					complete = false
+					break
+				}
+				runs[i][j] = race.run(race.Candidates[i], instance, limit)
+				race.Runs++
+			}
+			if !complete {
+				break
+			}
+		}
+		if !complete {
+			break
+		}
+		race.Rounds++
+		for _, i := range alive {
+			standing := &standings[i]
+			standing.Rounds, standing.Solved, standing.Time = race.Rounds, 0, 0
+			for _, run := range runs[i] {
+				if run.solved {
+					standing.Solved++
+					standing.Time += run.elapsed
+				} else {
+					standing.Time += limit
+				}
+			}
+		}
+		sort.SliceStable(alive, func(a, b int) bool { return standings[alive[a]].better(standings[alive[b]]) })
+		if len(alive) == 1 {
+			break
+		}
+		alive = alive[:(len(alive)+1)/2]
+		limit *= 2
+	}
+
+	sort.SliceStable(standings, func(a, b int) bool {
+		if standings[a].Rounds != standings[b].Rounds {
+			return standings[a].Rounds > standings[b].Rounds
+		}
+		return standings[a].better(standings[b])
+	})
+	race.Standings = standings
+	winner := standings[0]
+	winner.Configuration.Reasons = []string{fmt.Sprintf("won a race of %d configurations on %d instances in %d "+
+		"rounds, solving %d in %v", len(race.Candidates), len(race.Instances), race.Rounds, winner.Solved,
+		winner.Time.Round(time.Millisecond))}
+	return winner.Configuration
+}
+
+func (standing RaceStanding) better(other RaceStanding) bool {
+	if standing.Solved != other.Solved {
+		return standing.Solved > other.Solved
+	}
+	return standing.Time < other.Time
+}
+
+func (race *Race) run(configuration Configuration, instance *Problem, limit time.Duration) raceRun {
+	solver := NewSolver(instance)
+	configuration.Apply(solver)
+	solver.MaxSolutions = race.MaxSolutions
+	solver.Timeout = limit
+	start := time.Now()
+	solver.Solve()
+	return raceRun{time.Since(start), solver.Status != Unknown}
+}
+
+// The configurations worth racing on the instances: each ordering, domwdeg with and without restarts, with and
+// without arc consistency up front, every optimization strategy when some instance has soft constraints, and
+// what Recommend picks for each instance
+func TuningCandidates(instances []*Problem) []Configuration {
+	strategies := []OptimizationStrategy{BranchAndBound}
+	for _, instance := range instances {
+		if len(instance.SoftConstraints) > 0 {
+			strategies = []OptimizationStrategy{BranchAndBound, CoreGuided, RussianDoll}
+			break
+		}
+	}
+	var candidates []Configuration
+	seen := make(map[string]bool)
+	add := func(configuration Configuration) {
+		if !seen[configuration.String()] {
+			seen[configuration.String()] = true
+			candidates = append(candidates, configuration)
+		}
+	}
+	for _, instance := range instances {
+		add(AnalyzeModel(instance).Recommend())
+	}
+	for _, strategy := range strategies {
+		for _, level := range []PreprocessLevel{NoPreprocessing, ArcConsistency} {
+			orderings := []VariableOrdering{LexicographicOrder, MinimumRemainingValues, DomainOverWeightedDegree}
+			for _, ordering := range orderings {
+				add(Configuration{VariableOrdering: ordering, Propagation: true, PreprocessLevel: level,
+					Optimization: strategy})
+			}
+			add(Configuration{VariableOrdering: DomainOverWeightedDegree, RestartCutoff: 100, Propagation: true,
+				PreprocessLevel: level, Optimization: strategy})
+		}
+	}
+	return candidates
+}
+
+// One row per configuration, winner first
+func PrintRaceTable(w io.Writer, race *Race) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "configuration\trounds\tsolved\ttime\t")
+	for _, standing := range race.Standings {
+		fmt.Fprintf(table, "%s\t%d\t%d/%d\t%v\t\n", standing.Configuration, standing.Rounds, standing.Solved,
+			len(race.Instances), standing.Time.Round(time.Millisecond))
+	}
+	table.Flush()
+}
+
+// A configuration as a profile, such as {"strategy": "domwdeg", "restarts": 100, "preprocess": "arc",
+// "propagation": true, "optimize": "bnb"}
+type configurationJSON struct {
+	Strategy    string   `json:"strategy"`
+	Restarts    int      `json:"restarts,omitempty"`
+	Preprocess  string   `json:"preprocess"`
+	Propagation bool     `json:"propagation"`
+	Decompose   bool     `json:"decompose,omitempty"`
+	Optimize    string   `json:"optimize"`
+	Reasons     []string `json:"reasons,omitempty"`
+}
+
+func (configuration Configuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(configurationJSON{
+		Strategy:    configuration.VariableOrdering.String(),
+		Restarts:    configuration.RestartCutoff,
+		Preprocess:  configuration.PreprocessLevel.String(),
+		Propagation: configuration.Propagation,
+		Decompose:   configuration.Decompose,
+		Optimize:    configuration.Optimization.String(),
+		Reasons:     configuration.Reasons,
+	})
+}
+
+// Settings left out of the profile keep their zero values, the settings of a plain NewSolver
+func (configuration *Configuration) UnmarshalJSON(data []byte) error {
+	var decoded configurationJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	parsed := Configuration{
+		RestartCutoff: decoded.Restarts,
+		Propagation:   decoded.Propagation,
+		Decompose:     decoded.Decompose,
+		Reasons:       decoded.Reasons,
+	}
+	var err error
+	if decoded.Strategy != "" {
+		if parsed.VariableOrdering, err = ParseVariableOrdering(decoded.Strategy); err != nil {
+			return err
+		}
+	}
+	if decoded.Preprocess != "" {
+		if parsed.PreprocessLevel, err = ParsePreprocessLevel(decoded.Preprocess); err != nil {
+			return err
+		}
+	}
+	if decoded.Optimize != "" {
+		if parsed.Optimization, err = ParseOptimizationStrategy(decoded.Optimize); err != nil {
+			return err
+		}
+	}
+	if parsed.RestartCutoff < 0 {
+		return fmt.Errorf("restarts can't be negative")
+	}
+	*configuration = parsed
+	return nil
+}
+
+// Reads a profile SaveProfile wrote
+func LoadProfile(filename string) (Configuration, error) {
+	var configuration Configuration
+	encoded, err := os.ReadFile(filename)
+	if err != nil {
+		return configuration, err
+	}
+	err = json.Unmarshal(encoded, &configuration)
+	return configuration, err
+}
+
+// Writes the configuration as a JSON profile, for csp solve --profile
+func SaveProfile(filename string, configuration Configuration) error {
+	return WriteJSONFile(filename, configuration)
+}
@@ -0,0 +1,97 @@
+package csp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// How often WatchModel looks at the file. Polling keeps it to the standard library and works on any file system.
+const watchInterval = 500 * time.Millisecond
+
+// Solves the model file, then again every time it changes, printing how the solutions changed: + for new ones and
+// - for ones that are gone, in red and green when colorize is on. A model that doesn't load gets its error printed
+// and the watch carries on. Runs until stop is closed, or for good when it's nil.
+func WatchModel(filename string, maxSolutions int, out io.Writer, colorize bool, stop <-chan struct{}) {
+	var previous map[string]bool
+	var modified time.Time
+	var size int64 = -1
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	fmt.Fprintf(out, "Watching %s, Ctrl-C to stop\n", filename)
+	for {
+		info, err := os.Stat(filename)
+		switch {
+		case err != nil:
+			if size != -2 {
+				fmt.Fprintln(out, err)
+				size = -2
+			}
+		case !info.ModTime().Equal(modified) || info.Size() != size:
+			modified, size = info.ModTime(), info.Size()
+			if solutions, ok := watchSolve(filename, maxSolutions, previous, out, colorize); ok {
+				previous = solutions
+			}
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Solves once and prints the difference with the previous solutions, returning the new ones and whether the model
+// loaded at all
+func watchSolve(filename string, maxSolutions int, previous map[string]bool, out io.Writer,
+	colorize bool) (map[string]bool, bool) {
+	fmt.Fprintf(out, "\n[%s] ", time.Now().Format("15:04:05"))
+	problem, err := LoadModelFile(filename)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return nil, false
+	}
+	solver := NewModelSolver(problem, maxSolutions)
+	start := time.Now()
+	solutions := solver.Solve()
+	if problem.Optimizing() {
+		solutions = nil
+		if solver.Best != nil {
+			solutions = []map[string]int{solver.Best}
+		}
+	}
+
+	current := make(map[string]bool)
+	var added, removed []string
+	for _, solution := range solutions {
+		line := FormatSolution(problem, solution)
+		current[line] = true
+		if !previous[line] {
+			added = append(added, line)
+		}
+	}
+	for solution := range previous {
+		if !current[solution] {
+			removed = append(removed, solution)
+		}
+	}
+	sort.Strings(removed)
+	fmt.Fprintf(out, "%d solutions (%s), %d new, %d gone, %d nodes in %v\n", len(current), solver.Status,
+		len(added), len(removed), solver.Nodes, time.Since(start).Round(time.Microsecond))
+	if problem.Optimizing() && solver.Best != nil {
+		fmt.Fprintf(out, "  cost %d", solver.BestCost)
+		if problem.Objective != "" {
+			fmt.Fprintf(out, ", objective %d", solver.BestObjective)
+		}
+		fmt.Fprintln(out)
+	}
+	for _, line := range removed {
+		fmt.Fprintln(out, colored("- "+line, colorRed, colorize))
+	}
+	for _, line := range added {
+		fmt.Fprintln(out, colored("+ "+line, colorGreen, colorize))
+	}
+	return current, true
+}